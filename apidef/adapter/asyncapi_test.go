@@ -342,6 +342,28 @@ const expectedGraphqlConfig = `{
     },
     "introspection": {
         "disabled": false
+    },
+    "persisted_queries": {
+        "automatic": false,
+        "ttl": 0,
+        "safelist": false
+    },
+    "cost_analysis": {
+        "enabled": false,
+        "weights": null,
+        "slicing_arguments": null,
+        "default_list_size": 0,
+        "charge_quota": false
+    },
+    "cache": {
+        "enabled": false,
+        "default_ttl": 0,
+        "type_ttls": null,
+        "private_types": null,
+        "data_source_fetches": {
+            "enabled": false,
+            "ttl": 0
+        }
     }
 }`
 
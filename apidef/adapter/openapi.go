@@ -26,6 +26,7 @@ const (
 
 type openAPI struct {
 	orgId         string
+	upstreamURL   string
 	input         []byte
 	report        *operationreport.Report
 	apiDefinition *apidef.APIDefinition
@@ -49,8 +50,13 @@ func (o *openAPI) prepareGraphQLEngineConfig() error {
 		return fmt.Errorf("document is nil")
 	}
 
-	if len(o.document.Servers) == 0 {
-		return errors.New("no server defined in OpenAPI spec")
+	serverURL := o.upstreamURL
+	if serverURL == "" {
+		if len(o.document.Servers) == 0 {
+			return errors.New("no server defined in OpenAPI spec")
+		}
+		// We only support one server definition and always pick the first one.
+		serverURL = o.document.Servers[0].URL
 	}
 
 	graphqlTypes := map[string][]string{
@@ -58,8 +64,7 @@ func (o *openAPI) prepareGraphQLEngineConfig() error {
 		GraphQLTypeMutation: {http.MethodPost, http.MethodPut, http.MethodDelete},
 	}
 
-	// We only support one server definition and always pick the first one.
-	server, err := url.Parse(o.document.Servers[0].URL)
+	server, err := url.Parse(serverURL)
 	if err != nil {
 		return err
 	}
@@ -206,4 +211,16 @@ func NewOpenAPIAdapter(orgId string, input []byte) ImportAdapter {
 	}
 }
 
+// NewOpenAPIAdapterWithUpstream returns the adapter of the OpenAPI document with the REST data sources targeting
+// the upstream URL instead of the first server of the document, as the servers of a Tyk OAS API are the gateway.
+func NewOpenAPIAdapterWithUpstream(orgId string, input []byte, upstreamURL string) ImportAdapter {
+	report := operationreport.Report{}
+	return &openAPI{
+		report:      &report,
+		input:       input,
+		orgId:       orgId,
+		upstreamURL: upstreamURL,
+	}
+}
+
 var _ ImportAdapter = (*openAPI)(nil)
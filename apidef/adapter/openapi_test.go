@@ -323,6 +323,28 @@ const expectedOpenAPIGraphQLConfig = `{
     },
     "introspection": {
         "disabled": false
+    },
+    "persisted_queries": {
+        "automatic": false,
+        "ttl": 0,
+        "safelist": false
+    },
+    "cost_analysis": {
+        "enabled": false,
+        "weights": null,
+        "slicing_arguments": null,
+        "default_list_size": 0,
+        "charge_quota": false
+    },
+    "cache": {
+        "enabled": false,
+        "default_ttl": 0,
+        "type_ttls": null,
+        "private_types": null,
+        "data_source_fetches": {
+            "enabled": false,
+            "ttl": 0
+        }
     }
 }`
 
@@ -349,3 +371,17 @@ func TestGraphQLConfigAdapter_OpenAPI(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedOpenAPIGraphQLConfig, dst.String())
 }
+
+func TestGraphQLConfigAdapter_OpenAPIWithUpstream(t *testing.T) {
+	adapter := NewOpenAPIAdapterWithUpstream("my-org-id", []byte(petstoreExpandedOpenAPI3), "http://upstream:8080/v1")
+
+	actualApiDefinition, err := adapter.Import()
+	require.NoError(t, err)
+	require.Equal(t, expectedOpenAPIGraphQLSchema, actualApiDefinition.GraphQL.Schema)
+
+	for _, dataSource := range actualApiDefinition.GraphQL.Engine.DataSources {
+		var config apidef.GraphQLEngineDataSourceConfigREST
+		require.NoError(t, json.Unmarshal(dataSource.Config, &config))
+		require.Regexp(t, `^http://upstream:8080/v1/pets`, config.URL)
+	}
+}
@@ -0,0 +1,259 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/ast"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+const (
+	// PersistGraphQLPathVariablePrefix is the prefix of the variables of a persisted operation read from the path.
+	PersistGraphQLPathVariablePrefix = "$path."
+	// PersistGraphQLBodyVariablePrefix is the prefix of the variables of a persisted operation read from the JSON body.
+	PersistGraphQLBodyVariablePrefix = "$body."
+)
+
+// restSelectionDepth is the depth of the object fields selected by the generated operations.
+const restSelectionDepth = 2
+
+var errNotGraphQLAPI = errors.New("API is not a GraphQL API")
+
+// rest exposes the root fields of a GraphQL API as REST endpoints, each endpoint is a persisted operation.
+type rest struct {
+	apiDefinition *apidef.APIDefinition
+	document      *ast.Document
+}
+
+// NewRESTAdapter returns the adapter adding REST endpoints for the queries and mutations of the GraphQL API.
+//
+// Queries whose required arguments are IDs or strings are exposed as GET endpoints with the required arguments in
+// the path, their optional arguments keep their default values. The other queries and the mutations are exposed as
+// POST endpoints reading the arguments from the properties of the JSON body. The endpoints defined by the API are
+// kept.
+func NewRESTAdapter(apiDefinition *apidef.APIDefinition) ImportAdapter {
+	return &rest{apiDefinition: apiDefinition}
+}
+
+func (r *rest) Import() (*apidef.APIDefinition, error) {
+	if !r.apiDefinition.GraphQL.Enabled {
+		return nil, errNotGraphQLAPI
+	}
+
+	document, report := astparser.ParseGraphqlDocumentString(r.apiDefinition.GraphQL.Schema)
+	if report.HasErrors() {
+		return nil, report
+	}
+	r.document = &document
+
+	queryTypeName := string(document.Index.QueryTypeName)
+	if queryTypeName == "" {
+		queryTypeName = GraphQLTypeQuery
+	}
+	mutationTypeName := string(document.Index.MutationTypeName)
+	if mutationTypeName == "" {
+		mutationTypeName = GraphQLTypeMutation
+	}
+
+	endpoints := append(r.endpoints(queryTypeName, ast.OperationTypeQuery), r.endpoints(mutationTypeName, ast.OperationTypeMutation)...)
+
+	def := *r.apiDefinition
+	def.VersionData.Versions = make(map[string]apidef.VersionInfo, len(r.apiDefinition.VersionData.Versions))
+	for name, version := range r.apiDefinition.VersionData.Versions {
+		version.UseExtendedPaths = true
+		version.ExtendedPaths.PersistGraphQL = mergePersistGraphQL(version.ExtendedPaths.PersistGraphQL, endpoints)
+		def.VersionData.Versions[name] = version
+	}
+
+	return &def, nil
+}
+
+// mergePersistGraphQL returns the endpoints with the generated endpoints which don't have the method and path of an
+// existing endpoint.
+func mergePersistGraphQL(endpoints, generated []apidef.PersistGraphQLMeta) []apidef.PersistGraphQLMeta {
+	merged := make([]apidef.PersistGraphQLMeta, len(endpoints), len(endpoints)+len(generated))
+	copy(merged, endpoints)
+
+	existing := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		existing[endpoint.Method+" "+endpoint.Path] = true
+	}
+
+	for _, endpoint := range generated {
+		if !existing[endpoint.Method+" "+endpoint.Path] {
+			merged = append(merged, endpoint)
+		}
+	}
+
+	return merged
+}
+
+func (r *rest) endpoints(typeName string, operationType ast.OperationType) []apidef.PersistGraphQLMeta {
+	node, ok := r.document.Index.FirstNodeByNameStr(typeName)
+	if !ok || node.Kind != ast.NodeKindObjectTypeDefinition {
+		return nil
+	}
+
+	var endpoints []apidef.PersistGraphQLMeta
+	for _, ref := range r.document.NodeFieldDefinitions(node) {
+		fieldName := r.document.FieldDefinitionNameString(ref)
+		if strings.HasPrefix(fieldName, "__") {
+			continue
+		}
+		endpoints = append(endpoints, r.endpoint(ref, fieldName, operationType))
+	}
+
+	return endpoints
+}
+
+func (r *rest) endpoint(ref int, fieldName string, operationType ast.OperationType) apidef.PersistGraphQLMeta {
+	args := r.document.FieldDefinitionArgumentsDefinitions(ref)
+
+	get := operationType == ast.OperationTypeQuery
+	for _, arg := range args {
+		if r.isRequired(arg) && !r.isPathArgument(arg) {
+			get = false
+		}
+	}
+
+	endpoint := apidef.PersistGraphQLMeta{
+		Path:      "/" + fieldName,
+		Method:    http.MethodPost,
+		Variables: map[string]interface{}{},
+	}
+	if get {
+		endpoint.Method = http.MethodGet
+	}
+
+	var definitions, arguments []string
+	for _, arg := range args {
+		name := r.document.InputValueDefinitionNameString(arg)
+		switch {
+		case !get:
+			endpoint.Variables[name] = PersistGraphQLBodyVariablePrefix + name
+		case r.isRequired(arg):
+			endpoint.Path += "/{" + name + "}"
+			endpoint.Variables[name] = PersistGraphQLPathVariablePrefix + name
+		default:
+			continue
+		}
+
+		argType, _ := r.document.PrintTypeBytes(r.document.InputValueDefinitionType(arg), nil)
+		definitions = append(definitions, fmt.Sprintf("$%s: %s", name, argType))
+		arguments = append(arguments, fmt.Sprintf("%s: $%s", name, name))
+	}
+
+	var operation strings.Builder
+	if operationType == ast.OperationTypeMutation {
+		operation.WriteString("mutation")
+	} else {
+		operation.WriteString("query")
+	}
+	if len(definitions) > 0 {
+		operation.WriteString(" (" + strings.Join(definitions, ", ") + ")")
+	}
+	operation.WriteString(" { " + fieldName)
+	if len(arguments) > 0 {
+		operation.WriteString("(" + strings.Join(arguments, ", ") + ")")
+	}
+	if selection := r.selection(r.document.ResolveTypeNameString(r.document.FieldDefinitionType(ref)), restSelectionDepth); selection != "" {
+		operation.WriteString(" " + selection)
+	}
+	operation.WriteString(" }")
+	endpoint.Operation = operation.String()
+
+	return endpoint
+}
+
+// selection returns the selection set of the type, its leaf fields and its object fields up to the depth.
+func (r *rest) selection(typeName string, depth int) string {
+	node, ok := r.document.Index.FirstNodeByNameStr(typeName)
+	if !ok {
+		return ""
+	}
+
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition:
+	case ast.NodeKindUnionTypeDefinition:
+		return "{ __typename }"
+	default:
+		return ""
+	}
+
+	var fields []string
+	for _, ref := range r.document.NodeFieldDefinitions(node) {
+		if r.hasRequiredArguments(ref) {
+			continue
+		}
+
+		fieldName := r.document.FieldDefinitionNameString(ref)
+		fieldTypeName := r.document.ResolveTypeNameString(r.document.FieldDefinitionType(ref))
+		if !r.isObject(fieldTypeName) {
+			fields = append(fields, fieldName)
+			continue
+		}
+
+		if depth > 1 {
+			if selection := r.selection(fieldTypeName, depth-1); selection != "" {
+				fields = append(fields, fieldName+" "+selection)
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return "{ __typename }"
+	}
+
+	return "{ " + strings.Join(fields, " ") + " }"
+}
+
+func (r *rest) isObject(typeName string) bool {
+	node, ok := r.document.Index.FirstNodeByNameStr(typeName)
+	if !ok {
+		return false
+	}
+
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
+		return true
+	}
+	return false
+}
+
+func (r *rest) hasRequiredArguments(ref int) bool {
+	for _, arg := range r.document.FieldDefinitionArgumentsDefinitions(ref) {
+		if r.isRequired(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rest) isRequired(arg int) bool {
+	return r.document.TypeIsNonNull(r.document.InputValueDefinitionType(arg)) && !r.document.InputValueDefinitionHasDefaultValue(arg)
+}
+
+// isPathArgument returns true for the arguments which can be read from a path segment, the IDs and strings.
+func (r *rest) isPathArgument(arg int) bool {
+	typeRef := r.document.InputValueDefinitionType(arg)
+	if r.document.TypeIsNonNull(typeRef) {
+		typeRef = r.document.Types[typeRef].OfType
+	}
+
+	if r.document.Types[typeRef].TypeKind != ast.TypeKindNamed {
+		return false
+	}
+
+	switch r.document.TypeNameString(typeRef) {
+	case "ID", "String":
+		return true
+	}
+	return false
+}
+
+var _ ImportAdapter = (*rest)(nil)
@@ -0,0 +1,119 @@
+package adapter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+const restTestSchema = `
+type Query {
+	user(id: ID!): User
+	users(limit: Int = 10, name: String): [User!]!
+	search(term: String!, first: Int!): [SearchResult]
+}
+
+type Mutation {
+	createUser(input: UserInput!): User!
+}
+
+union SearchResult = User | Address
+
+type User {
+	id: ID!
+	name: String
+	friends(first: Int!): [User]
+	address: Address
+}
+
+type Address {
+	city: String
+	country: Country
+}
+
+type Country {
+	code: String
+}
+
+input UserInput {
+	name: String!
+}`
+
+func TestRESTAdapter(t *testing.T) {
+	existing := apidef.PersistGraphQLMeta{Path: "/user/{id}", Method: http.MethodGet, Operation: "{ me { id } }"}
+
+	def := &apidef.APIDefinition{
+		GraphQL: apidef.GraphQLConfig{Enabled: true, Schema: restTestSchema},
+		VersionData: apidef.VersionData{
+			Versions: map[string]apidef.VersionInfo{
+				"Default": {
+					Name: "Default",
+					ExtendedPaths: apidef.ExtendedPathsSet{
+						PersistGraphQL: []apidef.PersistGraphQLMeta{existing},
+					},
+				},
+			},
+		},
+	}
+
+	generated, err := NewRESTAdapter(def).Import()
+	require.NoError(t, err)
+
+	version := generated.VersionData.Versions["Default"]
+	assert.True(t, version.UseExtendedPaths)
+	assert.Equal(t, []apidef.PersistGraphQLMeta{
+		existing,
+		{
+			Path:      "/users",
+			Method:    http.MethodGet,
+			Operation: "query { users { id name address { city } } }",
+			Variables: map[string]interface{}{},
+		},
+		{
+			Path:      "/search",
+			Method:    http.MethodPost,
+			Operation: "query ($term: String!, $first: Int!) { search(term: $term, first: $first) { __typename } }",
+			Variables: map[string]interface{}{"term": "$body.term", "first": "$body.first"},
+		},
+		{
+			Path:      "/createUser",
+			Method:    http.MethodPost,
+			Operation: "mutation ($input: UserInput!) { createUser(input: $input) { id name address { city } } }",
+			Variables: map[string]interface{}{"input": "$body.input"},
+		},
+	}, version.ExtendedPaths.PersistGraphQL)
+
+	assert.Len(t, def.VersionData.Versions["Default"].ExtendedPaths.PersistGraphQL, 1, "the API definition is not modified")
+
+	t.Run("path arguments", func(t *testing.T) {
+		def := &apidef.APIDefinition{
+			GraphQL: apidef.GraphQLConfig{Enabled: true, Schema: restTestSchema},
+			VersionData: apidef.VersionData{
+				Versions: map[string]apidef.VersionInfo{"Default": {Name: "Default"}},
+			},
+		}
+
+		generated, err := NewRESTAdapter(def).Import()
+		require.NoError(t, err)
+		assert.Equal(t, apidef.PersistGraphQLMeta{
+			Path:      "/user/{id}",
+			Method:    http.MethodGet,
+			Operation: "query ($id: ID!) { user(id: $id) { id name address { city } } }",
+			Variables: map[string]interface{}{"id": "$path.id"},
+		}, generated.VersionData.Versions["Default"].ExtendedPaths.PersistGraphQL[0])
+	})
+
+	t.Run("not a GraphQL API", func(t *testing.T) {
+		_, err := NewRESTAdapter(&apidef.APIDefinition{}).Import()
+		assert.ErrorIs(t, err, errNotGraphQLAPI)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := NewRESTAdapter(&apidef.APIDefinition{GraphQL: apidef.GraphQLConfig{Enabled: true, Schema: "type Query {"}}).Import()
+		assert.Error(t, err)
+	})
+}
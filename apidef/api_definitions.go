@@ -60,14 +60,20 @@ const (
 	UseBlob SourceMode = "blob"
 	UseFile SourceMode = "file"
 
-	RequestXML  RequestInputType = "xml"
-	RequestJSON RequestInputType = "json"
+	RequestXML       RequestInputType = "xml"
+	RequestJSON      RequestInputType = "json"
+	RequestForm      RequestInputType = "form"
+	RequestMultipart RequestInputType = "multipart"
 
 	OttoDriver     MiddlewareDriver = "otto"
 	PythonDriver   MiddlewareDriver = "python"
 	LuaDriver      MiddlewareDriver = "lua"
 	GrpcDriver     MiddlewareDriver = "grpc"
 	GoPluginDriver MiddlewareDriver = "goplugin"
+	WasmDriver     MiddlewareDriver = "wasm"
+
+	JSEngineOtto = "otto"
+	JSEngineGoja = "goja"
 
 	BodySource        IdExtractorSource = "body"
 	HeaderSource      IdExtractorSource = "header"
@@ -87,6 +93,7 @@ const (
 	JWTClaim      AuthTypeEnum = "jwt_claim"
 	OIDCUser      AuthTypeEnum = "oidc_user"
 	OAuthKey      AuthTypeEnum = "oauth_key"
+	ClientCert    AuthTypeEnum = "client_certificate"
 	UnsetAuth     AuthTypeEnum = ""
 
 	// For routing triggers
@@ -172,6 +179,10 @@ type CacheMeta struct {
 	CacheKeyRegex          string `bson:"cache_key_regex" json:"cache_key_regex"`
 	CacheOnlyResponseCodes []int  `bson:"cache_response_codes" json:"cache_response_codes"`
 	Timeout                int64  `bson:"timeout" json:"timeout"`
+	// StaleWhileRevalidate overrides the API level stale-while-revalidate window in seconds.
+	StaleWhileRevalidate int64 `bson:"stale_while_revalidate" json:"stale_while_revalidate"`
+	// StaleIfError overrides the API level stale-if-error window in seconds.
+	StaleIfError int64 `bson:"stale_if_error" json:"stale_if_error"`
 }
 
 type RequestInputType string
@@ -181,6 +192,38 @@ type TemplateData struct {
 	Mode           SourceMode       `bson:"template_mode" json:"template_mode"`
 	EnableSession  bool             `bson:"enable_session" json:"enable_session"`
 	TemplateSource string           `bson:"template_source" json:"template_source"`
+	// Output is the format the body is converted to when no template is set.
+	Output RequestInputType `bson:"output_type,omitempty" json:"output_type,omitempty"`
+	// XML configures the conversion of the body from and to XML.
+	XML XMLOptions `bson:"xml_options,omitempty" json:"xml_options,omitempty"`
+}
+
+// XMLOptions configures the conversion of bodies from and to XML.
+//
+// XML elements are converted to JSON objects: attributes are keys prefixed with `-`, the text of an element having
+// attributes or children is the `#text` key, and repeated elements are arrays.
+type XMLOptions struct {
+	// RootElement is the name of the root element of the converted XML. It defaults to the single key of the
+	// converted object, or `doc`.
+	RootElement string `bson:"root_element,omitempty" json:"root_element,omitempty"`
+	// ArrayPaths are the dot separated paths of the elements always converted to arrays, even when they occur once,
+	// for example `Users.User`.
+	ArrayPaths []string `bson:"array_paths,omitempty" json:"array_paths,omitempty"`
+	// Namespaces are the namespaces declared on the root element of the converted XML, by prefix. When
+	// KeepNamespaces is set, the prefixes are also used for the elements of these namespaces in the converted XML.
+	Namespaces map[string]string `bson:"namespaces,omitempty" json:"namespaces,omitempty"`
+	// KeepNamespaces keeps the namespace prefixes of the element and attribute names, they are removed by default.
+	KeepNamespaces bool `bson:"keep_namespaces,omitempty" json:"keep_namespaces,omitempty"`
+	// SOAPEnvelope wraps the converted XML in a SOAP envelope, and unwraps the body of a SOAP envelope converted from XML.
+	SOAPEnvelope bool `bson:"soap_envelope,omitempty" json:"soap_envelope,omitempty"`
+}
+
+// ResponseCondition restricts a response middleware to the responses matching all of its set criteria.
+type ResponseCondition struct {
+	// StatusCodes are the matching status codes: a code such as `404`, a class such as `5xx`, or a range such as `500-504`.
+	StatusCodes []string `mapstructure:"status_codes" bson:"status_codes" json:"status_codes,omitempty"`
+	// ContentTypes are the matching media types, a type may end with a `/*` wildcard.
+	ContentTypes []string `mapstructure:"content_types" bson:"content_types" json:"content_types,omitempty"`
 }
 
 type TemplateMeta struct {
@@ -188,12 +231,16 @@ type TemplateMeta struct {
 	TemplateData TemplateData `bson:"template_data" json:"template_data"`
 	Path         string       `bson:"path" json:"path"`
 	Method       string       `bson:"method" json:"method"`
+	// ResponseCondition restricts response transforms to the matching responses.
+	ResponseCondition *ResponseCondition `bson:"response_condition,omitempty" json:"response_condition,omitempty"`
 }
 
 type TransformJQMeta struct {
 	Filter string `bson:"filter" json:"filter"`
 	Path   string `bson:"path" json:"path"`
 	Method string `bson:"method" json:"method"`
+	// ResponseCondition restricts response transforms to the matching responses.
+	ResponseCondition *ResponseCondition `bson:"response_condition,omitempty" json:"response_condition,omitempty"`
 }
 
 type HeaderInjectionMeta struct {
@@ -203,6 +250,8 @@ type HeaderInjectionMeta struct {
 	Path          string            `bson:"path" json:"path"`
 	Method        string            `bson:"method" json:"method"`
 	ActOnResponse bool              `bson:"act_on" json:"act_on"`
+	// ResponseCondition restricts response header transforms to the matching responses.
+	ResponseCondition *ResponseCondition `bson:"response_condition,omitempty" json:"response_condition,omitempty"`
 }
 
 func (h *HeaderInjectionMeta) Enabled() bool {
@@ -222,6 +271,22 @@ type TrackEndpointMeta struct {
 	Method   string `bson:"method" json:"method"`
 }
 
+// RedactionMeta configures the redaction rules of an API path, they apply on top of the rules of the API.
+type RedactionMeta struct {
+	Disabled bool            `bson:"disabled" json:"disabled"`
+	Path     string          `bson:"path" json:"path"`
+	Method   string          `bson:"method" json:"method"`
+	Rules    []RedactionRule `bson:"rules" json:"rules"`
+}
+
+// RoutingMeta configures the conditional routing rules of an API path, they're evaluated before the rules of the API.
+type RoutingMeta struct {
+	Disabled bool          `bson:"disabled" json:"disabled"`
+	Path     string        `bson:"path" json:"path"`
+	Method   string        `bson:"method" json:"method"`
+	Rules    []RoutingRule `bson:"rules" json:"rules"`
+}
+
 // RateLimitMeta configures rate limits per API path.
 type RateLimitMeta struct {
 	Disabled bool   `bson:"disabled" json:"disabled"`
@@ -268,6 +333,29 @@ type RequestSizeMeta struct {
 	SizeLimit int64  `bson:"size_limit" json:"size_limit"`
 }
 
+// ContentPolicyMeta restricts the content of the requests to an endpoint. Limits set to zero are not enforced.
+type ContentPolicyMeta struct {
+	Disabled bool   `bson:"disabled" json:"disabled"`
+	Path     string `bson:"path" json:"path"`
+	Method   string `bson:"method" json:"method"`
+	// AllowedContentTypes are the media types accepted for request bodies, a type may end with a `/*` wildcard.
+	AllowedContentTypes []string `bson:"allowed_content_types" json:"allowed_content_types,omitempty"`
+	// MaxJSONDepth is the maximum nesting depth of objects and arrays in JSON bodies.
+	MaxJSONDepth int `bson:"max_json_depth" json:"max_json_depth,omitempty"`
+	// MaxJSONArrayLength is the maximum number of items of an array in JSON bodies.
+	MaxJSONArrayLength int `bson:"max_json_array_length" json:"max_json_array_length,omitempty"`
+	// MaxJSONStringLength is the maximum number of characters of a string or object key in JSON bodies.
+	MaxJSONStringLength int `bson:"max_json_string_length" json:"max_json_string_length,omitempty"`
+	// MaxMultipartParts is the maximum number of parts of multipart bodies.
+	MaxMultipartParts int `bson:"max_multipart_parts" json:"max_multipart_parts,omitempty"`
+	// MaxMultipartPartSize is the maximum size of a part of multipart bodies in bytes.
+	MaxMultipartPartSize int64 `bson:"max_multipart_part_size" json:"max_multipart_part_size,omitempty"`
+	// MaxQueryParams is the maximum number of query parameters.
+	MaxQueryParams int `bson:"max_query_params" json:"max_query_params,omitempty"`
+	// MaxHeaders is the maximum number of request headers.
+	MaxHeaders int `bson:"max_headers" json:"max_headers,omitempty"`
+}
+
 type CircuitBreakerMeta struct {
 	Disabled             bool    `bson:"disabled" json:"disabled"`
 	Path                 string  `bson:"path" json:"path"`
@@ -396,6 +484,7 @@ type ExtendedPathsSet struct {
 	URLRewrite              []URLRewriteMeta      `bson:"url_rewrites" json:"url_rewrites,omitempty"`
 	Virtual                 []VirtualMeta         `bson:"virtual" json:"virtual,omitempty"`
 	SizeLimit               []RequestSizeMeta     `bson:"size_limits" json:"size_limits,omitempty"`
+	ContentPolicy           []ContentPolicyMeta   `bson:"content_policies" json:"content_policies,omitempty"`
 	MethodTransforms        []MethodTransformMeta `bson:"method_transforms" json:"method_transforms,omitempty"`
 	TrackEndpoints          []TrackEndpointMeta   `bson:"track_endpoints" json:"track_endpoints,omitempty"`
 	DoNotTrackEndpoints     []TrackEndpointMeta   `bson:"do_not_track_endpoints" json:"do_not_track_endpoints,omitempty"`
@@ -405,6 +494,8 @@ type ExtendedPathsSet struct {
 	GoPlugin                []GoPluginMeta        `bson:"go_plugin" json:"go_plugin,omitempty"`
 	PersistGraphQL          []PersistGraphQLMeta  `bson:"persist_graphql" json:"persist_graphql"`
 	RateLimit               []RateLimitMeta       `bson:"rate_limit" json:"rate_limit"`
+	Redaction               []RedactionMeta       `bson:"redaction" json:"redaction,omitempty"`
+	Routing                 []RoutingMeta         `bson:"routing" json:"routing,omitempty"`
 }
 
 // Clear omits values that have OAS API definition conversions in place.
@@ -477,9 +568,11 @@ type VersionInfo struct {
 	GlobalResponseHeaders         map[string]string `bson:"global_response_headers" json:"global_response_headers"`
 	GlobalResponseHeadersRemove   []string          `bson:"global_response_headers_remove" json:"global_response_headers_remove"`
 	GlobalResponseHeadersDisabled bool              `bson:"global_response_headers_disabled" json:"global_response_headers_disabled"`
-	IgnoreEndpointCase            bool              `bson:"ignore_endpoint_case" json:"ignore_endpoint_case"`
-	GlobalSizeLimit               int64             `bson:"global_size_limit" json:"global_size_limit"`
-	OverrideTarget                string            `bson:"override_target" json:"override_target"`
+	// GlobalResponseHeadersCondition restricts the global response header transforms to the matching responses.
+	GlobalResponseHeadersCondition *ResponseCondition `bson:"global_response_headers_condition,omitempty" json:"global_response_headers_condition,omitempty"`
+	IgnoreEndpointCase             bool               `bson:"ignore_endpoint_case" json:"ignore_endpoint_case"`
+	GlobalSizeLimit                int64              `bson:"global_size_limit" json:"global_size_limit"`
+	OverrideTarget                 string             `bson:"override_target" json:"override_target"`
 }
 
 func (v *VersionInfo) GlobalHeadersEnabled() bool {
@@ -579,6 +672,23 @@ type MiddlewareSection struct {
 	IdExtractor MiddlewareIdExtractor  `bson:"id_extractor" json:"id_extractor"`
 }
 
+// JSRuntimeConfig configures the JavaScript engine running the `otto` driver middleware and the virtual endpoints.
+type JSRuntimeConfig struct {
+	// Engine is the JavaScript engine, either `otto` (ES5, the default) or `goja` (ES2020+, with `fetch`).
+	Engine string `bson:"engine" json:"engine"`
+	// Timeout is the maximum duration in seconds of an invocation, defaults to the gateway's `jsvm_timeout`.
+	Timeout int `bson:"timeout" json:"timeout"`
+	// PoolSize is the number of `goja` VMs prepared ahead of the invocations, defaults to the number of CPUs.
+	// Each invocation runs on a fresh VM, so no global state is kept between invocations.
+	PoolSize int `bson:"pool_size" json:"pool_size"`
+	// MaxMemory is the maximum memory in bytes allocated by a `goja` invocation through the built-in functions
+	// creating strings and arrays, such as `String.prototype.repeat`, `Array.prototype.push` or `JSON.parse`,
+	// unlimited if 0. Memory allocated by operators and literals isn't counted.
+	MaxMemory int64 `bson:"max_memory" json:"max_memory"`
+	// MaxCallStackSize is the maximum depth of the `goja` call stack, unlimited if 0.
+	MaxCallStackSize int `bson:"max_call_stack_size" json:"max_call_stack_size"`
+}
+
 type CacheOptions struct {
 	CacheTimeout               int64    `bson:"cache_timeout" json:"cache_timeout"`
 	EnableCache                bool     `bson:"enable_cache" json:"enable_cache"`
@@ -587,6 +697,23 @@ type CacheOptions struct {
 	EnableUpstreamCacheControl bool     `bson:"enable_upstream_cache_control" json:"enable_upstream_cache_control"`
 	CacheControlTTLHeader      string   `bson:"cache_control_ttl_header" json:"cache_control_ttl_header"`
 	CacheByHeaders             []string `bson:"cache_by_headers" json:"cache_by_headers"`
+	// StaleWhileRevalidate is the time in seconds after a cached response expires during which it is
+	// still served while it is refreshed from the upstream in the background (RFC 5861).
+	StaleWhileRevalidate int64 `bson:"stale_while_revalidate" json:"stale_while_revalidate"`
+	// StaleIfError is the time in seconds after a cached response expires during which it is
+	// served instead of an upstream error (RFC 5861).
+	StaleIfError int64 `bson:"stale_if_error" json:"stale_if_error"`
+	// EnableRequestCoalescing makes concurrent cache misses for the same cache key wait for the
+	// first request to populate the cache instead of all going to the upstream.
+	EnableRequestCoalescing bool `bson:"enable_request_coalescing" json:"enable_request_coalescing"`
+	// EnableHTTPSemantics makes the cache follow RFC 9111: it honours `Vary` and the `Cache-Control`
+	// request and response directives, generates strong ETags for cached responses and refreshes
+	// expired responses with conditional requests to the upstream.
+	EnableHTTPSemantics bool `bson:"enable_http_semantics" json:"enable_http_semantics"`
+	// Backend is the storage of the cached responses: `redis`, `memory`, `disk` or `s3`.
+	// The backends other than Redis are configured in the gateway `response_cache_storage` settings.
+	// Defaults to Redis.
+	Backend string `bson:"backend" json:"backend"`
 }
 
 type ResponseProcessor struct {
@@ -685,9 +812,16 @@ type APIDefinition struct {
 		ExtractFromBody    bool   `bson:"extract_from_body" json:"extract_from_body"`
 		BodyUserRegexp     string `bson:"body_user_regexp" json:"body_user_regexp"`
 		BodyPasswordRegexp string `bson:"body_password_regexp" json:"body_password_regexp"`
+		// LDAP verifies basic auth credentials against an LDAP or Active Directory server
+		// instead of looking up a key in storage.
+		LDAP LDAPAuthConfig `bson:"ldap" json:"ldap"`
 	} `bson:"basic_auth" json:"basic_auth"`
 	UseMutualTLSAuth   bool     `bson:"use_mutual_tls_auth" json:"use_mutual_tls_auth"`
 	ClientCertificates []string `bson:"client_certificates" json:"client_certificates"`
+	// ClientCertificateIdentity derives sessions from the fields of the client certificate.
+	ClientCertificateIdentity ClientCertificateIdentity `bson:"client_certificate_identity" json:"client_certificate_identity"`
+	// ClientCertificateRevocation configures CRL and OCSP checks of client certificates.
+	ClientCertificateRevocation ClientCertificateRevocation `bson:"client_certificate_revocation" json:"client_certificate_revocation"`
 
 	// UpstreamCertificates stores the domain to certificate mapping for upstream mutualTLS
 	UpstreamCertificates map[string]string `bson:"upstream_certificates" json:"upstream_certificates"`
@@ -733,6 +867,7 @@ type APIDefinition struct {
 	CustomMiddleware                     MiddlewareSection      `bson:"custom_middleware" json:"custom_middleware"`
 	CustomMiddlewareBundle               string                 `bson:"custom_middleware_bundle" json:"custom_middleware_bundle"`
 	CustomMiddlewareBundleDisabled       bool                   `bson:"custom_middleware_bundle_disabled" json:"custom_middleware_bundle_disabled"`
+	JSRuntime                            JSRuntimeConfig        `bson:"js_runtime" json:"js_runtime"`
 	CacheOptions                         CacheOptions           `bson:"cache_options" json:"cache_options"`
 	SessionLifetimeRespectsKeyExpiration bool                   `bson:"session_lifetime_respects_key_expiration" json:"session_lifetime_respects_key_expiration,omitempty"`
 	SessionLifetime                      int64                  `bson:"session_lifetime" json:"session_lifetime"`
@@ -750,6 +885,9 @@ type APIDefinition struct {
 	ExpireAnalyticsAfter                 int64                  `mapstructure:"expire_analytics_after" bson:"expire_analytics_after" json:"expire_analytics_after"` // must have an expireAt TTL index set (http://docs.mongodb.org/manual/tutorial/expire-data/)
 	ResponseProcessors                   []ResponseProcessor    `bson:"response_processors" json:"response_processors"`
 	CORS                                 CORSConfig             `bson:"CORS" json:"CORS"`
+	Compression                          CompressionConfig      `bson:"compression" json:"compression"`
+	Redaction                            RedactionConfig        `bson:"redaction" json:"redaction"`
+	Routing                              RoutingConfig          `bson:"routing" json:"routing"`
 	Domain                               string                 `bson:"domain" json:"domain"`
 	DomainDisabled                       bool                   `bson:"domain_disabled" json:"domain_disabled,omitempty"`
 	Certificates                         []string               `bson:"certificates" json:"certificates"`
@@ -778,6 +916,111 @@ type APIDefinition struct {
 	UpstreamAuth UpstreamAuth `bson:"upstream_auth" json:"upstream_auth"`
 }
 
+// LDAPAuthConfig holds the configuration for authenticating basic auth users against an LDAP directory.
+// The user is verified by binding to the directory with the supplied credentials, the groups of the user
+// are mapped to policies which are applied to a virtual session.
+type LDAPAuthConfig struct {
+	// Enabled enables LDAP authentication for basic auth.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Server is the hostname of the LDAP server.
+	Server string `bson:"server" json:"server"`
+	// Port is the port of the LDAP server. Defaults to 389, or 636 when UseSSL is set.
+	Port uint16 `bson:"port" json:"port"`
+	// UseSSL connects to the server over TLS (ldaps).
+	UseSSL bool `bson:"use_ssl" json:"use_ssl"`
+	// StartTLS upgrades the plain connection to TLS with the StartTLS extended operation.
+	StartTLS bool `bson:"start_tls" json:"start_tls"`
+	// SSLInsecureSkipVerify skips the verification of the LDAP server certificate.
+	SSLInsecureSkipVerify bool `bson:"ssl_insecure_skip_verify" json:"ssl_insecure_skip_verify"`
+	// UserDNTemplate builds the DN used to bind as the user, `{username}` is replaced with the supplied
+	// username, e.g. `uid={username},ou=people,dc=example,dc=com` or `{username}@corp.example.com` for AD.
+	// When empty, the user DN is found with a search using SearchBindDN and UserFilter.
+	UserDNTemplate string `bson:"user_dn_template" json:"user_dn_template"`
+	// SearchBindDN is the DN of the service account used to search for users.
+	SearchBindDN string `bson:"search_bind_dn" json:"search_bind_dn"`
+	// SearchBindPassword is the password of the service account used to search for users.
+	SearchBindPassword string `bson:"search_bind_password" json:"search_bind_password"`
+	// BaseDN is the base DN for user searches.
+	BaseDN string `bson:"base_dn" json:"base_dn"`
+	// UserFilter is the filter used to find the user entry, `{username}` is replaced with the escaped
+	// username. Defaults to `(uid={username})`.
+	UserFilter string `bson:"user_filter" json:"user_filter"`
+	// GroupAttribute is the attribute of the user entry listing the groups of the user. Defaults to `memberOf`.
+	GroupAttribute string `bson:"group_attribute" json:"group_attribute"`
+	// GroupPolicies maps group DNs or common names to policy IDs.
+	GroupPolicies map[string]string `bson:"group_policies" json:"group_policies"`
+	// DefaultPolicies are applied to every authenticated user.
+	DefaultPolicies []string `bson:"default_policies" json:"default_policies"`
+	// MetadataAttributes lists user entry attributes which are copied to the session metadata.
+	MetadataAttributes []string `bson:"metadata_attributes" json:"metadata_attributes"`
+	// CacheTTL is the time in seconds a successful bind is cached. Defaults to 60, set to -1 to disable caching.
+	CacheTTL int64 `bson:"cache_ttl" json:"cache_ttl"`
+	// Timeout is the connection and read timeout in seconds. Defaults to 5.
+	Timeout int64 `bson:"timeout" json:"timeout"`
+}
+
+// Client certificate fields which can be used for identity mapping.
+const (
+	CertFieldSubjectCN    = "subject.cn"
+	CertFieldSubjectO     = "subject.o"
+	CertFieldSubjectOU    = "subject.ou"
+	CertFieldSANURI       = "san.uri"
+	CertFieldSANDNS       = "san.dns"
+	CertFieldSANEmail     = "san.email"
+	CertFieldSerialNumber = "serial"
+)
+
+// ClientCertificateIdentity holds the configuration for deriving sessions from the fields of the
+// client certificate presented over mutual TLS, without creating a key per certificate.
+type ClientCertificateIdentity struct {
+	// Enabled enables client certificate identity mapping as an authentication mechanism.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// IdentityField is the certificate field used as the session identity, one of `subject.cn`,
+	// `subject.o`, `subject.ou`, `san.uri`, `san.dns`, `san.email` or `serial` (lower case hex).
+	// Defaults to `subject.cn`.
+	IdentityField string `bson:"identity_field" json:"identity_field"`
+	// Rules map certificate field values to policies and metadata. Policies of all matching rules are applied.
+	Rules []CertificateIdentityRule `bson:"rules" json:"rules"`
+	// DefaultPolicies are applied to every certificate with an identity.
+	DefaultPolicies []string `bson:"default_policies" json:"default_policies"`
+}
+
+// CertificateIdentityRule maps a client certificate field to policies and metadata.
+type CertificateIdentityRule struct {
+	// Field is the certificate field the rule matches, see ClientCertificateIdentity.IdentityField.
+	Field string `bson:"field" json:"field"`
+	// Match is a regular expression matched against the field values. An empty value matches any value.
+	Match string `bson:"match" json:"match"`
+	// Policies are the policy IDs applied when the rule matches.
+	Policies []string `bson:"policies" json:"policies"`
+	// Metadata is added to the session when the rule matches. Values can reference
+	// capture groups of Match, e.g. `$1` or `${name}`.
+	Metadata map[string]string `bson:"metadata" json:"metadata"`
+}
+
+// ClientCertificateRevocation holds the configuration for revocation checks of client certificates.
+type ClientCertificateRevocation struct {
+	// CheckCRL enables checking client certificates against certificate revocation lists.
+	CheckCRL bool `bson:"check_crl" json:"check_crl"`
+	// CRLURLs are the URLs of the CRLs to check. When empty, the CRL distribution points of the certificate are used.
+	CRLURLs []string `bson:"crl_urls" json:"crl_urls"`
+	// CheckOCSP enables checking client certificates with an OCSP responder.
+	CheckOCSP bool `bson:"check_ocsp" json:"check_ocsp"`
+	// OCSPResponderURL is the URL of the OCSP responder. When empty, the OCSP server of the certificate is used.
+	OCSPResponderURL string `bson:"ocsp_responder_url" json:"ocsp_responder_url"`
+	// FailOpen allows requests when the revocation status can't be determined.
+	FailOpen bool `bson:"fail_open" json:"fail_open"`
+	// CacheTTL is the maximum time in seconds a CRL or OCSP response is cached. Defaults to 300.
+	CacheTTL int64 `bson:"cache_ttl" json:"cache_ttl"`
+	// Timeout is the timeout in seconds for CRL downloads and OCSP queries. Defaults to 5.
+	Timeout int64 `bson:"timeout" json:"timeout"`
+}
+
+// Enabled returns true if any revocation check is enabled.
+func (c ClientCertificateRevocation) Enabled() bool {
+	return c.CheckCRL || c.CheckOCSP
+}
+
 // UpstreamAuth holds the configurations related to upstream API authentication.
 type UpstreamAuth struct {
 	// Enabled enables upstream API authentication.
@@ -988,6 +1231,204 @@ type CORSConfig struct {
 	Debug              bool     `bson:"debug" json:"debug"`
 }
 
+// CompressionConfig configures the compression of the responses and the decompression of the request bodies of an API.
+type CompressionConfig struct {
+	// Enabled compresses the responses with an algorithm the client accepts in `Accept-Encoding`.
+	// Responses compressed by the upstream are passed through as they are.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Algorithms are the content codings the responses are compressed with: `br`, `zstd` and `gzip`.
+	// Their order breaks ties between the codings the client accepts with the same weight. Defaults to all of them.
+	Algorithms []string `bson:"algorithms" json:"algorithms"`
+	// MinSize is the minimum size in bytes of the responses to compress, defaults to 1024.
+	// Streamed responses of unknown size are always compressed.
+	MinSize int64 `bson:"min_size" json:"min_size"`
+	// ContentTypes are the media types of the responses to compress. A type ending with `/*` matches all its
+	// subtypes. Defaults to text, JSON, XML and JavaScript types.
+	ContentTypes []string `bson:"content_types" json:"content_types"`
+	// Level is the compression level from 1, the fastest, to 9, the smallest. Defaults to the default level of each algorithm.
+	Level int `bson:"level" json:"level"`
+	// DecompressRequests decompresses `gzip`, `deflate`, `br` and `zstd` encoded request bodies, so the
+	// transforms and validation work on the decompressed body, which is proxied to the upstream.
+	DecompressRequests bool `bson:"decompress_requests" json:"decompress_requests"`
+	// MaxDecompressedSize is the maximum size in bytes of a decompressed request body, defaults to 10MB.
+	// Larger requests are rejected.
+	MaxDecompressedSize int64 `bson:"max_decompressed_size" json:"max_decompressed_size"`
+}
+
+// RedactionAction is the action taken on the values matched by a redaction rule.
+type RedactionAction string
+
+// RedactionTarget is the data a redaction rule applies to.
+type RedactionTarget string
+
+const (
+	// RedactionMask replaces the characters of the matched values with the mask character.
+	RedactionMask RedactionAction = "mask"
+	// RedactionHash replaces the matched values with their hex encoded SHA-256 hash.
+	RedactionHash RedactionAction = "hash"
+	// RedactionRemove removes the matched fields, or the matched text of the detectors and patterns.
+	RedactionRemove RedactionAction = "remove"
+
+	// RedactionTargetRequest redacts the request bodies proxied to the upstream.
+	RedactionTargetRequest RedactionTarget = "request"
+	// RedactionTargetResponse redacts the response bodies returned to the client.
+	RedactionTargetResponse RedactionTarget = "response"
+	// RedactionTargetAnalytics redacts the raw request and response captured in the analytics records.
+	RedactionTargetAnalytics RedactionTarget = "analytics"
+)
+
+// RedactionConfig configures the redaction of sensitive data from the request and response bodies of an API, and
+// from the raw request and response captured in the analytics records.
+type RedactionConfig struct {
+	// Enabled activates the redaction rules of the API.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Rules are the redaction rules applying to all the endpoints of the API.
+	Rules []RedactionRule `bson:"rules" json:"rules"`
+}
+
+// RedactionRule selects the sensitive data of JSON bodies with JSONPath or JMESPath selectors, or of any body with
+// detectors and patterns, and masks, hashes or removes it.
+//
+// Without selectors, the detectors and patterns apply to all the string values of JSON bodies, or to the whole text
+// of other bodies. With selectors, they apply to the string values within the selected values, and the selected
+// values are redacted as a whole when no detector or pattern is set.
+type RedactionRule struct {
+	// Name identifies the rule in the logs.
+	Name string `bson:"name" json:"name,omitempty"`
+	// JSONPath are JSONPath selectors, for example `$.card.number` or `$..email`.
+	JSONPath []string `bson:"json_path" json:"json_path,omitempty"`
+	// JMESPath are JMESPath selectors, for example `card.number` or `users[*].email`.
+	JMESPath []string `bson:"jmes_path" json:"jmes_path,omitempty"`
+	// Detectors are built-in detectors of sensitive values: `card_number`, `email`, `ssn` and `iban`.
+	Detectors []string `bson:"detectors" json:"detectors,omitempty"`
+	// Patterns are regular expressions matching sensitive values.
+	Patterns []string `bson:"patterns" json:"patterns,omitempty"`
+	// Action is `mask`, `hash` or `remove`, defaults to `mask`.
+	Action RedactionAction `bson:"action" json:"action,omitempty"`
+	// MaskCharacter is the character the masked values are replaced with, defaults to `*`.
+	MaskCharacter string `bson:"mask_character" json:"mask_character,omitempty"`
+	// KeepLast is the number of trailing characters left unmasked.
+	KeepLast int `bson:"keep_last" json:"keep_last,omitempty"`
+	// Targets are the data the rule applies to: `request`, `response` and `analytics`. Defaults to all of them.
+	Targets []RedactionTarget `bson:"targets" json:"targets,omitempty"`
+	// Policies restricts the rule to the sessions having one of the policies.
+	Policies []string `bson:"policies" json:"policies,omitempty"`
+	// Tags restricts the rule to the sessions having one of the tags.
+	// When both policies and tags are set, the rule applies to the sessions having one of either.
+	Tags []string `bson:"tags" json:"tags,omitempty"`
+}
+
+// RoutingSource is the part of the request a routing condition reads.
+type RoutingSource string
+
+// RoutingOperator is the comparison of a routing condition.
+type RoutingOperator string
+
+const (
+	// RoutingSourceHeader reads the request header named by the key.
+	RoutingSourceHeader RoutingSource = "header"
+	// RoutingSourceQuery reads the query parameter named by the key.
+	RoutingSourceQuery RoutingSource = "query"
+	// RoutingSourcePath reads the request path.
+	RoutingSourcePath RoutingSource = "path"
+	// RoutingSourceMethod reads the request method.
+	RoutingSourceMethod RoutingSource = "method"
+	// RoutingSourceHost reads the request host.
+	RoutingSourceHost RoutingSource = "host"
+	// RoutingSourceSessionMeta reads the session metadata field named by the key.
+	RoutingSourceSessionMeta RoutingSource = "session_meta"
+	// RoutingSourceContext reads the context variable named by the key.
+	RoutingSourceContext RoutingSource = "context"
+	// RoutingSourceBody reads the field of the JSON request body at the dot separated path of the key,
+	// for example `order.items.0.sku`.
+	RoutingSourceBody RoutingSource = "body"
+
+	// RoutingEquals matches values equal to the value, numbers and booleans are compared by value.
+	RoutingEquals RoutingOperator = "eq"
+	// RoutingNotEquals matches values not equal to the value.
+	RoutingNotEquals RoutingOperator = "neq"
+	// RoutingContains matches values containing the value.
+	RoutingContains RoutingOperator = "contains"
+	// RoutingPrefix matches values starting with the value.
+	RoutingPrefix RoutingOperator = "prefix"
+	// RoutingSuffix matches values ending with the value.
+	RoutingSuffix RoutingOperator = "suffix"
+	// RoutingRegex matches values matching the regular expression of the value.
+	RoutingRegex RoutingOperator = "regex"
+	// RoutingIn matches values equal to one of the values.
+	RoutingIn RoutingOperator = "in"
+	// RoutingGreater matches numbers greater than the value.
+	RoutingGreater RoutingOperator = "gt"
+	// RoutingGreaterOrEqual matches numbers greater than or equal to the value.
+	RoutingGreaterOrEqual RoutingOperator = "gte"
+	// RoutingLess matches numbers less than the value.
+	RoutingLess RoutingOperator = "lt"
+	// RoutingLessOrEqual matches numbers less than or equal to the value.
+	RoutingLessOrEqual RoutingOperator = "lte"
+	// RoutingExists matches values present in the request.
+	RoutingExists RoutingOperator = "exists"
+)
+
+// RoutingConfig configures the conditional routing of an API.
+type RoutingConfig struct {
+	// Enabled activates the routing rules of the API.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Rules are evaluated in order for all the endpoints of the API, the actions of the first matching rule apply.
+	Rules []RoutingRule `bson:"rules" json:"rules"`
+}
+
+// RoutingRule applies its actions to the requests matching its conditions.
+type RoutingRule struct {
+	// Name identifies the rule in the logs and evaluation results.
+	Name string `bson:"name" json:"name,omitempty"`
+	// Match is `all` to require all the conditions to match, or `any` to require one of them. Defaults to `all`.
+	Match RoutingTriggerOnType `bson:"match" json:"match,omitempty"`
+	// Conditions are the conditions of the rule, a rule without conditions matches all the requests.
+	Conditions []RoutingCondition `bson:"conditions" json:"conditions,omitempty"`
+	// Action is applied to the matching requests.
+	Action RoutingAction `bson:"action" json:"action"`
+}
+
+// RoutingCondition compares a value of the request.
+type RoutingCondition struct {
+	// Source is the part of the request the value is read from.
+	Source RoutingSource `bson:"source" json:"source"`
+	// Key names the value within the source: the header, query parameter, session metadata field, context
+	// variable, or body field path.
+	Key string `bson:"key" json:"key,omitempty"`
+	// Operator is the comparison.
+	Operator RoutingOperator `bson:"operator" json:"operator"`
+	// Value is the operand of the comparison.
+	Value string `bson:"value" json:"value,omitempty"`
+	// Values are the operands of the `in` operator.
+	Values []string `bson:"values" json:"values,omitempty"`
+	// Negate inverts the result of the comparison.
+	Negate bool `bson:"negate" json:"negate,omitempty"`
+}
+
+// RoutingAction is applied to the requests matching a routing rule. The headers are set first, then a fixed
+// response is returned, or the request is switched to the version and upstream.
+type RoutingAction struct {
+	// UpstreamURL is the upstream the request is proxied to, it supports request context variables.
+	UpstreamURL string `bson:"upstream_url" json:"upstream_url,omitempty"`
+	// Version is the API version the request is switched to.
+	Version string `bson:"version" json:"version,omitempty"`
+	// SetHeaders are the request headers to set, the values support request context variables.
+	SetHeaders map[string]string `bson:"set_headers" json:"set_headers,omitempty"`
+	// Response is returned instead of proxying the request when its code is set.
+	Response RoutingResponse `bson:"response" json:"response"`
+}
+
+// RoutingResponse is a fixed response returned by a routing rule.
+type RoutingResponse struct {
+	// Code is the status code of the response.
+	Code int `bson:"code" json:"code,omitempty"`
+	// Body is the body of the response.
+	Body string `bson:"body" json:"body,omitempty"`
+	// Headers are the headers of the response.
+	Headers map[string]string `bson:"headers" json:"headers,omitempty"`
+}
+
 // GraphQLConfig is the root config object for a GraphQL API.
 type GraphQLConfig struct {
 	// Enabled indicates if GraphQL should be enabled.
@@ -1014,6 +1455,12 @@ type GraphQLConfig struct {
 	Supergraph GraphQLSupergraphConfig `bson:"supergraph" json:"supergraph"`
 	// Introspection holds the configuration for GraphQL Introspection
 	Introspection GraphQLIntrospectionConfig `bson:"introspection" json:"introspection"`
+	// PersistedQueries holds the configuration for persisted queries and the operation safelist.
+	PersistedQueries GraphQLPersistedQueriesConfig `bson:"persisted_queries" json:"persisted_queries"`
+	// CostAnalysis holds the configuration for the static cost analysis of the requests.
+	CostAnalysis GraphQLCostAnalysisConfig `bson:"cost_analysis" json:"cost_analysis"`
+	// Cache holds the configuration for the caching of the responses.
+	Cache GraphQLCacheConfig `bson:"cache" json:"cache"`
 }
 
 type GraphQLConfigVersion string
@@ -1029,6 +1476,63 @@ type GraphQLIntrospectionConfig struct {
 	Disabled bool `bson:"disabled" json:"disabled"`
 }
 
+// GraphQLPersistedQueriesConfig configures the persisted queries of a GraphQL API. Operations are identified by the
+// SHA-256 hash of their query, sent in the persistedQuery extension of the request.
+type GraphQLPersistedQueriesConfig struct {
+	// Automatic enables automatic persisted queries, a query sent along with its hash is stored to be
+	// referenced by its hash alone in the following requests.
+	Automatic bool `bson:"automatic" json:"automatic"`
+	// TTL is the lifetime in seconds of the automatically persisted queries, 0 keeps them forever.
+	TTL int64 `bson:"ttl" json:"ttl"`
+	// Safelist only allows the operations registered through the Gateway API, for every key of the API.
+	// Policies can enforce the safelist for their keys only.
+	Safelist bool `bson:"safelist" json:"safelist"`
+}
+
+// GraphQLCostAnalysisConfig configures the static cost analysis of the requests, the max cost of a request is set by
+// the policies. The cost of a field is its weight plus the cost of its selections, multiplied by the size of the list
+// it returns. Weights and list sizes are read from the @cost(weight) and @listSize(assumedSize, slicingArguments)
+// directives of the schema.
+type GraphQLCostAnalysisConfig struct {
+	// Enabled enables the cost analysis.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Weights overrides the weights of the schema, keyed by type ("Country") or field ("Query.countries"). Without
+	// weight, object fields cost 1 and scalar fields cost 0.
+	Weights map[string]int `bson:"weights" json:"weights"`
+	// SlicingArguments are the arguments setting the size of the lists without @listSize directive, e.g. first.
+	SlicingArguments []string `bson:"slicing_arguments" json:"slicing_arguments"`
+	// DefaultListSize is the size of the lists without slicing argument nor assumed size, it defaults to 1.
+	DefaultListSize int `bson:"default_list_size" json:"default_list_size"`
+	// ChargeQuota counts the cost of a request against the quota of the key instead of a single request.
+	ChargeQuota bool `bson:"charge_quota" json:"charge_quota"`
+}
+
+// GraphQLCacheConfig configures the caching of the responses of query operations. Operations are normalised before
+// keying, and a response lives as long as the field with the lowest lifetime it holds. Lifetimes are read from the
+// @cacheControl(maxAge, scope) directives of the schema, or from the type TTLs.
+type GraphQLCacheConfig struct {
+	// Enabled enables the caching of the responses.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// DefaultTTL is the lifetime in seconds of the root fields, and of the fields returning objects, without cache
+	// hint. Responses holding such fields are not cached when it is 0.
+	DefaultTTL int64 `bson:"default_ttl" json:"default_ttl"`
+	// TypeTTLs overrides the lifetime in seconds of the fields returning the types.
+	TypeTTLs map[string]int64 `bson:"type_ttls" json:"type_ttls"`
+	// PrivateTypes are the types holding per-session data, responses holding them are cached per session.
+	PrivateTypes []string `bson:"private_types" json:"private_types"`
+	// DataSourceFetches holds the configuration for the caching of the data source fetches.
+	DataSourceFetches GraphQLDataSourceCacheConfig `bson:"data_source_fetches" json:"data_source_fetches"`
+}
+
+// GraphQLDataSourceCacheConfig configures the caching of the data source fetches of the execution engine, it is only
+// supported by the engine version 3.
+type GraphQLDataSourceCacheConfig struct {
+	// Enabled enables the caching of the fetches.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// TTL is the lifetime in seconds of the fetched data, it defaults to the default TTL of the cache.
+	TTL int64 `bson:"ttl" json:"ttl"`
+}
+
 type GraphQLResponseExtensions struct {
 	OnErrorForwarding bool `bson:"on_error_forwarding" json:"on_error_forwarding"`
 }
@@ -1635,6 +2139,8 @@ type WebHookHandlerConf struct {
 	HeaderList map[string]string `bson:"header_map" json:"header_map"`
 	// The cool-down for the event so it does not trigger again (in seconds).
 	EventTimeout int64 `bson:"event_timeout" json:"event_timeout"`
+	// SigningSecret is the secret of the HMAC-SHA256 signature of the payload, sent in the X-Tyk-Signature header.
+	SigningSecret string `bson:"signing_secret" json:"signing_secret,omitempty"`
 }
 
 // Scan scans WebHookHandlerConf from `any` in.
@@ -1647,3 +2153,52 @@ func (w *WebHookHandlerConf) Scan(in any) error {
 	*w = *conf
 	return nil
 }
+
+// EventSinkHandlerConf holds configuration related to the Kafka, NATS and AMQP event handlers, which publish the
+// events to a message broker.
+type EventSinkHandlerConf struct {
+	// Disabled enables/disables this event handler.
+	Disabled bool `bson:"disabled" json:"disabled"`
+	// ID optional ID of the event handler, to be used in pro mode.
+	ID string `bson:"id" json:"id"`
+	// Name is the name of the event handler.
+	Name string `bson:"name" json:"name"`
+	// Addresses are the addresses of the Kafka brokers, the URLs of the NATS servers or the URL of the AMQP broker.
+	Addresses []string `bson:"addresses" json:"addresses"`
+	// Topic is the Kafka topic, the NATS subject or the AMQP routing key the events are published to.
+	Topic string `bson:"topic" json:"topic"`
+	// Exchange is the AMQP exchange the events are published to, the default exchange when empty.
+	Exchange string `bson:"exchange" json:"exchange,omitempty"`
+	// Format is the serialization of the events, "json" or "cloudevents". Defaults to "json".
+	Format string `bson:"format" json:"format,omitempty"`
+	// BatchSize is the number of events published together. Defaults to 1, each event is published when it fires.
+	BatchSize int `bson:"batch_size" json:"batch_size,omitempty"`
+	// FlushInterval is the delay in seconds after which an incomplete batch is published. Defaults to 1.
+	FlushInterval float64 `bson:"flush_interval" json:"flush_interval,omitempty"`
+	// TLS configures the TLS connection to the broker.
+	TLS EventSinkTLSConf `bson:"tls" json:"tls"`
+}
+
+// EventSinkTLSConf holds the TLS configuration of the connection to a message broker.
+type EventSinkTLSConf struct {
+	// Enabled enables TLS.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// CAFile is the path of the CA certificates of the broker, the system CAs are used when empty.
+	CAFile string `bson:"ca_file" json:"ca_file,omitempty"`
+	// CertFile and KeyFile are the paths of the client certificate and of its private key, for mutual TLS.
+	CertFile string `bson:"cert_file" json:"cert_file,omitempty"`
+	KeyFile  string `bson:"key_file" json:"key_file,omitempty"`
+	// InsecureSkipVerify disables the verification of the certificate of the broker.
+	InsecureSkipVerify bool `bson:"insecure_skip_verify" json:"insecure_skip_verify,omitempty"`
+}
+
+// Scan scans EventSinkHandlerConf from `any` in.
+func (e *EventSinkHandlerConf) Scan(in any) error {
+	conf, err := reflect.Cast[EventSinkHandlerConf](in)
+	if err != nil {
+		return err
+	}
+
+	*e = *conf
+	return nil
+}
@@ -36,6 +36,7 @@ type Authentication struct {
 	// - `oidc_user`
 	// - `oauth_key`
 	// - `custom_auth`
+	// - `client_certificate`
 	//
 	// Tyk classic API definition: `base_identity_provided_by`.
 	BaseIdentityProvider apidef.AuthTypeEnum `bson:"baseIdentityProvider,omitempty" json:"baseIdentityProvider,omitempty"`
@@ -100,19 +100,23 @@ type WebhookEvent struct {
 	BodyTemplate string `json:"bodyTemplate,omitempty" bson:"bodyTemplate,omitempty"`
 	// Headers are the list of request headers to be used.
 	Headers Headers `json:"headers,omitempty" bson:"headers,omitempty"`
+	// SigningSecret is the secret of the HMAC-SHA256 signature of the payload, sent in the X-Tyk-Signature header.
+	// The payload isn't signed when it's empty.
+	SigningSecret string `json:"signingSecret,omitempty" bson:"signingSecret,omitempty"`
 }
 
 // GetWebhookConf converts EventHandler.WebhookEvent apidef.WebHookHandlerConf.
 func (e *EventHandler) GetWebhookConf() apidef.WebHookHandlerConf {
 	return apidef.WebHookHandlerConf{
-		Disabled:     !e.Enabled,
-		ID:           e.ID,
-		Name:         e.Name,
-		Method:       e.Webhook.Method,
-		TargetPath:   e.Webhook.URL,
-		HeaderList:   e.Webhook.Headers.Map(),
-		EventTimeout: int64(e.Webhook.CoolDownPeriod.Seconds()),
-		TemplatePath: e.Webhook.BodyTemplate,
+		Disabled:      !e.Enabled,
+		ID:            e.ID,
+		Name:          e.Name,
+		Method:        e.Webhook.Method,
+		TargetPath:    e.Webhook.URL,
+		HeaderList:    e.Webhook.Headers.Map(),
+		EventTimeout:  int64(e.Webhook.CoolDownPeriod.Seconds()),
+		TemplatePath:  e.Webhook.BodyTemplate,
+		SigningSecret: e.Webhook.SigningSecret,
 	}
 }
 
@@ -149,6 +153,7 @@ func (e *EventHandlers) Fill(api apidef.APIDefinition) {
 						Headers:        NewHeaders(whConf.HeaderList),
 						BodyTemplate:   whConf.TemplatePath,
 						CoolDownPeriod: ReadableDuration(time.Duration(whConf.EventTimeout) * time.Second),
+						SigningSecret:  whConf.SigningSecret,
 					},
 				}
 
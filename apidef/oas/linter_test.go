@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/xeipuuv/gojsonschema"
 
+	"github.com/TykTechnologies/tyk/apidef"
 	"github.com/TykTechnologies/tyk/internal/event"
 	"github.com/TykTechnologies/tyk/internal/time"
 )
@@ -26,12 +27,37 @@ func TestXTykGateway_Lint(t *testing.T) {
 	Fill(t, &securityScheme, 0)
 	{
 		settings.Middleware.Global.PluginConfig.Driver = "goplugin"
+		settings.Middleware.Global.PluginConfig.JSRuntime.Engine = "goja"
+		settings.Middleware.Global.Cache.Backend = "disk"
+		settings.Middleware.Global.Compression.Algorithms = []string{"br", "gzip"}
+		settings.Middleware.Global.Compression.Level = 5
+		settings.Middleware.Global.Compression.MinSize = 1024
+		settings.Middleware.Global.Compression.MaxDecompressedSize = 1 << 20
+		validRedactionRules(settings.Middleware.Global.Redaction)
+		validRoutingRules(settings.Middleware.Global.Routing)
+		validResponseCondition(settings.Middleware.Global.TransformRequestHeaders.Condition)
+		validResponseCondition(settings.Middleware.Global.TransformResponseHeaders.Condition)
 		for _, op := range settings.Middleware.Operations {
+			validRedactionRules(op.Redaction)
+			validRoutingRules(op.Routing)
 			if op.TransformRequestBody != nil {
 				op.TransformRequestBody.Format = "json"
+				op.TransformRequestBody.OutputFormat = "xml"
+				validResponseCondition(op.TransformRequestBody.Condition)
 			}
 			if op.TransformResponseBody != nil {
 				op.TransformResponseBody.Format = "json"
+				op.TransformResponseBody.OutputFormat = "form"
+				validResponseCondition(op.TransformResponseBody.Condition)
+			}
+			if op.TransformRequestHeaders != nil {
+				validResponseCondition(op.TransformRequestHeaders.Condition)
+			}
+			if op.TransformResponseHeaders != nil {
+				validResponseCondition(op.TransformResponseHeaders.Condition)
+			}
+			if op.ValidateResponse != nil {
+				op.ValidateResponse.Mode = ValidateResponseModeLog
 			}
 			if op.RateLimit != nil {
 				op.RateLimit.Per = ReadableDuration(time.Minute)
@@ -71,6 +97,10 @@ func TestXTykGateway_Lint(t *testing.T) {
 		settings.Server.Authentication.SecuritySchemes = map[string]interface{}{
 			"test-basic": securityScheme,
 		}
+		settings.Server.ClientCertificates.Identity.IdentityField = apidef.CertFieldSANURI
+		for i := range settings.Server.ClientCertificates.Identity.Rules {
+			settings.Server.ClientCertificates.Identity.Rules[i].Field = apidef.CertFieldSubjectOU
+		}
 		for i := range settings.Server.EventHandlers {
 			settings.Server.EventHandlers[i].Kind = event.WebhookKind
 			settings.Server.EventHandlers[i].Webhook.Method = http.MethodPost
@@ -125,3 +155,43 @@ func TestXTykGateway_Lint(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// validRedactionRules sets valid selectors and enum values on the faker filled redaction rules.
+func validRedactionRules(redaction *Redaction) {
+	if redaction == nil {
+		return
+	}
+
+	for i := range redaction.Rules {
+		redaction.Rules[i].JSONPath = []string{"$.card.number"}
+		redaction.Rules[i].Detectors = []string{"card_number", "email"}
+		redaction.Rules[i].Action = apidef.RedactionHash
+		redaction.Rules[i].KeepLast = 4
+		redaction.Rules[i].Targets = []apidef.RedactionTarget{apidef.RedactionTargetResponse, apidef.RedactionTargetAnalytics}
+	}
+}
+
+// validRoutingRules sets valid enum values and status codes on the faker filled routing rules.
+func validRoutingRules(routing *Routing) {
+	if routing == nil {
+		return
+	}
+
+	for i := range routing.Rules {
+		routing.Rules[i].Match = apidef.Any
+		routing.Rules[i].Action.Response.Code = http.StatusTeapot
+		for j := range routing.Rules[i].Conditions {
+			routing.Rules[i].Conditions[j].Source = apidef.RoutingSourceHeader
+			routing.Rules[i].Conditions[j].Operator = apidef.RoutingIn
+		}
+	}
+}
+
+// validResponseCondition sets valid status code patterns on the faker filled response condition.
+func validResponseCondition(cond *ResponseCondition) {
+	if cond == nil {
+		return
+	}
+
+	cond.StatusCodes = []string{"200", "4xx", "500-504"}
+}
@@ -51,6 +51,19 @@ type Global struct {
 	// Tyk classic API definition: `CORS`.
 	CORS *CORS `bson:"cors,omitempty" json:"cors,omitempty"`
 
+	// Compression contains the configuration related to the compression of responses and decompression of requests.
+	// Tyk classic API definition: `compression`.
+	Compression *Compression `bson:"compression,omitempty" json:"compression,omitempty"`
+
+	// Redaction contains the rules masking, hashing or removing sensitive data from the request and response bodies,
+	// and from the raw request and response captured in the analytics records.
+	// Tyk classic API definition: `redaction`.
+	Redaction *Redaction `bson:"redaction,omitempty" json:"redaction,omitempty"`
+
+	// Routing contains the conditional routing rules applying to all the endpoints of the API.
+	// Tyk classic API definition: `routing`.
+	Routing *Routing `bson:"routing,omitempty" json:"routing,omitempty"`
+
 	// PrePlugin contains configuration related to the custom plugin that is run before authentication.
 	// Deprecated: Use PrePlugins instead.
 	PrePlugin *PrePlugin `bson:"prePlugin,omitempty" json:"prePlugin,omitempty"`
@@ -160,6 +173,33 @@ func (g *Global) Fill(api apidef.APIDefinition) {
 		g.CORS = nil
 	}
 
+	if g.Compression == nil {
+		g.Compression = &Compression{}
+	}
+
+	g.Compression.Fill(api.Compression)
+	if ShouldOmit(g.Compression) {
+		g.Compression = nil
+	}
+
+	if g.Redaction == nil {
+		g.Redaction = &Redaction{}
+	}
+
+	g.Redaction.Fill(api.Redaction)
+	if ShouldOmit(g.Redaction) {
+		g.Redaction = nil
+	}
+
+	if g.Routing == nil {
+		g.Routing = &Routing{}
+	}
+
+	g.Routing.Fill(api.Routing)
+	if ShouldOmit(g.Routing) {
+		g.Routing = nil
+	}
+
 	g.PrePlugins.Fill(api.CustomMiddleware.Pre)
 	g.PrePlugin = nil
 
@@ -216,9 +256,10 @@ func (g *Global) Fill(api apidef.APIDefinition) {
 	}
 
 	g.TransformResponseHeaders.Fill(apidef.HeaderInjectionMeta{
-		Disabled:      vInfo.GlobalResponseHeadersDisabled,
-		AddHeaders:    vInfo.GlobalResponseHeaders,
-		DeleteHeaders: vInfo.GlobalResponseHeadersRemove,
+		Disabled:          vInfo.GlobalResponseHeadersDisabled,
+		AddHeaders:        vInfo.GlobalResponseHeaders,
+		DeleteHeaders:     vInfo.GlobalResponseHeadersRemove,
+		ResponseCondition: vInfo.GlobalResponseHeadersCondition,
 	})
 	if ShouldOmit(g.TransformResponseHeaders) {
 		g.TransformResponseHeaders = nil
@@ -271,6 +312,33 @@ func (g *Global) ExtractTo(api *apidef.APIDefinition) {
 
 	g.CORS.ExtractTo(&api.CORS)
 
+	if g.Compression == nil {
+		g.Compression = &Compression{}
+		defer func() {
+			g.Compression = nil
+		}()
+	}
+
+	g.Compression.ExtractTo(&api.Compression)
+
+	if g.Redaction == nil {
+		g.Redaction = &Redaction{}
+		defer func() {
+			g.Redaction = nil
+		}()
+	}
+
+	g.Redaction.ExtractTo(&api.Redaction)
+
+	if g.Routing == nil {
+		g.Routing = &Routing{}
+		defer func() {
+			g.Routing = nil
+		}()
+	}
+
+	g.Routing.ExtractTo(&api.Routing)
+
 	g.extractPrePluginsTo(api)
 
 	g.extractPostAuthenticationPluginsTo(api)
@@ -326,6 +394,7 @@ func (g *Global) ExtractTo(api *apidef.APIDefinition) {
 	vInfo.GlobalResponseHeadersDisabled = resHeaderMeta.Disabled
 	vInfo.GlobalResponseHeaders = resHeaderMeta.AddHeaders
 	vInfo.GlobalResponseHeadersRemove = resHeaderMeta.DeleteHeaders
+	vInfo.GlobalResponseHeadersCondition = resHeaderMeta.ResponseCondition
 	api.VersionData.Versions[Main] = vInfo
 }
 
@@ -454,7 +523,8 @@ type PluginConfig struct {
 	// - `python`,
 	// - `lua`,
 	// - `grpc`,
-	// - `goplugin`.
+	// - `goplugin`,
+	// - `wasm`.
 	//
 	// Tyk classic API definition: `custom_middleware.driver`.
 	Driver apidef.MiddlewareDriver `bson:"driver,omitempty" json:"driver,omitempty"`
@@ -464,6 +534,9 @@ type PluginConfig struct {
 
 	// Data configures custom plugin data.
 	Data *PluginConfigData `bson:"data,omitempty" json:"data,omitempty"`
+
+	// JSRuntime configures the JavaScript engine running the `otto` driver plugins and the virtual endpoints.
+	JSRuntime *JSRuntime `bson:"jsRuntime,omitempty" json:"jsRuntime,omitempty"`
 }
 
 // Fill fills PluginConfig from apidef.
@@ -487,6 +560,15 @@ func (p *PluginConfig) Fill(api apidef.APIDefinition) {
 	if ShouldOmit(p.Data) {
 		p.Data = nil
 	}
+
+	if p.JSRuntime == nil {
+		p.JSRuntime = &JSRuntime{}
+	}
+
+	p.JSRuntime.Fill(api.JSRuntime)
+	if ShouldOmit(p.JSRuntime) {
+		p.JSRuntime = nil
+	}
 }
 
 // ExtractTo extracts *PluginConfig into *apidef.
@@ -510,6 +592,63 @@ func (p *PluginConfig) ExtractTo(api *apidef.APIDefinition) {
 	}
 
 	p.Data.ExtractTo(api)
+
+	if p.JSRuntime == nil {
+		p.JSRuntime = &JSRuntime{}
+		defer func() {
+			p.JSRuntime = nil
+		}()
+	}
+
+	p.JSRuntime.ExtractTo(&api.JSRuntime)
+}
+
+// JSRuntime configures the JavaScript engine.
+type JSRuntime struct {
+	// Engine is the JavaScript engine, either `otto` (ES5, the default) or `goja` (ES2020+, with `fetch`).
+	//
+	// Tyk classic API definition: `js_runtime.engine`.
+	Engine string `bson:"engine,omitempty" json:"engine,omitempty"`
+
+	// Timeout is the maximum duration in seconds of an invocation, defaults to the gateway's `jsvm_timeout`.
+	//
+	// Tyk classic API definition: `js_runtime.timeout`.
+	Timeout int `bson:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// PoolSize is the number of `goja` VMs prepared ahead of the invocations, defaults to the number of CPUs.
+	// Each invocation runs on a fresh VM, so no global state is kept between invocations.
+	//
+	// Tyk classic API definition: `js_runtime.pool_size`.
+	PoolSize int `bson:"poolSize,omitempty" json:"poolSize,omitempty"`
+
+	// MaxMemory is the maximum memory in bytes allocated by a `goja` invocation through the built-in functions
+	// creating strings and arrays, unlimited if 0. Memory allocated by operators and literals isn't counted.
+	//
+	// Tyk classic API definition: `js_runtime.max_memory`.
+	MaxMemory int64 `bson:"maxMemory,omitempty" json:"maxMemory,omitempty"`
+
+	// MaxCallStackSize is the maximum depth of the `goja` call stack, unlimited if 0.
+	//
+	// Tyk classic API definition: `js_runtime.max_call_stack_size`.
+	MaxCallStackSize int `bson:"maxCallStackSize,omitempty" json:"maxCallStackSize,omitempty"`
+}
+
+// Fill fills *JSRuntime from apidef.JSRuntimeConfig.
+func (j *JSRuntime) Fill(conf apidef.JSRuntimeConfig) {
+	j.Engine = conf.Engine
+	j.Timeout = conf.Timeout
+	j.PoolSize = conf.PoolSize
+	j.MaxMemory = conf.MaxMemory
+	j.MaxCallStackSize = conf.MaxCallStackSize
+}
+
+// ExtractTo extracts *JSRuntime into *apidef.JSRuntimeConfig.
+func (j *JSRuntime) ExtractTo(conf *apidef.JSRuntimeConfig) {
+	conf.Engine = j.Engine
+	conf.Timeout = j.Timeout
+	conf.PoolSize = j.PoolSize
+	conf.MaxMemory = j.MaxMemory
+	conf.MaxCallStackSize = j.MaxCallStackSize
 }
 
 // PluginBundle holds configuration for custom plugins.
@@ -615,6 +754,206 @@ func (c *CORS) ExtractTo(cors *apidef.CORSConfig) {
 	cors.AllowedMethods = c.AllowedMethods
 }
 
+// Compression holds configuration for the compression of responses and decompression of request bodies.
+type Compression struct {
+	// Enabled compresses the responses with an algorithm the client accepts in `Accept-Encoding`.
+	// Responses compressed by the upstream are passed through as they are.
+	//
+	// Tyk classic API definition: `compression.enabled`.
+	Enabled bool `bson:"enabled" json:"enabled"` // required
+
+	// Algorithms are the content codings the responses are compressed with: `br`, `zstd` and `gzip`.
+	// Their order breaks ties between the codings the client accepts with the same weight. Defaults to all of them.
+	//
+	// Tyk classic API definition: `compression.algorithms`.
+	Algorithms []string `bson:"algorithms,omitempty" json:"algorithms,omitempty"`
+
+	// MinSize is the minimum size in bytes of the responses to compress, defaults to 1024.
+	// Streamed responses of unknown size are always compressed.
+	//
+	// Tyk classic API definition: `compression.min_size`.
+	MinSize int64 `bson:"minSize,omitempty" json:"minSize,omitempty"`
+
+	// ContentTypes are the media types of the responses to compress. A type ending with `/*` matches all its
+	// subtypes. Defaults to text, JSON, XML and JavaScript types.
+	//
+	// Tyk classic API definition: `compression.content_types`.
+	ContentTypes []string `bson:"contentTypes,omitempty" json:"contentTypes,omitempty"`
+
+	// Level is the compression level from 1, the fastest, to 9, the smallest. Defaults to the default level of each algorithm.
+	//
+	// Tyk classic API definition: `compression.level`.
+	Level int `bson:"level,omitempty" json:"level,omitempty"`
+
+	// DecompressRequests decompresses `gzip`, `deflate`, `br` and `zstd` encoded request bodies, so the
+	// transforms and validation work on the decompressed body, which is proxied to the upstream.
+	//
+	// Tyk classic API definition: `compression.decompress_requests`.
+	DecompressRequests bool `bson:"decompressRequests,omitempty" json:"decompressRequests,omitempty"`
+
+	// MaxDecompressedSize is the maximum size in bytes of a decompressed request body, defaults to 10MB.
+	// Larger requests are rejected.
+	//
+	// Tyk classic API definition: `compression.max_decompressed_size`.
+	MaxDecompressedSize int64 `bson:"maxDecompressedSize,omitempty" json:"maxDecompressedSize,omitempty"`
+}
+
+// Fill fills *Compression from apidef.CompressionConfig.
+func (c *Compression) Fill(compression apidef.CompressionConfig) {
+	c.Enabled = compression.Enabled
+	c.Algorithms = compression.Algorithms
+	c.MinSize = compression.MinSize
+	c.ContentTypes = compression.ContentTypes
+	c.Level = compression.Level
+	c.DecompressRequests = compression.DecompressRequests
+	c.MaxDecompressedSize = compression.MaxDecompressedSize
+}
+
+// ExtractTo extracts *Compression into *apidef.CompressionConfig.
+func (c *Compression) ExtractTo(compression *apidef.CompressionConfig) {
+	compression.Enabled = c.Enabled
+	compression.Algorithms = c.Algorithms
+	compression.MinSize = c.MinSize
+	compression.ContentTypes = c.ContentTypes
+	compression.Level = c.Level
+	compression.DecompressRequests = c.DecompressRequests
+	compression.MaxDecompressedSize = c.MaxDecompressedSize
+}
+
+// Redaction holds the redaction rules of an API or an endpoint.
+type Redaction struct {
+	// Enabled activates the redaction rules.
+	//
+	// Tyk classic API definition: `redaction.enabled`, `!version_data.versions..extended_paths.redaction[].disabled`.
+	Enabled bool `bson:"enabled" json:"enabled"` // required
+
+	// Rules are the redaction rules. The rules of an endpoint apply on top of the rules of the API.
+	//
+	// Tyk classic API definition: `redaction.rules`, `version_data.versions..extended_paths.redaction[].rules`.
+	Rules []RedactionRule `bson:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// Fill fills *Redaction from apidef.RedactionConfig.
+func (r *Redaction) Fill(redaction apidef.RedactionConfig) {
+	r.Enabled = redaction.Enabled
+	r.Rules = fillRedactionRules(redaction.Rules)
+}
+
+// ExtractTo extracts *Redaction into *apidef.RedactionConfig.
+func (r *Redaction) ExtractTo(redaction *apidef.RedactionConfig) {
+	redaction.Enabled = r.Enabled
+	redaction.Rules = extractRedactionRules(r.Rules)
+}
+
+// RedactionRule selects sensitive data with JSONPath or JMESPath selectors, detectors and patterns, and masks,
+// hashes or removes it. Without selectors, the detectors and patterns apply to all the values of the body.
+type RedactionRule struct {
+	// Name identifies the rule in the logs.
+	//
+	// Tyk classic API definition: `redaction.rules[].name`.
+	Name string `bson:"name,omitempty" json:"name,omitempty"`
+
+	// JSONPath are JSONPath selectors of JSON body values, for example `$.card.number` or `$..email`.
+	//
+	// Tyk classic API definition: `redaction.rules[].json_path`.
+	JSONPath []string `bson:"jsonPath,omitempty" json:"jsonPath,omitempty"`
+
+	// JMESPath are JMESPath selectors of JSON body values, for example `card.number` or `users[].ssn`.
+	//
+	// Tyk classic API definition: `redaction.rules[].jmes_path`.
+	JMESPath []string `bson:"jmesPath,omitempty" json:"jmesPath,omitempty"`
+
+	// Detectors are built-in detectors of sensitive values: `card_number`, `email`, `ssn` and `iban`.
+	//
+	// Tyk classic API definition: `redaction.rules[].detectors`.
+	Detectors []string `bson:"detectors,omitempty" json:"detectors,omitempty"`
+
+	// Patterns are regular expressions matching sensitive values.
+	//
+	// Tyk classic API definition: `redaction.rules[].patterns`.
+	Patterns []string `bson:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// Action is the action taken on the matched values: `mask`, `hash` or `remove`. Defaults to `mask`.
+	//
+	// Tyk classic API definition: `redaction.rules[].action`.
+	Action apidef.RedactionAction `bson:"action,omitempty" json:"action,omitempty"`
+
+	// MaskCharacter is the character the masked values are replaced with, defaults to `*`.
+	//
+	// Tyk classic API definition: `redaction.rules[].mask_character`.
+	MaskCharacter string `bson:"maskCharacter,omitempty" json:"maskCharacter,omitempty"`
+
+	// KeepLast is the number of trailing characters left unmasked.
+	//
+	// Tyk classic API definition: `redaction.rules[].keep_last`.
+	KeepLast int `bson:"keepLast,omitempty" json:"keepLast,omitempty"`
+
+	// Targets are the data the rule applies to: `request`, `response` and `analytics`. Defaults to all of them.
+	//
+	// Tyk classic API definition: `redaction.rules[].targets`.
+	Targets []apidef.RedactionTarget `bson:"targets,omitempty" json:"targets,omitempty"`
+
+	// Policies restricts the rule to the sessions having one of the policies.
+	//
+	// Tyk classic API definition: `redaction.rules[].policies`.
+	Policies []string `bson:"policies,omitempty" json:"policies,omitempty"`
+
+	// Tags restricts the rule to the sessions having one of the tags.
+	//
+	// Tyk classic API definition: `redaction.rules[].tags`.
+	Tags []string `bson:"tags,omitempty" json:"tags,omitempty"`
+}
+
+func fillRedactionRules(rules []apidef.RedactionRule) []RedactionRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	filled := make([]RedactionRule, len(rules))
+	for i, rule := range rules {
+		filled[i] = RedactionRule{
+			Name:          rule.Name,
+			JSONPath:      rule.JSONPath,
+			JMESPath:      rule.JMESPath,
+			Detectors:     rule.Detectors,
+			Patterns:      rule.Patterns,
+			Action:        rule.Action,
+			MaskCharacter: rule.MaskCharacter,
+			KeepLast:      rule.KeepLast,
+			Targets:       rule.Targets,
+			Policies:      rule.Policies,
+			Tags:          rule.Tags,
+		}
+	}
+
+	return filled
+}
+
+func extractRedactionRules(rules []RedactionRule) []apidef.RedactionRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	extracted := make([]apidef.RedactionRule, len(rules))
+	for i, rule := range rules {
+		extracted[i] = apidef.RedactionRule{
+			Name:          rule.Name,
+			JSONPath:      rule.JSONPath,
+			JMESPath:      rule.JMESPath,
+			Detectors:     rule.Detectors,
+			Patterns:      rule.Patterns,
+			Action:        rule.Action,
+			MaskCharacter: rule.MaskCharacter,
+			KeepLast:      rule.KeepLast,
+			Targets:       rule.Targets,
+			Policies:      rule.Policies,
+			Tags:          rule.Tags,
+		}
+	}
+
+	return extracted
+}
+
 // Cache holds configuration for caching the requests.
 type Cache struct {
 	// Enabled turns global cache middleware on or off. It is still possible to enable caching on a per-path basis
@@ -653,6 +992,38 @@ type Cache struct {
 	//
 	// Tyk classic API definition: `cache_options.cache_control_ttl_header`
 	ControlTTLHeaderName string `bson:"controlTTLHeaderName,omitempty" json:"controlTTLHeaderName,omitempty"`
+
+	// StaleWhileRevalidate is the time in seconds after a cached response expires during which it is
+	// still served while it is refreshed from the upstream in the background.
+	//
+	// Tyk classic API definition: `cache_options.stale_while_revalidate`
+	StaleWhileRevalidate int64 `bson:"staleWhileRevalidate,omitempty" json:"staleWhileRevalidate,omitempty"`
+
+	// StaleIfError is the time in seconds after a cached response expires during which it is
+	// served instead of an upstream error.
+	//
+	// Tyk classic API definition: `cache_options.stale_if_error`
+	StaleIfError int64 `bson:"staleIfError,omitempty" json:"staleIfError,omitempty"`
+
+	// EnableRequestCoalescing makes concurrent cache misses for the same cache key wait for the
+	// first request to populate the cache.
+	//
+	// Tyk classic API definition: `cache_options.enable_request_coalescing`
+	EnableRequestCoalescing bool `bson:"enableRequestCoalescing,omitempty" json:"enableRequestCoalescing,omitempty"`
+
+	// EnableHTTPSemantics makes the cache follow RFC 9111. It honours `Vary` and the `Cache-Control`
+	// request and response directives, generates strong ETags and revalidates expired responses
+	// with conditional requests to the upstream.
+	//
+	// Tyk classic API definition: `cache_options.enable_http_semantics`
+	EnableHTTPSemantics bool `bson:"enableHttpSemantics,omitempty" json:"enableHttpSemantics,omitempty"`
+
+	// Backend is the storage of the cached responses: `redis`, `memory`, `disk` or `s3`.
+	// The backends other than Redis are configured in the gateway `response_cache_storage` settings.
+	// Defaults to Redis.
+	//
+	// Tyk classic API definition: `cache_options.backend`
+	Backend string `bson:"backend,omitempty" json:"backend,omitempty"`
 }
 
 // Fill fills *Cache from apidef.CacheOptions.
@@ -664,6 +1035,11 @@ func (c *Cache) Fill(cache apidef.CacheOptions) {
 	c.CacheByHeaders = cache.CacheByHeaders
 	c.EnableUpstreamCacheControl = cache.EnableUpstreamCacheControl
 	c.ControlTTLHeaderName = cache.CacheControlTTLHeader
+	c.StaleWhileRevalidate = cache.StaleWhileRevalidate
+	c.StaleIfError = cache.StaleIfError
+	c.EnableRequestCoalescing = cache.EnableRequestCoalescing
+	c.EnableHTTPSemantics = cache.EnableHTTPSemantics
+	c.Backend = cache.Backend
 }
 
 // ExtractTo extracts *Cache into *apidef.CacheOptions.
@@ -675,6 +1051,11 @@ func (c *Cache) ExtractTo(cache *apidef.CacheOptions) {
 	cache.CacheByHeaders = c.CacheByHeaders
 	cache.EnableUpstreamCacheControl = c.EnableUpstreamCacheControl
 	cache.CacheControlTTLHeader = c.ControlTTLHeaderName
+	cache.StaleWhileRevalidate = c.StaleWhileRevalidate
+	cache.StaleIfError = c.StaleIfError
+	cache.EnableRequestCoalescing = c.EnableRequestCoalescing
+	cache.EnableHTTPSemantics = c.EnableHTTPSemantics
+	cache.Backend = c.Backend
 }
 
 // Paths is a mapping of API endpoints to Path plugin configurations.
@@ -1057,29 +1438,48 @@ func (tm *TransformRequestMethod) ExtractTo(meta *apidef.MethodTransformMeta) {
 type TransformBody struct {
 	// Enabled activates transform request/request body middleware.
 	Enabled bool `bson:"enabled" json:"enabled"`
-	// Format of the request/response body, xml or json.
+	// Format of the request/response body, xml, json, form or multipart.
 	Format apidef.RequestInputType `bson:"format" json:"format"`
 	// Path file path for the template.
 	Path string `bson:"path,omitempty" json:"path,omitempty"`
 	// Body base64 encoded representation of the template.
 	Body string `bson:"body,omitempty" json:"body,omitempty"`
+	// OutputFormat is the format the body is converted to when no template is set, xml, json, form or multipart.
+	OutputFormat apidef.RequestInputType `bson:"outputFormat,omitempty" json:"outputFormat,omitempty"`
+	// XML configures the conversion of the body from and to XML.
+	XML *XMLConversion `bson:"xml,omitempty" json:"xml,omitempty"`
+	// Condition restricts response body transforms to the matching upstream responses.
+	Condition *ResponseCondition `bson:"condition,omitempty" json:"condition,omitempty"`
 }
 
 // Fill fills *TransformBody from apidef.TemplateMeta.
 func (tr *TransformBody) Fill(meta apidef.TemplateMeta) {
 	tr.Enabled = !meta.Disabled
 	tr.Format = meta.TemplateData.Input
+	tr.OutputFormat = meta.TemplateData.Output
 	if meta.TemplateData.Mode == apidef.UseBlob {
 		tr.Body = meta.TemplateData.TemplateSource
 	} else {
 		tr.Path = meta.TemplateData.TemplateSource
 	}
+
+	if tr.XML == nil {
+		tr.XML = &XMLConversion{}
+	}
+
+	tr.XML.Fill(meta.TemplateData.XML)
+	if ShouldOmit(tr.XML) {
+		tr.XML = nil
+	}
+
+	tr.Condition = newResponseCondition(meta.ResponseCondition)
 }
 
 // ExtractTo extracts data from *TransformBody into *apidef.TemplateMeta.
 func (tr *TransformBody) ExtractTo(meta *apidef.TemplateMeta) {
 	meta.Disabled = !tr.Enabled
 	meta.TemplateData.Input = tr.Format
+	meta.TemplateData.Output = tr.OutputFormat
 	meta.TemplateData.EnableSession = true
 	if tr.Body != "" {
 		meta.TemplateData.Mode = apidef.UseBlob
@@ -1088,6 +1488,74 @@ func (tr *TransformBody) ExtractTo(meta *apidef.TemplateMeta) {
 		meta.TemplateData.Mode = apidef.UseFile
 		meta.TemplateData.TemplateSource = tr.Path
 	}
+
+	meta.TemplateData.XML = apidef.XMLOptions{}
+	if tr.XML != nil {
+		tr.XML.ExtractTo(&meta.TemplateData.XML)
+	}
+
+	meta.ResponseCondition = tr.Condition.extract()
+}
+
+// ResponseCondition restricts response transforms to the upstream responses matching all of its set criteria.
+type ResponseCondition struct {
+	// StatusCodes are the matching status codes: a code such as `404`, a class such as `5xx`, or a range such as `500-504`.
+	StatusCodes []string `bson:"statusCodes,omitempty" json:"statusCodes,omitempty"`
+	// ContentTypes are the matching media types, a type may end with a `/*` wildcard.
+	ContentTypes []string `bson:"contentTypes,omitempty" json:"contentTypes,omitempty"`
+}
+
+func newResponseCondition(cond *apidef.ResponseCondition) *ResponseCondition {
+	if cond == nil {
+		return nil
+	}
+
+	return &ResponseCondition{StatusCodes: cond.StatusCodes, ContentTypes: cond.ContentTypes}
+}
+
+func (c *ResponseCondition) extract() *apidef.ResponseCondition {
+	if c == nil {
+		return nil
+	}
+
+	return &apidef.ResponseCondition{StatusCodes: c.StatusCodes, ContentTypes: c.ContentTypes}
+}
+
+// XMLConversion configures the conversion of bodies from and to XML.
+//
+// XML elements are converted to JSON objects: attributes are keys prefixed with `-`, the text of an element having
+// attributes or children is the `#text` key, and repeated elements are arrays.
+type XMLConversion struct {
+	// RootElement is the name of the root element of the converted XML.
+	// It defaults to the single key of the converted object, or `doc`.
+	RootElement string `bson:"rootElement,omitempty" json:"rootElement,omitempty"`
+	// ArrayPaths are the dot separated paths of the elements always converted to arrays, even when they occur once,
+	// for example `Users.User`.
+	ArrayPaths []string `bson:"arrayPaths,omitempty" json:"arrayPaths,omitempty"`
+	// Namespaces are the namespaces declared on the root element of the converted XML, by prefix.
+	Namespaces map[string]string `bson:"namespaces,omitempty" json:"namespaces,omitempty"`
+	// KeepNamespaces keeps the namespace prefixes of the element and attribute names, they are removed by default.
+	KeepNamespaces bool `bson:"keepNamespaces,omitempty" json:"keepNamespaces,omitempty"`
+	// SOAPEnvelope wraps the converted XML in a SOAP envelope, and unwraps the body of a SOAP envelope converted from XML.
+	SOAPEnvelope bool `bson:"soapEnvelope,omitempty" json:"soapEnvelope,omitempty"`
+}
+
+// Fill fills *XMLConversion from apidef.XMLOptions.
+func (x *XMLConversion) Fill(options apidef.XMLOptions) {
+	x.RootElement = options.RootElement
+	x.ArrayPaths = options.ArrayPaths
+	x.Namespaces = options.Namespaces
+	x.KeepNamespaces = options.KeepNamespaces
+	x.SOAPEnvelope = options.SOAPEnvelope
+}
+
+// ExtractTo extracts *XMLConversion into *apidef.XMLOptions.
+func (x *XMLConversion) ExtractTo(options *apidef.XMLOptions) {
+	options.RootElement = x.RootElement
+	options.ArrayPaths = x.ArrayPaths
+	options.Namespaces = x.Namespaces
+	options.KeepNamespaces = x.KeepNamespaces
+	options.SOAPEnvelope = x.SOAPEnvelope
 }
 
 // TransformHeaders holds configuration about request/response header transformations.
@@ -1098,6 +1566,8 @@ type TransformHeaders struct {
 	Remove []string `bson:"remove,omitempty" json:"remove,omitempty"`
 	// Add specifies headers to be added to the request/response.
 	Add Headers `bson:"add,omitempty" json:"add,omitempty"`
+	// Condition restricts response header transforms to the matching upstream responses.
+	Condition *ResponseCondition `bson:"condition,omitempty" json:"condition,omitempty"`
 }
 
 // Fill fills *TransformHeaders from apidef.HeaderInjectionMeta.
@@ -1109,6 +1579,8 @@ func (th *TransformHeaders) Fill(meta apidef.HeaderInjectionMeta) {
 	if len(th.Add) == 0 {
 		th.Add = nil
 	}
+
+	th.Condition = newResponseCondition(meta.ResponseCondition)
 }
 
 // ExtractTo extracts *TransformHeaders into *apidef.HeaderInjectionMeta.
@@ -1120,6 +1592,8 @@ func (th *TransformHeaders) ExtractTo(meta *apidef.HeaderInjectionMeta) {
 	for _, header := range th.Add {
 		meta.AddHeaders[header.Name] = header.Value
 	}
+
+	meta.ResponseCondition = th.Condition.extract()
 }
 
 // CachePlugin holds the configuration for the cache plugins.
@@ -1137,6 +1611,12 @@ type CachePlugin struct {
 
 	// Timeout is the TTL for the endpoint level caching in seconds. 0 means no caching.
 	Timeout int64 `bson:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// StaleWhileRevalidate overrides the API level stale-while-revalidate window in seconds.
+	StaleWhileRevalidate int64 `bson:"staleWhileRevalidate,omitempty" json:"staleWhileRevalidate,omitempty"`
+
+	// StaleIfError overrides the API level stale-if-error window in seconds.
+	StaleIfError int64 `bson:"staleIfError,omitempty" json:"staleIfError,omitempty"`
 }
 
 // Fill fills *CachePlugin from apidef.CacheMeta.
@@ -1145,6 +1625,8 @@ func (a *CachePlugin) Fill(cm apidef.CacheMeta) {
 	a.CacheByRegex = cm.CacheKeyRegex
 	a.CacheResponseCodes = cm.CacheOnlyResponseCodes
 	a.Timeout = cm.Timeout
+	a.StaleWhileRevalidate = cm.StaleWhileRevalidate
+	a.StaleIfError = cm.StaleIfError
 }
 
 // ExtractTo extracts *CachePlugin values to *apidef.CacheMeta.
@@ -1153,6 +1635,8 @@ func (a *CachePlugin) ExtractTo(cm *apidef.CacheMeta) {
 	cm.CacheKeyRegex = a.CacheByRegex
 	cm.CacheOnlyResponseCodes = a.CacheResponseCodes
 	cm.Timeout = a.Timeout
+	cm.StaleWhileRevalidate = a.StaleWhileRevalidate
+	cm.StaleIfError = a.StaleIfError
 }
 
 // EnforceTimeout holds the configuration for enforcing request timeouts.
@@ -1548,6 +2032,54 @@ func (r *RequestSizeLimit) ExtractTo(meta *apidef.RequestSizeMeta) {
 	meta.SizeLimit = r.Value
 }
 
+// ContentPolicy restricts the content of the requests to an endpoint. Limits set to zero are not enforced.
+type ContentPolicy struct {
+	// Enabled activates the content policy.
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// AllowedContentTypes are the media types accepted for request bodies, a type may end with a `/*` wildcard.
+	AllowedContentTypes []string `bson:"allowedContentTypes,omitempty" json:"allowedContentTypes,omitempty"`
+	// MaxJSONDepth is the maximum nesting depth of objects and arrays in JSON bodies.
+	MaxJSONDepth int `bson:"maxJSONDepth,omitempty" json:"maxJSONDepth,omitempty"`
+	// MaxJSONArrayLength is the maximum number of items of an array in JSON bodies.
+	MaxJSONArrayLength int `bson:"maxJSONArrayLength,omitempty" json:"maxJSONArrayLength,omitempty"`
+	// MaxJSONStringLength is the maximum number of characters of a string or object key in JSON bodies.
+	MaxJSONStringLength int `bson:"maxJSONStringLength,omitempty" json:"maxJSONStringLength,omitempty"`
+	// MaxMultipartParts is the maximum number of parts of multipart bodies.
+	MaxMultipartParts int `bson:"maxMultipartParts,omitempty" json:"maxMultipartParts,omitempty"`
+	// MaxMultipartPartSize is the maximum size of a part of multipart bodies in bytes.
+	MaxMultipartPartSize int64 `bson:"maxMultipartPartSize,omitempty" json:"maxMultipartPartSize,omitempty"`
+	// MaxQueryParams is the maximum number of query parameters.
+	MaxQueryParams int `bson:"maxQueryParams,omitempty" json:"maxQueryParams,omitempty"`
+	// MaxHeaders is the maximum number of request headers.
+	MaxHeaders int `bson:"maxHeaders,omitempty" json:"maxHeaders,omitempty"`
+}
+
+// Fill fills *ContentPolicy from apidef.ContentPolicyMeta.
+func (c *ContentPolicy) Fill(meta apidef.ContentPolicyMeta) {
+	c.Enabled = !meta.Disabled
+	c.AllowedContentTypes = meta.AllowedContentTypes
+	c.MaxJSONDepth = meta.MaxJSONDepth
+	c.MaxJSONArrayLength = meta.MaxJSONArrayLength
+	c.MaxJSONStringLength = meta.MaxJSONStringLength
+	c.MaxMultipartParts = meta.MaxMultipartParts
+	c.MaxMultipartPartSize = meta.MaxMultipartPartSize
+	c.MaxQueryParams = meta.MaxQueryParams
+	c.MaxHeaders = meta.MaxHeaders
+}
+
+// ExtractTo extracts *ContentPolicy into *apidef.ContentPolicyMeta.
+func (c *ContentPolicy) ExtractTo(meta *apidef.ContentPolicyMeta) {
+	meta.Disabled = !c.Enabled
+	meta.AllowedContentTypes = c.AllowedContentTypes
+	meta.MaxJSONDepth = c.MaxJSONDepth
+	meta.MaxJSONArrayLength = c.MaxJSONArrayLength
+	meta.MaxJSONStringLength = c.MaxJSONStringLength
+	meta.MaxMultipartParts = c.MaxMultipartParts
+	meta.MaxMultipartPartSize = c.MaxMultipartPartSize
+	meta.MaxQueryParams = c.MaxQueryParams
+	meta.MaxHeaders = c.MaxHeaders
+}
+
 // TrafficLogs holds configuration about API log analytics.
 type TrafficLogs struct {
 	// Enabled enables traffic log analytics for the API.
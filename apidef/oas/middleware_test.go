@@ -2,6 +2,7 @@ package oas
 
 import (
 	"encoding/json"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -297,6 +298,96 @@ func TestCache(t *testing.T) {
 	assert.Equal(t, emptyCache, resultCache)
 }
 
+func TestRedaction(t *testing.T) {
+	t.Parallel()
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		var emptyRedaction Redaction
+
+		var convertedRedaction apidef.RedactionConfig
+		emptyRedaction.ExtractTo(&convertedRedaction)
+
+		var resultRedaction Redaction
+		resultRedaction.Fill(convertedRedaction)
+
+		assert.Equal(t, emptyRedaction, resultRedaction)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+		expectedRedaction := Redaction{
+			Enabled: true,
+			Rules: []RedactionRule{{
+				Name:      "cards",
+				JSONPath:  []string{"$..number"},
+				Detectors: []string{"card_number"},
+				Action:    apidef.RedactionMask,
+				KeepLast:  4,
+				Targets:   []apidef.RedactionTarget{apidef.RedactionTargetResponse},
+				Policies:  []string{"partners"},
+			}},
+		}
+
+		var redaction apidef.RedactionConfig
+		expectedRedaction.ExtractTo(&redaction)
+		assert.Equal(t, []string{"$..number"}, redaction.Rules[0].JSONPath)
+
+		var actualRedaction Redaction
+		actualRedaction.Fill(redaction)
+		assert.Equal(t, expectedRedaction, actualRedaction)
+	})
+}
+
+func TestRouting(t *testing.T) {
+	t.Parallel()
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		var emptyRouting Routing
+
+		var convertedRouting apidef.RoutingConfig
+		emptyRouting.ExtractTo(&convertedRouting)
+
+		var resultRouting Routing
+		resultRouting.Fill(convertedRouting)
+
+		assert.Equal(t, emptyRouting, resultRouting)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+		expectedRouting := Routing{
+			Enabled: true,
+			Rules: []RoutingRule{{
+				Name:  "gold",
+				Match: apidef.Any,
+				Conditions: []RoutingCondition{{
+					Source:   apidef.RoutingSourceBody,
+					Key:      "order.total",
+					Operator: apidef.RoutingGreater,
+					Value:    "100",
+				}},
+				Action: RoutingAction{
+					UpstreamURL: "http://gold.internal",
+					SetHeaders:  Headers{{Name: "X-Tier", Value: "gold"}},
+				},
+			}, {
+				Name: "blocked",
+				Action: RoutingAction{
+					Response: &RoutingResponse{Code: http.StatusForbidden, Body: "blocked"},
+				},
+			}},
+		}
+
+		var routing apidef.RoutingConfig
+		expectedRouting.ExtractTo(&routing)
+		assert.Equal(t, map[string]string{"X-Tier": "gold"}, routing.Rules[0].Action.SetHeaders)
+
+		var actualRouting Routing
+		actualRouting.Fill(routing)
+		assert.Equal(t, expectedRouting, actualRouting)
+	})
+}
+
 func TestExtendedPaths(t *testing.T) {
 	t.Run("empty", func(t *testing.T) {
 		paths := make(Paths)
@@ -384,6 +475,42 @@ func TestTransformBody(t *testing.T) {
 		assert.Equal(t, transformReqBody, newTransformReqBody)
 	})
 
+	t.Run("conversion", func(t *testing.T) {
+		transformReqBody := TransformBody{
+			Format:       apidef.RequestJSON,
+			OutputFormat: apidef.RequestXML,
+			Enabled:      true,
+			XML: &XMLConversion{
+				RootElement:  "GetUser",
+				ArrayPaths:   []string{"GetUser.Tags"},
+				Namespaces:   map[string]string{"m": "http://example.com/users"},
+				SOAPEnvelope: true,
+			},
+		}
+
+		meta := apidef.TemplateMeta{}
+		transformReqBody.ExtractTo(&meta)
+		assert.Equal(t, apidef.TemplateMeta{
+			Disabled: false,
+			TemplateData: apidef.TemplateData{
+				EnableSession: true,
+				Mode:          apidef.UseFile,
+				Input:         apidef.RequestJSON,
+				Output:        apidef.RequestXML,
+				XML: apidef.XMLOptions{
+					RootElement:  "GetUser",
+					ArrayPaths:   []string{"GetUser.Tags"},
+					Namespaces:   map[string]string{"m": "http://example.com/users"},
+					SOAPEnvelope: true,
+				},
+			},
+		}, meta)
+
+		newTransformReqBody := TransformBody{}
+		newTransformReqBody.Fill(meta)
+		assert.Equal(t, transformReqBody, newTransformReqBody)
+	})
+
 	t.Run("blob should have precedence", func(t *testing.T) {
 		transformReqBody := TransformBody{
 			Path:    "/opt/tyk-gateway/template.tmpl",
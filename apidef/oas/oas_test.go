@@ -216,9 +216,13 @@ func TestOAS_ExtractTo_ResetAPIDefinition(t *testing.T) {
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQ[0].Filter",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQ[0].Path",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQ[0].Method",
+		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQ[0].ResponseCondition.StatusCodes[0]",
+		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQ[0].ResponseCondition.ContentTypes[0]",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQResponse[0].Filter",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQResponse[0].Path",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQResponse[0].Method",
+		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQResponse[0].ResponseCondition.StatusCodes[0]",
+		"APIDefinition.VersionData.Versions[0].ExtendedPaths.TransformJQResponse[0].ResponseCondition.ContentTypes[0]",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.PersistGraphQL[0].Path",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.PersistGraphQL[0].Method",
 		"APIDefinition.VersionData.Versions[0].ExtendedPaths.PersistGraphQL[0].Operation",
@@ -309,6 +313,20 @@ func TestOAS_ExtractTo_ResetAPIDefinition(t *testing.T) {
 		"APIDefinition.GraphQL.Supergraph.GlobalHeaders[0]",
 		"APIDefinition.GraphQL.Supergraph.DisableQueryBatching",
 		"APIDefinition.GraphQL.Introspection.Disabled",
+		"APIDefinition.GraphQL.PersistedQueries.Automatic",
+		"APIDefinition.GraphQL.PersistedQueries.TTL",
+		"APIDefinition.GraphQL.PersistedQueries.Safelist",
+		"APIDefinition.GraphQL.CostAnalysis.Enabled",
+		"APIDefinition.GraphQL.CostAnalysis.Weights[0]",
+		"APIDefinition.GraphQL.CostAnalysis.SlicingArguments[0]",
+		"APIDefinition.GraphQL.CostAnalysis.DefaultListSize",
+		"APIDefinition.GraphQL.CostAnalysis.ChargeQuota",
+		"APIDefinition.GraphQL.Cache.Enabled",
+		"APIDefinition.GraphQL.Cache.DefaultTTL",
+		"APIDefinition.GraphQL.Cache.TypeTTLs[0]",
+		"APIDefinition.GraphQL.Cache.PrivateTypes[0]",
+		"APIDefinition.GraphQL.Cache.DataSourceFetches.Enabled",
+		"APIDefinition.GraphQL.Cache.DataSourceFetches.TTL",
 		"APIDefinition.AnalyticsPlugin.Enabled",
 		"APIDefinition.AnalyticsPlugin.PluginPath",
 		"APIDefinition.AnalyticsPlugin.FuncName",
@@ -58,6 +58,9 @@ type Operation struct {
 	// ValidateRequest contains the request validation configuration.
 	ValidateRequest *ValidateRequest `bson:"validateRequest,omitempty" json:"validateRequest,omitempty"`
 
+	// ValidateResponse contains the response validation configuration.
+	ValidateResponse *ValidateResponse `bson:"validateResponse,omitempty" json:"validateResponse,omitempty"`
+
 	// MockResponse contains the mock response configuration.
 	MockResponse *MockResponse `bson:"mockResponse,omitempty" json:"mockResponse,omitempty"`
 
@@ -79,8 +82,17 @@ type Operation struct {
 	// RequestSizeLimit limits the maximum allowed size of the request body in bytes.
 	RequestSizeLimit *RequestSizeLimit `bson:"requestSizeLimit,omitempty" json:"requestSizeLimit,omitempty"`
 
+	// ContentPolicy restricts the content types, JSON and multipart structure, query parameters and headers of the requests.
+	ContentPolicy *ContentPolicy `bson:"contentPolicy,omitempty" json:"contentPolicy,omitempty"`
+
 	// RateLimit contains endpoint level rate limit configuration.
 	RateLimit *RateLimitEndpoint `bson:"rateLimit,omitempty" json:"rateLimit,omitempty"`
+
+	// Redaction contains the endpoint level redaction rules, applying on top of the rules of the API.
+	Redaction *Redaction `bson:"redaction,omitempty" json:"redaction,omitempty"`
+
+	// Routing contains the endpoint level routing rules, evaluated before the rules of the API.
+	Routing *Routing `bson:"routing,omitempty" json:"routing,omitempty"`
 }
 
 // AllowanceType holds the valid allowance types values.
@@ -162,7 +174,10 @@ func (s *OAS) fillPathsAndOperations(ep apidef.ExtendedPathsSet) {
 	s.fillTrackEndpoint(ep.TrackEndpoints)
 	s.fillDoNotTrackEndpoint(ep.DoNotTrackEndpoints)
 	s.fillRequestSizeLimit(ep.SizeLimit)
+	s.fillContentPolicy(ep.ContentPolicy)
 	s.fillRateLimitEndpoints(ep.RateLimit)
+	s.fillRedaction(ep.Redaction)
+	s.fillRouting(ep.Routing)
 }
 
 func (s *OAS) extractPathsAndOperations(ep *apidef.ExtendedPathsSet) {
@@ -196,7 +211,10 @@ func (s *OAS) extractPathsAndOperations(ep *apidef.ExtendedPathsSet) {
 					tykOp.extractTrackEndpointTo(ep, path, method)
 					tykOp.extractDoNotTrackEndpointTo(ep, path, method)
 					tykOp.extractRequestSizeLimitTo(ep, path, method)
+					tykOp.extractContentPolicyTo(ep, path, method)
 					tykOp.extractRateLimitEndpointTo(ep, path, method)
+					tykOp.extractRedactionTo(ep, path, method)
+					tykOp.extractRoutingTo(ep, path, method)
 					break
 				}
 			}
@@ -359,6 +377,21 @@ func (s *OAS) fillRequestSizeLimit(metas []apidef.RequestSizeMeta) {
 	}
 }
 
+func (s *OAS) fillContentPolicy(metas []apidef.ContentPolicyMeta) {
+	for _, meta := range metas {
+		operationID := s.getOperationID(meta.Path, meta.Method)
+		operation := s.GetTykExtension().getOperation(operationID)
+		if operation.ContentPolicy == nil {
+			operation.ContentPolicy = &ContentPolicy{}
+		}
+
+		operation.ContentPolicy.Fill(meta)
+		if ShouldOmit(operation.ContentPolicy) {
+			operation.ContentPolicy = nil
+		}
+	}
+}
+
 func (o *Operation) extractAllowanceTo(ep *apidef.ExtendedPathsSet, path string, method string, typ AllowanceType) {
 	allowance := o.Allow
 	endpointMetas := &ep.WhiteList
@@ -454,6 +487,16 @@ func (o *Operation) extractEnforceTimeoutTo(ep *apidef.ExtendedPathsSet, path st
 	ep.HardTimeouts = append(ep.HardTimeouts, meta)
 }
 
+func (o *Operation) extractContentPolicyTo(ep *apidef.ExtendedPathsSet, path string, method string) {
+	if o.ContentPolicy == nil {
+		return
+	}
+
+	meta := apidef.ContentPolicyMeta{Path: path, Method: method}
+	o.ContentPolicy.ExtractTo(&meta)
+	ep.ContentPolicy = append(ep.ContentPolicy, meta)
+}
+
 func (o *Operation) extractRequestSizeLimitTo(ep *apidef.ExtendedPathsSet, path string, method string) {
 	if o.RequestSizeLimit == nil {
 		return
@@ -664,6 +707,33 @@ func (v *ValidateRequest) Import(enabled bool) {
 	v.ErrorResponseCode = http.StatusUnprocessableEntity
 }
 
+// Response validation modes.
+const (
+	// ValidateResponseModeBlock replaces the invalid responses with an error.
+	ValidateResponseModeBlock = "block"
+	// ValidateResponseModeLog only reports the invalid responses.
+	ValidateResponseModeLog = "log"
+)
+
+// ValidateResponse holds configuration required for validating the upstream responses against the operation responses.
+// The status code, the headers and the JSON body of a response are validated.
+type ValidateResponse struct {
+	// Enabled is a boolean flag, if set to `true`, it enables response validation.
+	Enabled bool `bson:"enabled" json:"enabled"`
+
+	// Mode is the action taken when a response fails validation:
+	//
+	// - `block` replaces the response with a 502 Bad Gateway error, the default,
+	// - `log` passes the response through.
+	//
+	// In both modes, the `ResponseValidationFailed` event is fired and the analytics record is tagged with `response-validation-failed`.
+	Mode string `bson:"mode,omitempty" json:"mode,omitempty"`
+
+	// MaxBodySize is the maximum size in bytes of the response body validated, compressed and decompressed.
+	// Larger responses are passed through without validation. The default is 10MB.
+	MaxBodySize int64 `bson:"maxBodySize,omitempty" json:"maxBodySize,omitempty"`
+}
+
 func convertSchema(mapSchema map[string]interface{}) (*openapi3.Schema, error) {
 	bytes, err := json.Marshal(mapSchema)
 	if err != nil {
@@ -817,6 +887,66 @@ func (o *Operation) extractRateLimitEndpointTo(ep *apidef.ExtendedPathsSet, path
 	ep.RateLimit = append(ep.RateLimit, meta)
 }
 
+func (s *OAS) fillRedaction(endpointMetas []apidef.RedactionMeta) {
+	for _, em := range endpointMetas {
+		operationID := s.getOperationID(em.Path, em.Method)
+		operation := s.GetTykExtension().getOperation(operationID)
+		if operation.Redaction == nil {
+			operation.Redaction = &Redaction{}
+		}
+
+		operation.Redaction.Enabled = !em.Disabled
+		operation.Redaction.Rules = fillRedactionRules(em.Rules)
+		if ShouldOmit(operation.Redaction) {
+			operation.Redaction = nil
+		}
+	}
+}
+
+func (o *Operation) extractRedactionTo(ep *apidef.ExtendedPathsSet, path string, method string) {
+	if o.Redaction == nil {
+		return
+	}
+
+	meta := apidef.RedactionMeta{
+		Disabled: !o.Redaction.Enabled,
+		Path:     path,
+		Method:   method,
+		Rules:    extractRedactionRules(o.Redaction.Rules),
+	}
+	ep.Redaction = append(ep.Redaction, meta)
+}
+
+func (s *OAS) fillRouting(endpointMetas []apidef.RoutingMeta) {
+	for _, em := range endpointMetas {
+		operationID := s.getOperationID(em.Path, em.Method)
+		operation := s.GetTykExtension().getOperation(operationID)
+		if operation.Routing == nil {
+			operation.Routing = &Routing{}
+		}
+
+		operation.Routing.Enabled = !em.Disabled
+		operation.Routing.Rules = fillRoutingRules(em.Rules)
+		if ShouldOmit(operation.Routing) {
+			operation.Routing = nil
+		}
+	}
+}
+
+func (o *Operation) extractRoutingTo(ep *apidef.ExtendedPathsSet, path string, method string) {
+	if o.Routing == nil {
+		return
+	}
+
+	meta := apidef.RoutingMeta{
+		Disabled: !o.Routing.Enabled,
+		Path:     path,
+		Method:   method,
+		Rules:    extractRoutingRules(o.Routing.Rules),
+	}
+	ep.Routing = append(ep.Routing, meta)
+}
+
 func (s *OAS) fillEndpointPostPlugins(endpointMetas []apidef.GoPluginMeta) {
 	for _, em := range endpointMetas {
 		operationID := s.getOperationID(em.Path, em.Method)
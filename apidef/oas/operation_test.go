@@ -77,6 +77,7 @@ func TestOAS_PathsAndOperations(t *testing.T) {
 	operation.TrackEndpoint = nil                     // This one also fills native part, let's skip it for this test.
 	operation.DoNotTrackEndpoint = nil                // This one also fills native part, let's skip it for this test.
 	operation.ValidateRequest = nil                   // This one also fills native part, let's skip it for this test.
+	operation.ValidateResponse = nil                  // This one is OAS only, let's skip it for this test.
 	operation.MockResponse = nil                      // This one also fills native part, let's skip it for this test.
 	operation.URLRewrite = nil                        // This one also fills native part, let's skip it for this test.
 	operation.Internal = nil                          // This one also fills native part, let's skip it for this test.
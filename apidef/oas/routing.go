@@ -0,0 +1,239 @@
+package oas
+
+import (
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+// Routing holds the conditional routing rules of an API or an endpoint.
+type Routing struct {
+	// Enabled activates the routing rules.
+	//
+	// Tyk classic API definition: `routing.enabled`, `!version_data.versions..extended_paths.routing[].disabled`.
+	Enabled bool `bson:"enabled" json:"enabled"` // required
+
+	// Rules are evaluated in order, the action of the first matching rule applies. The rules of an endpoint are
+	// evaluated before the rules of the API.
+	//
+	// Tyk classic API definition: `routing.rules`, `version_data.versions..extended_paths.routing[].rules`.
+	Rules []RoutingRule `bson:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// Fill fills *Routing from apidef.RoutingConfig.
+func (r *Routing) Fill(routing apidef.RoutingConfig) {
+	r.Enabled = routing.Enabled
+	r.Rules = fillRoutingRules(routing.Rules)
+}
+
+// ExtractTo extracts *Routing into *apidef.RoutingConfig.
+func (r *Routing) ExtractTo(routing *apidef.RoutingConfig) {
+	routing.Enabled = r.Enabled
+	routing.Rules = extractRoutingRules(r.Rules)
+}
+
+// RoutingRule applies its action to the requests matching its conditions.
+type RoutingRule struct {
+	// Name identifies the rule in the logs and evaluation results.
+	//
+	// Tyk classic API definition: `routing.rules[].name`.
+	Name string `bson:"name,omitempty" json:"name,omitempty"`
+
+	// Match is `all` to require all the conditions to match, or `any` to require one of them. Defaults to `all`.
+	//
+	// Tyk classic API definition: `routing.rules[].match`.
+	Match apidef.RoutingTriggerOnType `bson:"match,omitempty" json:"match,omitempty"`
+
+	// Conditions are the conditions of the rule, a rule without conditions matches all the requests.
+	//
+	// Tyk classic API definition: `routing.rules[].conditions`.
+	Conditions []RoutingCondition `bson:"conditions,omitempty" json:"conditions,omitempty"`
+
+	// Action is applied to the matching requests.
+	//
+	// Tyk classic API definition: `routing.rules[].action`.
+	Action RoutingAction `bson:"action" json:"action"`
+}
+
+// RoutingCondition compares a value of the request.
+type RoutingCondition struct {
+	// Source is the part of the request the value is read from: `header`, `query`, `path`, `method`, `host`,
+	// `session_meta`, `context` or `body`.
+	//
+	// Tyk classic API definition: `routing.rules[].conditions[].source`.
+	Source apidef.RoutingSource `bson:"source" json:"source"`
+
+	// Key names the value within the source: the header, query parameter, session metadata field, context
+	// variable, or the dot separated path of a JSON body field.
+	//
+	// Tyk classic API definition: `routing.rules[].conditions[].key`.
+	Key string `bson:"key,omitempty" json:"key,omitempty"`
+
+	// Operator is the comparison: `eq`, `neq`, `contains`, `prefix`, `suffix`, `regex`, `in`, `gt`, `gte`, `lt`,
+	// `lte` or `exists`.
+	//
+	// Tyk classic API definition: `routing.rules[].conditions[].operator`.
+	Operator apidef.RoutingOperator `bson:"operator" json:"operator"`
+
+	// Value is the operand of the comparison.
+	//
+	// Tyk classic API definition: `routing.rules[].conditions[].value`.
+	Value string `bson:"value,omitempty" json:"value,omitempty"`
+
+	// Values are the operands of the `in` operator.
+	//
+	// Tyk classic API definition: `routing.rules[].conditions[].values`.
+	Values []string `bson:"values,omitempty" json:"values,omitempty"`
+
+	// Negate inverts the result of the comparison.
+	//
+	// Tyk classic API definition: `routing.rules[].conditions[].negate`.
+	Negate bool `bson:"negate,omitempty" json:"negate,omitempty"`
+}
+
+// RoutingAction is applied to the requests matching a routing rule. The headers are set first, then the fixed
+// response is returned, or the request is switched to the version and upstream.
+type RoutingAction struct {
+	// UpstreamURL is the upstream the request is proxied to, it supports request context variables.
+	//
+	// Tyk classic API definition: `routing.rules[].action.upstream_url`.
+	UpstreamURL string `bson:"upstreamURL,omitempty" json:"upstreamURL,omitempty"`
+
+	// Version is the API version the request is switched to.
+	//
+	// Tyk classic API definition: `routing.rules[].action.version`.
+	Version string `bson:"version,omitempty" json:"version,omitempty"`
+
+	// SetHeaders are the request headers to set, the values support request context variables.
+	//
+	// Tyk classic API definition: `routing.rules[].action.set_headers`.
+	SetHeaders Headers `bson:"setHeaders,omitempty" json:"setHeaders,omitempty"`
+
+	// Response is returned instead of proxying the request.
+	//
+	// Tyk classic API definition: `routing.rules[].action.response`.
+	Response *RoutingResponse `bson:"response,omitempty" json:"response,omitempty"`
+}
+
+// RoutingResponse is a fixed response returned by a routing rule.
+type RoutingResponse struct {
+	// Code is the status code of the response.
+	//
+	// Tyk classic API definition: `routing.rules[].action.response.code`.
+	Code int `bson:"code" json:"code"` // required
+
+	// Body is the body of the response.
+	//
+	// Tyk classic API definition: `routing.rules[].action.response.body`.
+	Body string `bson:"body,omitempty" json:"body,omitempty"`
+
+	// Headers are the headers of the response.
+	//
+	// Tyk classic API definition: `routing.rules[].action.response.headers`.
+	Headers Headers `bson:"headers,omitempty" json:"headers,omitempty"`
+}
+
+func fillRoutingRules(rules []apidef.RoutingRule) []RoutingRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	filled := make([]RoutingRule, len(rules))
+	for i, rule := range rules {
+		filled[i] = RoutingRule{
+			Name:   rule.Name,
+			Match:  rule.Match,
+			Action: fillRoutingAction(rule.Action),
+		}
+
+		for _, c := range rule.Conditions {
+			filled[i].Conditions = append(filled[i].Conditions, RoutingCondition{
+				Source:   c.Source,
+				Key:      c.Key,
+				Operator: c.Operator,
+				Value:    c.Value,
+				Values:   c.Values,
+				Negate:   c.Negate,
+			})
+		}
+	}
+
+	return filled
+}
+
+func fillRoutingAction(action apidef.RoutingAction) RoutingAction {
+	filled := RoutingAction{
+		UpstreamURL: action.UpstreamURL,
+		Version:     action.Version,
+		SetHeaders:  newHeadersOrNil(action.SetHeaders),
+		Response: &RoutingResponse{
+			Code:    action.Response.Code,
+			Body:    action.Response.Body,
+			Headers: newHeadersOrNil(action.Response.Headers),
+		},
+	}
+
+	if ShouldOmit(filled.Response) {
+		filled.Response = nil
+	}
+
+	return filled
+}
+
+func extractRoutingRules(rules []RoutingRule) []apidef.RoutingRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	extracted := make([]apidef.RoutingRule, len(rules))
+	for i, rule := range rules {
+		extracted[i] = apidef.RoutingRule{
+			Name:   rule.Name,
+			Match:  rule.Match,
+			Action: rule.Action.extract(),
+		}
+
+		for _, c := range rule.Conditions {
+			extracted[i].Conditions = append(extracted[i].Conditions, apidef.RoutingCondition{
+				Source:   c.Source,
+				Key:      c.Key,
+				Operator: c.Operator,
+				Value:    c.Value,
+				Values:   c.Values,
+				Negate:   c.Negate,
+			})
+		}
+	}
+
+	return extracted
+}
+
+func (a RoutingAction) extract() apidef.RoutingAction {
+	action := apidef.RoutingAction{
+		UpstreamURL: a.UpstreamURL,
+		Version:     a.Version,
+		SetHeaders:  headersMapOrNil(a.SetHeaders),
+	}
+
+	if a.Response != nil {
+		action.Response = apidef.RoutingResponse{
+			Code:    a.Response.Code,
+			Body:    a.Response.Body,
+			Headers: headersMapOrNil(a.Response.Headers),
+		}
+	}
+
+	return action
+}
+
+func newHeadersOrNil(in map[string]string) Headers {
+	if len(in) == 0 {
+		return nil
+	}
+	return NewHeaders(in)
+}
+
+func headersMapOrNil(hs Headers) map[string]string {
+	if len(hs) == 0 {
+		return nil
+	}
+	return hs.Map()
+}
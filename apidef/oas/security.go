@@ -268,6 +268,8 @@ type Basic struct {
 	// ExtractCredentialsFromBody helps to extract username and password from body. In some cases, like dealing with SOAP,
 	// user credentials can be passed via request body.
 	ExtractCredentialsFromBody *ExtractCredentialsFromBody `bson:"extractCredentialsFromBody,omitempty" json:"extractCredentialsFromBody,omitempty"`
+	// LDAP configures verifying the credentials against an LDAP or Active Directory server.
+	LDAP *LDAPAuth `bson:"ldap,omitempty" json:"ldap,omitempty"`
 }
 
 // Import populates *Basic from it's arguments.
@@ -319,6 +321,16 @@ func (s *OAS) fillBasic(api apidef.APIDefinition) {
 		basic.ExtractCredentialsFromBody = nil
 	}
 
+	if basic.LDAP == nil {
+		basic.LDAP = &LDAPAuth{}
+	}
+
+	basic.LDAP.Fill(api.BasicAuth.LDAP)
+
+	if ShouldOmit(basic.LDAP) {
+		basic.LDAP = nil
+	}
+
 	s.getTykSecuritySchemes()[ac.Name] = basic
 
 	if ShouldOmit(basic) {
@@ -339,6 +351,10 @@ func (s *OAS) extractBasicTo(api *apidef.APIDefinition, name string) {
 		basic.ExtractCredentialsFromBody.ExtractTo(api)
 	}
 
+	if basic.LDAP != nil {
+		basic.LDAP.ExtractTo(&api.BasicAuth.LDAP)
+	}
+
 	api.AuthConfigs[apidef.BasicType] = ac
 }
 
@@ -369,6 +385,104 @@ func (e *ExtractCredentialsFromBody) ExtractTo(api *apidef.APIDefinition) {
 	api.BasicAuth.BodyPasswordRegexp = e.PasswordRegexp
 }
 
+// LDAPAuth configures the verification of basic auth credentials against an LDAP directory.
+// The user binds with the supplied credentials and the groups of the user are mapped to policies.
+type LDAPAuth struct {
+	// Enabled activates LDAP authentication.
+	// Tyk classic API definition: `basic_auth.ldap.enabled`
+	Enabled bool `bson:"enabled" json:"enabled"` // required
+	// Server is the hostname of the LDAP server.
+	// Tyk classic API definition: `basic_auth.ldap.server`
+	Server string `bson:"server,omitempty" json:"server,omitempty"`
+	// Port is the port of the LDAP server.
+	// Tyk classic API definition: `basic_auth.ldap.port`
+	Port uint16 `bson:"port,omitempty" json:"port,omitempty"`
+	// UseSSL connects to the server over TLS (ldaps).
+	// Tyk classic API definition: `basic_auth.ldap.use_ssl`
+	UseSSL bool `bson:"useSSL,omitempty" json:"useSSL,omitempty"`
+	// StartTLS upgrades the connection to TLS with the StartTLS operation.
+	// Tyk classic API definition: `basic_auth.ldap.start_tls`
+	StartTLS bool `bson:"startTLS,omitempty" json:"startTLS,omitempty"`
+	// InsecureSkipVerify skips the verification of the LDAP server certificate.
+	// Tyk classic API definition: `basic_auth.ldap.ssl_insecure_skip_verify`
+	InsecureSkipVerify bool `bson:"insecureSkipVerify,omitempty" json:"insecureSkipVerify,omitempty"`
+	// UserDNTemplate builds the DN used to bind as the user, e.g. `uid={username},ou=people,dc=example,dc=com`.
+	// Tyk classic API definition: `basic_auth.ldap.user_dn_template`
+	UserDNTemplate string `bson:"userDNTemplate,omitempty" json:"userDNTemplate,omitempty"`
+	// SearchBindDN is the DN of the service account used to search for users.
+	// Tyk classic API definition: `basic_auth.ldap.search_bind_dn`
+	SearchBindDN string `bson:"searchBindDN,omitempty" json:"searchBindDN,omitempty"`
+	// SearchBindPassword is the password of the service account used to search for users.
+	// Tyk classic API definition: `basic_auth.ldap.search_bind_password`
+	SearchBindPassword string `bson:"searchBindPassword,omitempty" json:"searchBindPassword,omitempty"`
+	// BaseDN is the base DN for user searches.
+	// Tyk classic API definition: `basic_auth.ldap.base_dn`
+	BaseDN string `bson:"baseDN,omitempty" json:"baseDN,omitempty"`
+	// UserFilter is the filter used to find the user entry, e.g. `(uid={username})`.
+	// Tyk classic API definition: `basic_auth.ldap.user_filter`
+	UserFilter string `bson:"userFilter,omitempty" json:"userFilter,omitempty"`
+	// GroupAttribute is the user entry attribute listing the groups of the user.
+	// Tyk classic API definition: `basic_auth.ldap.group_attribute`
+	GroupAttribute string `bson:"groupAttribute,omitempty" json:"groupAttribute,omitempty"`
+	// GroupPolicies maps group DNs or common names to policy IDs.
+	// Tyk classic API definition: `basic_auth.ldap.group_policies`
+	GroupPolicies map[string]string `bson:"groupPolicies,omitempty" json:"groupPolicies,omitempty"`
+	// DefaultPolicies are applied to every authenticated user.
+	// Tyk classic API definition: `basic_auth.ldap.default_policies`
+	DefaultPolicies []string `bson:"defaultPolicies,omitempty" json:"defaultPolicies,omitempty"`
+	// MetadataAttributes lists user entry attributes copied to the session metadata.
+	// Tyk classic API definition: `basic_auth.ldap.metadata_attributes`
+	MetadataAttributes []string `bson:"metadataAttributes,omitempty" json:"metadataAttributes,omitempty"`
+	// CacheTTL is the time in seconds a successful bind is cached.
+	// Tyk classic API definition: `basic_auth.ldap.cache_ttl`
+	CacheTTL int64 `bson:"cacheTTL,omitempty" json:"cacheTTL,omitempty"`
+	// Timeout is the connection and read timeout in seconds.
+	// Tyk classic API definition: `basic_auth.ldap.timeout`
+	Timeout int64 `bson:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// Fill fills *LDAPAuth from apidef.LDAPAuthConfig.
+func (l *LDAPAuth) Fill(conf apidef.LDAPAuthConfig) {
+	l.Enabled = conf.Enabled
+	l.Server = conf.Server
+	l.Port = conf.Port
+	l.UseSSL = conf.UseSSL
+	l.StartTLS = conf.StartTLS
+	l.InsecureSkipVerify = conf.SSLInsecureSkipVerify
+	l.UserDNTemplate = conf.UserDNTemplate
+	l.SearchBindDN = conf.SearchBindDN
+	l.SearchBindPassword = conf.SearchBindPassword
+	l.BaseDN = conf.BaseDN
+	l.UserFilter = conf.UserFilter
+	l.GroupAttribute = conf.GroupAttribute
+	l.GroupPolicies = conf.GroupPolicies
+	l.DefaultPolicies = conf.DefaultPolicies
+	l.MetadataAttributes = conf.MetadataAttributes
+	l.CacheTTL = conf.CacheTTL
+	l.Timeout = conf.Timeout
+}
+
+// ExtractTo extracts *LDAPAuth into *apidef.LDAPAuthConfig.
+func (l *LDAPAuth) ExtractTo(conf *apidef.LDAPAuthConfig) {
+	conf.Enabled = l.Enabled
+	conf.Server = l.Server
+	conf.Port = l.Port
+	conf.UseSSL = l.UseSSL
+	conf.StartTLS = l.StartTLS
+	conf.SSLInsecureSkipVerify = l.InsecureSkipVerify
+	conf.UserDNTemplate = l.UserDNTemplate
+	conf.SearchBindDN = l.SearchBindDN
+	conf.SearchBindPassword = l.SearchBindPassword
+	conf.BaseDN = l.BaseDN
+	conf.UserFilter = l.UserFilter
+	conf.GroupAttribute = l.GroupAttribute
+	conf.GroupPolicies = l.GroupPolicies
+	conf.DefaultPolicies = l.DefaultPolicies
+	conf.MetadataAttributes = l.MetadataAttributes
+	conf.CacheTTL = l.CacheTTL
+	conf.Timeout = l.Timeout
+}
+
 // OAuth configures the OAuth middleware.
 type OAuth struct {
 	// Enabled activates the OAuth middleware.
@@ -798,6 +912,7 @@ func resetSecuritySchemes(api *apidef.APIDefinition) {
 	api.BasicAuth.ExtractFromBody = false
 	api.BasicAuth.BodyUserRegexp = ""
 	api.BasicAuth.BodyPasswordRegexp = ""
+	api.BasicAuth.LDAP = apidef.LDAPAuthConfig{}
 	delete(api.AuthConfigs, "basic")
 
 	// HMAC
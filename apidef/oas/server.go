@@ -193,18 +193,163 @@ type ClientCertificates struct {
 	Enabled bool `bson:"enabled" json:"enabled"`
 	// Allowlist is the list of client certificates which are allowed.
 	Allowlist []string `bson:"allowlist" json:"allowlist"`
+	// Identity configures deriving sessions from the fields of the client certificate.
+	Identity *CertificateIdentity `bson:"identity,omitempty" json:"identity,omitempty"`
+	// Revocation configures CRL and OCSP checks of client certificates.
+	Revocation *CertificateRevocation `bson:"revocation,omitempty" json:"revocation,omitempty"`
 }
 
 // Fill fills *ClientCertificates from apidef.APIDefinition.
 func (cc *ClientCertificates) Fill(api apidef.APIDefinition) {
 	cc.Enabled = api.UseMutualTLSAuth
 	cc.Allowlist = api.ClientCertificates
+
+	if cc.Identity == nil {
+		cc.Identity = &CertificateIdentity{}
+	}
+
+	cc.Identity.Fill(api.ClientCertificateIdentity)
+	if ShouldOmit(cc.Identity) {
+		cc.Identity = nil
+	}
+
+	if cc.Revocation == nil {
+		cc.Revocation = &CertificateRevocation{}
+	}
+
+	cc.Revocation.Fill(api.ClientCertificateRevocation)
+	if ShouldOmit(cc.Revocation) {
+		cc.Revocation = nil
+	}
 }
 
 // ExtractTo extracts *ClientCertificates into *apidef.APIDefinition.
 func (cc *ClientCertificates) ExtractTo(api *apidef.APIDefinition) {
 	api.UseMutualTLSAuth = cc.Enabled
 	api.ClientCertificates = cc.Allowlist
+
+	if cc.Identity == nil {
+		cc.Identity = &CertificateIdentity{}
+		defer func() {
+			cc.Identity = nil
+		}()
+	}
+
+	cc.Identity.ExtractTo(&api.ClientCertificateIdentity)
+
+	if cc.Revocation == nil {
+		cc.Revocation = &CertificateRevocation{}
+		defer func() {
+			cc.Revocation = nil
+		}()
+	}
+
+	cc.Revocation.ExtractTo(&api.ClientCertificateRevocation)
+}
+
+// CertificateIdentity configures deriving sessions from the fields of the client certificate,
+// without creating a key per certificate.
+type CertificateIdentity struct {
+	// Enabled activates client certificate identity mapping as an authentication mechanism.
+	// Tyk classic API definition: `client_certificate_identity.enabled`
+	Enabled bool `bson:"enabled" json:"enabled"` // required
+	// IdentityField is the certificate field used as the session identity, one of `subject.cn`,
+	// `subject.o`, `subject.ou`, `san.uri`, `san.dns`, `san.email` or `serial`. Defaults to `subject.cn`.
+	// Tyk classic API definition: `client_certificate_identity.identity_field`
+	IdentityField string `bson:"identityField,omitempty" json:"identityField,omitempty"`
+	// Rules map certificate field values to policies and metadata.
+	// Tyk classic API definition: `client_certificate_identity.rules`
+	Rules []CertificateIdentityRule `bson:"rules,omitempty" json:"rules,omitempty"`
+	// DefaultPolicies are applied to every certificate with an identity.
+	// Tyk classic API definition: `client_certificate_identity.default_policies`
+	DefaultPolicies []string `bson:"defaultPolicies,omitempty" json:"defaultPolicies,omitempty"`
+}
+
+// CertificateIdentityRule maps a client certificate field to policies and metadata.
+type CertificateIdentityRule struct {
+	// Field is the certificate field the rule matches.
+	// Tyk classic API definition: `client_certificate_identity.rules[].field`
+	Field string `bson:"field" json:"field"`
+	// Match is a regular expression matched against the field values. An empty value matches any value.
+	// Tyk classic API definition: `client_certificate_identity.rules[].match`
+	Match string `bson:"match,omitempty" json:"match,omitempty"`
+	// Policies are the policy IDs applied when the rule matches.
+	// Tyk classic API definition: `client_certificate_identity.rules[].policies`
+	Policies []string `bson:"policies,omitempty" json:"policies,omitempty"`
+	// Metadata is added to the session when the rule matches, values can reference capture groups of Match.
+	// Tyk classic API definition: `client_certificate_identity.rules[].metadata`
+	Metadata map[string]string `bson:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// Fill fills *CertificateIdentity from apidef.ClientCertificateIdentity.
+func (ci *CertificateIdentity) Fill(conf apidef.ClientCertificateIdentity) {
+	ci.Enabled = conf.Enabled
+	ci.IdentityField = conf.IdentityField
+	ci.DefaultPolicies = conf.DefaultPolicies
+
+	ci.Rules = nil
+	for _, rule := range conf.Rules {
+		ci.Rules = append(ci.Rules, CertificateIdentityRule(rule))
+	}
+}
+
+// ExtractTo extracts *CertificateIdentity into *apidef.ClientCertificateIdentity.
+func (ci *CertificateIdentity) ExtractTo(conf *apidef.ClientCertificateIdentity) {
+	conf.Enabled = ci.Enabled
+	conf.IdentityField = ci.IdentityField
+	conf.DefaultPolicies = ci.DefaultPolicies
+
+	conf.Rules = nil
+	for _, rule := range ci.Rules {
+		conf.Rules = append(conf.Rules, apidef.CertificateIdentityRule(rule))
+	}
+}
+
+// CertificateRevocation configures the revocation checks of client certificates.
+type CertificateRevocation struct {
+	// CheckCRL enables checking client certificates against certificate revocation lists.
+	// Tyk classic API definition: `client_certificate_revocation.check_crl`
+	CheckCRL bool `bson:"checkCRL,omitempty" json:"checkCRL,omitempty"`
+	// CRLURLs are the URLs of the CRLs to check. When empty, the CRL distribution points of the certificate are used.
+	// Tyk classic API definition: `client_certificate_revocation.crl_urls`
+	CRLURLs []string `bson:"crlURLs,omitempty" json:"crlURLs,omitempty"`
+	// CheckOCSP enables checking client certificates with an OCSP responder.
+	// Tyk classic API definition: `client_certificate_revocation.check_ocsp`
+	CheckOCSP bool `bson:"checkOCSP,omitempty" json:"checkOCSP,omitempty"`
+	// OCSPResponderURL is the URL of the OCSP responder. When empty, the OCSP server of the certificate is used.
+	// Tyk classic API definition: `client_certificate_revocation.ocsp_responder_url`
+	OCSPResponderURL string `bson:"ocspResponderURL,omitempty" json:"ocspResponderURL,omitempty"`
+	// FailOpen allows requests when the revocation status can't be determined.
+	// Tyk classic API definition: `client_certificate_revocation.fail_open`
+	FailOpen bool `bson:"failOpen,omitempty" json:"failOpen,omitempty"`
+	// CacheTTL is the maximum time in seconds a CRL or OCSP response is cached.
+	// Tyk classic API definition: `client_certificate_revocation.cache_ttl`
+	CacheTTL int64 `bson:"cacheTTL,omitempty" json:"cacheTTL,omitempty"`
+	// Timeout is the timeout in seconds for CRL downloads and OCSP queries.
+	// Tyk classic API definition: `client_certificate_revocation.timeout`
+	Timeout int64 `bson:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// Fill fills *CertificateRevocation from apidef.ClientCertificateRevocation.
+func (cr *CertificateRevocation) Fill(conf apidef.ClientCertificateRevocation) {
+	cr.CheckCRL = conf.CheckCRL
+	cr.CRLURLs = conf.CRLURLs
+	cr.CheckOCSP = conf.CheckOCSP
+	cr.OCSPResponderURL = conf.OCSPResponderURL
+	cr.FailOpen = conf.FailOpen
+	cr.CacheTTL = conf.CacheTTL
+	cr.Timeout = conf.Timeout
+}
+
+// ExtractTo extracts *CertificateRevocation into *apidef.ClientCertificateRevocation.
+func (cr *CertificateRevocation) ExtractTo(conf *apidef.ClientCertificateRevocation) {
+	conf.CheckCRL = cr.CheckCRL
+	conf.CRLURLs = cr.CRLURLs
+	conf.CheckOCSP = cr.CheckOCSP
+	conf.OCSPResponderURL = cr.OCSPResponderURL
+	conf.FailOpen = cr.FailOpen
+	conf.CacheTTL = cr.CacheTTL
+	conf.Timeout = cr.Timeout
 }
 
 // GatewayTags holds a list of segment tags that should apply for a gateway.
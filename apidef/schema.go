@@ -201,6 +201,18 @@ const Schema = `{
         "null"
       ]
     },
+    "client_certificate_identity": {
+      "type": [
+        "object",
+        "null"
+      ]
+    },
+    "client_certificate_revocation": {
+      "type": [
+        "object",
+        "null"
+      ]
+    },
     "upstream_certificates": {
       "type": [
         "object",
@@ -291,6 +303,24 @@ const Schema = `{
         "null"
       ]
     },
+    "compression": {
+      "type": [
+        "object",
+        "null"
+      ]
+    },
+    "redaction": {
+      "type": [
+        "object",
+        "null"
+      ]
+    },
+    "routing": {
+      "type": [
+        "object",
+        "null"
+      ]
+    },
     "response_processors": {
       "type": [
         "array",
@@ -349,6 +379,22 @@ const Schema = `{
     "dont_set_quota_on_create": {
       "type": "boolean"
     },
+    "js_runtime": {
+      "type": [
+        "object",
+        "null"
+      ],
+      "properties": {
+        "engine": {
+          "type": "string",
+          "enum": [
+            "",
+            "otto",
+            "goja"
+          ]
+        }
+      }
+    },
     "custom_middleware": {
       "type": [
         "object",
@@ -947,6 +993,109 @@ const Schema = `{
             }
           }
         },
+        "cost_analysis": {
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "enabled": {
+              "type": "boolean"
+            },
+            "weights": {
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "integer"
+              }
+            },
+            "slicing_arguments": {
+              "type": [
+                "array",
+                "null"
+              ],
+              "items": {
+                "type": "string"
+              }
+            },
+            "default_list_size": {
+              "type": "integer",
+              "minimum": 0
+            },
+            "charge_quota": {
+              "type": "boolean"
+            }
+          }
+        },
+        "cache": {
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "enabled": {
+              "type": "boolean"
+            },
+            "default_ttl": {
+              "type": "integer",
+              "minimum": 0
+            },
+            "type_ttls": {
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "integer",
+                "minimum": 0
+              }
+            },
+            "private_types": {
+              "type": [
+                "array",
+                "null"
+              ],
+              "items": {
+                "type": "string"
+              }
+            },
+            "data_source_fetches": {
+              "type": [
+                "object",
+                "null"
+              ],
+              "properties": {
+                "enabled": {
+                  "type": "boolean"
+                },
+                "ttl": {
+                  "type": "integer",
+                  "minimum": 0
+                }
+              }
+            }
+          }
+        },
+        "persisted_queries": {
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "automatic": {
+              "type": "boolean"
+            },
+            "ttl": {
+              "type": "integer",
+              "minimum": 0
+            },
+            "safelist": {
+              "type": "boolean"
+            }
+          }
+        },
         "playground": {
           "type": [
             "object",
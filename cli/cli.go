@@ -10,6 +10,7 @@ import (
 	kingpin "github.com/alecthomas/kingpin/v2"
 
 	"github.com/TykTechnologies/tyk/cli/bundler"
+	"github.com/TykTechnologies/tyk/cli/generator"
 	"github.com/TykTechnologies/tyk/cli/importer"
 	"github.com/TykTechnologies/tyk/cli/linter"
 	"github.com/TykTechnologies/tyk/cli/plugin"
@@ -105,6 +106,9 @@ func Init(confPaths []string) {
 	// Add import command:
 	importer.AddTo(app)
 
+	// Add generate command:
+	generator.AddTo(app)
+
 	// Add bundler commands:
 	bundler.AddTo(app)
 
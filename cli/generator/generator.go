@@ -0,0 +1,133 @@
+package generator
+
+//lint:file-ignore faillint This file should be ignored by faillint (fmt in use).
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/apidef/adapter"
+	"github.com/TykTechnologies/tyk/apidef/oas"
+)
+
+const (
+	cmdName = "generate"
+	cmdDesc = "Generates a GraphQL API from an OAS document, or REST endpoints for a GraphQL API"
+)
+
+var (
+	gen *Generator = &Generator{}
+
+	errUnknownMode = errors.New("unknown mode")
+)
+
+// Generator wraps the generate functionality.
+type Generator struct {
+	input          *string
+	graphQLMode    *bool
+	restMode       *bool
+	orgID          *string
+	upstreamTarget *string
+}
+
+// AddTo initializes a generator object.
+func AddTo(app *kingpin.Application) {
+	cmd := app.Command(cmdName, cmdDesc)
+	gen.input = cmd.Arg("input file", "e.g. oas.json, openapi.yaml, api.json etc.").Required().String()
+	gen.graphQLMode = cmd.Flag("graphql", "Generate a UDG GraphQL API from an OAS document").Bool()
+	gen.restMode = cmd.Flag("rest", "Add REST endpoints for the queries and mutations of a GraphQL API definition").Bool()
+	gen.orgID = cmd.Flag("org-id", "assign the generated API Definition to this org_id").String()
+	gen.upstreamTarget = cmd.Flag("upstream-target", "set the upstream target of the REST data sources, defaults to the upstream of a Tyk OAS API or to the first server").PlaceHolder("URL").String()
+	cmd.Action(gen.Generate)
+}
+
+// Generate performs the generate process.
+func (g *Generator) Generate(_ *kingpin.ParseContext) (err error) {
+	if *g.graphQLMode {
+		err = g.handleGraphQLMode()
+	} else if *g.restMode {
+		err = g.handleRESTMode()
+	} else {
+		err = errUnknownMode
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(0)
+	return nil
+}
+
+func (g *Generator) handleGraphQLMode() error {
+	input, err := os.ReadFile(*g.input)
+	if err != nil {
+		return fmt.Errorf("file load error: %w", err)
+	}
+
+	upstreamTarget := *g.upstreamTarget
+	if upstreamTarget == "" {
+		upstreamTarget = tykOASUpstreamURL(input)
+	}
+
+	def, err := adapter.NewOpenAPIAdapterWithUpstream(*g.orgID, input, upstreamTarget).Import()
+	if err != nil {
+		return fmt.Errorf("failed to generate GraphQL API: %w", err)
+	}
+
+	g.printDef(def)
+	return nil
+}
+
+func (g *Generator) handleRESTMode() error {
+	f, err := os.Open(*g.input)
+	if err != nil {
+		return fmt.Errorf("file load error: %w", err)
+	}
+	defer f.Close()
+
+	def := &apidef.APIDefinition{}
+	if err := json.NewDecoder(f).Decode(def); err != nil {
+		return fmt.Errorf("failed to load and decode file data for API Definition: %w", err)
+	}
+
+	def, err = adapter.NewRESTAdapter(def).Import()
+	if err != nil {
+		return fmt.Errorf("failed to generate REST endpoints: %w", err)
+	}
+
+	g.printDef(def)
+	return nil
+}
+
+// tykOASUpstreamURL returns the upstream URL of a Tyk OAS API definition, the servers of the document being the
+// gateway.
+func tykOASUpstreamURL(input []byte) string {
+	var document oas.OAS
+	if err := json.Unmarshal(input, &document); err != nil {
+		return ""
+	}
+
+	if ext := document.GetTykExtension(); ext != nil {
+		return ext.Upstream.URL
+	}
+	return ""
+}
+
+func (g *Generator) printDef(def *apidef.APIDefinition) {
+	asJSON, err := json.MarshalIndent(def, "", "    ")
+	if err != nil {
+		log.Error("Marshalling failed: ", err)
+	}
+
+	// The id attribute is for BSON only and breaks the parser if it's empty, cull it here.
+	fixed := strings.Replace(string(asJSON), `    "id": "",`, "", 1)
+	fmt.Println(fixed)
+}
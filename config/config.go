@@ -310,6 +310,8 @@ type WebHookHandlerConf struct {
 	HeaderList map[string]string `bson:"header_map" json:"header_map"`
 	// The cool-down for the event so it does not trigger again (in seconds).
 	EventTimeout int64 `bson:"event_timeout" json:"event_timeout"`
+	// The secret of the HMAC-SHA256 signature of the payload, sent in the X-Tyk-Signature header.
+	SigningSecret string `bson:"signing_secret" json:"signing_secret,omitempty"`
 }
 
 type SlaveOptionsConfig struct {
@@ -377,6 +379,84 @@ type LocalSessionCacheConf struct {
 	CachedSessionTimeout int `json:"cached_session_timeout"`
 	CacheSessionEviction int `json:"cached_session_eviction"`
 }
+
+// LocalResponseCacheConf configures the in-memory tier of the response cache, which holds hot
+// cached responses on the node in front of Redis.
+type LocalResponseCacheConf struct {
+	// Set this to `true` to keep cached responses in memory on the node, saving the Redis lookup on cache hits.
+	Enabled bool `json:"enabled"`
+
+	// MaxSize is the maximum size in bytes of the responses held in memory. The least recently used responses are evicted first.
+	// The default is 64MB.
+	MaxSize int64 `json:"max_size"`
+
+	// TTL is the time in seconds a response is held in memory. A response is never held beyond its cache expiry.
+	// The default is 5 seconds.
+	TTL int64 `json:"ttl"`
+}
+
+// ResponseCacheStorageConf configures the storage backends an API can select for its response cache
+// with `cache_options.backend`, instead of Redis.
+type ResponseCacheStorageConf struct {
+	// Memory configures the `memory` backend, holding the cached responses of all APIs using it on the node.
+	Memory MemoryCacheStorageConf `json:"memory"`
+
+	// Disk configures the `disk` backend, writing the cached responses of all APIs using it to files on the node.
+	Disk DiskCacheStorageConf `json:"disk"`
+
+	// S3 configures the `s3` backend, storing the cached responses in an S3 compatible object store.
+	S3 S3CacheStorageConf `json:"s3"`
+}
+
+// MemoryCacheStorageConf configures the in-memory response cache backend.
+type MemoryCacheStorageConf struct {
+	// MaxSize is the maximum size in bytes of the cached responses. The least recently used responses are evicted first.
+	// The default is 256MB.
+	MaxSize int64 `json:"max_size"`
+}
+
+// DiskCacheStorageConf configures the on-disk response cache backend.
+type DiskCacheStorageConf struct {
+	// Path is the directory the cached responses are written to, in a subdirectory named after the host which the
+	// gateway locks. Other gateways of the host use the next free subdirectory, `<host>-1`, `<host>-2` and so on.
+	// Cached responses left in the subdirectory by a previous run are removed on start.
+	// The default is `tyk-response-cache` in the temporary directory of the system.
+	Path string `json:"path"`
+
+	// MaxSize is the maximum size in bytes of the cached responses. The least recently used responses are evicted first.
+	// The default is 1GB.
+	MaxSize int64 `json:"max_size"`
+}
+
+// S3CacheStorageConf configures the S3 compatible object store response cache backend.
+// S3 doesn't expire objects, the gateway ignores expired objects and removes them when read.
+// Configure a lifecycle rule on the bucket to remove expired objects which aren't read again.
+type S3CacheStorageConf struct {
+	// Endpoint is the URL of an S3 compatible object store. The AWS endpoint of the region is used if not set.
+	Endpoint string `json:"endpoint"`
+
+	// Region is the region of the bucket.
+	Region string `json:"region"`
+
+	// Bucket is the name of the bucket the cached responses are stored in.
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the object keys of the cached responses.
+	Prefix string `json:"prefix"`
+
+	// AccessKeyID and SecretAccessKey are the static credentials used to access the bucket.
+	// The default AWS credential chain is used if not set.
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+
+	// UsePathStyle addresses the bucket in the path of the URL instead of the host name,
+	// as required by most S3 compatible object stores.
+	UsePathStyle bool `json:"use_path_style"`
+
+	// Timeout is the timeout in seconds of the object store requests. The default is 5 seconds.
+	Timeout int64 `json:"timeout"`
+}
+
 type CertsData []CertData
 
 func (certs *CertsData) Decode(value string) error {
@@ -557,6 +637,13 @@ type CoProcessConfig struct {
 
 	// If you have multiple Python versions installed you can specify your version.
 	PythonVersion string `json:"python_version"`
+
+	// Maximum memory in bytes a WebAssembly plugin instance can use, rounded up to 64KiB pages.
+	// Defaults to 64MB.
+	WasmMaxMemory int64 `json:"wasm_max_memory"`
+
+	// Maximum time in seconds a WebAssembly plugin can run for a single hook call. Defaults to 5 seconds.
+	WasmTimeout float64 `json:"wasm_timeout"`
 }
 
 type CertificatesConfig struct {
@@ -657,6 +744,59 @@ func (pwl *PortsWhiteList) Decode(value string) error {
 	return nil
 }
 
+// BasicAuthHashOptionsConfig holds the cost parameters of the basic auth password hash functions.
+// Zero values fall back to the defaults noted on each field.
+type BasicAuthHashOptionsConfig struct {
+	// BCryptCost is the bcrypt cost factor. Defaults to 10.
+	BCryptCost int `json:"bcrypt_cost"`
+	// Argon2Memory is the amount of memory used by argon2id, in KiB. Defaults to 65536.
+	Argon2Memory uint32 `json:"argon2_memory"`
+	// Argon2Iterations is the number of argon2id passes over the memory. Defaults to 3.
+	Argon2Iterations uint32 `json:"argon2_iterations"`
+	// Argon2Parallelism is the number of argon2id threads. Defaults to 2.
+	Argon2Parallelism uint8 `json:"argon2_parallelism"`
+	// ScryptN is the scrypt CPU/memory cost, it must be a power of two. Defaults to 32768.
+	ScryptN int `json:"scrypt_n"`
+	// ScryptR is the scrypt block size. Defaults to 8.
+	ScryptR int `json:"scrypt_r"`
+	// ScryptP is the scrypt parallelization factor. Defaults to 1.
+	ScryptP int `json:"scrypt_p"`
+	// RehashOnLogin enables transparent upgrade of stored password hashes. When a user logs in
+	// successfully and the stored hash uses a different algorithm or cost than configured,
+	// the password is hashed again with the current settings and the key is saved.
+	RehashOnLogin bool `json:"rehash_on_login"`
+}
+
+// BasicAuthLockoutConfig configures brute-force protection for basic auth users.
+type BasicAuthLockoutConfig struct {
+	// Enabled turns on the lock out of users after MaxAttempts failed logins.
+	Enabled bool `json:"enabled"`
+	// MaxAttempts is the number of failed logins allowed within Window. Defaults to 5.
+	MaxAttempts int64 `json:"max_attempts"`
+	// Window is the period in seconds in which failed logins are counted,
+	// it also sets for how long the user stays locked out. Defaults to 300.
+	Window int64 `json:"window"`
+}
+
+// WebhooksConfig configures the delivery of the webhook events. Deliveries are kept in a persistent outbox until
+// they succeed, failed deliveries are retried with an exponential backoff and moved to the dead-letter queue once
+// the retries are exhausted. Zero values fall back to the defaults noted on each field.
+type WebhooksConfig struct {
+	// MaxRetries is the number of retries of a failed delivery before it's moved to the dead-letter queue.
+	// Defaults to 5, a negative value disables the retries.
+	MaxRetries int `json:"max_retries"`
+	// RetryBackoff is the delay in seconds before the first retry, it doubles with each retry. Defaults to 1.
+	RetryBackoff float64 `json:"retry_backoff"`
+	// MaxRetryBackoff is the maximum delay in seconds between two retries. Defaults to 300.
+	MaxRetryBackoff float64 `json:"max_retry_backoff"`
+	// MaxConcurrentDeliveries is the number of deliveries sent concurrently by the gateway. Defaults to 10.
+	MaxConcurrentDeliveries int `json:"max_concurrent_deliveries"`
+	// Timeout is the timeout in seconds of a delivery request. Defaults to 30.
+	Timeout float64 `json:"timeout"`
+	// DeadLetterRetention is the time in seconds a delivery is kept in the dead-letter queue. Defaults to 604800 (7 days).
+	DeadLetterRetention float64 `json:"dead_letter_retention"`
+}
+
 // StreamingConfig is for configuring tyk streaming
 type StreamingConfig struct {
 	Enabled     bool     `json:"enabled"`
@@ -728,10 +868,17 @@ type Config struct {
 	// Specify the Key hashing algorithm. Possible values: murmur64, murmur128, sha256.
 	HashKeyFunction string `json:"hash_key_function"`
 
-	// Specify the Key hashing algorithm for "basic auth". Possible values: murmur64, murmur128, sha256, bcrypt.
+	// Specify the Key hashing algorithm for "basic auth". Possible values: murmur64, murmur128, sha256, bcrypt, argon2id, scrypt.
 	// Will default to "bcrypt" if not set.
 	BasicAuthHashKeyFunction string `json:"basic_auth_hash_key_function"`
 
+	// BasicAuthHashOptions configures the cost parameters of the bcrypt, argon2id and scrypt
+	// basic auth hash functions, and whether stored passwords are upgraded on login.
+	BasicAuthHashOptions BasicAuthHashOptionsConfig `json:"basic_auth_hash_options"`
+
+	// BasicAuthLockout configures the temporary lock out of basic auth users after repeated failed logins.
+	BasicAuthLockout BasicAuthLockoutConfig `json:"basic_auth_lockout"`
+
 	// Specify your previous key hashing algorithm if you migrated from one algorithm to another.
 	HashKeyFunctionFallback []string `json:"hash_key_function_fallback"`
 
@@ -950,6 +1097,14 @@ type Config struct {
 	// This does not affect rate limiting.
 	LocalSessionCache LocalSessionCacheConf `json:"local_session_cache"`
 
+	// LocalResponseCache enables an in-memory tier of the API response cache on each node. Responses are read from memory
+	// before Redis and are evicted on all nodes when the API cache is invalidated.
+	LocalResponseCache LocalResponseCacheConf `json:"local_response_cache"`
+
+	// ResponseCacheStorage configures the storage backends an API can select for its response cache instead of Redis,
+	// to keep large responses or responses with long expiry times out of Redis memory.
+	ResponseCacheStorage ResponseCacheStorageConf `json:"response_cache_storage"`
+
 	// Enable to use a separate Redis for cache storage
 	EnableSeperateCacheStore bool               `json:"enable_separate_cache_store"`
 	CacheStorage             StorageOptionsConf `json:"cache_storage"`
@@ -1063,6 +1218,9 @@ type Config struct {
 	EventTriggers        map[apidef.TykEvent][]TykEventHandler `json:"event_trigers_defunct"`  // Deprecated: Config.GetEventTriggers instead.
 	EventTriggersDefunct map[apidef.TykEvent][]TykEventHandler `json:"event_triggers_defunct"` // Deprecated: Config.GetEventTriggers instead.
 
+	// Webhooks configures the retries and the concurrency of the webhook deliveries.
+	Webhooks WebhooksConfig `json:"webhooks"`
+
 	// HideGeneratorHeader will mask the 'X-Generator' and 'X-Mascot-...' headers, if set to true.
 	HideGeneratorHeader bool `json:"hide_generator_header"`
 
@@ -53,6 +53,16 @@ const (
 	// CacheOptions holds cache options required for cache writer middleware.
 	CacheOptions
 	OASDefinition
+	// ResponseValidationFailed marks a request whose upstream response failed validation.
+	ResponseValidationFailed
+	// RedactionRules holds the redaction rules applying to the request.
+	RedactionRules
+	// UpstreamTarget holds the upstream URL a routing rule switched the request to.
+	UpstreamTarget
+	// RoutedVersion holds the name of the API version a routing rule switched the request to.
+	RoutedVersion
+	// GraphQLPassthrough marks a GraphQL request sent as is to the upstream of a proxy only API.
+	GraphQLPassthrough
 )
 
 func ctxSetSession(r *http.Request, s *user.SessionState, scheduleUpdate bool, hashKey bool) {
@@ -48,7 +48,9 @@ import (
 
 	"github.com/TykTechnologies/tyk/config"
 
+	"github.com/TykTechnologies/tyk/internal/crypto"
 	"github.com/TykTechnologies/tyk/internal/otel"
+	"github.com/TykTechnologies/tyk/internal/redaction"
 	"github.com/TykTechnologies/tyk/internal/redis"
 	"github.com/TykTechnologies/tyk/internal/uuid"
 
@@ -59,7 +61,6 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/TykTechnologies/tyk/apidef"
 	"github.com/TykTechnologies/tyk/ctx"
@@ -400,23 +401,32 @@ func (gw *Gateway) doAddOrUpdate(keyName string, newSession *user.SessionState,
 // need to be managed by API, but only for GetDetail, GetList, UpdateKey and DeleteKey
 
 func (gw *Gateway) setBasicAuthSessionPassword(session *user.SessionState) {
+	hashedPass, hashType, err := gw.hashBasicAuthPassword(session.BasicAuthData.Password)
+	if err != nil {
+		log.WithError(err).Error("Could not hash password, setting to plaintext")
+		session.BasicAuthData.Hash = user.HashPlainText
+		return
+	}
+
+	session.BasicAuthData.Password = hashedPass
+	session.BasicAuthData.Hash = hashType
+}
+
+// hashBasicAuthPassword hashes the plain password with the configured basic auth algorithm.
+func (gw *Gateway) hashBasicAuthPassword(password string) (string, user.HashType, error) {
 	basicAuthHashAlgo := gw.basicAuthHashAlgo()
 
-	if basicAuthHashAlgo == string(user.HashBCrypt) {
-		session.BasicAuthData.Hash = user.HashBCrypt
-		hashedPassBytes, err := bcrypt.GenerateFromPassword([]byte(session.BasicAuthData.Password), 10)
+	switch basicAuthHashAlgo {
+	case user.HashBCrypt, user.HashArgon2id, user.HashScrypt:
+		hashedPass, err := crypto.HashPassword(basicAuthHashAlgo, password, gw.basicAuthHashParams())
 		if err != nil {
-			log.WithError(err).Error("Could not hash password, setting to plaintext")
-			session.BasicAuthData.Hash = user.HashPlainText
-			return
+			return "", "", err
 		}
 
-		session.BasicAuthData.Password = string(hashedPassBytes)
-		return
+		return hashedPass, user.HashType(basicAuthHashAlgo), nil
 	}
 
-	session.BasicAuthData.Password = storage.HashStr(session.BasicAuthData.Password, basicAuthHashAlgo)
-	session.BasicAuthData.Hash = user.HashType(basicAuthHashAlgo)
+	return storage.HashStr(password, basicAuthHashAlgo), user.HashType(basicAuthHashAlgo), nil
 }
 
 func (gw *Gateway) basicAuthHashAlgo() string {
@@ -435,6 +445,21 @@ func (gw *Gateway) basicAuthHashAlgo() string {
 	return algo
 }
 
+// basicAuthHashParams returns the configured cost parameters for basic auth password hashing.
+func (gw *Gateway) basicAuthHashParams() crypto.PasswordHashParams {
+	opts := gw.GetConfig().BasicAuthHashOptions
+
+	return crypto.PasswordHashParams{
+		BCryptCost:        opts.BCryptCost,
+		Argon2Memory:      opts.Argon2Memory,
+		Argon2Iterations:  opts.Argon2Iterations,
+		Argon2Parallelism: opts.Argon2Parallelism,
+		ScryptN:           opts.ScryptN,
+		ScryptR:           opts.ScryptR,
+		ScryptP:           opts.ScryptP,
+	}.WithDefaults()
+}
+
 func (gw *Gateway) handleAddOrUpdate(keyName string, r *http.Request, isHashed bool) (interface{}, int) {
 	suppressReset := r.URL.Query().Get("suppress_reset") == "1"
 
@@ -2846,6 +2871,19 @@ func userRatesCheck(w http.ResponseWriter, r *http.Request) {
 func (gw *Gateway) invalidateCacheHandler(w http.ResponseWriter, r *http.Request) {
 	apiID := mux.Vars(r)["apiID"]
 
+	query := r.URL.Query()
+	inv := cacheInvalidation{
+		APIID:    apiID,
+		Tags:     query["tag"],
+		URLs:     query["url"],
+		Patterns: query["pattern"],
+	}
+
+	if len(inv.Tags) > 0 || len(inv.URLs) > 0 || len(inv.Patterns) > 0 {
+		gw.invalidateCacheEntriesHandler(w, r, inv)
+		return
+	}
+
 	if ok := gw.invalidateAPICache(apiID); !ok {
 		err := errors.New("scan/delete failed")
 		var orgid string
@@ -2868,9 +2906,44 @@ func (gw *Gateway) invalidateCacheHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// flush the in-memory tier and the node-local cache stores of the other gateways in the cluster
+	gw.MainNotifier.Notify(Notification{
+		Command: NoticeFlushAPICache,
+		Payload: apiID,
+		Gw:      gw,
+	})
+
 	doJSONWrite(w, http.StatusOK, apiOk("cache invalidated"))
 }
 
+// invalidateCacheEntriesHandler purges the cache entries matching the tags and URLs and
+// notifies the other gateways in the cluster to evict them from their in-memory tier and node-local cache stores.
+func (gw *Gateway) invalidateCacheEntriesHandler(w http.ResponseWriter, r *http.Request, inv cacheInvalidation) {
+	keys, err := gw.invalidateCacheEntries(inv)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"prefix":  "api",
+			"api_id":  inv.APIID,
+			"status":  "fail",
+			"err":     err,
+			"user_ip": requestIPHops(r),
+		}).Error("Failed to delete cache entries: ", err)
+
+		doJSONWrite(w, http.StatusInternalServerError, apiError("Cache invalidation failed"))
+		return
+	}
+
+	inv.Keys = keys
+	payload, _ := json.Marshal(inv)
+	gw.MainNotifier.Notify(Notification{
+		Command: NoticeInvalidateAPICache,
+		Payload: string(payload),
+		Gw:      gw,
+	})
+
+	doJSONWrite(w, http.StatusOK, apiOk(fmt.Sprintf("%d cache entries invalidated", len(keys))))
+}
+
 func (gw *Gateway) RevokeTokenHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 
@@ -3072,6 +3145,46 @@ func ctxGetCacheOptions(r *http.Request) *cacheOptions {
 	return key
 }
 
+func ctxSetResponseValidationFailed(r *http.Request) {
+	setCtxValue(r, ctx.ResponseValidationFailed, true)
+}
+
+// ctxResponseValidationFailed returns true if the upstream response of the request failed validation.
+func ctxResponseValidationFailed(r *http.Request) bool {
+	failed, _ := r.Context().Value(ctx.ResponseValidationFailed).(bool)
+	return failed
+}
+
+func ctxSetRedactionRules(r *http.Request, rules []*redaction.Rule) {
+	setCtxValue(r, ctx.RedactionRules, rules)
+}
+
+func ctxSetUpstreamTarget(r *http.Request, target *url.URL) {
+	setCtxValue(r, ctx.UpstreamTarget, target)
+}
+
+// ctxGetUpstreamTarget returns the upstream the request was routed to, or nil to use the upstream of the API.
+func ctxGetUpstreamTarget(r *http.Request) *url.URL {
+	target, _ := r.Context().Value(ctx.UpstreamTarget).(*url.URL)
+	return target
+}
+
+func ctxSetRoutedVersion(r *http.Request, name string) {
+	setCtxValue(r, ctx.RoutedVersion, name)
+}
+
+// ctxGetRoutedVersion returns the version the request was routed to, or an empty string if it wasn't.
+func ctxGetRoutedVersion(r *http.Request) string {
+	name, _ := r.Context().Value(ctx.RoutedVersion).(string)
+	return name
+}
+
+// ctxGetRedactionRules returns the redaction rules resolved for the request, and false if they weren't resolved yet.
+func ctxGetRedactionRules(r *http.Request) ([]*redaction.Rule, bool) {
+	rules, ok := r.Context().Value(ctx.RedactionRules).([]*redaction.Rule)
+	return rules, ok
+}
+
 func ctxGetSession(r *http.Request) *user.SessionState {
 	return ctx.GetSession(r)
 }
@@ -3254,6 +3367,15 @@ func ctxGetGraphQLIsWebSocketUpgrade(r *http.Request) (isWebSocketUpgrade bool)
 	return false
 }
 
+func ctxSetGraphQLPassthrough(r *http.Request, passthrough bool) {
+	setCtxValue(r, ctx.GraphQLPassthrough, passthrough)
+}
+
+func ctxGetGraphQLPassthrough(r *http.Request) bool {
+	passthrough, _ := r.Context().Value(ctx.GraphQLPassthrough).(bool)
+	return passthrough
+}
+
 func ctxGetDefaultVersion(r *http.Request) bool {
 	return r.Context().Value(ctx.VersionDefault) != nil
 }
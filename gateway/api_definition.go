@@ -25,6 +25,8 @@ import (
 
 	"github.com/TykTechnologies/tyk/internal/graphengine"
 	"github.com/TykTechnologies/tyk/internal/httputil"
+	"github.com/TykTechnologies/tyk/internal/redaction"
+	"github.com/TykTechnologies/tyk/internal/routing"
 
 	"github.com/getkin/kin-openapi/routers/gorillamux"
 
@@ -92,6 +94,9 @@ const (
 	GoPlugin
 	PersistGraphQL
 	RateLimit
+	Redacted
+	Routed
+	ContentPolicyEnforced
 )
 
 // RequestStatus is a custom type to avoid collisions
@@ -129,6 +134,9 @@ const (
 	StatusGoPlugin                 RequestStatus = "Go plugin"
 	StatusPersistGraphQL           RequestStatus = "Persist GraphQL"
 	StatusRateLimit                RequestStatus = "Rate Limited"
+	StatusRedacted                 RequestStatus = "Redacted"
+	StatusRouted                   RequestStatus = "Routed"
+	StatusContentPolicy            RequestStatus = "Content policy enforced"
 )
 
 // URLSpec represents a flattened specification for URLs, used to check if a proxy URL
@@ -155,6 +163,7 @@ type URLSpec struct {
 	URLRewrite                *apidef.URLRewriteMeta
 	VirtualPathSpec           apidef.VirtualMeta
 	RequestSize               apidef.RequestSizeMeta
+	ContentPolicy             apidef.ContentPolicyMeta
 	MethodTransform           apidef.MethodTransformMeta
 	TrackEndpoint             apidef.TrackEndpointMeta
 	DoNotTrackEndpoint        apidef.TrackEndpointMeta
@@ -163,6 +172,8 @@ type URLSpec struct {
 	GoPluginMeta              GoPluginMiddleware
 	PersistGraphQL            apidef.PersistGraphQLMeta
 	RateLimit                 apidef.RateLimitMeta
+	Redaction                 RedactionSpec
+	Routing                   RoutingSpec
 
 	IgnoreCase bool
 }
@@ -172,6 +183,8 @@ type EndPointCacheMeta struct {
 	CacheKeyRegex          string
 	CacheOnlyResponseCodes []int
 	Timeout                int64
+	StaleWhileRevalidate   int64
+	StaleIfError           int64
 }
 
 type TransformSpec struct {
@@ -179,6 +192,18 @@ type TransformSpec struct {
 	Template *texttemplate.Template
 }
 
+// RedactionSpec holds the compiled redaction rules of an endpoint.
+type RedactionSpec struct {
+	apidef.RedactionMeta
+	rules []*redaction.Rule
+}
+
+// RoutingSpec holds the compiled routing rules of an endpoint.
+type RoutingSpec struct {
+	apidef.RoutingMeta
+	rules []*routing.Rule
+}
+
 type ExtendedCircuitBreakerMeta struct {
 	apidef.CircuitBreakerMeta
 	CB *circuit.Breaker `json:"-"`
@@ -224,9 +249,15 @@ type APISpec struct {
 
 	GraphEngine graphengine.Engine
 
-	HasMock            bool
-	HasValidateRequest bool
-	OASRouter          routers.Router
+	HasMock             bool
+	HasValidateRequest  bool
+	HasValidateResponse bool
+	OASRouter           routers.Router
+
+	// redactionRules are the compiled API level redaction rules.
+	redactionRules []*redaction.Rule
+	// routingRules are the compiled API level routing rules.
+	routingRules []*routing.Rule
 }
 
 // GetSessionLifetimeRespectsKeyExpiration returns a boolean to tell whether session lifetime should respect to key expiration or not.
@@ -239,6 +270,11 @@ func (a *APISpec) GetSessionLifetimeRespectsKeyExpiration() bool {
 	return a.SessionLifetimeRespectsKeyExpiration
 }
 
+// graphQLCacheEnabled returns true when the responses of the GraphQL API are cached by operation.
+func (a *APISpec) graphQLCacheEnabled() bool {
+	return a.GraphQL.Enabled && a.GraphQL.Cache.Enabled
+}
+
 // AddUnloadHook adds a function to be called when the API spec is unloaded
 func (s *APISpec) AddUnloadHook(hook func()) {
 	s.unloadHooks = append(s.unloadHooks, hook)
@@ -267,7 +303,7 @@ func (s *APISpec) Unload() {
 	// release all other resources associated with spec
 
 	// JSVM object is a circular dependecy hell, but we can check if it initialized like this
-	if s.JSVM.VM != nil {
+	if s.JSVM.initialized() {
 		s.JSVM.DeInit()
 	}
 
@@ -451,6 +487,15 @@ func (a APIDefinitionLoader) MakeSpec(def *model.MergedAPI, logger *logrus.Entry
 	}
 
 	spec.setHasMock()
+	spec.setHasValidateResponse()
+
+	if spec.Redaction.Enabled {
+		spec.redactionRules = compileRedactionRules(spec.Redaction.Rules)
+	}
+
+	if spec.Routing.Enabled {
+		spec.routingRules = compileRoutingRules(spec.Routing.Rules)
+	}
 
 	return spec, nil
 }
@@ -896,6 +941,8 @@ func (a APIDefinitionLoader) compileCachedPathSpec(oldpaths []string, newpaths [
 		newSpec.CacheConfig.CacheKeyRegex = spec.CacheKeyRegex
 		newSpec.CacheConfig.CacheOnlyResponseCodes = spec.CacheOnlyResponseCodes
 		newSpec.CacheConfig.Timeout = spec.Timeout
+		newSpec.CacheConfig.StaleWhileRevalidate = spec.StaleWhileRevalidate
+		newSpec.CacheConfig.StaleIfError = spec.StaleIfError
 		// Extend with method actions
 		urlSpec = append(urlSpec, newSpec)
 	}
@@ -947,11 +994,13 @@ func (a APIDefinitionLoader) compileTransformPathSpec(paths []apidef.TemplateMet
 		// Load the templates
 		var err error
 
-		switch stringSpec.TemplateData.Mode {
-		case apidef.UseFile:
+		switch {
+		case isBodyConversion(stringSpec.TemplateData):
+			log.Debug("-- Conversion mode")
+		case stringSpec.TemplateData.Mode == apidef.UseFile:
 			log.Debug("-- Using File mode")
 			newTransformSpec.Template, err = a.loadFileTemplate(stringSpec.TemplateData.TemplateSource)
-		case apidef.UseBlob:
+		case stringSpec.TemplateData.Mode == apidef.UseBlob:
 			log.Debug("-- Blob mode")
 			newTransformSpec.Template, err = a.loadBlobTemplate(stringSpec.TemplateData.TemplateSource)
 		default:
@@ -1064,6 +1113,72 @@ func (a APIDefinitionLoader) compileRequestSizePathSpec(paths []apidef.RequestSi
 	return urlSpec
 }
 
+func (a APIDefinitionLoader) compileContentPolicyPathSpec(paths []apidef.ContentPolicyMeta, stat URLStatus, conf config.Config) []URLSpec {
+	// transform an extended configuration URL into an array of URLSpecs
+	// This way we can iterate the whole array once, on match we break with status
+	urlSpec := []URLSpec{}
+
+	for _, stringSpec := range paths {
+		if stringSpec.Disabled {
+			continue
+		}
+
+		newSpec := URLSpec{}
+		a.generateRegex(stringSpec.Path, &newSpec, stat, conf)
+		newSpec.ContentPolicy = stringSpec
+
+		urlSpec = append(urlSpec, newSpec)
+	}
+
+	return urlSpec
+}
+
+func (a APIDefinitionLoader) compileRedactionPathSpec(paths []apidef.RedactionMeta, stat URLStatus, conf config.Config) []URLSpec {
+	// transform an extended configuration URL into an array of URLSpecs
+	// This way we can iterate the whole array once, on match we break with status
+	urlSpec := []URLSpec{}
+
+	for _, stringSpec := range paths {
+		if stringSpec.Disabled {
+			continue
+		}
+
+		newSpec := URLSpec{}
+		a.generateRegex(stringSpec.Path, &newSpec, stat, conf)
+		newSpec.Redaction = RedactionSpec{
+			RedactionMeta: stringSpec,
+			rules:         compileRedactionRules(stringSpec.Rules),
+		}
+
+		urlSpec = append(urlSpec, newSpec)
+	}
+
+	return urlSpec
+}
+
+func (a APIDefinitionLoader) compileRoutingPathSpec(paths []apidef.RoutingMeta, stat URLStatus, conf config.Config) []URLSpec {
+	// transform an extended configuration URL into an array of URLSpecs
+	// This way we can iterate the whole array once, on match we break with status
+	urlSpec := []URLSpec{}
+
+	for _, stringSpec := range paths {
+		if stringSpec.Disabled {
+			continue
+		}
+
+		newSpec := URLSpec{}
+		a.generateRegex(stringSpec.Path, &newSpec, stat, conf)
+		newSpec.Routing = RoutingSpec{
+			RoutingMeta: stringSpec,
+			rules:       compileRoutingRules(stringSpec.Rules),
+		}
+
+		urlSpec = append(urlSpec, newSpec)
+	}
+
+	return urlSpec
+}
+
 func (a APIDefinitionLoader) compileCircuitBreakerPathSpec(paths []apidef.CircuitBreakerMeta, stat URLStatus, apiSpec *APISpec, conf config.Config) []URLSpec {
 	// transform an extended configuration URL into an array of URLSpecs
 	// This way we can iterate the whole array once, on match we break with status
@@ -1370,6 +1485,9 @@ func (a APIDefinitionLoader) getExtendedPathSpecs(apiVersionDef apidef.VersionIn
 	goPlugins := a.compileGopluginPathsSpec(apiVersionDef.ExtendedPaths.GoPlugin, GoPlugin, apiSpec, conf)
 	persistGraphQL := a.compilePersistGraphQLPathSpec(apiVersionDef.ExtendedPaths.PersistGraphQL, PersistGraphQL, apiSpec, conf)
 	rateLimitPaths := a.compileRateLimitPathsSpec(apiVersionDef.ExtendedPaths.RateLimit, RateLimit, conf)
+	redactionPaths := a.compileRedactionPathSpec(apiVersionDef.ExtendedPaths.Redaction, Redacted, conf)
+	routingPaths := a.compileRoutingPathSpec(apiVersionDef.ExtendedPaths.Routing, Routed, conf)
+	contentPolicyPaths := a.compileContentPolicyPathSpec(apiVersionDef.ExtendedPaths.ContentPolicy, ContentPolicyEnforced, conf)
 
 	combinedPath := []URLSpec{}
 	combinedPath = append(combinedPath, mockResponsePaths...)
@@ -1396,6 +1514,9 @@ func (a APIDefinitionLoader) getExtendedPathSpecs(apiVersionDef apidef.VersionIn
 	combinedPath = append(combinedPath, validateJSON...)
 	combinedPath = append(combinedPath, internalPaths...)
 	combinedPath = append(combinedPath, rateLimitPaths...)
+	combinedPath = append(combinedPath, redactionPaths...)
+	combinedPath = append(combinedPath, routingPaths...)
+	combinedPath = append(combinedPath, contentPolicyPaths...)
 
 	return combinedPath, len(whiteListPaths) > 0
 }
@@ -1458,6 +1579,12 @@ func (a *APISpec) getURLStatus(stat URLStatus) RequestStatus {
 		return StatusPersistGraphQL
 	case RateLimit:
 		return StatusRateLimit
+	case Redacted:
+		return StatusRedacted
+	case Routed:
+		return StatusRouted
+	case ContentPolicyEnforced:
+		return StatusContentPolicy
 	default:
 		log.Error("URL Status was not one of Ignored, Blacklist or WhiteList! Blocking.")
 		return EndPointNotAllowed
@@ -1793,6 +1920,25 @@ func (a *APISpec) SanitizeProxyPaths(r *http.Request) {
 	log.Debug("Upstream path is: ", r.URL.Path)
 }
 
+func (a *APISpec) setHasValidateResponse() {
+	a.HasValidateResponse = false
+	if !a.IsOAS {
+		return
+	}
+
+	middleware := a.OAS.GetTykMiddleware()
+	if middleware == nil {
+		return
+	}
+
+	for _, operation := range middleware.Operations {
+		if operation.ValidateResponse != nil && operation.ValidateResponse.Enabled {
+			a.HasValidateResponse = true
+			return
+		}
+	}
+}
+
 func (a *APISpec) setHasMock() {
 	if !a.IsOAS {
 		a.HasMock = false
@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/TykTechnologies/tyk/apidef/adapter"
+)
+
+// apiOASGraphQLHandler generates a UDG GraphQL API from an OAS API, with a field and a REST data source targeting
+// the upstream of the API for each operation. The generated API definition is returned, it isn't created.
+func (gw *Gateway) apiOASGraphQLHandler(w http.ResponseWriter, r *http.Request) {
+	spec := gw.getApiSpec(mux.Vars(r)["apiID"])
+	if spec == nil || !spec.IsOAS {
+		doJSONWrite(w, http.StatusNotFound, apiError("OAS API not found"))
+		return
+	}
+
+	document := spec.OAS
+	input, err := json.Marshal(&document)
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal OAS API definition")
+		doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to marshal API definition"))
+		return
+	}
+
+	def, err := adapter.NewOpenAPIAdapterWithUpstream(spec.OrgID, input, spec.Proxy.TargetURL).Import()
+	if err != nil {
+		doJSONWrite(w, http.StatusBadRequest, apiError("Failed to generate GraphQL API: "+err.Error()))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, def)
+}
+
+// graphQLRESTHandler returns the definition of a GraphQL API with REST endpoints added for its queries and
+// mutations, as persisted operations. The API definition isn't updated.
+func (gw *Gateway) graphQLRESTHandler(w http.ResponseWriter, r *http.Request) {
+	spec := gw.getApiSpec(mux.Vars(r)["apiID"])
+	if spec == nil || !spec.GraphQL.Enabled {
+		doJSONWrite(w, http.StatusNotFound, apiError("GraphQL API not found"))
+		return
+	}
+
+	def, err := adapter.NewRESTAdapter(spec.APIDefinition).Import()
+	if err != nil {
+		doJSONWrite(w, http.StatusBadRequest, apiError("Failed to generate REST endpoints: "+err.Error()))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, def)
+}
@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/apidef/oas"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+const testOASForGraphQLGenerator = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "servers": [{"url": "http://gateway.example/pets-api/"}],
+  "paths": {
+    "/pets/{id}": {
+      "get": {
+        "operationId": "findPetById",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+        "responses": {
+          "200": {
+            "description": "pet",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func TestGraphQLGenerator(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	oasDoc, err := openapi3.NewLoader().LoadFromData([]byte(testOASForGraphQLGenerator))
+	require.NoError(t, err)
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = "oas-pets"
+		spec.OAS = oas.OAS{T: *oasDoc}
+		spec.IsOAS = true
+		spec.Proxy.ListenPath = "/pets-api/"
+		spec.Proxy.TargetURL = "http://upstream.example/v1"
+	}, func(spec *APISpec) {
+		spec.APIID = "graphql-hello"
+		spec.Proxy.ListenPath = "/graphql-hello/"
+		spec.GraphQL.Enabled = true
+		spec.GraphQL.ExecutionMode = apidef.GraphQLExecutionModeProxyOnly
+		spec.GraphQL.Version = apidef.GraphQLConfigVersion2
+		spec.GraphQL.Schema = gqlProxyUpstreamSchema
+	})
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodGet, Path: "/tyk/apis/oas/oas-pets/graphql", AdminAuth: true, Code: http.StatusOK,
+			BodyMatch: `"execution_mode":"executionEngine"`},
+		{Method: http.MethodGet, Path: "/tyk/apis/oas/oas-pets/graphql", AdminAuth: true, Code: http.StatusOK,
+			BodyMatch: `findPetById\(id: Int!\): Pet`},
+		{Method: http.MethodGet, Path: "/tyk/apis/oas/oas-pets/graphql", AdminAuth: true, Code: http.StatusOK,
+			BodyMatch: `http://upstream.example/v1/pets/{{.arguments.id}}`},
+		{Method: http.MethodGet, Path: "/tyk/apis/oas/graphql-hello/graphql", AdminAuth: true, Code: http.StatusNotFound},
+		{Method: http.MethodGet, Path: "/tyk/apis/graphql-hello/graphql/rest", AdminAuth: true, Code: http.StatusOK,
+			BodyMatch: `"path":"/hello/{name}","method":"GET","operation":"query \(\$name: String!\) { hello\(name: \$name\) }"`},
+		{Method: http.MethodGet, Path: "/tyk/apis/oas-pets/graphql/rest", AdminAuth: true, Code: http.StatusNotFound},
+	}...)
+}
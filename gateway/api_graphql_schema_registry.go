@@ -0,0 +1,299 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/afero"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/federation"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/schemaregistry"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+// graphQLSchemaPublish is the request publishing a schema version of a GraphQL API.
+type graphQLSchemaPublish struct {
+	Schema string `json:"schema"`
+	// AllowBreakingChanges accepts a schema with breaking changes from the latest version.
+	AllowBreakingChanges bool `json:"allow_breaking_changes"`
+	// DryRun checks the schema without publishing it.
+	DryRun bool `json:"dry_run"`
+}
+
+// graphQLSchemaPublished is the response of a published schema version.
+type graphQLSchemaPublished struct {
+	*schemaregistry.Version
+	// Supergraphs are the IDs of the supergraph APIs composed with the schema of a subgraph.
+	Supergraphs []string `json:"supergraphs,omitempty"`
+	// Applied is true when the API definitions were updated with the schema, they are applied on reload.
+	Applied bool `json:"applied"`
+}
+
+// graphQLSchemaRejected is the response of a schema which can't be published.
+type graphQLSchemaRejected struct {
+	Status  string                  `json:"status"`
+	Message string                  `json:"message"`
+	Changes []schemaregistry.Change `json:"changes,omitempty"`
+}
+
+// schemaPublishAttempts is the number of attempts to publish a schema while other versions are published.
+const schemaPublishAttempts = 5
+
+func (gw *Gateway) schemaRegistryStore(apiID string) *schemaregistry.Store {
+	handler := &storage.RedisCluster{KeyPrefix: schemaregistry.KeyPrefix, ConnectionHandler: gw.StorageConnectionHandler}
+	return schemaregistry.NewStore(handler, apiID)
+}
+
+// graphQLSchemasHandler lists and publishes the schema versions of a GraphQL API.
+func (gw *Gateway) graphQLSchemasHandler(w http.ResponseWriter, r *http.Request) {
+	spec := gw.getApiSpec(mux.Vars(r)["apiID"])
+	if spec == nil || !spec.GraphQL.Enabled {
+		doJSONWrite(w, http.StatusNotFound, apiError("GraphQL API not found"))
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		versions, err := gw.schemaRegistryStore(spec.APIID).Versions()
+		if err != nil {
+			doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to list schema versions"))
+			return
+		}
+		doJSONWrite(w, http.StatusOK, versions)
+		return
+	}
+
+	var req graphQLSchemaPublish
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		doJSONWrite(w, http.StatusBadRequest, apiError("Request malformed"))
+		return
+	}
+
+	obj, code := gw.publishGraphQLSchema(spec, req, 0)
+	doJSONWrite(w, code, obj)
+}
+
+// graphQLSchemaHandler returns a schema version of a GraphQL API.
+func (gw *Gateway) graphQLSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	version, code, msg := gw.graphQLSchemaVersion(r)
+	if version == nil {
+		doJSONWrite(w, code, apiError(msg))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, version)
+}
+
+// graphQLSchemaRollbackHandler publishes a previous schema version of a GraphQL API as its latest version. The
+// rollback is deliberate, so breaking changes are accepted, but the supergraphs must still compose.
+func (gw *Gateway) graphQLSchemaRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	version, code, msg := gw.graphQLSchemaVersion(r)
+	if version == nil {
+		doJSONWrite(w, code, apiError(msg))
+		return
+	}
+
+	spec := gw.getApiSpec(mux.Vars(r)["apiID"])
+	req := graphQLSchemaPublish{Schema: version.Schema, AllowBreakingChanges: true}
+	obj, code := gw.publishGraphQLSchema(spec, req, version.Version)
+	doJSONWrite(w, code, obj)
+}
+
+func (gw *Gateway) graphQLSchemaVersion(r *http.Request) (*schemaregistry.Version, int, string) {
+	vars := mux.Vars(r)
+	if spec := gw.getApiSpec(vars["apiID"]); spec == nil || !spec.GraphQL.Enabled {
+		return nil, http.StatusNotFound, "GraphQL API not found"
+	}
+
+	number, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		return nil, http.StatusBadRequest, "Invalid schema version"
+	}
+
+	version, err := gw.schemaRegistryStore(vars["apiID"]).Version(number)
+	switch {
+	case errors.Is(err, schemaregistry.ErrNotFound):
+		return nil, http.StatusNotFound, "Schema version not found"
+	case err != nil:
+		return nil, http.StatusInternalServerError, "Failed to get schema version"
+	}
+
+	return version, http.StatusOK, ""
+}
+
+// publishGraphQLSchema checks the schema against the latest version and the supergraphs composed with the API,
+// then stores it as the latest version. When the gateway loads its API definitions from files, the definitions of
+// the API and of its supergraphs are updated with the schema.
+func (gw *Gateway) publishGraphQLSchema(spec *APISpec, req graphQLSchemaPublish, rolledBackFrom int) (interface{}, int) {
+	store := gw.schemaRegistryStore(spec.APIID)
+
+	var (
+		published   graphQLSchemaPublished
+		supergraphs []*apidef.APIDefinition
+		def         *apidef.APIDefinition
+	)
+
+	// the schema is checked again against the new latest version when another one is published concurrently
+	for attempt := 1; ; attempt++ {
+		version, err := store.Next(req.Schema, graphQLSpecSchema(spec))
+		switch {
+		case errors.Is(err, schemaregistry.ErrInvalidSchema):
+			return apiError(err.Error()), http.StatusBadRequest
+		case err != nil:
+			log.WithError(err).Error("Failed to diff GraphQL schema")
+			return apiError("Failed to diff schema"), http.StatusInternalServerError
+		}
+		version.RolledBackFrom = rolledBackFrom
+
+		if !req.AllowBreakingChanges && schemaregistry.HasBreakingChanges(version.Changes) {
+			return graphQLSchemaRejected{
+				Status:  "error",
+				Message: "schema has breaking changes",
+				Changes: version.Changes,
+			}, http.StatusConflict
+		}
+
+		supergraphs, err = gw.composeSupergraphs(spec.APIID, req.Schema)
+		if err != nil {
+			return graphQLSchemaRejected{Status: "error", Message: err.Error()}, http.StatusConflict
+		}
+
+		published = graphQLSchemaPublished{Version: version}
+		for _, def := range supergraphs {
+			published.Supergraphs = append(published.Supergraphs, def.APIID)
+		}
+
+		if req.DryRun {
+			return published, http.StatusOK
+		}
+
+		// the definitions are updated when the gateway loads them from files, they're built before the version
+		// is saved so it isn't saved when they can't be
+		if def == nil && !gw.GetConfig().UseDBAppConfigs && !spec.IsOAS {
+			if def, err = graphQLDefinitionWithSchema(spec.APIDefinition, req.Schema); err != nil {
+				log.WithError(err).Error("Failed to build GraphQL schema")
+				return apiError("Failed to build schema"), http.StatusInternalServerError
+			}
+		}
+
+		err = store.Save(version)
+		if err == nil {
+			break
+		}
+
+		if errors.Is(err, schemaregistry.ErrVersionConflict) {
+			if attempt < schemaPublishAttempts {
+				continue
+			}
+			return apiError(err.Error()), http.StatusConflict
+		}
+
+		log.WithError(err).Error("Failed to save GraphQL schema")
+		return apiError("Failed to save schema"), http.StatusInternalServerError
+	}
+
+	if def == nil {
+		return published, http.StatusOK
+	}
+
+	fs := afero.NewOsFs()
+	for _, def := range append(supergraphs, def) {
+		if err, code := gw.writeToFile(fs, def, def.APIID); err != nil {
+			// the version isn't published when the definitions applying it can't be written
+			if err := store.Discard(published.Version); err != nil {
+				log.WithError(err).Error("Failed to discard GraphQL schema version")
+			}
+			return apiError(err.Error()), code
+		}
+	}
+	published.Applied = true
+
+	return published, http.StatusOK
+}
+
+// composeSupergraphs returns copies of the supergraph APIs composed with the subgraph API, with the schema of the
+// subgraph replaced by the given one, or an error when they can't be composed.
+func (gw *Gateway) composeSupergraphs(subgraphID, schema string) ([]*apidef.APIDefinition, error) {
+	gw.apisMu.RLock()
+	defer gw.apisMu.RUnlock()
+
+	var supergraphs []*apidef.APIDefinition
+	for _, spec := range gw.apisByID {
+		if !spec.GraphQL.Enabled || spec.GraphQL.ExecutionMode != apidef.GraphQLExecutionModeSupergraph {
+			continue
+		}
+
+		subgraphs := make([]apidef.GraphQLSubgraphEntity, len(spec.GraphQL.Supergraph.Subgraphs))
+		copy(subgraphs, spec.GraphQL.Supergraph.Subgraphs)
+
+		found := false
+		sdls := make([]string, 0, len(subgraphs))
+		for i := range subgraphs {
+			if subgraphs[i].APIID == subgraphID {
+				subgraphs[i].SDL = schema
+				found = true
+			}
+			sdls = append(sdls, subgraphs[i].SDL)
+		}
+
+		if !found {
+			continue
+		}
+
+		merged, err := schemaregistry.Compose(sdls...)
+		if err != nil {
+			return nil, fmt.Errorf("supergraph %s: %w", spec.APIID, err)
+		}
+
+		def := *spec.APIDefinition
+		now := time.Now().UTC()
+		def.GraphQL.Supergraph.Subgraphs = subgraphs
+		def.GraphQL.Supergraph.MergedSDL = merged
+		def.GraphQL.Supergraph.UpdatedAt = &now
+		def.GraphQL.Schema = merged
+		supergraphs = append(supergraphs, &def)
+	}
+
+	sort.Slice(supergraphs, func(i, j int) bool {
+		return supergraphs[i].APIID < supergraphs[j].APIID
+	})
+
+	return supergraphs, nil
+}
+
+// graphQLSpecSchema returns the schema of the API the registry keeps, the SDL of a subgraph.
+func graphQLSpecSchema(spec *APISpec) string {
+	if spec.GraphQL.ExecutionMode == apidef.GraphQLExecutionModeSubgraph {
+		return spec.GraphQL.Subgraph.SDL
+	}
+	return spec.GraphQL.Schema
+}
+
+// graphQLDefinitionWithSchema returns a copy of the API definition with the schema, the schema of a subgraph is
+// its SDL extended with the federation fields.
+func graphQLDefinitionWithSchema(def *apidef.APIDefinition, schema string) (*apidef.APIDefinition, error) {
+	updated := *def
+	if def.GraphQL.ExecutionMode != apidef.GraphQLExecutionModeSubgraph {
+		updated.GraphQL.Schema = schema
+		return &updated, nil
+	}
+
+	baseSchema, err := federation.BuildBaseSchemaDocument(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.GraphQL.Schema, err = federation.BuildFederationSchema(baseSchema, schema); err != nil {
+		return nil, err
+	}
+	updated.GraphQL.Subgraph.SDL = schema
+
+	return &updated, nil
+}
@@ -0,0 +1,142 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/schemaregistry"
+	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func TestGraphQLSchemaRegistry(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	registry := &storage.RedisCluster{KeyPrefix: schemaregistry.KeyPrefix, ConnectionHandler: ts.Gw.StorageConnectionHandler}
+	registry.DeleteScanMatch(schemaregistry.KeyPrefix + "*")
+
+	subgraph := func(apiID, schema, sdl string) func(spec *APISpec) {
+		return func(spec *APISpec) {
+			spec.APIID = apiID
+			spec.Proxy.ListenPath = "/" + apiID
+			spec.GraphQL = apidef.GraphQLConfig{
+				Enabled:       true,
+				ExecutionMode: apidef.GraphQLExecutionModeSubgraph,
+				Version:       apidef.GraphQLConfigVersion2,
+				Schema:        schema,
+				Subgraph:      apidef.GraphQLSubgraphConfig{SDL: sdl},
+			}
+		}
+	}
+
+	ts.Gw.BuildAndLoadAPI(
+		subgraph("accounts", gqlSubgraphSchemaAccounts, gqlSubgraphSDLAccounts),
+		subgraph("bank-accounts", gqlSubgraphSchemaBankAccounts, gqlSubgraphSDLBankAccounts),
+		func(spec *APISpec) {
+			spec.APIID = "supergraph"
+			spec.Proxy.ListenPath = "/supergraph"
+			spec.GraphQL = apidef.GraphQLConfig{
+				Enabled:       true,
+				Version:       apidef.GraphQLConfigVersion2,
+				ExecutionMode: apidef.GraphQLExecutionModeSupergraph,
+				Supergraph: apidef.GraphQLSupergraphConfig{
+					Subgraphs: []apidef.GraphQLSubgraphEntity{
+						{APIID: "accounts", URL: "tyk://accounts", SDL: gqlSubgraphSDLAccounts},
+						{APIID: "bank-accounts", URL: "tyk://bank-accounts", SDL: gqlSubgraphSDLBankAccounts},
+					},
+					MergedSDL: gqlMergedSupergraphSDL,
+				},
+				Schema: gqlMergedSupergraphSDL,
+			}
+		},
+	)
+
+	withEmail := strings.Replace(gqlSubgraphSDLAccounts, "username: String!", "username: String!\n\temail: String", 1)
+	withoutAllUsers := strings.Replace(withEmail, "allUsers: [User]", "", 1)
+	conflicting := withEmail + "\ntype BankAccount { id: ID! }"
+
+	publish := func(schema string, allowBreakingChanges bool) graphQLSchemaPublish {
+		return graphQLSchemaPublish{Schema: schema, AllowBreakingChanges: allowBreakingChanges}
+	}
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish("type Query {", false),
+			AdminAuth: true, Code: http.StatusBadRequest},
+		{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish(withoutAllUsers, false),
+			AdminAuth: true, Code: http.StatusConflict, BodyMatch: `"type":"FIELD_REMOVED","path":"Query.allUsers"`},
+		{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish(conflicting, true),
+			AdminAuth: true, Code: http.StatusConflict, BodyMatch: `supergraph composition failed`},
+		{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: graphQLSchemaPublish{Schema: withEmail, DryRun: true},
+			AdminAuth: true, Code: http.StatusOK, BodyMatch: `"applied":false`},
+		{Method: http.MethodGet, Path: "/tyk/apis/accounts/graphql/schemas", AdminAuth: true, Code: http.StatusOK, BodyMatch: `^\[\]`},
+		{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish(withEmail, false),
+			AdminAuth: true, Code: http.StatusOK, BodyMatch: `"version":1,.*"type":"FIELD_ADDED","path":"User.email".*"supergraphs":\["supergraph"\],"applied":true`},
+		{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish(withoutAllUsers, true),
+			AdminAuth: true, Code: http.StatusOK, BodyMatch: `"version":2,`},
+		{Method: http.MethodGet, Path: "/tyk/apis/accounts/graphql/schemas/3", AdminAuth: true, Code: http.StatusNotFound},
+		{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas/1/rollback", AdminAuth: true, Code: http.StatusOK,
+			BodyMatch: `"version":3,.*"type":"FIELD_ADDED","path":"Query.allUsers".*"rolled_back_from":1`},
+		{Method: http.MethodGet, Path: "/tyk/apis/accounts/graphql/schemas", AdminAuth: true, Code: http.StatusOK,
+			BodyMatch: `"version":3`},
+	}...)
+
+	t.Run("versions are claimed once", func(t *testing.T) {
+		require.NoError(t, registry.SetKey("accounts.version.4", "{}", 0))
+		_, _ = ts.Run(t, test.TestCase{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish(withEmail, true),
+			AdminAuth: true, Code: http.StatusConflict, BodyMatch: schemaregistry.ErrVersionConflict.Error()})
+
+		registry.DeleteKey("accounts.version.4")
+		_, _ = ts.Run(t, test.TestCase{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish(withEmail, true),
+			AdminAuth: true, Code: http.StatusOK, BodyMatch: `"version":4,`})
+	})
+
+	t.Run("version is discarded when the definitions can't be written", func(t *testing.T) {
+		conf := ts.Gw.GetConfig()
+		appPath := conf.AppPath
+		conf.AppPath = filepath.Join(t.TempDir(), "missing")
+		ts.Gw.SetConfig(conf)
+		defer func() {
+			conf.AppPath = appPath
+			ts.Gw.SetConfig(conf)
+		}()
+
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Path: "/tyk/apis/accounts/graphql/schemas", Data: publish(withoutAllUsers, true),
+				AdminAuth: true, Code: http.StatusInternalServerError},
+			{Method: http.MethodGet, Path: "/tyk/apis/accounts/graphql/schemas/5", AdminAuth: true, Code: http.StatusNotFound},
+		}...)
+
+		latest, err := ts.Gw.schemaRegistryStore("accounts").Latest()
+		require.NoError(t, err)
+		assert.Equal(t, 4, latest.Version)
+	})
+
+	t.Run("definitions are updated", func(t *testing.T) {
+		read := func(apiID string) apidef.APIDefinition {
+			data, err := os.ReadFile(filepath.Join(ts.Gw.GetConfig().AppPath, apiID+".json"))
+			require.NoError(t, err)
+
+			var def apidef.APIDefinition
+			require.NoError(t, json.Unmarshal(data, &def))
+			return def
+		}
+
+		accounts := read("accounts")
+		assert.Equal(t, withEmail, accounts.GraphQL.Subgraph.SDL)
+		assert.Contains(t, accounts.GraphQL.Schema, "_entities")
+
+		supergraph := read("supergraph")
+		assert.Equal(t, withEmail, supergraph.GraphQL.Supergraph.Subgraphs[0].SDL)
+		assert.Contains(t, supergraph.GraphQL.Supergraph.MergedSDL, "email: String")
+		assert.Equal(t, supergraph.GraphQL.Supergraph.MergedSDL, supergraph.GraphQL.Schema)
+	})
+}
@@ -136,6 +136,10 @@ func (gw *Gateway) generateSubRoutes(spec *APISpec, router *mux.Router, logger *
 
 		router.Use(c.Handler)
 	}
+
+	if spec.Compression.Enabled {
+		router.Use(newCompressor(spec.Compression).handler)
+	}
 }
 
 func (gw *Gateway) processSpec(spec *APISpec, apisByListen map[string]int,
@@ -244,8 +248,8 @@ func (gw *Gateway) processSpec(spec *APISpec, apisByListen map[string]int,
 		spec.JSVM.LoadJSPaths(mwPaths, prefix)
 	}
 
-	//  if bundle was used - fix paths for goplugin-type custom middle-wares
-	if mwDriver == apidef.GoPluginDriver && prefix != "" {
+	//  if bundle was used - fix paths for goplugin-type and wasm-type custom middle-wares
+	if (mwDriver == apidef.GoPluginDriver || mwDriver == apidef.WasmDriver) && prefix != "" {
 		mwAuthCheckFunc.Path = filepath.Join(prefix, mwAuthCheckFunc.Path)
 		fixFuncPath(prefix, mwPreFuncs)
 		fixFuncPath(prefix, mwPostFuncs)
@@ -253,6 +257,10 @@ func (gw *Gateway) processSpec(spec *APISpec, apisByListen map[string]int,
 		fixFuncPath(prefix, mwResponseFuncs)
 	}
 
+	if mwDriver == apidef.WasmDriver && gw.GetConfig().CoProcessOptions.EnableCoProcess {
+		gw.loadWasmPlugins(spec, mwAuthCheckFunc, mwPreFuncs, mwPostAuthCheckFuncs, mwPostFuncs, mwResponseFuncs)
+	}
+
 	enableVersionOverrides := false
 	for _, versionData := range spec.VersionData.Versions {
 		if versionData.OverrideTarget != "" && !spec.VersionData.NotVersioned {
@@ -326,6 +334,7 @@ func (gw *Gateway) processSpec(spec *APISpec, apisByListen map[string]int,
 	gw.mwAppendEnabled(&chainArray, &CertificateCheckMW{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &OrganizationMonitor{BaseMiddleware: baseMid, mon: Monitor{Gw: gw}})
 	gw.mwAppendEnabled(&chainArray, &RequestSizeLimitMiddleware{baseMid})
+	gw.mwAppendEnabled(&chainArray, &ContentPolicyMiddleware{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &MiddlewareContextVars{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &TrackEndpointMiddleware{baseMid})
 
@@ -339,10 +348,14 @@ func (gw *Gateway) processSpec(spec *APISpec, apisByListen map[string]int,
 			logger.Info("Checking security policy: External OAuth")
 		}
 
-		if gw.mwAppendEnabled(&authArray, &BasicAuthKeyIsValid{baseMid, nil, nil}) {
+		if gw.mwAppendEnabled(&authArray, &BasicAuthKeyIsValid{BaseMiddleware: baseMid}) {
 			logger.Info("Checking security policy: Basic")
 		}
 
+		if gw.mwAppendEnabled(&authArray, &CertificateIdentityMW{BaseMiddleware: baseMid}) {
+			logger.Info("Checking security policy: Client Certificate")
+		}
+
 		if gw.mwAppendEnabled(&authArray, &HTTPSignatureValidationMiddleware{BaseMiddleware: baseMid}) {
 			logger.Info("Checking security policy: HMAC")
 		}
@@ -425,6 +438,7 @@ func (gw *Gateway) processSpec(spec *APISpec, apisByListen map[string]int,
 	}
 
 	gw.mwAppendEnabled(&chainArray, &RateLimitForAPI{BaseMiddleware: baseMid})
+	gw.mwAppendEnabled(&chainArray, &GraphQLPersistedQueryMiddleware{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &GraphQLMiddleware{BaseMiddleware: baseMid})
 
 	if streamMw := getStreamingMiddleware(baseMid); streamMw != nil {
@@ -444,17 +458,21 @@ func (gw *Gateway) processSpec(spec *APISpec, apisByListen map[string]int,
 		gw.mwAppendEnabled(&chainArray, upstreamOAuthMw)
 	}
 
+	gw.mwAppendEnabled(&chainArray, &RequestDecompressionMiddleware{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &ValidateJSON{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &ValidateRequest{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &PersistGraphQLOperationMiddleware{BaseMiddleware: baseMid})
+	gw.mwAppendEnabled(&chainArray, &RedactionMiddleware{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &TransformMiddleware{baseMid})
 	gw.mwAppendEnabled(&chainArray, &TransformJQMiddleware{baseMid})
 	gw.mwAppendEnabled(&chainArray, &TransformHeaders{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &URLRewriteMiddleware{BaseMiddleware: baseMid})
+	gw.mwAppendEnabled(&chainArray, &RoutingMiddleware{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &TransformMethod{BaseMiddleware: baseMid})
 
 	// Earliest we can respond with cache get 200 ok
-	gw.mwAppendEnabled(&chainArray, &RedisCacheMiddleware{BaseMiddleware: baseMid, store: &cacheStore})
+	cacheEntries, cacheBackend := gw.responseCacheStore(spec, &cacheStore)
+	gw.mwAppendEnabled(&chainArray, &RedisCacheMiddleware{BaseMiddleware: baseMid, store: &cacheStore, entries: cacheEntries, backend: cacheBackend})
 
 	gw.mwAppendEnabled(&chainArray, &VirtualEndpoint{BaseMiddleware: baseMid})
 	gw.mwAppendEnabled(&chainArray, &RequestSigning{BaseMiddleware: baseMid})
@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/TykTechnologies/tyk/internal/webhook"
+)
+
+// webhookOutboxHandler lists the deliveries waiting for an attempt or a retry.
+func (gw *Gateway) webhookOutboxHandler(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := gw.webhookDeliveries().store.Pending()
+	if err != nil {
+		doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to read the webhook outbox"))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, webhookDeliveriesResponse(deliveries, r.URL.Query().Get("api_id")))
+}
+
+// webhookDeadLettersHandler lists the deliveries whose retries are exhausted.
+func (gw *Gateway) webhookDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := gw.webhookDeliveries().store.DeadLetters()
+	if err != nil {
+		doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to read the webhook dead-letter queue"))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, webhookDeliveriesResponse(deliveries, r.URL.Query().Get("api_id")))
+}
+
+// webhookReplayHandler moves a dead-lettered delivery back to the outbox.
+func (gw *Gateway) webhookReplayHandler(w http.ResponseWriter, r *http.Request) {
+	delivery, err := gw.webhookDeliveries().Replay(mux.Vars(r)["id"])
+	if errors.Is(err, webhook.ErrNotFound) {
+		doJSONWrite(w, http.StatusNotFound, apiError("Dead-lettered webhook not found"))
+		return
+	}
+	if err != nil {
+		doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to replay the webhook"))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, delivery)
+}
+
+// webhookDiscardHandler deletes a dead-lettered delivery.
+func (gw *Gateway) webhookDiscardHandler(w http.ResponseWriter, r *http.Request) {
+	err := gw.webhookDeliveries().store.Discard(mux.Vars(r)["id"])
+	if errors.Is(err, webhook.ErrNotFound) {
+		doJSONWrite(w, http.StatusNotFound, apiError("Dead-lettered webhook not found"))
+		return
+	}
+	if err != nil {
+		doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to delete the webhook"))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, apiOk("deleted"))
+}
+
+// webhookDeliveriesResponse returns the deliveries of the API, or all of them when apiID is empty.
+func webhookDeliveriesResponse(deliveries []*webhook.Delivery, apiID string) []*webhook.Delivery {
+	filtered := make([]*webhook.Delivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		if apiID != "" && delivery.APIID != apiID {
+			continue
+		}
+
+		filtered = append(filtered, delivery)
+	}
+
+	return filtered
+}
@@ -0,0 +1,438 @@
+package gateway
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+)
+
+// Content codings supported by the gateway.
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+	encodingBrotli  = "br"
+	encodingZstd    = "zstd"
+)
+
+const defaultCompressionMinSize = 1024
+
+var (
+	defaultCompressionAlgorithms = []string{encodingBrotli, encodingZstd, encodingGzip}
+
+	defaultCompressionContentTypes = []string{
+		"text/*",
+		"application/json",
+		"application/*+json",
+		"application/xml",
+		"application/*+xml",
+		"application/javascript",
+		"application/x-javascript",
+		"application/graphql-response+json",
+		"image/svg+xml",
+	}
+
+	errCompressionStarted = errors.New("can't hijack a compressed response")
+)
+
+// compressor compresses the responses of an API.
+type compressor struct {
+	algorithms   []string
+	minSize      int64
+	contentTypes []string
+	pools        map[string]*sync.Pool
+}
+
+func newCompressor(conf apidef.CompressionConfig) *compressor {
+	c := &compressor{
+		algorithms:   defaultCompressionAlgorithms,
+		minSize:      conf.MinSize,
+		contentTypes: defaultCompressionContentTypes,
+		pools:        map[string]*sync.Pool{},
+	}
+
+	if len(conf.Algorithms) > 0 {
+		c.algorithms = nil
+		for _, algorithm := range conf.Algorithms {
+			if newEncoder(algorithm, conf.Level) == nil {
+				log.WithField("algorithm", algorithm).Warning("Unsupported compression algorithm")
+				continue
+			}
+			c.algorithms = append(c.algorithms, algorithm)
+		}
+	}
+
+	if c.minSize <= 0 {
+		c.minSize = defaultCompressionMinSize
+	}
+
+	if len(conf.ContentTypes) > 0 {
+		c.contentTypes = conf.ContentTypes
+	}
+
+	for _, algorithm := range c.algorithms {
+		algorithm, level := algorithm, conf.Level
+		c.pools[algorithm] = &sync.Pool{New: func() interface{} {
+			return newEncoder(algorithm, level)
+		}}
+	}
+
+	return c
+}
+
+// encoder is a compressing writer which can be reset to write to another writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type zstdEncoder struct {
+	*zstd.Encoder
+}
+
+func (e zstdEncoder) Reset(w io.Writer) {
+	e.Encoder.Reset(w)
+}
+
+// newEncoder creates an encoder of the algorithm with a compression level from 1 to 9,
+// or the default level of the algorithm if the level is 0.
+func newEncoder(algorithm string, level int) encoder {
+	if level > 9 {
+		level = 9
+	}
+
+	switch algorithm {
+	case encodingGzip:
+		if level <= 0 {
+			level = gzip.DefaultCompression
+		}
+		w, _ := gzip.NewWriterLevel(io.Discard, level)
+		return w
+	case encodingBrotli:
+		if level <= 0 {
+			level = 5
+		}
+		return brotli.NewWriterLevel(io.Discard, (level*brotli.BestCompression+8)/9)
+	case encodingZstd:
+		zstdLevel := zstd.SpeedDefault
+		switch {
+		case level <= 0:
+		case level <= 2:
+			zstdLevel = zstd.SpeedFastest
+		case level <= 5:
+			zstdLevel = zstd.SpeedDefault
+		case level <= 8:
+			zstdLevel = zstd.SpeedBetterCompression
+		default:
+			zstdLevel = zstd.SpeedBestCompression
+		}
+		w, err := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstdLevel), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil
+		}
+		return zstdEncoder{w}
+	}
+
+	return nil
+}
+
+// negotiate returns the algorithm the response is compressed with, the one with the highest weight in the
+// Accept-Encoding request header. Ties are broken by the order of the algorithms.
+func (c *compressor) negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	weights := map[string]float64{}
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		weight := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				weight = q
+			}
+		}
+		weights[name] = weight
+	}
+
+	var (
+		best       string
+		bestWeight float64
+	)
+
+	for _, algorithm := range c.algorithms {
+		weight, ok := weights[algorithm]
+		if !ok {
+			weight = weights["*"]
+		}
+
+		if weight > bestWeight {
+			best, bestWeight = algorithm, weight
+		}
+	}
+
+	return best
+}
+
+// compressible checks if the response with the headers is eligible to be compressed.
+func (c *compressor) compressible(status int, h http.Header) bool {
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+
+	if h.Get(header.ContentEncoding) != "" || parseCacheControl(h.Get(header.CacheControl)).has("no-transform") {
+		return false
+	}
+
+	if length, err := strconv.ParseInt(h.Get(header.ContentLength), 10, 64); err == nil && length < c.minSize {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(h.Get(header.ContentType))
+	if err != nil {
+		return false
+	}
+
+	for _, contentType := range c.contentTypes {
+		if matchMediaType(contentType, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchMediaType matches the media type with a pattern, where `*` matches any sequence of characters.
+func matchMediaType(pattern, mediaType string) bool {
+	pattern = strings.ToLower(pattern)
+
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == mediaType
+	}
+
+	return len(mediaType) >= len(prefix)+len(suffix) && strings.HasPrefix(mediaType, prefix) && strings.HasSuffix(mediaType, suffix)
+}
+
+// handler compresses the responses written by the next handler.
+func (c *compressor) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// upgraded connections are hijacked and can't be compressed
+		if r.Method == http.MethodHead || len(c.algorithms) == 0 || r.Header.Get(header.Upgrade) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			compressor:     c,
+			encoding:       c.negotiate(r.Header.Get(header.AcceptEncoding)),
+		}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressResponseWriter compresses the response body with the negotiated encoding. Responses of unknown
+// length are buffered up to the minimum size before compressing them, unless they're flushed first.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compressor *compressor
+	encoding   string
+
+	status      int
+	pending     bool // the response is eligible, the headers are held until its size is known
+	wroteHeader bool
+	buf         []byte
+	encoder     encoder
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader || w.pending {
+		return
+	}
+
+	// informational responses are sent as they are
+	if status >= 100 && status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.status = status
+	h := w.Header()
+
+	if !w.compressor.compressible(status, h) {
+		w.commit(false)
+		return
+	}
+
+	h.Add(header.Vary, header.AcceptEncoding)
+	if w.encoding == "" {
+		w.commit(false)
+		return
+	}
+
+	if h.Get(header.ContentLength) != "" {
+		w.commit(true)
+		return
+	}
+
+	w.pending = true
+}
+
+// commit writes the response headers, compressing the body from now on if compress is set.
+func (w *compressResponseWriter) commit(compress bool) {
+	w.pending = false
+	w.wroteHeader = true
+
+	if compress {
+		h := w.Header()
+		h.Del(header.ContentLength)
+		h.Set(header.ContentEncoding, w.encoding)
+
+		// the compressed representation is not byte for byte identical
+		if etag := h.Get(header.ETag); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set(header.ETag, "W/"+etag)
+		}
+
+		w.encoder = w.compressor.pools[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader && !w.pending {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.pending {
+		w.buf = append(w.buf, p...)
+		if int64(len(w.buf)) < w.compressor.minSize {
+			return len(p), nil
+		}
+
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+
+	return w.ResponseWriter.Write(p)
+}
+
+// start compresses the response and writes the buffered body.
+func (w *compressResponseWriter) start() error {
+	w.commit(true)
+
+	buf := w.buf
+	w.buf = nil
+
+	_, err := w.encoder.Write(buf)
+	return err
+}
+
+// Flush writes the compressed data written so far. Responses of unknown length are compressed once flushed,
+// as they are streamed.
+func (w *compressResponseWriter) Flush() {
+	if w.pending {
+		if err := w.start(); err != nil {
+			return
+		}
+	}
+
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			return
+		}
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.encoder != nil || w.pending {
+		return nil, nil, errCompressionStarted
+	}
+
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	w.wroteHeader = true
+	return hijacker.Hijack()
+}
+
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close completes the response. A buffered response smaller than the minimum size is written uncompressed.
+func (w *compressResponseWriter) close() {
+	if w.pending {
+		w.commit(false)
+		if len(w.buf) > 0 {
+			_, _ = w.ResponseWriter.Write(w.buf)
+		}
+		w.buf = nil
+	}
+
+	if w.encoder == nil {
+		return
+	}
+
+	if err := w.encoder.Close(); err != nil {
+		log.WithError(err).Debug("Couldn't complete the compressed response")
+	}
+
+	w.encoder.Reset(io.Discard)
+	w.compressor.pools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+}
+
+// decompressRequestBody returns a reader decompressing the request body with the content coding.
+func decompressRequestBody(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case encodingGzip, "x-gzip":
+		return gzip.NewReader(body)
+	case encodingDeflate:
+		return zlib.NewReader(body)
+	case encodingBrotli:
+		return io.NopCloser(brotli.NewReader(body)), nil
+	case encodingZstd:
+		decoder, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+
+	return nil, errUnsupportedEncoding
+}
+
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
@@ -0,0 +1,258 @@
+package gateway
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+
+	reader, err := decompressRequestBody(encoding, bytes.NewReader(body))
+	require.NoError(t, err)
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(decompressed)
+}
+
+func TestCompressor_Negotiate(t *testing.T) {
+	c := newCompressor(apidef.CompressionConfig{Enabled: true})
+
+	testCases := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", encodingGzip},
+		{"gzip, deflate, br", encodingBrotli},
+		{"gzip, zstd", encodingZstd},
+		{"br;q=0.5, gzip", encodingGzip},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", encodingBrotli},
+		{"*;q=0.1, gzip;q=0.2", encodingGzip},
+		{"GZIP", encodingGzip},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, c.negotiate(tc.acceptEncoding), tc.acceptEncoding)
+	}
+
+	c = newCompressor(apidef.CompressionConfig{Enabled: true, Algorithms: []string{"gzip", "unknown", "br"}})
+	assert.Equal(t, []string{encodingGzip, encodingBrotli}, c.algorithms)
+	assert.Equal(t, encodingGzip, c.negotiate("br, gzip"))
+}
+
+func TestMatchMediaType(t *testing.T) {
+	assert.True(t, matchMediaType("application/json", "application/json"))
+	assert.True(t, matchMediaType("text/*", "text/html"))
+	assert.True(t, matchMediaType("application/*+json", "application/problem+json"))
+	assert.True(t, matchMediaType("*", "image/png"))
+	assert.False(t, matchMediaType("application/json", "application/xml"))
+	assert.False(t, matchMediaType("application/*+json", "application/json"))
+}
+
+func TestCompressResponseWriter(t *testing.T) {
+	large := strings.Repeat(`{"key":"value"}`, 200)
+
+	serve := func(conf apidef.CompressionConfig, acceptEncoding string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		conf.Enabled = true
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if acceptEncoding != "" {
+			r.Header.Set(header.AcceptEncoding, acceptEncoding)
+		}
+
+		w := httptest.NewRecorder()
+		newCompressor(conf).handler(handler).ServeHTTP(w, r)
+		return w
+	}
+
+	jsonHandler := func(body string, headers map[string]string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(header.ContentType, "application/json")
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			_, _ = io.WriteString(w, body)
+		}
+	}
+
+	for _, encoding := range []string{encodingGzip, encodingBrotli, encodingZstd} {
+		t.Run("compress with "+encoding, func(t *testing.T) {
+			w := serve(apidef.CompressionConfig{Level: 9}, encoding, jsonHandler(large, map[string]string{header.ETag: `"v1"`}))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, encoding, w.Header().Get(header.ContentEncoding))
+			assert.Equal(t, header.AcceptEncoding, w.Header().Get(header.Vary))
+			assert.Equal(t, `W/"v1"`, w.Header().Get(header.ETag))
+			assert.Less(t, w.Body.Len(), len(large))
+			assert.Equal(t, large, decompress(t, encoding, w.Body.Bytes()))
+		})
+	}
+
+	t.Run("known length", func(t *testing.T) {
+		w := serve(apidef.CompressionConfig{}, encodingGzip, jsonHandler(large, map[string]string{header.ContentLength: "3000"}))
+		assert.Equal(t, encodingGzip, w.Header().Get(header.ContentEncoding))
+		assert.Empty(t, w.Header().Get(header.ContentLength))
+		assert.Equal(t, large, decompress(t, encodingGzip, w.Body.Bytes()))
+
+		w = serve(apidef.CompressionConfig{}, encodingGzip, jsonHandler("{}", map[string]string{header.ContentLength: "2"}))
+		assert.Empty(t, w.Header().Get(header.ContentEncoding))
+		assert.Empty(t, w.Header().Get(header.Vary))
+		assert.Equal(t, "{}", w.Body.String())
+	})
+
+	t.Run("unknown length below the minimum size", func(t *testing.T) {
+		w := serve(apidef.CompressionConfig{MinSize: 100}, encodingGzip, jsonHandler(strings.Repeat("a", 99), nil))
+		assert.Empty(t, w.Header().Get(header.ContentEncoding))
+		assert.Equal(t, header.AcceptEncoding, w.Header().Get(header.Vary))
+		assert.Equal(t, strings.Repeat("a", 99), w.Body.String())
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		w := serve(apidef.CompressionConfig{}, "", jsonHandler(large, nil))
+		assert.Empty(t, w.Header().Get(header.ContentEncoding))
+		assert.Equal(t, header.AcceptEncoding, w.Header().Get(header.Vary))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("not eligible", func(t *testing.T) {
+		testCases := map[string]map[string]string{
+			"content type":      {header.ContentType: "image/png"},
+			"encoded":           {header.ContentEncoding: "gzip"},
+			"no transform":      {header.CacheControl: "public, no-transform"},
+			"configured type":   {header.ContentType: "text/html"},
+			"no content type":   {header.ContentType: ""},
+			"invalid mime type": {header.ContentType: ";;"},
+		}
+
+		for name, headers := range testCases {
+			t.Run(name, func(t *testing.T) {
+				conf := apidef.CompressionConfig{ContentTypes: []string{"application/json"}}
+				w := serve(conf, encodingGzip, jsonHandler(large, headers))
+				assert.Equal(t, headers[header.ContentEncoding], w.Header().Get(header.ContentEncoding))
+				assert.Empty(t, w.Header().Get(header.Vary))
+				assert.Equal(t, large, w.Body.String())
+			})
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		w := serve(apidef.CompressionConfig{}, encodingGzip, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(header.ContentType, "application/json")
+			w.WriteHeader(http.StatusNotModified)
+		})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Header().Get(header.ContentEncoding))
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		var flushed []string
+		w := serve(apidef.CompressionConfig{}, encodingGzip, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(header.ContentType, "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+
+			for _, event := range []string{"data: 1\n\n", "data: 2\n\n"} {
+				_, _ = io.WriteString(w, event)
+				w.(http.Flusher).Flush()
+
+				// each event is readable by the client once flushed
+				rec := w.(*compressResponseWriter).ResponseWriter.(*httptest.ResponseRecorder)
+				assert.True(t, rec.Flushed)
+				flushed = append(flushed, decompressPartial(t, rec.Body.Bytes()))
+			}
+		})
+
+		assert.Equal(t, encodingGzip, w.Header().Get(header.ContentEncoding))
+		assert.Equal(t, []string{"data: 1\n\n", "data: 1\n\ndata: 2\n\n"}, flushed)
+		assert.Equal(t, "data: 1\n\ndata: 2\n\n", decompress(t, encodingGzip, w.Body.Bytes()))
+	})
+}
+
+// decompressPartial reads the gzip stream flushed so far.
+func decompressPartial(t *testing.T, body []byte) string {
+	t.Helper()
+
+	reader, err := decompressRequestBody(encodingGzip, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	_, _ = io.Copy(&out, reader)
+	return out.String()
+}
+
+func TestCompression(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	large := strings.Repeat(`{"key":"value"}`, 200)
+
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set(header.ContentType, "application/json")
+		_, _ = io.WriteString(w, large)
+	}))
+	defer upstream.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = randStringBytes(8)
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.CacheOptions.EnableCache = true
+		spec.CacheOptions.CacheAllSafeRequests = true
+		spec.CacheOptions.CacheTimeout = 60
+		spec.Compression = apidef.CompressionConfig{
+			Enabled:    true,
+			Algorithms: []string{"gzip", "br"},
+		}
+	})
+
+	decompressed := func(encoding string) func([]byte) bool {
+		return func(body []byte) bool {
+			return decompress(t, encoding, body) == large
+		}
+	}
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{
+			Path: "/", Headers: map[string]string{header.AcceptEncoding: "gzip"}, Code: http.StatusOK,
+			HeadersMatch:  map[string]string{header.ContentEncoding: "gzip", header.Vary: header.AcceptEncoding},
+			BodyMatchFunc: decompressed(encodingGzip),
+		},
+		// cached responses are compressed for each client
+		{
+			Path: "/", Headers: map[string]string{header.AcceptEncoding: "br"}, Code: http.StatusOK,
+			HeadersMatch:  map[string]string{header.ContentEncoding: "br", cachedResponseHeader: "1"},
+			BodyMatchFunc: decompressed(encodingBrotli),
+		},
+		{
+			Path: "/", Headers: map[string]string{header.AcceptEncoding: "identity"}, Code: http.StatusOK,
+			HeadersNotMatch: map[string]string{header.ContentEncoding: "br"},
+			HeadersMatch:    map[string]string{cachedResponseHeader: "1"},
+			BodyMatch:       large,
+		},
+	}...)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodHead, Path: "/", Headers: map[string]string{header.AcceptEncoding: "gzip"}, Code: http.StatusOK,
+			HeadersNotMatch: map[string]string{header.ContentEncoding: "gzip"}},
+	}...)
+}
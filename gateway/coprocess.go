@@ -23,7 +23,7 @@ import (
 )
 
 var (
-	supportedDrivers = []apidef.MiddlewareDriver{apidef.PythonDriver, apidef.LuaDriver, apidef.GrpcDriver}
+	supportedDrivers = []apidef.MiddlewareDriver{apidef.PythonDriver, apidef.LuaDriver, apidef.GrpcDriver, apidef.WasmDriver}
 	loadedDrivers    = map[apidef.MiddlewareDriver]coprocess.Dispatcher{}
 )
 
@@ -272,6 +272,15 @@ func (gw *Gateway) CoProcessInit() {
 		}
 	}
 
+	// Load WebAssembly dispatcher:
+	wasmDispatcher, err := NewWasmDispatcher(gw.GetConfig().CoProcessOptions)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"prefix": "coprocess",
+		}).WithError(err).Error("Couldn't load wasm dispatcher")
+		return
+	}
+	loadedDrivers[apidef.WasmDriver] = wasmDispatcher
 }
 
 // EnabledForSpec checks if this middleware should be enabled for a given API.
@@ -0,0 +1,316 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/coprocess"
+)
+
+const (
+	wasmPageSize = 64 * 1024
+
+	defaultWasmMaxMemory = 64 << 20
+	defaultWasmTimeout   = 5 * time.Second
+
+	// wasmMaxOutputSize limits the size of the object a plugin writes back.
+	wasmMaxOutputSize = 64 << 20
+)
+
+var errWasmOutputTooLarge = errors.New("wasm plugin output is too large")
+
+// WasmDispatcher runs the plugins of the wasm driver. Plugins are WASI command modules: every hook call runs a new
+// instance of the module, which reads the JSON encoded coprocess.Object from stdin and writes the modified object
+// to stdout. The hook name is passed as the first argument, messages written to stderr are logged.
+//
+// Instances are sandboxed, they have no access to the file system or the network, their memory is capped by the
+// wasm_max_memory and their run time by the wasm_timeout coprocess options.
+type WasmDispatcher struct {
+	runtime wazero.Runtime
+	timeout time.Duration
+
+	mu   sync.RWMutex
+	apis map[string]*wasmPlugins
+}
+
+// wasmPlugins are the compiled modules of an API, by hook.
+type wasmPlugins struct {
+	modules map[wasmHook]wazero.CompiledModule
+	// inFlight tracks the running hooks, so the modules are closed once they complete.
+	inFlight sync.WaitGroup
+}
+
+type wasmHook struct {
+	hookType coprocess.HookType
+	name     string
+}
+
+// NewWasmDispatcher creates the dispatcher of the wasm driver.
+func NewWasmDispatcher(conf config.CoProcessConfig) (*WasmDispatcher, error) {
+	maxMemory := conf.WasmMaxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultWasmMaxMemory
+	}
+
+	timeout := time.Duration(conf.WasmTimeout * float64(time.Second))
+	if timeout <= 0 {
+		timeout = defaultWasmTimeout
+	}
+
+	ctx := context.Background()
+	runtimeConf := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32((maxMemory + wasmPageSize - 1) / wasmPageSize)).
+		WithCloseOnContextDone(true).
+		WithCompilationCache(wazero.NewCompilationCache())
+
+	r := wazero.NewRuntimeWithConfig(ctx, runtimeConf)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+
+	return &WasmDispatcher{
+		runtime: r,
+		timeout: timeout,
+		apis:    map[string]*wasmPlugins{},
+	}, nil
+}
+
+// Register compiles the plugins of the API and makes them available to its hooks, replacing the previously
+// registered ones. The returned function unregisters them.
+func (d *WasmDispatcher) Register(apiID string, hooks map[coprocess.HookType][]apidef.MiddlewareDefinition) (func(), error) {
+	ctx := context.Background()
+	plugins := &wasmPlugins{modules: map[wasmHook]wazero.CompiledModule{}}
+	compiled := map[string]wazero.CompiledModule{}
+
+	for hookType, defs := range hooks {
+		for _, def := range defs {
+			if def.Disabled || def.Name == "" {
+				continue
+			}
+
+			module, ok := compiled[def.Path]
+			if !ok {
+				binary, err := os.ReadFile(def.Path)
+				if err != nil {
+					plugins.close(ctx)
+					return nil, err
+				}
+
+				module, err = d.runtime.CompileModule(ctx, binary)
+				if err != nil {
+					plugins.close(ctx)
+					return nil, fmt.Errorf("couldn't compile %s: %w", filepath.Base(def.Path), err)
+				}
+				compiled[def.Path] = module
+			}
+
+			plugins.modules[wasmHook{hookType: hookType, name: def.Name}] = module
+		}
+	}
+
+	d.mu.Lock()
+	previous := d.apis[apiID]
+	d.apis[apiID] = plugins
+	d.mu.Unlock()
+
+	if previous != nil {
+		go previous.release()
+	}
+
+	return func() {
+		d.mu.Lock()
+		current := d.apis[apiID] == plugins
+		if current {
+			delete(d.apis, apiID)
+		}
+		d.mu.Unlock()
+
+		if current {
+			go plugins.release()
+		}
+	}, nil
+}
+
+// release closes the modules once the running hooks complete.
+func (p *wasmPlugins) release() {
+	p.inFlight.Wait()
+	p.close(context.Background())
+}
+
+func (p *wasmPlugins) close(ctx context.Context) {
+	for _, module := range p.modules {
+		_ = module.Close(ctx)
+	}
+}
+
+// module returns the module of the hook, tracking its use until done is called.
+func (d *WasmDispatcher) module(apiID string, hook wasmHook) (module wazero.CompiledModule, done func()) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	plugins := d.apis[apiID]
+	if plugins == nil {
+		return nil, nil
+	}
+
+	module = plugins.modules[hook]
+	if module == nil {
+		return nil, nil
+	}
+
+	plugins.inFlight.Add(1)
+	return module, plugins.inFlight.Done
+}
+
+// Dispatch runs the plugin of the object hook.
+func (d *WasmDispatcher) Dispatch(object *coprocess.Object) (*coprocess.Object, error) {
+	hook := wasmHook{hookType: object.HookType, name: object.HookName}
+	module, done := d.module(object.Spec["APIID"], hook)
+	if module == nil {
+		return nil, fmt.Errorf("no wasm plugin for hook '%s'", object.HookName)
+	}
+	defer done()
+
+	input, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: wasmMaxOutputSize}
+	var stderr bytes.Buffer
+
+	moduleConf := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs("tyk-plugin", object.HookName).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(stdout).
+		WithStderr(&stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader).
+		WithStartFunctions("_start")
+
+	instance, err := d.runtime.InstantiateModule(ctx, module, moduleConf)
+	if instance != nil {
+		_ = instance.Close(ctx)
+	}
+
+	if stderr.Len() > 0 {
+		log.WithFields(logrus.Fields{
+			"prefix": "coprocess",
+			"hook":   object.HookName,
+		}).Debug(stderr.String())
+	}
+
+	if err != nil {
+		var exitErr *sys.ExitError
+		switch {
+		case errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded:
+			return nil, fmt.Errorf("wasm plugin '%s' timed out", object.HookName)
+		case errors.As(err, &exitErr):
+			return nil, fmt.Errorf("wasm plugin '%s' exited with code %d", object.HookName, exitErr.ExitCode())
+		}
+		return nil, fmt.Errorf("wasm plugin '%s' failed: %w", object.HookName, err)
+	}
+
+	if stdout.overflow {
+		return nil, errWasmOutputTooLarge
+	}
+
+	// a plugin writing nothing leaves the object unchanged
+	if stdout.Len() == 0 {
+		return object, nil
+	}
+
+	newObject := &coprocess.Object{}
+	if err := json.Unmarshal(stdout.Bytes(), newObject); err != nil {
+		return nil, fmt.Errorf("invalid object returned by wasm plugin '%s': %w", object.HookName, err)
+	}
+
+	return newObject, nil
+}
+
+// DispatchObject is the same as Dispatch.
+func (d *WasmDispatcher) DispatchObject(object *coprocess.Object) (*coprocess.Object, error) {
+	return d.Dispatch(object)
+}
+
+// DispatchEvent is a no-op, the wasm driver doesn't handle events.
+func (d *WasmDispatcher) DispatchEvent([]byte) {}
+
+// LoadModules is a no-op, the modules are registered per API.
+func (d *WasmDispatcher) LoadModules() {}
+
+// HandleMiddlewareCache is a no-op, the modules are loaded from the bundle path when the API is loaded.
+func (d *WasmDispatcher) HandleMiddlewareCache(*apidef.BundleManifest, string) {}
+
+// Reload is a no-op, the modules are registered again when the APIs are reloaded.
+func (d *WasmDispatcher) Reload() {}
+
+// limitedBuffer is a buffer discarding the writes past its limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		b.overflow = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// loadWasmPlugins registers the wasm plugins of the API with the wasm dispatcher.
+func (gw *Gateway) loadWasmPlugins(spec *APISpec, auth apidef.MiddlewareDefinition, pre, postAuth, post, response []apidef.MiddlewareDefinition) {
+	logger := log.WithFields(logrus.Fields{
+		"prefix":   "coprocess",
+		"api_id":   spec.APIID,
+		"api_name": spec.Name,
+	})
+
+	dispatcher, _ := loadedDrivers[apidef.WasmDriver].(*WasmDispatcher)
+	if dispatcher == nil {
+		logger.Error("The wasm driver isn't loaded, enable the coprocess plugins to use it")
+		return
+	}
+
+	hooks := map[coprocess.HookType][]apidef.MiddlewareDefinition{
+		coprocess.HookType_Pre:         pre,
+		coprocess.HookType_PostKeyAuth: postAuth,
+		coprocess.HookType_Post:        post,
+		coprocess.HookType_Response:    response,
+	}
+	if coprocessAuthEnabled(spec) {
+		hooks[coprocess.HookType_CustomKeyCheck] = []apidef.MiddlewareDefinition{auth}
+	}
+
+	unregister, err := dispatcher.Register(spec.APIID, hooks)
+	if err != nil {
+		logger.WithError(err).Error("Couldn't load wasm plugins")
+		return
+	}
+
+	spec.AddUnloadHook(unregister)
+}
@@ -0,0 +1,236 @@
+package gateway
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/coprocess"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+var (
+	wasmPluginOnce sync.Once
+	wasmPlugin     []byte
+	wasmPluginErr  error
+)
+
+// testWasmPlugin builds the plugin in testdata/wasmplugin, the test is skipped if it can't be built.
+func testWasmPlugin(t *testing.T) []byte {
+	t.Helper()
+
+	wasmPluginOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "wasmplugin")
+		if err != nil {
+			wasmPluginErr = err
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		output := filepath.Join(dir, "plugin.wasm")
+		cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "build", "-o", output, ".")
+		cmd.Dir = filepath.Join("testdata", "wasmplugin")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			wasmPluginErr = fmt.Errorf("%w: %s", err, out)
+			return
+		}
+
+		wasmPlugin, wasmPluginErr = os.ReadFile(output)
+	})
+
+	if wasmPluginErr != nil {
+		t.Skip("Couldn't build the wasm plugin: ", wasmPluginErr)
+	}
+
+	return wasmPlugin
+}
+
+func testWasmPluginPath(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	require.NoError(t, os.WriteFile(path, testWasmPlugin(t), 0644))
+	return path
+}
+
+func TestWasmDispatcher(t *testing.T) {
+	path := testWasmPluginPath(t)
+
+	d, err := NewWasmDispatcher(config.CoProcessConfig{WasmMaxMemory: 32 << 20, WasmTimeout: 1})
+	require.NoError(t, err)
+
+	hooks := map[coprocess.HookType][]apidef.MiddlewareDefinition{
+		coprocess.HookType_Pre: {
+			{Name: "AddHeader", Path: path},
+			{Name: "Loop", Path: path},
+			{Name: "Alloc", Path: path},
+			{Name: "Exit", Path: path},
+			{Name: "Disabled", Path: path, Disabled: true},
+		},
+	}
+	unregister, err := d.Register("api", hooks)
+	require.NoError(t, err)
+
+	dispatch := func(name string) (*coprocess.Object, error) {
+		return d.Dispatch(&coprocess.Object{
+			HookType: coprocess.HookType_Pre,
+			HookName: name,
+			Request:  &coprocess.MiniRequestObject{Headers: map[string]string{}},
+			Spec:     map[string]string{"APIID": "api"},
+		})
+	}
+
+	t.Run("modifies the object", func(t *testing.T) {
+		object, err := dispatch("AddHeader")
+		require.NoError(t, err)
+		assert.Equal(t, "pre", object.Request.SetHeaders["X-Wasm-Plugin"])
+	})
+
+	t.Run("CPU time is limited", func(t *testing.T) {
+		_, err := dispatch("Loop")
+		assert.ErrorContains(t, err, "timed out")
+	})
+
+	t.Run("memory is limited", func(t *testing.T) {
+		_, err := dispatch("Alloc")
+		assert.Error(t, err)
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		_, err := dispatch("Exit")
+		assert.ErrorContains(t, err, "exited with code 3")
+	})
+
+	t.Run("unknown hook", func(t *testing.T) {
+		_, err := dispatch("Disabled")
+		assert.Error(t, err)
+
+		_, err = d.Dispatch(&coprocess.Object{
+			HookType: coprocess.HookType_Post,
+			HookName: "AddHeader",
+			Spec:     map[string]string{"APIID": "api"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid module", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.wasm")
+		require.NoError(t, os.WriteFile(invalid, []byte("not wasm"), 0644))
+
+		_, err := d.Register("invalid", map[coprocess.HookType][]apidef.MiddlewareDefinition{
+			coprocess.HookType_Pre: {{Name: "AddHeader", Path: invalid}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("unregister", func(t *testing.T) {
+		unregister()
+
+		_, err := dispatch("AddHeader")
+		assert.Error(t, err)
+	})
+}
+
+func TestWasmPlugins(t *testing.T) {
+	path := testWasmPluginPath(t)
+
+	ts := StartTest(nil, TestConfig{
+		CoprocessConfig: config.CoProcessConfig{EnableCoProcess: true},
+	})
+	defer ts.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = "wasm-request"
+		spec.Proxy.ListenPath = "/request/"
+		spec.UseKeylessAccess = false
+		spec.EnableCoProcessAuth = true
+		spec.CustomMiddleware = apidef.MiddlewareSection{
+			Driver:      apidef.WasmDriver,
+			Pre:         []apidef.MiddlewareDefinition{{Name: "AddHeader", Path: path}},
+			AuthCheck:   apidef.MiddlewareDefinition{Name: "Auth", Path: path},
+			PostKeyAuth: []apidef.MiddlewareDefinition{{Name: "PostAuth", Path: path}},
+		}
+	}, func(spec *APISpec) {
+		spec.APIID = "wasm-reject"
+		spec.Proxy.ListenPath = "/reject/"
+		spec.CustomMiddleware = apidef.MiddlewareSection{
+			Driver: apidef.WasmDriver,
+			Post:   []apidef.MiddlewareDefinition{{Name: "Reject", Path: path}},
+		}
+	}, func(spec *APISpec) {
+		spec.APIID = "wasm-response"
+		spec.Proxy.ListenPath = "/response/"
+		spec.CustomMiddleware = apidef.MiddlewareSection{
+			Driver:   apidef.WasmDriver,
+			Response: []apidef.MiddlewareDefinition{{Name: "Response", Path: path}},
+		}
+	}, func(spec *APISpec) {
+		spec.APIID = "wasm-timeout"
+		spec.Proxy.ListenPath = "/timeout/"
+		spec.CustomMiddleware = apidef.MiddlewareSection{
+			Driver: apidef.WasmDriver,
+			Pre:    []apidef.MiddlewareDefinition{{Name: "Exit", Path: path}},
+		}
+	})
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Path: "/request/", Code: http.StatusUnauthorized},
+		{Path: "/request/", Headers: map[string]string{"Authorization": "wasm-token"}, Code: http.StatusOK,
+			BodyMatch: `"X-Wasm-Plugin":"pre"`},
+		{Path: "/request/", Headers: map[string]string{"Authorization": "wasm-token"}, Code: http.StatusOK,
+			BodyMatch: `"X-Wasm-Session":"wasm"`},
+		{Path: "/reject/", Code: http.StatusForbidden, BodyMatch: "rejected by wasm"},
+		{Path: "/response/", Code: http.StatusOK, BodyMatch: "^from wasm$",
+			HeadersMatch: map[string]string{"X-Wasm-Response": "modified"}},
+		{Path: "/timeout/", Code: http.StatusInternalServerError},
+	}...)
+}
+
+func TestWasmPluginBundle(t *testing.T) {
+	plugin := testWasmPlugin(t)
+
+	ts := StartTest(nil, TestConfig{
+		CoprocessConfig: config.CoProcessConfig{EnableCoProcess: true},
+	})
+	defer ts.Close()
+
+	manifest, err := json.Marshal(apidef.BundleManifest{
+		FileList: []string{"plugin.wasm"},
+		CustomMiddleware: apidef.MiddlewareSection{
+			Driver: apidef.WasmDriver,
+			Pre:    []apidef.MiddlewareDefinition{{Name: "AddHeader", Path: "plugin.wasm"}},
+		},
+		Checksum: fmt.Sprintf("%x", md5.Sum(plugin)),
+	})
+	require.NoError(t, err)
+
+	// the bundle is extracted beforehand, as downloading a large bundle from the test server is slow
+	bundle := "wasm-" + randStringBytes(8) + ".zip"
+	bundlePath := ts.Gw.getBundleDestPath(&APISpec{APIDefinition: &apidef.APIDefinition{CustomMiddlewareBundle: bundle}})
+	require.NoError(t, os.MkdirAll(bundlePath, 0755))
+	defer os.RemoveAll(bundlePath)
+
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "manifest.json"), manifest, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "plugin.wasm"), plugin, 0644))
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = "wasm-bundle"
+		spec.Proxy.ListenPath = "/"
+		spec.CustomMiddlewareBundle = bundle
+	})
+
+	_, _ = ts.Run(t, test.TestCase{Path: "/", Code: http.StatusOK, BodyMatch: `"X-Wasm-Plugin":"pre"`})
+}
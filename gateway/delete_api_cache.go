@@ -2,11 +2,217 @@ package gateway
 
 import (
 	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/TykTechnologies/tyk/header"
 	"github.com/TykTechnologies/tyk/storage"
 )
 
+const (
+	// cacheIndexURLs is the sorted set of `<url> <cache key>` members of the cached entries of an API.
+	cacheIndexURLs = "-index:urls"
+	// cacheIndexTagPrefix prefixes the sorted sets of cache keys tagged by the upstream.
+	cacheIndexTagPrefix = "-index:tag:"
+)
+
+// The members of the cache indexes are scored by the expiry of their entry, in seconds since the epoch,
+// so the members of expired entries are skipped on reads and pruned on writes.
+
+// cacheInvalidation describes the cache entries of an API to purge. It is also the payload
+// of the NoticeInvalidateAPICache notification.
+type cacheInvalidation struct {
+	APIID    string   `json:"api_id"`
+	Tags     []string `json:"tags,omitempty"`
+	URLs     []string `json:"urls,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	// Keys are the cache keys purged by the gateway handling the request, which the
+	// other gateways evict from their in-memory tier and node-local cache stores.
+	Keys []string `json:"keys,omitempty"`
+}
+
 func (gw *Gateway) invalidateAPICache(apiID string) bool {
+	gw.localResponseCache.flush(apiID)
+
+	if entries := gw.nonRedisCacheStore(apiID); entries != nil {
+		if err := entries.DeletePrefix(""); err != nil {
+			log.WithError(err).Errorf("cache invalidation failed for: %s", apiID)
+			return false
+		}
+	}
+
 	store := storage.RedisCluster{IsCache: true, ConnectionHandler: gw.StorageConnectionHandler}
 	return store.DeleteScanMatch(fmt.Sprintf("cache-%s*", apiID))
 }
+
+// invalidateCacheEntries purges the cache entries of an API matching the tags, URLs or URL patterns.
+// It returns the keys of the purged entries.
+func (gw *Gateway) invalidateCacheEntries(inv cacheInvalidation) ([]string, error) {
+	patterns := make([]*regexp.Regexp, 0, len(inv.Patterns))
+	for _, pattern := range inv.Patterns {
+		re, err := compileCacheGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, re)
+	}
+
+	store := &storage.RedisCluster{KeyPrefix: "cache-" + inv.APIID, IsCache: true, ConnectionHandler: gw.StorageConnectionHandler}
+
+	keys := map[string]struct{}{}
+
+	for _, tag := range inv.Tags {
+		members, err := liveCacheIndexMembers(store, cacheIndexTagPrefix+tag)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range members {
+			keys[key] = struct{}{}
+		}
+		store.DeleteKey(cacheIndexTagPrefix + tag)
+	}
+
+	if len(inv.URLs) > 0 || len(patterns) > 0 {
+		members, err := liveCacheIndexMembers(store, cacheIndexURLs)
+		if err != nil {
+			return nil, err
+		}
+
+		var matched bool
+		for _, member := range members {
+			url, key, ok := strings.Cut(member, " ")
+			if !ok || !matchCacheURL(url, inv.URLs, patterns) {
+				continue
+			}
+
+			keys[key] = struct{}{}
+			// score the member as expired, it's removed with the expired members below
+			store.AddToSortedSet(cacheIndexURLs, member, 0)
+			matched = true
+		}
+
+		if matched {
+			pruneCacheIndex(store, cacheIndexURLs)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	purged := make([]string, 0, len(keys))
+	for key := range keys {
+		purged = append(purged, key)
+	}
+
+	gw.localResponseCache.delete(inv.APIID, purged...)
+
+	if entries := gw.nonRedisCacheStore(inv.APIID); entries != nil {
+		if err := entries.Delete(purged...); err != nil {
+			return nil, err
+		}
+	}
+
+	// DeleteKeys prefixes the keys in place
+	store.DeleteKeys(append([]string(nil), purged...))
+
+	return purged, nil
+}
+
+// indexCacheEntry records a cache entry under its URL and tags, so it can be purged selectively.
+func (m *ResponseCacheMiddleware) indexCacheEntry(url, key string, tags []string, ttl int64) {
+	m.addToCacheIndex(cacheIndexURLs, url+" "+key, ttl)
+
+	for _, tag := range tags {
+		m.addToCacheIndex(cacheIndexTagPrefix+tag, key, ttl)
+	}
+}
+
+// addToCacheIndex adds the member to an index, scored by the expiry of its entry, and removes the members
+// of expired entries. The index is kept until its last entry expires.
+func (m *ResponseCacheMiddleware) addToCacheIndex(index, member string, ttl int64) {
+	score := math.Inf(1)
+	if ttl > 0 {
+		score = float64(time.Now().Unix() + ttl)
+	}
+
+	m.store.AddToSortedSet(index, member, score)
+	pruneCacheIndex(m.store, index)
+
+	if ttl <= 0 {
+		return
+	}
+
+	if exp, err := m.store.GetExp(index); err == nil && exp >= ttl {
+		return
+	}
+
+	if err := m.store.SetExp(index, ttl); err != nil {
+		m.Logger().WithError(err).Warning("could not set cache index expiry")
+	}
+}
+
+// liveCacheIndexMembers returns the members of an index whose entries haven't expired.
+func liveCacheIndexMembers(store storage.Handler, index string) ([]string, error) {
+	members, _, err := store.GetSortedSetRange(index, strconv.FormatInt(time.Now().Unix()+1, 10), "+inf")
+	return members, err
+}
+
+// pruneCacheIndex removes the members of expired entries from an index.
+func pruneCacheIndex(store storage.Handler, index string) {
+	if err := store.RemoveSortedSetRange(index, "-inf", strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		log.WithError(err).Warning("could not prune cache index")
+	}
+}
+
+// cacheTags returns the tags set by the upstream in the Surrogate-Key or Cache-Tag response
+// headers. Surrogate-Key tags are separated by spaces,
+// Cache-Tag tags by commas.
+func cacheTags(h http.Header) []string {
+	var tags []string
+
+	for _, value := range h.Values(header.SurrogateKey) {
+		tags = append(tags, strings.Fields(value)...)
+	}
+
+	for _, value := range h.Values(header.CacheTag) {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
+// compileCacheGlob compiles a URL glob pattern where `*` matches any sequence of characters,
+// including `/`, and `?` matches a single character.
+func compileCacheGlob(pattern string) (*regexp.Regexp, error) {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+
+	return regexp.Compile("^" + expr + "$")
+}
+
+func matchCacheURL(url string, urls []string, patterns []*regexp.Regexp) bool {
+	for _, u := range urls {
+		if u == url {
+			return true
+		}
+	}
+
+	for _, re := range patterns {
+		if re.MatchString(url) {
+			return true
+		}
+	}
+
+	return false
+}
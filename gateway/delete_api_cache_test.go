@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func TestInvalidateCacheEntries(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/123", "/products/123/reviews":
+			w.Header().Set(header.SurrogateKey, "product:123 products")
+		case "/products/456":
+			w.Header().Set(header.CacheTag, "product:456, products")
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = "cache-purge"
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.CacheOptions.EnableCache = true
+		spec.CacheOptions.CacheAllSafeRequests = true
+		spec.CacheOptions.CacheTimeout = 60
+	})
+
+	cached := map[string]string{cachedResponseHeader: "1"}
+	paths := []string{"/products/123", "/products/123/reviews", "/products/456", "/about"}
+
+	warmup := func(t *testing.T) {
+		t.Helper()
+		for _, path := range paths {
+			_, _ = ts.Run(t, test.TestCase{Path: path, Code: http.StatusOK, Delay: 10 * time.Millisecond})
+		}
+	}
+
+	check := func(t *testing.T, purged ...string) {
+		t.Helper()
+		for _, path := range paths {
+			tc := test.TestCase{Path: path, Code: http.StatusOK, HeadersMatch: cached}
+			for _, p := range purged {
+				if p == path {
+					tc.HeadersMatch, tc.HeadersNotMatch = nil, cached
+				}
+			}
+			_, _ = ts.Run(t, tc)
+		}
+	}
+
+	purge := func(t *testing.T, query string) {
+		t.Helper()
+		_, _ = ts.Run(t, test.TestCase{Method: http.MethodDelete, Path: "/tyk/cache/cache-purge?" + query, AdminAuth: true, Code: http.StatusOK})
+	}
+
+	t.Run("by tag", func(t *testing.T) {
+		warmup(t)
+		purge(t, "tag=product:123")
+		check(t, "/products/123", "/products/123/reviews")
+	})
+
+	t.Run("by tag from Cache-Tag", func(t *testing.T) {
+		warmup(t)
+		purge(t, "tag=product:456")
+		check(t, "/products/456")
+	})
+
+	t.Run("by url", func(t *testing.T) {
+		warmup(t)
+		purge(t, "url=/products/123")
+		check(t, "/products/123")
+	})
+
+	t.Run("by pattern", func(t *testing.T) {
+		warmup(t)
+		purge(t, "pattern=/products/123*&url=/about")
+		check(t, "/products/123", "/products/123/reviews", "/about")
+	})
+
+	t.Run("index", func(t *testing.T) {
+		store := &storage.RedisCluster{KeyPrefix: "cache-cache-purge", IsCache: true, ConnectionHandler: ts.Gw.StorageConnectionHandler}
+		warmup(t)
+
+		members, err := liveCacheIndexMembers(store, cacheIndexURLs)
+		assert.NoError(t, err)
+		assert.Len(t, members, len(paths))
+
+		purge(t, "url=/about")
+		members, _, err = store.GetSortedSetRange(cacheIndexURLs, "-inf", "+inf")
+		assert.NoError(t, err)
+		assert.Len(t, members, len(paths)-1, "purged members are removed from the index")
+
+		// members of expired entries are pruned on the next write
+		store.AddToSortedSet(cacheIndexURLs, "/expired key", 1)
+		_, _ = ts.Run(t, test.TestCase{Path: "/about", Code: http.StatusOK})
+		members, _, err = store.GetSortedSetRange(cacheIndexURLs, "-inf", "+inf")
+		assert.NoError(t, err)
+		assert.Len(t, members, len(paths))
+		assert.NotContains(t, members, "/expired key")
+	})
+
+	t.Run("whole api", func(t *testing.T) {
+		warmup(t)
+		purge(t, "")
+		check(t, paths...)
+	})
+}
+
+func TestCacheTags(t *testing.T) {
+	h := http.Header{}
+	h.Add(header.SurrogateKey, "a  b")
+	h.Add(header.SurrogateKey, "c")
+	h.Add(header.CacheTag, "d, e,,")
+
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, cacheTags(h))
+	assert.Empty(t, cacheTags(http.Header{}))
+}
+
+func TestCompileCacheGlob(t *testing.T) {
+	testcases := []struct {
+		pattern string
+		url     string
+		match   bool
+	}{
+		{"/products/123*", "/products/123", true},
+		{"/products/123*", "/products/1234?page=2", true},
+		{"/products/123*", "/products/123/reviews", true},
+		{"/products/12?", "/products/123", true},
+		{"/products/12?", "/products/1234", false},
+		{"/products/*/reviews", "/products/123/reviews", true},
+		{"/products/(1)", "/products/1", false},
+		{"*", "/anything", true},
+	}
+
+	for _, tc := range testcases {
+		re, err := compileCacheGlob(tc.pattern)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.match, re.MatchString(tc.url), "%s ~ %s", tc.pattern, tc.url)
+	}
+}
@@ -0,0 +1,113 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/internal/event"
+	"github.com/TykTechnologies/tyk/internal/eventsink"
+)
+
+// defaultEventSinkFlushInterval is the delay after which an incomplete batch of events is published.
+const defaultEventSinkFlushInterval = time.Second
+
+// EventSinkHandler is an event handler publishing the events to a Kafka, NATS or AMQP broker.
+type EventSinkHandler struct {
+	kind    event.HandlerName
+	conf    apidef.EventSinkHandlerConf
+	batcher *eventsink.Batcher
+	// apiID is the API of the handler, empty for the global handlers.
+	apiID string
+	Gw    *Gateway
+}
+
+// Init enables the init of event handler instances when they are created on ApiSpec creation
+func (h *EventSinkHandler) Init(handlerConf interface{}) error {
+	logger := log.WithFields(logrus.Fields{
+		"prefix":  "events",
+		"handler": h.kind,
+	})
+
+	if err := h.conf.Scan(handlerConf); err != nil {
+		logger.Error("Problem getting configuration, skipping. ", err)
+		return err
+	}
+
+	if h.conf.Disabled {
+		logger.Infof("skipping disabled event handler %s", h.conf.Name)
+		return ErrEventHandlerDisabled
+	}
+
+	if len(h.conf.Addresses) == 0 {
+		return errors.New("no broker address is configured")
+	}
+
+	if h.conf.Topic == "" && h.kind != event.AMQPHandler {
+		return errors.New("no topic is configured")
+	}
+
+	switch h.conf.Format {
+	case "", eventsink.FormatJSON, eventsink.FormatCloudEvents:
+	default:
+		return fmt.Errorf("unsupported event format %q", h.conf.Format)
+	}
+
+	tlsConfig, err := eventsink.TLSConfig(h.conf.TLS)
+	if err != nil {
+		return err
+	}
+
+	var dial eventsink.Dialer
+	switch h.kind {
+	case event.KafkaHandler:
+		dial = eventsink.DialKafka(h.conf.Addresses, h.conf.Topic, tlsConfig)
+	case event.NATSHandler:
+		dial = eventsink.DialNATS(h.conf.Addresses, h.conf.Topic, tlsConfig)
+	case event.AMQPHandler:
+		dial = eventsink.DialAMQP(h.conf.Addresses, h.conf.Exchange, h.conf.Topic, tlsConfig)
+	default:
+		return fmt.Errorf("unsupported event sink %q", h.kind)
+	}
+
+	flushInterval := webhookSeconds(h.conf.FlushInterval, defaultEventSinkFlushInterval)
+	h.batcher = eventsink.NewBatcher(dial, h.conf.BatchSize, flushInterval, func(err error, dropped int) {
+		logger.WithError(err).Errorf("Could not publish %d events", dropped)
+	})
+	go h.batcher.Run(h.Gw.ctx)
+
+	return nil
+}
+
+// HandleEvent will be fired when the event handler instance is found in an APISpec EventPaths object during a request chain
+func (h *EventSinkHandler) HandleEvent(em config.EventMessage) {
+	source, key := "/tyk/gateway", string(em.Type)
+	if h.apiID != "" {
+		source, key = "/tyk/apis/"+h.apiID, h.apiID
+	}
+
+	message, err := eventsink.Encode(h.conf.Format, em, source, key, time.Now())
+	if err != nil {
+		log.WithError(err).WithFields(logrus.Fields{
+			"prefix":  "events",
+			"handler": h.kind,
+		}).Error("Could not encode the event")
+		return
+	}
+
+	if err := h.batcher.Add(message); err != nil {
+		log.WithError(err).WithFields(logrus.Fields{
+			"prefix":  "events",
+			"handler": h.kind,
+		}).Warning("Event dropped")
+	}
+}
+
+// Close stops the handler once the queued events are published.
+func (h *EventSinkHandler) Close() {
+	h.batcher.Close()
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/event"
+	"github.com/TykTechnologies/tyk/internal/eventsink"
+	"github.com/TykTechnologies/tyk/internal/eventsink/eventsinktest"
+)
+
+func receiveNATSMessage(t *testing.T, server *eventsinktest.NATSServer) *nats.Msg {
+	t.Helper()
+
+	select {
+	case msg := <-server.Messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not published")
+		return nil
+	}
+}
+
+func TestEventSinkHandler(t *testing.T) {
+	server := eventsinktest.NewNATSServer(t)
+
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.EventHandlers.Events = map[apidef.TykEvent][]apidef.EventHandlerTriggerConfig{
+			EventQuotaExceeded: {{
+				Handler: event.NATSHandler,
+				HandlerMeta: map[string]interface{}{
+					"addresses": []string{server.URL},
+					"topic":     "tyk.global",
+				},
+			}},
+		}
+	})
+	defer ts.Close()
+
+	t.Run("global", func(t *testing.T) {
+		ts.Gw.initGenericEventHandlers()
+		ts.Gw.FireSystemEvent(EventQuotaExceeded, EventKeyFailureMeta{Key: "global-key"})
+
+		msg := receiveNATSMessage(t, server)
+		assert.Equal(t, "tyk.global", msg.Subject)
+		assert.Equal(t, header.ApplicationJSON, msg.Header.Get(header.ContentType))
+
+		var em config.EventMessage
+		require.NoError(t, json.Unmarshal(msg.Data, &em))
+		assert.Equal(t, EventQuotaExceeded, em.Type)
+		assert.Equal(t, "global-key", em.Meta.(map[string]interface{})["Key"])
+	})
+
+	t.Run("per API", func(t *testing.T) {
+		spec := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+			spec.APIID = "event-sink"
+			spec.Proxy.ListenPath = "/event-sink/"
+			spec.EventHandlers.Events = map[apidef.TykEvent][]apidef.EventHandlerTriggerConfig{
+				EventAuthFailure: {{
+					Handler: event.NATSHandler,
+					HandlerMeta: map[string]interface{}{
+						"addresses":  []string{server.URL},
+						"topic":      "tyk.api",
+						"format":     eventsink.FormatCloudEvents,
+						"batch_size": 2,
+					},
+				}},
+			}
+		})[0]
+
+		spec.FireEvent(EventAuthFailure, EventKeyFailureMeta{Key: "first"})
+		spec.FireEvent(EventAuthFailure, EventKeyFailureMeta{Key: "second"})
+
+		keys := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			msg := receiveNATSMessage(t, server)
+			assert.Equal(t, "tyk.api", msg.Subject)
+			assert.Equal(t, eventsink.MediaTypeCloudEvents, msg.Header.Get(header.ContentType))
+
+			var cloudEvent struct {
+				Source string                 `json:"source"`
+				Type   string                 `json:"type"`
+				Data   map[string]interface{} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(msg.Data, &cloudEvent))
+			assert.Equal(t, "/tyk/apis/event-sink", cloudEvent.Source)
+			assert.Equal(t, eventsink.CloudEventsTypePrefix+string(EventAuthFailure), cloudEvent.Type)
+			keys[cloudEvent.Data["Key"].(string)] = true
+		}
+		assert.Equal(t, map[string]bool{"first": true, "second": true}, keys)
+	})
+}
+
+func TestEventSinkHandler_Init(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	testCases := []struct {
+		name    string
+		kind    event.HandlerName
+		conf    map[string]interface{}
+		wantErr error
+	}{
+		{name: "disabled", kind: event.KafkaHandler, conf: map[string]interface{}{"disabled": true}, wantErr: ErrEventHandlerDisabled},
+		{name: "no address", kind: event.KafkaHandler, conf: map[string]interface{}{"topic": "events"}},
+		{name: "no topic", kind: event.NATSHandler, conf: map[string]interface{}{"addresses": []string{"nats://localhost:4222"}}},
+		{name: "invalid format", kind: event.KafkaHandler, conf: map[string]interface{}{"addresses": []string{"localhost:9092"}, "topic": "events", "format": "xml"}},
+		{name: "invalid TLS", kind: event.AMQPHandler, conf: map[string]interface{}{"addresses": []string{"amqps://localhost"}, "tls": map[string]interface{}{"enabled": true, "ca_file": "missing.pem"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ts.Gw.EventHandlerByName(apidef.EventHandlerTriggerConfig{Handler: tc.kind, HandlerMeta: tc.conf}, nil)
+			assert.Error(t, err)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+
+	t.Run("AMQP without routing key", func(t *testing.T) {
+		h, err := ts.Gw.EventHandlerByName(apidef.EventHandlerTriggerConfig{
+			Handler:     event.AMQPHandler,
+			HandlerMeta: map[string]interface{}{"addresses": []string{"amqp://localhost"}, "exchange": "tyk"},
+		}, nil)
+		require.NoError(t, err)
+		h.(*EventSinkHandler).Close()
+	})
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"errors"
 	htmltemplate "html/template"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path/filepath"
@@ -19,6 +18,7 @@ import (
 	"github.com/TykTechnologies/tyk/config"
 	"github.com/TykTechnologies/tyk/header"
 	"github.com/TykTechnologies/tyk/internal/event"
+	"github.com/TykTechnologies/tyk/internal/webhook"
 	"github.com/TykTechnologies/tyk/storage"
 )
 
@@ -49,7 +49,9 @@ type WebHookHandler struct {
 
 	contentType      string
 	dashboardService DashboardServiceSender
-	Gw               *Gateway
+	// apiID is the API of the handler, empty for the global handlers.
+	apiID string
+	Gw    *Gateway
 }
 
 // Init enables the init of event handler instances when they are created on ApiSpec creation
@@ -243,35 +245,15 @@ func (w *WebHookHandler) HandleEvent(em config.EventMessage) {
 		return
 	}
 
-	cli := &http.Client{Timeout: 30 * time.Second}
+	// The request is kept in the outbox until it's delivered, failed attempts are retried by the dispatcher until
+	// the retries are exhausted.
+	delivery := webhook.NewDelivery(req, reqBody, time.Now())
+	delivery.APIID = w.apiID
+	delivery.Webhook = w.conf.Name
+	delivery.Event = string(em.Type)
+	delivery.Signed = w.conf.SigningSecret != ""
 
-	resp, err := cli.Do(req)
-	if err != nil {
-		log.WithFields(logrus.Fields{
-			"prefix": "webhooks",
-		}).Error("Webhook request failed: ", err)
-	} else {
-		defer resp.Body.Close()
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			content, err := ioutil.ReadAll(resp.Body)
-			if err == nil {
-				log.WithFields(logrus.Fields{
-					"prefix":       "webhooks",
-					"responseCode": resp.StatusCode,
-				}).Debug(string(content))
-			} else {
-				log.WithFields(logrus.Fields{
-					"prefix": "webhooks",
-				}).Error(err)
-			}
-
-		} else {
-			log.WithFields(logrus.Fields{
-				"prefix":       "webhooks",
-				"responseCode": resp.StatusCode,
-			}).Error("Request to webhook failed")
-		}
-	}
+	w.Gw.webhookDeliveries().Deliver(w.Gw.ctx, delivery)
 
 	if w.dashboardService != nil && em.Type == EventTriggerExceeded {
 		w.dashboardService.NotifyDashboardOfEvent(em.Meta)
@@ -0,0 +1,316 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/webhook"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+const (
+	defaultWebhookMaxRetries              = 5
+	defaultWebhookRetryBackoff            = time.Second
+	defaultWebhookMaxRetryBackoff         = 300 * time.Second
+	defaultWebhookMaxConcurrentDeliveries = 10
+	defaultWebhookTimeout                 = 30 * time.Second
+	defaultWebhookDeadLetterRetention     = 7 * 24 * time.Hour
+
+	// webhookPollInterval is the interval at which the outbox is checked for deliveries due for an attempt.
+	webhookPollInterval = time.Second
+	// webhookClaimMargin is added to the timeout of a delivery for the lock of its attempt.
+	webhookClaimMargin = 5 * time.Second
+)
+
+// errWebhookSecretNotFound is returned when the webhook of a signed delivery isn't loaded anymore.
+var errWebhookSecretNotFound = errors.New("signing secret of the webhook not found")
+
+// webhookDispatcher attempts the deliveries of the outbox when they are due, moving them to the dead-letter queue once
+// their retries are exhausted.
+type webhookDispatcher struct {
+	Gw     *Gateway
+	store  *webhook.Store
+	notify chan struct{}
+	sem    chan struct{}
+
+	inFlightMu sync.Mutex
+	inFlight   map[string]bool
+}
+
+// webhookDeliveries returns the dispatcher of the webhook deliveries, started with the gateway on first use.
+func (gw *Gateway) webhookDeliveries() *webhookDispatcher {
+	gw.webhookDispatcherOnce.Do(func() {
+		store := &storage.RedisCluster{KeyPrefix: webhook.KeyPrefix, ConnectionHandler: gw.StorageConnectionHandler}
+		store.Connect()
+
+		maxConcurrent := gw.GetConfig().Webhooks.MaxConcurrentDeliveries
+		if maxConcurrent <= 0 {
+			maxConcurrent = defaultWebhookMaxConcurrentDeliveries
+		}
+
+		gw.webhookDispatcher = &webhookDispatcher{
+			Gw:       gw,
+			store:    webhook.NewStore(store),
+			notify:   make(chan struct{}, 1),
+			sem:      make(chan struct{}, maxConcurrent),
+			inFlight: map[string]bool{},
+		}
+		go gw.webhookDispatcher.run(gw.ctx)
+	})
+
+	return gw.webhookDispatcher
+}
+
+// Deliver adds the delivery to the outbox and attempts it, the dispatcher retries it when the attempt fails. The
+// delivery is left to the dispatcher when the maximum of concurrent deliveries is reached. When the delivery can't be
+// added to the outbox, it's attempted once without being retried.
+func (d *webhookDispatcher) Deliver(ctx context.Context, delivery *webhook.Delivery) {
+	select {
+	case d.sem <- struct{}{}:
+	default:
+		delivery.NextAttemptAt = delivery.CreatedAt
+		if err := d.store.Enqueue(delivery); err != nil {
+			d.sendOnce(ctx, delivery, err)
+			return
+		}
+
+		d.wake()
+		return
+	}
+	defer func() {
+		<-d.sem
+	}()
+
+	// The claim of the attempt expires with its timeout, the dispatcher then attempts the delivery again when the
+	// gateway stops during this attempt.
+	delivery.NextAttemptAt = delivery.CreatedAt.Add(d.timeout() + webhookClaimMargin)
+	if err := d.store.Enqueue(delivery); err != nil {
+		d.sendOnce(ctx, delivery, err)
+		return
+	}
+
+	if !d.start(delivery.ID) {
+		return
+	}
+	defer d.finish(delivery.ID)
+
+	d.attempt(ctx, delivery)
+}
+
+// sendOnce attempts the delivery which couldn't be added to the outbox, it isn't retried when the attempt fails.
+func (d *webhookDispatcher) sendOnce(ctx context.Context, delivery *webhook.Delivery, enqueueErr error) {
+	logger := webhookDeliveryLogger(delivery)
+	logger.WithError(enqueueErr).Warning("Could not add the webhook to the outbox, the delivery isn't durable and won't be retried")
+
+	secret, err := d.secret(delivery)
+	if err != nil {
+		logger.WithError(err).Error("Webhook request failed")
+		return
+	}
+
+	client := &http.Client{Timeout: d.timeout()}
+	statusCode, err := webhook.Send(ctx, client, delivery, secret, time.Now())
+	if err != nil {
+		logger.WithError(err).Error("Webhook request failed")
+		return
+	}
+
+	logger.WithField("responseCode", statusCode).Debug("Webhook delivered")
+}
+
+// Replay moves the dead-lettered delivery back to the outbox and wakes the dispatcher up.
+func (d *webhookDispatcher) Replay(id string) (*webhook.Delivery, error) {
+	delivery, err := d.store.Replay(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	d.wake()
+	return delivery, nil
+}
+
+func (d *webhookDispatcher) wake() {
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (d *webhookDispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.notify:
+		}
+	}
+}
+
+func (d *webhookDispatcher) dispatch(ctx context.Context) {
+	pending, err := d.store.Pending()
+	if err != nil {
+		log.WithError(err).WithFields(logrus.Fields{
+			"prefix": "webhooks",
+		}).Error("Could not read the webhook outbox")
+		return
+	}
+
+	now := time.Now()
+	for _, delivery := range pending {
+		if delivery.NextAttemptAt.After(now) || !d.start(delivery.ID) {
+			continue
+		}
+
+		select {
+		case d.sem <- struct{}{}:
+		case <-ctx.Done():
+			d.finish(delivery.ID)
+			return
+		}
+
+		go func(id string) {
+			defer func() {
+				<-d.sem
+				d.finish(id)
+			}()
+			d.retry(ctx, id)
+		}(delivery.ID)
+	}
+}
+
+// start marks the delivery as in flight, it returns false when it's already attempted by this gateway or locked by
+// another one.
+func (d *webhookDispatcher) start(id string) bool {
+	d.inFlightMu.Lock()
+	defer d.inFlightMu.Unlock()
+
+	if d.inFlight[id] || !d.store.Claim(id, d.timeout()+webhookClaimMargin) {
+		return false
+	}
+
+	d.inFlight[id] = true
+	return true
+}
+
+func (d *webhookDispatcher) finish(id string) {
+	d.store.Release(id)
+
+	d.inFlightMu.Lock()
+	delete(d.inFlight, id)
+	d.inFlightMu.Unlock()
+}
+
+func (d *webhookDispatcher) retry(ctx context.Context, id string) {
+	// The delivery is read again as it may have been completed by another gateway since the outbox was read.
+	delivery, err := d.store.Get(id)
+	if err != nil || delivery.DeadLetteredAt != nil || delivery.NextAttemptAt.After(time.Now()) {
+		return
+	}
+
+	d.attempt(ctx, delivery)
+}
+
+// attempt sends the delivery, then removes it from the outbox when it succeeds, or schedules its retry or moves it to
+// the dead-letter queue when it fails.
+func (d *webhookDispatcher) attempt(ctx context.Context, delivery *webhook.Delivery) {
+	logger := webhookDeliveryLogger(delivery)
+
+	var statusCode int
+	secret, err := d.secret(delivery)
+	if err == nil {
+		client := &http.Client{Timeout: d.timeout()}
+		statusCode, err = webhook.Send(ctx, client, delivery, secret, time.Now())
+	}
+	delivery.Attempts++
+
+	if err == nil {
+		logger.WithField("responseCode", statusCode).Debug("Webhook delivered")
+		if err := d.store.Complete(delivery.ID); err != nil {
+			logger.WithError(err).Error("Could not remove the delivered webhook from the outbox")
+		}
+		return
+	}
+
+	delivery.LastError = err.Error()
+	delivery.LastStatusCode = statusCode
+
+	conf := d.Gw.GetConfig().Webhooks
+	maxRetries := conf.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultWebhookMaxRetries
+	}
+
+	if delivery.Attempts > maxRetries {
+		logger.WithError(err).Errorf("Webhook request failed after %d attempts, moving it to the dead-letter queue", delivery.Attempts)
+		if err := d.store.DeadLetter(delivery, time.Now(), webhookSeconds(conf.DeadLetterRetention, defaultWebhookDeadLetterRetention)); err != nil {
+			logger.WithError(err).Error("Could not move the webhook to the dead-letter queue")
+		}
+		return
+	}
+
+	backoff := webhook.Backoff(delivery.Attempts, webhookSeconds(conf.RetryBackoff, defaultWebhookRetryBackoff), webhookSeconds(conf.MaxRetryBackoff, defaultWebhookMaxRetryBackoff))
+	delivery.NextAttemptAt = time.Now().Add(backoff)
+
+	logger.WithError(err).Warningf("Webhook request failed, retrying in %s", backoff)
+	if err := d.store.Reschedule(delivery); err != nil {
+		logger.WithError(err).Error("Could not reschedule the webhook")
+	}
+}
+
+// secret returns the signing secret of the signed delivery, looked up in the webhooks of its API, or in the global
+// webhooks when it has no API, as it isn't stored with the delivery.
+func (d *webhookDispatcher) secret(delivery *webhook.Delivery) (string, error) {
+	if !delivery.Signed {
+		return "", nil
+	}
+
+	handlers := d.Gw.GetConfig().GetEventTriggers()
+	if delivery.APIID != "" {
+		spec := d.Gw.getApiSpec(delivery.APIID)
+		if spec == nil {
+			return "", errWebhookSecretNotFound
+		}
+		handlers = spec.EventPaths
+	}
+
+	for _, handler := range handlers[apidef.TykEvent(delivery.Event)] {
+		if h, ok := handler.(*WebHookHandler); ok && h.conf.Name == delivery.Webhook && h.conf.SigningSecret != "" {
+			return h.conf.SigningSecret, nil
+		}
+	}
+
+	return "", errWebhookSecretNotFound
+}
+
+func webhookDeliveryLogger(delivery *webhook.Delivery) *logrus.Entry {
+	return log.WithFields(logrus.Fields{
+		"prefix":   "webhooks",
+		"delivery": delivery.ID,
+		"event":    delivery.Event,
+		"target":   delivery.URL,
+	})
+}
+
+func (d *webhookDispatcher) timeout() time.Duration {
+	return webhookSeconds(d.Gw.GetConfig().Webhooks.Timeout, defaultWebhookTimeout)
+}
+
+// webhookSeconds returns the duration of the seconds, or the default when they aren't positive.
+func webhookSeconds(value float64, defaultValue time.Duration) time.Duration {
+	if value <= 0 {
+		return defaultValue
+	}
+	return time.Duration(value * float64(time.Second))
+}
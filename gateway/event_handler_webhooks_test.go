@@ -1,15 +1,26 @@
 package gateway
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/TykTechnologies/tyk/apidef"
 	"github.com/TykTechnologies/tyk/config"
 	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/webhook"
+	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/test"
 )
 
 func (ts *Test) createWebHookHandler(t *testing.T) *WebHookHandler {
@@ -334,3 +345,160 @@ func TestWebhookContentTypeHeader(t *testing.T) {
 	}
 
 }
+
+func TestWebhookDelivery(t *testing.T) {
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.Webhooks.MaxRetries = 2
+		globalConf.Webhooks.RetryBackoff = 0.01
+	})
+	defer ts.Close()
+
+	var (
+		failing  atomic.Bool
+		attempts atomic.Int32
+
+		mu        sync.Mutex
+		signature string
+		body      []byte
+	)
+	failing.Store(true)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+
+		mu.Lock()
+		signature = r.Header.Get(header.XTykSignature)
+		body, _ = io.ReadAll(r.Body)
+		mu.Unlock()
+
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+	defer receiver.Close()
+
+	const apiID = "webhook-delivery"
+	spec := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = apiID
+		spec.Proxy.ListenPath = "/webhook-delivery/"
+		spec.EventHandlers.Events = map[apidef.TykEvent][]apidef.EventHandlerTriggerConfig{
+			EventQuotaExceeded: {{
+				Handler: EH_WebHook,
+				HandlerMeta: map[string]interface{}{
+					"name":           "receiver",
+					"method":         http.MethodPost,
+					"target_path":    receiver.URL,
+					"signing_secret": "secret",
+				},
+			}},
+		}
+	})[0]
+	require.Len(t, spec.EventPaths[EventQuotaExceeded], 1)
+
+	spec.EventPaths[EventQuotaExceeded][0].HandleEvent(config.EventMessage{
+		Type: EventQuotaExceeded,
+		Meta: EventKeyFailureMeta{
+			EventMetaDefault: EventMetaDefault{Message: "Quota exceeded"},
+			Path:             "/quota",
+			Key:              "webhook-delivery-key",
+		},
+	})
+
+	deadLetters := func() []*webhook.Delivery {
+		deliveries, err := ts.Gw.webhookDeliveries().store.DeadLetters()
+		require.NoError(t, err)
+		return webhookDeliveriesResponse(deliveries, apiID)
+	}
+
+	require.Eventually(t, func() bool {
+		return len(deadLetters()) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+
+	deadLetter := deadLetters()[0]
+	assert.Equal(t, int32(3), attempts.Load(), "the delivery should be retried twice")
+	assert.Equal(t, 3, deadLetter.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, deadLetter.LastStatusCode)
+	assert.Equal(t, string(EventQuotaExceeded), deadLetter.Event)
+	assert.Equal(t, "receiver", deadLetter.Webhook)
+
+	mu.Lock()
+	assert.NoError(t, webhook.Verify("secret", signature, body, time.Now(), time.Minute))
+	mu.Unlock()
+
+	stored, err := (&storage.RedisCluster{KeyPrefix: webhook.KeyPrefix, ConnectionHandler: ts.Gw.StorageConnectionHandler}).GetKey(deadLetter.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored, "secret", "the signing secret should not be stored")
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodGet, Path: "/tyk/webhooks/dead-letters?api_id=" + apiID, AdminAuth: true, Code: http.StatusOK,
+			BodyMatch: `"last_status_code":503`, BodyNotMatch: `"secret"`},
+		{Method: http.MethodPost, Path: "/tyk/webhooks/dead-letters/unknown/replay", AdminAuth: true, Code: http.StatusNotFound},
+		{Method: http.MethodDelete, Path: "/tyk/webhooks/dead-letters/unknown", AdminAuth: true, Code: http.StatusNotFound},
+	}...)
+
+	failing.Store(false)
+	_, _ = ts.Run(t, test.TestCase{Method: http.MethodPost, Path: "/tyk/webhooks/dead-letters/" + deadLetter.ID + "/replay",
+		AdminAuth: true, Code: http.StatusOK, BodyMatch: `"attempts":0`})
+
+	require.Eventually(t, func() bool {
+		_, err := ts.Gw.webhookDeliveries().store.Get(deadLetter.ID)
+		return err != nil
+	}, 10*time.Second, 50*time.Millisecond, "the replayed delivery should succeed")
+
+	assert.Equal(t, int32(4), attempts.Load())
+	assert.Empty(t, deadLetters())
+	_, _ = ts.Run(t, test.TestCase{Method: http.MethodGet, Path: "/tyk/webhooks/outbox?api_id=" + apiID, AdminAuth: true,
+		Code: http.StatusOK, BodyMatch: `^\[\]`})
+}
+
+func TestWebhookDelivery_NoFreeSlot(t *testing.T) {
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.Webhooks.MaxConcurrentDeliveries = 1
+	})
+	defer ts.Close()
+
+	var attempts atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		attempts.Add(1)
+	}))
+	defer receiver.Close()
+
+	dispatcher := ts.Gw.webhookDeliveries()
+	dispatcher.sem <- struct{}{}
+
+	req, err := http.NewRequest(http.MethodPost, receiver.URL, nil)
+	require.NoError(t, err)
+	delivery := webhook.NewDelivery(req, "{}", time.Now())
+	dispatcher.Deliver(context.Background(), delivery)
+	assert.Equal(t, int32(0), attempts.Load(), "the delivery should be left to the dispatcher")
+
+	<-dispatcher.sem
+	require.Eventually(t, func() bool {
+		_, err := dispatcher.store.Get(delivery.ID)
+		return err != nil
+	}, 10*time.Second, 50*time.Millisecond, "the dispatcher should deliver it")
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestWebhookDelivery_OutboxUnavailable(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	var attempts atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		attempts.Add(1)
+	}))
+	defer receiver.Close()
+
+	dispatcher := ts.Gw.webhookDeliveries()
+	dispatcher.store = webhook.NewStore(&storage.RedisCluster{
+		KeyPrefix:         webhook.KeyPrefix,
+		ConnectionHandler: storage.NewConnectionHandler(context.Background()),
+	})
+
+	req, err := http.NewRequest(http.MethodPost, receiver.URL, nil)
+	require.NoError(t, err)
+	dispatcher.Deliver(context.Background(), webhook.NewDelivery(req, "{}", time.Now()))
+	assert.Equal(t, int32(1), attempts.Load(), "the delivery should be attempted without the outbox")
+}
@@ -54,6 +54,8 @@ const (
 	EventTokenUpdated = event.TokenUpdated
 	// EventTokenDeleted is an alias maintained for backwards compatibility.
 	EventTokenDeleted = event.TokenDeleted
+	// EventResponseValidationFailed is an alias for the ResponseValidationFailed event.
+	EventResponseValidationFailed = event.ResponseValidationFailed
 )
 
 type EventHostStatusMeta struct {
@@ -95,6 +97,15 @@ type EventTriggerExceededMeta struct {
 	UsagePercentage int64  `json:"usage_percentage"`
 }
 
+// EventResponseValidationFailedMeta is the metadata structure for an upstream response failing validation.
+type EventResponseValidationFailedMeta struct {
+	EventMetaDefault
+	Path       string
+	Method     string
+	StatusCode int
+	Reason     string
+}
+
 type EventTokenMeta struct {
 	EventMetaDefault
 	Org string
@@ -112,8 +123,21 @@ func (gw *Gateway) EventHandlerByName(handlerConf apidef.EventHandlerTriggerConf
 		return h, err
 	case EH_WebHook:
 		h := &WebHookHandler{Gw: gw}
+		if spec != nil {
+			h.apiID = spec.APIID
+		}
 		err := h.Init(conf)
 		return h, err
+	case event.KafkaHandler, event.NATSHandler, event.AMQPHandler:
+		h := &EventSinkHandler{kind: handlerConf.Handler, Gw: gw}
+		if spec != nil {
+			h.apiID = spec.APIID
+		}
+		err := h.Init(conf)
+		if err == nil && spec != nil {
+			spec.AddUnloadHook(h.Close)
+		}
+		return h, err
 	case EH_JSVMHandler:
 		// Load the globals and file here
 		if spec != nil {
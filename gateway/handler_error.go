@@ -18,6 +18,7 @@ import (
 	"github.com/TykTechnologies/tyk/config"
 
 	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/redaction"
 	"github.com/TykTechnologies/tyk/request"
 )
 
@@ -261,16 +262,17 @@ func (e *ErrorHandler) HandleError(w http.ResponseWriter, r *http.Request, errMs
 		rawRequest := ""
 		rawResponse := ""
 		if recordDetail(r, e.Spec) {
+			redactionRules := redaction.ForTarget(e.Spec.requestRedactionRules(r), apidef.RedactionTargetAnalytics)
 
 			// Get the wire format representation
 
 			var wireFormatReq bytes.Buffer
 			r.Write(&wireFormatReq)
-			rawRequest = base64.StdEncoding.EncodeToString(wireFormatReq.Bytes())
+			rawRequest = base64.StdEncoding.EncodeToString(redactWireRequest(wireFormatReq.Bytes(), redactionRules, e.Spec.maxRedactedSize()))
 
 			var wireFormatRes bytes.Buffer
 			response.Write(&wireFormatRes)
-			rawResponse = base64.StdEncoding.EncodeToString(wireFormatRes.Bytes())
+			rawResponse = base64.StdEncoding.EncodeToString(redactWireResponse(wireFormatRes.Bytes(), redactionRules, e.Spec.maxRedactedSize()))
 
 		}
 
@@ -12,6 +12,7 @@ import (
 
 	"github.com/TykTechnologies/tyk/ctx"
 	"github.com/TykTechnologies/tyk/internal/httputil"
+	"github.com/TykTechnologies/tyk/internal/redaction"
 
 	graphqlinternal "github.com/TykTechnologies/tyk/internal/graphql"
 
@@ -205,14 +206,20 @@ func (s *SuccessHandler) RecordHit(r *http.Request, timing analytics.Latency, co
 			tags = append(tags, "cached-response")
 		}
 
+		if ctxResponseValidationFailed(r) {
+			tags = append(tags, responseValidationFailedTag)
+		}
+
 		rawRequest := ""
 		rawResponse := ""
 
 		if recordDetail(r, s.Spec) {
+			redactionRules := redaction.ForTarget(s.Spec.requestRedactionRules(r), apidef.RedactionTargetAnalytics)
+
 			// Get the wire format representation
 			var wireFormatReq bytes.Buffer
 			r.Write(&wireFormatReq)
-			rawRequest = base64.StdEncoding.EncodeToString(wireFormatReq.Bytes())
+			rawRequest = base64.StdEncoding.EncodeToString(redactWireRequest(wireFormatReq.Bytes(), redactionRules, s.Spec.maxRedactedSize()))
 			// responseCopy, unlike requestCopy, can be nil
 			// here - if the response was cached in
 			// mw_redis_cache, RecordHit gets passed a nil
@@ -235,7 +242,7 @@ func (s *SuccessHandler) RecordHit(r *http.Request, timing analytics.Latency, co
 				var wireFormatRes bytes.Buffer
 				responseCopy.Write(&wireFormatRes)
 				responseCopy.Body = ioutil.NopCloser(bytes.NewBuffer(responseContent))
-				rawResponse = base64.StdEncoding.EncodeToString(wireFormatRes.Bytes())
+				rawResponse = base64.StdEncoding.EncodeToString(redactWireResponse(wireFormatRes.Bytes(), redactionRules, s.Spec.maxRedactedSize()))
 			}
 		}
 
@@ -0,0 +1,573 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+var (
+	errJSMemoryLimit      = errors.New("JS middleware exceeded the memory limit")
+	errJSPromiseUnsettled = errors.New("JS middleware returned a promise which is never settled")
+)
+
+// gojaVM runs the JSVM code with goja, an ES2020+ engine. Each invocation runs on a fresh runtime with the
+// loaded programs, as the otto engine runs each invocation on a copy of its VM, so no global state is kept
+// between invocations. The pool holds runtimes prepared ahead of the invocations.
+type gojaVM struct {
+	jsvm *JSVM
+	conf apidef.JSRuntimeConfig
+
+	mu         sync.RWMutex
+	programs   []*goja.Program
+	generation int
+
+	pool chan *gojaRuntime
+}
+
+// gojaRuntime is a runtime with the loaded programs of a generation.
+type gojaRuntime struct {
+	*goja.Runtime
+	generation int
+
+	// inv is the invocation running on the runtime.
+	inv *gojaInvocation
+}
+
+// gojaInvocation tracks the asynchronous operations of an invocation, such as `fetch`. Their results are run
+// on the runtime by the invocation once it's waiting for the returned promise.
+type gojaInvocation struct {
+	ctx     context.Context
+	tasks   chan func()
+	pending int
+
+	// allocated is the memory allocated by the built-in functions, counted against maxMemory.
+	allocated int64
+	maxMemory int64
+	stop      func(error)
+}
+
+// charge counts memory allocated by the invocation, stopping it once it exceeds the limit.
+func (inv *gojaInvocation) charge(bytes int64) {
+	inv.allocated += bytes
+	if inv.maxMemory > 0 && inv.allocated > inv.maxMemory {
+		inv.stop(errJSMemoryLimit)
+	}
+}
+
+func (j *JSVM) initGoja(conf apidef.JSRuntimeConfig) error {
+	poolSize := conf.PoolSize
+	if poolSize <= 0 {
+		poolSize = runtime.GOMAXPROCS(0)
+	}
+
+	j.goja = &gojaVM{
+		jsvm: j,
+		conf: conf,
+		pool: make(chan *gojaRuntime, poolSize),
+	}
+
+	if err := j.goja.load("tyk.js", strings.NewReader(coreJS+"\n"+tykJSResponseJS+"\n"+fetchJS)); err != nil {
+		return err
+	}
+
+	// Load user's TykJS on top, if any
+	if path := j.Gw.GetConfig().TykJSPath; path != "" {
+		f, err := os.Open(path)
+		if err == nil {
+			err = j.goja.load(path, f)
+			f.Close()
+
+			if err != nil {
+				j.Log.WithError(err).Error("Could not load user's TykJS")
+			}
+		}
+	}
+
+	return nil
+}
+
+// load compiles and runs the code on a runtime. The runtimes created afterwards run the code when created.
+func (g *gojaVM) load(name string, src io.Reader) error {
+	code, err := ioutil.ReadAll(src)
+	if err != nil {
+		return err
+	}
+
+	program, err := goja.Compile(name, string(code), false)
+	if err != nil {
+		return err
+	}
+
+	rt, err := g.get()
+	if err != nil {
+		return err
+	}
+
+	if _, err := rt.RunProgram(program); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.programs = append(g.programs, program)
+	g.generation++
+	rt.generation = g.generation
+	g.mu.Unlock()
+
+	g.put(rt)
+	return nil
+}
+
+// get returns an idle runtime with the current programs, creating it if there's none.
+func (g *gojaVM) get() (*gojaRuntime, error) {
+	g.mu.RLock()
+	generation := g.generation
+	g.mu.RUnlock()
+
+	for {
+		select {
+		case rt := <-g.pool:
+			if rt.generation == generation {
+				return rt, nil
+			}
+		default:
+			return g.newRuntime()
+		}
+	}
+}
+
+// put adds the runtime to the pool, unless the pool is full or the programs changed.
+func (g *gojaVM) put(rt *gojaRuntime) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if rt.generation != g.generation {
+		return
+	}
+
+	select {
+	case g.pool <- rt:
+	default:
+	}
+}
+
+// refill prepares a runtime for a later invocation, replacing the one used by an invocation.
+func (g *gojaVM) refill() {
+	rt, err := g.newRuntime()
+	if err != nil {
+		g.jsvm.Log.WithError(err).Error("Failed to prepare a JS runtime")
+		return
+	}
+
+	g.put(rt)
+}
+
+func (g *gojaVM) newRuntime() (*gojaRuntime, error) {
+	g.mu.RLock()
+	programs, generation := g.programs, g.generation
+	g.mu.RUnlock()
+
+	rt := &gojaRuntime{Runtime: goja.New(), generation: generation}
+	if g.conf.MaxCallStackSize > 0 {
+		rt.SetMaxCallStackSize(g.conf.MaxCallStackSize)
+	}
+
+	g.setAPI(rt)
+
+	if g.conf.MaxMemory > 0 {
+		if err := rt.limitMemory(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, program := range programs {
+		if _, err := rt.RunProgram(program); err != nil {
+			return nil, err
+		}
+	}
+
+	return rt, nil
+}
+
+// setAPI adds the TykJS functions to the runtime.
+func (g *gojaVM) setAPI(rt *gojaRuntime) {
+	j := g.jsvm
+	set := func(name string, fn interface{}) {
+		if err := rt.Set(name, fn); err != nil {
+			j.Log.WithError(err).Errorf("Failed to set %s", name)
+		}
+	}
+
+	// orUndefined returns undefined if the function failed, as the otto engine does
+	orUndefined := func(out string, err error) goja.Value {
+		if err != nil {
+			return goja.Undefined()
+		}
+		return rt.ToValue(out)
+	}
+
+	set("log", func(msg string) { j.jsLog(msg) })
+	set("rawlog", func(msg string) { j.jsRawLog(msg) })
+
+	set("b64dec", func(in string) goja.Value {
+		out, err := b64dec(in)
+		if err != nil {
+			j.Log.WithError(err).Error("Failed to base64 decode")
+		}
+		return orUndefined(out, err)
+	})
+	set("b64enc", func(in string) string {
+		return base64.StdEncoding.EncodeToString([]byte(in))
+	})
+	set("rawb64dec", func(in string) goja.Value {
+		out, err := base64.RawStdEncoding.DecodeString(in)
+		if err != nil {
+			j.Log.WithError(err).Error("Failed to base64 decode")
+		}
+		return orUndefined(string(out), err)
+	})
+	set("rawb64enc", func(in string) string {
+		return base64.RawStdEncoding.EncodeToString([]byte(in))
+	})
+
+	set("TykMakeHttpRequest", func(call goja.FunctionCall) goja.Value {
+		if goja.IsUndefined(call.Argument(0)) {
+			return goja.Undefined()
+		}
+		return orUndefined(j.makeHTTPRequest(call.Argument(0).String()))
+	})
+	set("TykGetKeyData", j.getKeyData)
+	set("TykSetKeyData", j.setKeyData)
+	set("TykBatchRequest", func(requestSet string) goja.Value {
+		return orUndefined(j.batchRequest(requestSet))
+	})
+
+	set("_tykFetch", func(call goja.FunctionCall) goja.Value {
+		inv := rt.inv
+		if inv == nil {
+			panic(rt.NewTypeError("fetch can only be called while handling a request"))
+		}
+
+		req := jsFetchRequest{}
+		if err := json.Unmarshal([]byte(call.Argument(1).String()), &req); err != nil {
+			panic(rt.NewTypeError("invalid fetch options: %s", err))
+		}
+		resource := call.Argument(0).String()
+
+		promise, resolve, reject := rt.NewPromise()
+		inv.pending++
+
+		go func() {
+			res, err := j.fetch(inv.ctx, resource, req)
+
+			task := func() {
+				inv.pending--
+				if err != nil {
+					reject(rt.NewTypeError("fetch failed: %s", err))
+					return
+				}
+				resolve(res)
+			}
+
+			select {
+			case inv.tasks <- task:
+			case <-inv.ctx.Done():
+			}
+		}()
+
+		return rt.ToValue(promise)
+	})
+}
+
+// run evaluates the expression, waiting for the promise it returns if the JS code is asynchronous.
+func (g *gojaVM) run(expr string, timeout time.Duration) (string, error) {
+	rt, err := g.get()
+	if err != nil {
+		return "", err
+	}
+
+	// the runtime isn't reused, so the next invocation doesn't see the state left by this one
+	defer func() { go g.refill() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rt.inv = &gojaInvocation{ctx: ctx, tasks: make(chan func()), maxMemory: g.conf.MaxMemory}
+
+	var (
+		mu       sync.Mutex
+		finished bool
+		reason   error
+		stopped  = make(chan struct{})
+	)
+
+	// stop interrupts the invocation, unless it has finished already
+	stop := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if finished || reason != nil {
+			return
+		}
+
+		reason = err
+		rt.Interrupt(err)
+		close(stopped)
+	}
+	rt.inv.stop = stop
+
+	timer := time.AfterFunc(timeout, func() {
+		stop(fmt.Errorf("JS middleware timed out after %s", timeout))
+	})
+	defer timer.Stop()
+
+	value, err := rt.RunString(expr)
+	if err == nil {
+		value, err = rt.await(value, stopped)
+	}
+
+	mu.Lock()
+	finished = true
+	mu.Unlock()
+
+	if reason != nil {
+		return "", reason
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return value.String(), nil
+}
+
+// await waits for the value to be settled if it's a promise, running the asynchronous tasks of the invocation.
+func (rt *gojaRuntime) await(value goja.Value, stopped <-chan struct{}) (goja.Value, error) {
+	promise, ok := value.Export().(*goja.Promise)
+	if !ok {
+		return value, nil
+	}
+
+	for promise.State() == goja.PromiseStatePending {
+		if rt.inv.pending == 0 {
+			return nil, errJSPromiseUnsettled
+		}
+
+		select {
+		case task := <-rt.inv.tasks:
+			task()
+		case <-stopped:
+			return nil, errors.New("interrupted")
+		}
+	}
+
+	if promise.State() == goja.PromiseStateRejected {
+		return nil, fmt.Errorf("JS middleware promise rejected: %s", promise.Result())
+	}
+
+	return promise.Result(), nil
+}
+
+// limitMemory wraps the built-in functions creating strings and arrays, so the memory they allocate is
+// charged to the running invocation. It isn't charged while the loaded programs run.
+func (rt *gojaRuntime) limitMemory() error {
+	value, err := rt.RunString(memoryLimitJS)
+	if err != nil {
+		return err
+	}
+
+	install, ok := goja.AssertFunction(value)
+	if !ok {
+		return errors.New("memory limit script is not a function")
+	}
+
+	_, err = install(goja.Undefined(), rt.ToValue(func(bytes int64) {
+		if rt.inv != nil && bytes > 0 {
+			rt.inv.charge(bytes)
+		}
+	}))
+	return err
+}
+
+// memoryLimitJS returns a function wrapping the built-in functions creating strings and arrays with the charge
+// of the memory they allocate, estimated as the length of the strings and 16 bytes per array element or object
+// property. The functions are wrapped before the user's code runs, which can't reach the original ones.
+const memoryLimitJS = `
+(function (charge) {
+	const apply = Reflect.apply, isArray = Array.isArray, keys = Object.keys, defineProperty = Object.defineProperty;
+
+	const sizeOf = (value) => {
+		switch (typeof value) {
+		case "string":
+			return value.length;
+		case "object":
+			if (value === null) {
+				return 0;
+			}
+			return (isArray(value) ? value.length : keys(value).length) * 16;
+		default:
+			return 16;
+		}
+	};
+
+	// wrap replaces the methods of an object, charging the size of their results or, for the methods adding
+	// their arguments to an array, of their arguments
+	const wrap = (object, names, byArguments) => {
+		for (const name of names) {
+			const original = object[name];
+			defineProperty(object, name, {
+				value: function (...args) {
+					const result = apply(original, this, args);
+
+					let size = 0;
+					if (byArguments) {
+						for (let i = 0; i < args.length; i++) {
+							size += sizeOf(args[i]);
+						}
+					} else {
+						size = sizeOf(result);
+					}
+
+					charge(size);
+					return result;
+				},
+				writable: true,
+				configurable: true,
+			});
+		}
+	};
+
+	wrap(String.prototype, ["concat", "padEnd", "padStart", "repeat", "replace", "replaceAll", "split"]);
+	wrap(Array.prototype, ["concat", "fill", "filter", "flat", "flatMap", "join", "map", "slice"]);
+	wrap(Array.prototype, ["push", "splice", "unshift"], true);
+	wrap(Array, ["from", "of"]);
+	wrap(JSON, ["parse", "stringify"]);
+})
+`
+
+// jsFetchRequest holds the options of a `fetch` call.
+type jsFetchRequest struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// fetch makes the request of a `fetch` call, returning the properties of the JS response.
+func (j *JSVM) fetch(ctx context.Context, resource string, req jsFetchRequest) (map[string]interface{}, error) {
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, strings.ToUpper(req.Method), resource, body)
+	if err != nil {
+		return nil, err
+	}
+
+	ignoreCanonical := j.Gw.GetConfig().IgnoreCanonicalMIMEHeaderKey
+	for k, v := range req.Headers {
+		setCustomHeader(r.Header, k, v, ignoreCanonical)
+	}
+	r.Close = true
+
+	resp, err := j.httpClient(r.Host).Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]interface{}, len(resp.Header))
+	for k, v := range resp.Header {
+		headers[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+
+	return map[string]interface{}{
+		"status":     resp.StatusCode,
+		"statusText": strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+		"url":        resp.Request.URL.String(),
+		"headers":    headers,
+		"body":       string(respBody),
+	}, nil
+}
+
+// fetchJS implements `fetch` over the `_tykFetch` function, which resolves with the properties of the response.
+const fetchJS = `
+class TykFetchHeaders {
+	constructor(headers) {
+		this._headers = {}
+		for (const [name, value] of Object.entries(headers || {})) {
+			this._headers[name.toLowerCase()] = String(value)
+		}
+	}
+
+	get(name) {
+		const value = this._headers[String(name).toLowerCase()]
+		return value === undefined ? null : value
+	}
+
+	has(name) {
+		return String(name).toLowerCase() in this._headers
+	}
+
+	forEach(callback) {
+		for (const [name, value] of Object.entries(this._headers)) {
+			callback(value, name, this)
+		}
+	}
+}
+
+class TykFetchResponse {
+	constructor(res) {
+		this.status = res.status
+		this.statusText = res.statusText
+		this.ok = res.status >= 200 && res.status < 300
+		this.url = res.url
+		this.headers = new TykFetchHeaders(res.headers)
+		this._body = res.body
+	}
+
+	async text() {
+		return this._body
+	}
+
+	async json() {
+		return JSON.parse(this._body)
+	}
+}
+
+function fetch(resource, options = {}) {
+	let headers = options.headers || {}
+	if (headers instanceof TykFetchHeaders) {
+		headers = headers._headers
+	}
+
+	return _tykFetch(String(resource), JSON.stringify({
+		method: options.method || "GET",
+		headers: headers,
+		body: options.body == null ? "" : String(options.body),
+	})).then(res => new TykFetchResponse(res))
+}
+`
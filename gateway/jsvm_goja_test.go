@@ -0,0 +1,307 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/test"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+func (ts *Test) testPrepareGojaVirtualEndpoint(js string, conf apidef.JSRuntimeConfig) {
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = randStringBytes(8)
+		spec.Proxy.ListenPath = "/"
+		spec.JSRuntime = conf
+		spec.JSRuntime.Engine = apidef.JSEngineGoja
+		spec.ConfigData = map[string]interface{}{"greeting": "hello"}
+
+		UpdateAPIVersion(spec, "v1", func(v *apidef.VersionInfo) {
+			v.UseExtendedPaths = true
+			v.ExtendedPaths.Virtual = []apidef.VirtualMeta{{
+				ResponseFunctionName: "handler",
+				FunctionSourceType:   apidef.UseBlob,
+				FunctionSourceURI:    base64.StdEncoding.EncodeToString([]byte(js)),
+				Path:                 "/virtual",
+				Method:               http.MethodGet,
+			}}
+		})
+	})
+}
+
+func TestGojaVirtualEndpoint(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	t.Run("modern syntax", func(t *testing.T) {
+		ts.testPrepareGojaVirtualEndpoint(`
+const handler = (request, session, config) => {
+	const { greeting } = config.config_data
+	const names = [...(request.Params.name ?? []), "tyk"]
+	class Greeter {
+		#greeting = greeting
+		greet(name) { return `+"`${this.#greeting} ${name}`"+` }
+	}
+	const greeter = new Greeter()
+	return TykJsResponse({
+		Body: JSON.stringify(names.map(name => greeter.greet(name))),
+		Code: 200,
+		Headers: { "X-Upper": b64enc("tyk") },
+	}, session.meta_data)
+}`, apidef.JSRuntimeConfig{})
+
+		_, _ = ts.Run(t, test.TestCase{
+			Path: "/virtual?name=gateway", Code: http.StatusOK,
+			BodyMatch:    `\["hello gateway","hello tyk"\]`,
+			HeadersMatch: map[string]string{"X-Upper": "dHlr"},
+		})
+	})
+
+	t.Run("async fetch", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Method", r.Method)
+			_, _ = w.Write([]byte(`{"received":"` + string(body) + `","auth":"` + r.Header.Get("Authorization") + `"}`))
+		}))
+		defer upstream.Close()
+
+		ts.testPrepareGojaVirtualEndpoint(`
+async function handler(request, session, config) {
+	const [first, second] = await Promise.all([
+		fetch("`+upstream.URL+`", { method: "POST", body: "one", headers: { Authorization: "secret" } }),
+		fetch("`+upstream.URL+`", { method: "PUT", body: "two" }),
+	])
+	const data = await first.json()
+	return TykJsResponse({
+		Body: data.received + " " + (await second.json()).received,
+		Code: first.status,
+		Headers: {
+			"X-Auth": data.auth,
+			"X-Method": second.headers.get("x-method"),
+			"X-Ok": String(first.ok),
+		},
+	}, {})
+}`, apidef.JSRuntimeConfig{})
+
+		_, _ = ts.Run(t, test.TestCase{
+			Path: "/virtual", Code: http.StatusOK, BodyMatch: "^one two$",
+			HeadersMatch: map[string]string{"X-Auth": "secret", "X-Method": "PUT", "X-Ok": "true"},
+		})
+	})
+
+	t.Run("failed fetch", func(t *testing.T) {
+		ts.testPrepareGojaVirtualEndpoint(`
+async function handler() {
+	try {
+		await fetch("http://127.0.0.1:1/unreachable")
+	} catch (e) {
+		return TykJsResponse({ Body: e instanceof TypeError ? "caught" : "unexpected", Code: 502 }, {})
+	}
+}`, apidef.JSRuntimeConfig{})
+
+		_, _ = ts.Run(t, test.TestCase{Path: "/virtual", Code: http.StatusBadGateway, BodyMatch: "caught"})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		testCases := map[string]struct {
+			js   string
+			conf apidef.JSRuntimeConfig
+		}{
+			"exception":         {js: `function handler() { throw new Error("failed") }`},
+			"rejected promise":  {js: `async function handler() { throw new Error("failed") }`},
+			"unsettled promise": {js: `function handler() { return new Promise(() => {}) }`},
+			"timeout":           {js: `function handler() { while (true) {} }`, conf: apidef.JSRuntimeConfig{Timeout: 1}},
+			"memory limit": {
+				js:   `function handler() { const a = []; while (true) { a.push("x".repeat(1024) + a.length) } }`,
+				conf: apidef.JSRuntimeConfig{Timeout: 30, MaxMemory: 32 << 20},
+			},
+			"call stack size": {
+				js:   `function handler() { const f = n => f(n + 1); return f(0) }`,
+				conf: apidef.JSRuntimeConfig{MaxCallStackSize: 100},
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				ts.testPrepareGojaVirtualEndpoint(tc.js, tc.conf)
+
+				start := time.Now()
+				_, _ = ts.Run(t, test.TestCase{Path: "/virtual", Code: http.StatusInternalServerError})
+				assert.Less(t, time.Since(start), 10*time.Second)
+			})
+		}
+	})
+}
+
+func TestGojaDynamicMiddleware(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	spec := &APISpec{APIDefinition: &apidef.APIDefinition{
+		JSRuntime: apidef.JSRuntimeConfig{Engine: apidef.JSEngineGoja, PoolSize: 1},
+	}}
+
+	jsvm := JSVM{}
+	jsvm.Init(spec, logrus.NewEntry(log), ts.Gw)
+	require.NotNil(t, jsvm.goja)
+	require.Nil(t, jsvm.VM)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"tier":"gold"}`))
+	}))
+	defer upstream.Close()
+
+	js := `
+let calls = 0
+const asyncMid = new TykJS.TykMiddleware.NewMiddleware({})
+asyncMid.NewProcessRequest(async (request, session) => {
+	const { tier } = await (await fetch("` + upstream.URL + `")).json()
+	request.SetHeaders["X-Tier"] = tier
+	request.SetHeaders["X-Calls"] = String(++calls)
+	request.Body = request.Body.toUpperCase()
+	return asyncMid.ReturnData(request, session.meta_data)
+})`
+	require.NoError(t, jsvm.load("async.js", strings.NewReader(js)))
+
+	spec.JSVM = jsvm
+	dynMid := &DynamicMiddleware{
+		BaseMiddleware:      &BaseMiddleware{Spec: spec, Gw: ts.Gw},
+		MiddlewareClassName: "asyncMid",
+		Pre:                 true,
+	}
+
+	for i := 1; i <= 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader("body"))
+		err, code := dynMid.ProcessRequest(nil, req, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, "BODY", string(body))
+		assert.Equal(t, "gold", req.Header.Get("X-Tier"))
+
+		// each invocation runs on a fresh runtime
+		assert.Equal(t, "1", req.Header.Get("X-Calls"))
+	}
+
+	t.Run("fetch outside of a request", func(t *testing.T) {
+		assert.Error(t, jsvm.load("fetch.js", strings.NewReader(`fetch("`+upstream.URL+`")`)))
+	})
+
+	t.Run("session metadata", func(t *testing.T) {
+		require.NoError(t, jsvm.load("meta.js", strings.NewReader(`
+const metaMid = new TykJS.TykMiddleware.NewMiddleware({})
+metaMid.NewProcessRequest((request, session) => metaMid.ReturnData(request, { ...session.meta_data, updated: "new" }))`)))
+		spec.JSVM = jsvm
+
+		dynMid := &DynamicMiddleware{
+			BaseMiddleware:      &BaseMiddleware{Spec: spec, Gw: ts.Gw},
+			MiddlewareClassName: "metaMid",
+			UseSession:          true,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		ctxSetSession(req, &user.SessionState{MetaData: map[string]interface{}{"same": "same"}}, true, false)
+		_, code := dynMid.ProcessRequest(nil, req, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]interface{}{"same": "same", "updated": "new"}, ctxGetSession(req).MetaData)
+	})
+}
+
+func TestGojaVM_Pool(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	jsvm := JSVM{}
+	jsvm.Init(&APISpec{APIDefinition: &apidef.APIDefinition{
+		JSRuntime: apidef.JSRuntimeConfig{Engine: apidef.JSEngineGoja, PoolSize: 2},
+	}}, logrus.NewEntry(log), ts.Gw)
+
+	require.NoError(t, jsvm.load("counter.js", strings.NewReader(`var counter = 0; function count() { return ++counter }`)))
+
+	// each invocation runs on a fresh runtime, with the state of the loaded code
+	for i := 0; i < 3; i++ {
+		out, err := jsvm.run("count()")
+		require.NoError(t, err)
+		assert.Equal(t, "1", out)
+	}
+
+	out, err := jsvm.run("leaked = true; JSON = null; typeof leaked")
+	require.NoError(t, err)
+	assert.Equal(t, "boolean", out)
+	out, err = jsvm.run("typeof leaked + typeof JSON")
+	require.NoError(t, err)
+	assert.Equal(t, "undefinedobject", out)
+
+	// the runtimes created after loading code run it
+	require.NoError(t, jsvm.load("other.js", strings.NewReader(`var other = true; counter = 10`)))
+	out, err = jsvm.run("count() + String(other)")
+	require.NoError(t, err)
+	assert.Equal(t, "11true", out)
+
+	// a failing script isn't loaded in the runtimes created afterwards
+	assert.Error(t, jsvm.load("failing.js", strings.NewReader(`var failed = true; undefinedFunction()`)))
+	out, err = jsvm.run("typeof failed")
+	require.NoError(t, err)
+	assert.Equal(t, "undefined", out)
+
+	// interrupted runtimes aren't reused
+	jsvm.Timeout = 100 * time.Millisecond
+	_, err = jsvm.run("counter = 100; while (true) {}")
+	assert.ErrorContains(t, err, "timed out")
+
+	for i := 0; i < 3; i++ {
+		out, err = jsvm.run("counter")
+		require.NoError(t, err)
+		assert.Equal(t, "10", out)
+	}
+}
+
+func TestGojaVM_MaxMemory(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	jsvm := JSVM{}
+	jsvm.Init(&APISpec{APIDefinition: &apidef.APIDefinition{
+		JSRuntime: apidef.JSRuntimeConfig{Engine: apidef.JSEngineGoja, Timeout: 30, MaxMemory: 1 << 20},
+	}}, logrus.NewEntry(log), ts.Gw)
+
+	// the loaded code isn't charged
+	require.NoError(t, jsvm.load("large.js", strings.NewReader(`var large = "x".repeat(2 << 20)`)))
+
+	out, err := jsvm.run(`const a = []; for (let i = 0; i < 100; i++) { a.push("x".repeat(1024)) }; a.join("").length`)
+	require.NoError(t, err)
+	assert.Equal(t, "102400", out)
+
+	testCases := map[string]string{
+		"array":  `const a = []; while (true) { a.push("x".repeat(1024) + a.length) }`,
+		"string": `let s = ""; while (true) { s = s.concat("x".repeat(1024)) }`,
+		"fill":   `new Array(1 << 20).fill(0)`,
+		"caught": `const a = []; while (true) { try { a.push("x".repeat(1024)) } catch (e) {} }`,
+	}
+
+	for name, js := range testCases {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			_, err := jsvm.run(js)
+			assert.ErrorIs(t, err, errJSMemoryLimit)
+			assert.Less(t, time.Since(start), 10*time.Second)
+		})
+	}
+
+	// each invocation has its own limit
+	out, err = jsvm.run(`"x".repeat(1000).length`)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", out)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/TykTechnologies/tyk/header"
 	"github.com/TykTechnologies/tyk/internal/cache"
 	"github.com/TykTechnologies/tyk/internal/event"
+	"github.com/TykTechnologies/tyk/internal/httputil"
 	"github.com/TykTechnologies/tyk/internal/middleware"
 	"github.com/TykTechnologies/tyk/internal/otel"
 	"github.com/TykTechnologies/tyk/internal/policy"
@@ -712,7 +713,7 @@ func handleOtelTracedResponse(rh TykResponseHandler, rw http.ResponseWriter, res
 }
 
 func shouldPerformTracing(rh TykResponseHandler, baseMw *BaseTykResponseHandler) bool {
-	return rh.Name() != "ResponseCacheMiddleware" || baseMw.Spec.CacheOptions.EnableCache
+	return rh.Name() != "ResponseCacheMiddleware" || baseMw.Spec.CacheOptions.EnableCache || baseMw.Spec.graphQLCacheEnabled()
 }
 
 func parseForm(r *http.Request) {
@@ -754,3 +755,20 @@ func (b *BaseTykResponseHandler) HandleResponse(rw http.ResponseWriter, res *htt
 }
 
 func (b *BaseTykResponseHandler) HandleError(writer http.ResponseWriter, h *http.Request) {}
+
+// responseConditionMatches returns true if the response matches the condition, a nil condition matches all responses.
+func responseConditionMatches(cond *apidef.ResponseCondition, res *http.Response) bool {
+	if cond == nil {
+		return true
+	}
+
+	if len(cond.StatusCodes) > 0 && !httputil.MatchStatusCode(res.StatusCode, cond.StatusCodes) {
+		return false
+	}
+
+	if len(cond.ContentTypes) > 0 && !httputil.MatchMediaType(res.Header.Get(header.ContentType), cond.ContentTypes) {
+		return false
+	}
+
+	return true
+}
@@ -55,7 +55,7 @@ func (a *APISpec) getMatchPathAndMethod(r *http.Request, mode URLStatus) (string
 		method    = r.Method
 	)
 
-	if mode == TransformedJQResponse || mode == HeaderInjectedResponse || mode == TransformedResponse {
+	if mode == TransformedJQResponse || mode == HeaderInjectedResponse || mode == TransformedResponse || mode == Redacted {
 		matchPath = ctxGetUrlRewritePath(r)
 		method = ctxGetRequestMethod(r)
 		if matchPath == "" {
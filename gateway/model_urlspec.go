@@ -44,6 +44,12 @@ func (u *URLSpec) modeSpecificSpec(mode URLStatus) (interface{}, bool) {
 		return &u.GoPluginMeta, true
 	case PersistGraphQL:
 		return &u.PersistGraphQL, true
+	case Redacted:
+		return &u.Redaction, true
+	case Routed:
+		return &u.Routing, true
+	case ContentPolicyEnforced:
+		return &u.ContentPolicy, true
 	default:
 		return nil, false
 	}
@@ -94,6 +100,12 @@ func (u *URLSpec) matchesMethod(method string) bool {
 		return method == u.PersistGraphQL.Method
 	case RateLimit:
 		return method == u.RateLimit.Method
+	case Redacted:
+		return method == u.Redaction.Method
+	case Routed:
+		return method == u.Routing.Method
+	case ContentPolicyEnforced:
+		return method == u.ContentPolicy.Method
 	default:
 		return false
 	}
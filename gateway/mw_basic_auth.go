@@ -6,10 +6,10 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/TykTechnologies/murmur3"
@@ -20,9 +20,17 @@ import (
 	"github.com/TykTechnologies/tyk/user"
 
 	"github.com/TykTechnologies/tyk/internal/cache"
+	"github.com/TykTechnologies/tyk/internal/crypto"
 )
 
-const defaultBasicAuthTTL int64 = 60
+const (
+	defaultBasicAuthTTL int64 = 60
+
+	defaultBasicAuthLockoutAttempts int64 = 5
+	defaultBasicAuthLockoutWindow   int64 = 300
+
+	basicAuthLockoutPrefix = "basic-auth-lockout-"
+)
 
 var basicAuthCache = cache.New(60, 3600)
 
@@ -34,6 +42,8 @@ type BasicAuthKeyIsValid struct {
 
 	bodyUserRegexp     *regexp.Regexp
 	bodyPasswordRegexp *regexp.Regexp
+
+	ldap ldapAuthenticator
 }
 
 func (k *BasicAuthKeyIsValid) Name() string {
@@ -67,6 +77,15 @@ func (k *BasicAuthKeyIsValid) EnabledForSpec() bool {
 		}
 	}
 
+	if k.Spec.BasicAuth.LDAP.Enabled {
+		if k.Spec.BasicAuth.LDAP.Server == "" {
+			k.Logger().Error("Basic Auth configured to use LDAP, but LDAP server is empty")
+			return false
+		}
+
+		k.ldap = newLDAPAuthenticator(k.Spec.BasicAuth.LDAP)
+	}
+
 	return true
 }
 
@@ -176,6 +195,10 @@ func (k *BasicAuthKeyIsValid) ProcessRequest(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	if k.ldap != nil {
+		return k.processLDAPAuth(w, r, username, password, token)
+	}
+
 	// Check if API key valid
 	keyName := username
 	logger := k.Logger().WithField("key", k.Gw.obfuscateKey(keyName))
@@ -199,11 +222,20 @@ func (k *BasicAuthKeyIsValid) ProcessRequest(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	if k.isLockedOut(keyName) {
+		logger.Warning("Attempted access with locked out user.")
+		return k.handleLockout(w, r, token)
+	}
+
 	if err := k.checkPassword(&session, password, logger); err != nil {
 		logger.WithError(err).Warn("Attempted access with existing user, failed password check.")
+		k.registerFailedLogin(keyName)
 		return k.handleAuthFail(w, r, token)
 	}
 
+	k.resetFailedLogins(keyName)
+	k.rehashPassword(&session, password, logger)
+
 	// Set session state on context, we will need it later
 	switch k.Spec.BaseIdentityProvidedBy {
 	case apidef.BasicAuthUser, apidef.UnsetAuth:
@@ -232,17 +264,118 @@ func (k *BasicAuthKeyIsValid) checkPassword(session *user.SessionState, plainPas
 			return errUnauthorized
 		}
 
+	case user.HashArgon2id, user.HashScrypt:
+		if err := k.compareHashAndPassword(string(session.BasicAuthData.Hash), session.BasicAuthData.Password, plainPassword, logger); err != nil {
+			return err
+		}
+
 	case user.HashBCrypt:
 		fallthrough
 
 	default:
-		if err := k.compareHashAndPassword(session.BasicAuthData.Password, plainPassword, logger); err != nil {
+		if err := k.compareHashAndPassword(user.HashBCrypt, session.BasicAuthData.Password, plainPassword, logger); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// rehashPassword upgrades the stored password hash of the session when it doesn't match
+// the configured algorithm or cost, and saves the session. It's only called after a
+// successful password check, when the plain password is known.
+func (k *BasicAuthKeyIsValid) rehashPassword(session *user.SessionState, plainPassword string, logger *logrus.Entry) {
+	if !k.Gw.GetConfig().BasicAuthHashOptions.RehashOnLogin {
+		return
+	}
+
+	algo := k.Gw.basicAuthHashAlgo()
+	if !crypto.PasswordNeedsRehash(string(session.BasicAuthData.Hash), session.BasicAuthData.Password, algo, k.Gw.basicAuthHashParams()) {
+		return
+	}
+
+	hashedPass, hashType, err := k.Gw.hashBasicAuthPassword(plainPassword)
+	if err != nil {
+		logger.WithError(err).Error("Could not rehash password, keeping the stored hash")
+		return
+	}
+
+	session.BasicAuthData.Password = hashedPass
+	session.BasicAuthData.Hash = hashType
+
+	lifetime := session.Lifetime(k.Spec.GetSessionLifetimeRespectsKeyExpiration(), k.Spec.SessionLifetime, k.Gw.GetConfig().ForceGlobalSessionLifetime, k.Gw.GetConfig().GlobalSessionLifetime)
+	if err := k.Gw.GlobalSessionManager.UpdateSession(session.KeyID, session, lifetime, false); err != nil {
+		logger.WithError(err).Error("Could not save rehashed password")
+		return
+	}
+
+	logger.WithField("hash_type", session.BasicAuthData.Hash).Info("Upgraded stored password hash.")
+}
+
+func (k *BasicAuthKeyIsValid) lockoutKey(keyName string) string {
+	return basicAuthLockoutPrefix + storage.HashKey(keyName, k.Gw.GetConfig().HashKeys)
+}
+
+func (k *BasicAuthKeyIsValid) lockoutConfig() (maxAttempts int64, window int64) {
+	conf := k.Gw.GetConfig().BasicAuthLockout
+
+	maxAttempts, window = conf.MaxAttempts, conf.Window
+	if maxAttempts <= 0 {
+		maxAttempts = defaultBasicAuthLockoutAttempts
+	}
+	if window <= 0 {
+		window = defaultBasicAuthLockoutWindow
+	}
+
+	return maxAttempts, window
+}
+
+// isLockedOut checks if the user reached the maximum number of failed logins.
+func (k *BasicAuthKeyIsValid) isLockedOut(keyName string) bool {
+	if !k.Gw.GetConfig().BasicAuthLockout.Enabled {
+		return false
+	}
+
+	val, err := k.Gw.GlobalSessionManager.Store().GetRawKey(k.lockoutKey(keyName))
+	if err != nil {
+		return false
+	}
+
+	attempts, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	maxAttempts, _ := k.lockoutConfig()
+	return attempts >= maxAttempts
+}
+
+// registerFailedLogin increments the failed login counter of the user. The counter
+// expires after the lockout window so the user is unlocked automatically.
+func (k *BasicAuthKeyIsValid) registerFailedLogin(keyName string) {
+	if !k.Gw.GetConfig().BasicAuthLockout.Enabled {
+		return
+	}
+
+	_, window := k.lockoutConfig()
+	k.Gw.GlobalSessionManager.Store().IncrememntWithExpire(k.lockoutKey(keyName), window)
+}
+
+func (k *BasicAuthKeyIsValid) resetFailedLogins(keyName string) {
+	if !k.Gw.GetConfig().BasicAuthLockout.Enabled {
+		return
+	}
+
+	k.Gw.GlobalSessionManager.Store().DeleteRawKey(k.lockoutKey(keyName))
+}
+
+func (k *BasicAuthKeyIsValid) handleLockout(w http.ResponseWriter, r *http.Request, token string) (error, int) {
+	AuthFailed(k, r, token)
+
+	reportHealthValue(k.Spec, KeyFailure, "-1")
+
+	return errors.New("User temporarily locked out due to too many failed login attempts"), http.StatusForbidden
+}
+
 func (k *BasicAuthKeyIsValid) handleAuthFail(w http.ResponseWriter, r *http.Request, token string) (error, int) {
 	// Fire Authfailed Event
 	AuthFailed(k, r, token)
@@ -253,26 +386,23 @@ func (k *BasicAuthKeyIsValid) handleAuthFail(w http.ResponseWriter, r *http.Requ
 	return k.requestForBasicAuth(w, "User not authorised")
 }
 
-func (k *BasicAuthKeyIsValid) doBcryptWithCache(cacheDuration int64, hashedPassword []byte, password []byte) error {
-	if err := bcrypt.CompareHashAndPassword(hashedPassword, password); err != nil {
+func (k *BasicAuthKeyIsValid) doCompareWithCache(algo string, cacheDuration int64, hashedPassword string, password string) error {
+	if err := crypto.ComparePassword(algo, hashedPassword, password); err != nil {
 		return err
 	}
 
 	hasher := murmur3.New64()
-	hasher.Write(password)
+	hasher.Write([]byte(password))
 
-	basicAuthCache.Set(string(hashedPassword), string(hasher.Sum(nil)), cacheDuration)
+	basicAuthCache.Set(hashedPassword, string(hasher.Sum(nil)), cacheDuration)
 
 	return nil
 }
 
-func (k *BasicAuthKeyIsValid) compareHashAndPassword(hash string, password string, logEntry *logrus.Entry) error {
-	passwordBytes := []byte(password)
-	hashBytes := []byte(hash)
-
+func (k *BasicAuthKeyIsValid) compareHashAndPassword(algo string, hash string, password string, logEntry *logrus.Entry) error {
 	if k.Spec.BasicAuth.DisableCaching {
 		logEntry.Debug("cache disabled")
-		return bcrypt.CompareHashAndPassword(hashBytes, passwordBytes)
+		return crypto.ComparePassword(algo, hash, password)
 	}
 
 	cacheTTL := defaultBasicAuthTTL // set a default TTL, then override based on BasicAuth.CacheTTL
@@ -282,20 +412,20 @@ func (k *BasicAuthKeyIsValid) compareHashAndPassword(hash string, password strin
 
 	cachedPass, inCache := basicAuthCache.Get(hash)
 	if !inCache {
-		logEntry.Debug("cache enabled: miss: bcrypt")
+		logEntry.Debug("cache enabled: miss: " + algo)
 		_, err, _ := cacheGroup.Do(hash+"."+password, func() (interface{}, error) {
-			return nil, k.doBcryptWithCache(cacheTTL, hashBytes, passwordBytes)
+			return nil, k.doCompareWithCache(algo, cacheTTL, hash, password)
 		})
 
 		return err
 	}
 
 	hasher := murmur3.New64()
-	hasher.Write(passwordBytes)
+	hasher.Write([]byte(password))
 
 	if cachedPass.(string) != string(hasher.Sum(nil)) {
-		logEntry.Warn("cache enabled: hit: failed auth: bcrypt")
-		return bcrypt.CompareHashAndPassword(hashBytes, passwordBytes)
+		logEntry.Warn("cache enabled: hit: failed auth: " + algo)
+		return crypto.ComparePassword(algo, hash, password)
 	}
 
 	logEntry.Debug("cache enabled: hit: success")
@@ -0,0 +1,396 @@
+package gateway
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mavricknz/ldap"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/cache"
+)
+
+const (
+	defaultLDAPAuthCacheTTL   int64 = 60
+	defaultLDAPAuthTimeout    int64 = 5
+	defaultLDAPUserFilter           = "(uid={username})"
+	defaultLDAPGroupAttribute       = "memberOf"
+
+	ldapUsernamePlaceholder = "{username}"
+)
+
+var (
+	ldapAuthCache = cache.New(defaultLDAPAuthCacheTTL, 3600)
+
+	errLDAPInvalidCredentials = errors.New("invalid LDAP credentials")
+
+	// newLDAPAuthenticator creates the authenticator used for an API, it is a variable so tests can replace it.
+	newLDAPAuthenticator = func(conf apidef.LDAPAuthConfig) ldapAuthenticator {
+		return &ldapDirectory{conf: conf}
+	}
+)
+
+// ldapIdentity is the user entry resolved from the directory after a successful bind.
+type ldapIdentity struct {
+	DN         string
+	Groups     []string
+	Attributes map[string][]string
+}
+
+// ldapAuthenticator verifies user credentials against a directory.
+type ldapAuthenticator interface {
+	// Authenticate returns errLDAPInvalidCredentials when the directory rejects the credentials.
+	Authenticate(username, password string) (*ldapIdentity, error)
+}
+
+// processLDAPAuth authenticates the user against the configured LDAP server and sets a virtual
+// session generated from the policies mapped to the groups of the user.
+func (k *BasicAuthKeyIsValid) processLDAPAuth(w http.ResponseWriter, r *http.Request, username, password, token string) (error, int) {
+	logger := k.Logger().WithField("key", k.Gw.obfuscateKey(username))
+
+	if k.isLockedOut(username) {
+		logger.Warning("Attempted access with locked out user.")
+		return k.handleLockout(w, r, token)
+	}
+
+	identity, err := k.ldapAuthenticate(username, password)
+	if err != nil {
+		if errors.Is(err, errLDAPInvalidCredentials) {
+			logger.Warning("Attempted access with invalid LDAP credentials.")
+			k.registerFailedLogin(username)
+		} else {
+			logger.WithError(err).Error("LDAP authentication failed")
+		}
+		return k.handleAuthFail(w, r, token)
+	}
+
+	k.resetFailedLogins(username)
+
+	policies := k.ldapPolicies(identity)
+	if len(policies) == 0 {
+		logger.Warning("No policy mapped to LDAP user.")
+		AuthFailed(k, r, token)
+		return errors.New("key not authorized: no matching policy found"), http.StatusForbidden
+	}
+
+	sessionID := k.Gw.generateToken(k.Spec.OrgID, fmt.Sprintf("%x", md5.Sum([]byte(username))))
+	session, exists := k.CheckSessionAndIdentityForValidKey(sessionID, r)
+	sessionID = session.KeyID
+	updateSession := false
+
+	if !exists {
+		session, err = k.Gw.generateSessionFromPolicy(policies[0], k.Spec.OrgID, true)
+		if err != nil {
+			logger.WithError(err).Error("Could not find a valid policy to apply to this LDAP user")
+			AuthFailed(k, r, token)
+			return errors.New("key not authorized: no matching policy"), http.StatusForbidden
+		}
+
+		updateSession = true
+	}
+
+	if !session.PoliciesEqualTo(policies) {
+		session.SetPolicies(policies...)
+		updateSession = true
+	}
+
+	if updateSession {
+		if err := k.ApplyPolicies(&session); err != nil {
+			logger.WithError(err).Error("Could not apply policies to LDAP user session")
+			AuthFailed(k, r, token)
+			return errors.New("key not authorized: could not apply policies"), http.StatusForbidden
+		}
+	}
+
+	metadata := k.ldapMetadata(identity)
+	if !reflect.DeepEqual(session.MetaData, metadata) {
+		session.MetaData = metadata
+		updateSession = true
+	}
+
+	if session.Alias != username {
+		session.Alias = username
+		updateSession = true
+	}
+
+	session.KeyID = sessionID
+	switch k.Spec.BaseIdentityProvidedBy {
+	case apidef.BasicAuthUser, apidef.UnsetAuth:
+		ctxSetSession(r, &session, updateSession, k.Gw.GetConfig().HashKeys)
+		if updateSession {
+			k.Gw.SessionCache.Set(session.KeyHash(), session.Clone(), cache.DefaultExpiration)
+		}
+	}
+
+	return nil, http.StatusOK
+}
+
+// ldapAuthenticate binds to the directory as the user, successful binds are cached
+// for the configured TTL so the directory isn't hit on every request.
+func (k *BasicAuthKeyIsValid) ldapAuthenticate(username, password string) (*ldapIdentity, error) {
+	conf := k.Spec.BasicAuth.LDAP
+
+	cacheTTL := conf.CacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = defaultLDAPAuthCacheTTL
+	}
+
+	hasher := sha256.New()
+	hasher.Write([]byte(k.Spec.APIID + "\x00" + username + "\x00" + password))
+	cacheKey := hex.EncodeToString(hasher.Sum(nil))
+
+	if cacheTTL > 0 {
+		if cached, found := ldapAuthCache.Get(cacheKey); found {
+			return cached.(*ldapIdentity), nil
+		}
+	}
+
+	res, err, _ := cacheGroup.Do("ldap."+cacheKey, func() (interface{}, error) {
+		return k.ldap.Authenticate(username, password)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	identity := res.(*ldapIdentity)
+	if cacheTTL > 0 {
+		ldapAuthCache.Set(cacheKey, identity, cacheTTL)
+	}
+
+	return identity, nil
+}
+
+// ldapPolicies returns the policies mapped to the groups of the user followed by the default policies.
+// Groups are matched against the mapping by DN or by common name, case insensitively.
+func (k *BasicAuthKeyIsValid) ldapPolicies(identity *ldapIdentity) []string {
+	conf := k.Spec.BasicAuth.LDAP
+
+	groupPolicies := make(map[string]string, len(conf.GroupPolicies))
+	for group, policyID := range conf.GroupPolicies {
+		groupPolicies[strings.ToLower(group)] = policyID
+	}
+
+	var policies []string
+	for _, group := range identity.Groups {
+		policyID, ok := groupPolicies[strings.ToLower(group)]
+		if !ok {
+			policyID, ok = groupPolicies[strings.ToLower(ldapCommonName(group))]
+		}
+
+		if ok && !contains(policies, policyID) {
+			policies = append(policies, policyID)
+		}
+	}
+
+	for _, policyID := range conf.DefaultPolicies {
+		if !contains(policies, policyID) {
+			policies = append(policies, policyID)
+		}
+	}
+
+	return policies
+}
+
+func (k *BasicAuthKeyIsValid) ldapMetadata(identity *ldapIdentity) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"ldap_dn": identity.DN,
+	}
+
+	for _, attr := range k.Spec.BasicAuth.LDAP.MetadataAttributes {
+		values := identity.Attributes[attr]
+		switch len(values) {
+		case 0:
+		case 1:
+			metadata[attr] = values[0]
+		default:
+			metadata[attr] = strings.Join(values, ",")
+		}
+	}
+
+	return metadata
+}
+
+// ldapCommonName returns the value of the first RDN of dn, e.g. `admins` for `cn=admins,ou=groups,dc=example,dc=com`.
+func ldapCommonName(dn string) string {
+	rdn := strings.SplitN(dn, ",", 2)[0]
+	if i := strings.Index(rdn, "="); i >= 0 {
+		return strings.TrimSpace(rdn[i+1:])
+	}
+
+	return rdn
+}
+
+// ldapEscapeDN escapes the special characters of an attribute value used in a DN, as per RFC 4514.
+func ldapEscapeDN(value string) string {
+	var sb strings.Builder
+	for i, c := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, c),
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(value)-1 && c == ' ':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+
+	return sb.String()
+}
+
+// ldapDirectory is the ldapAuthenticator talking to an LDAP server.
+type ldapDirectory struct {
+	conf apidef.LDAPAuthConfig
+}
+
+func (d *ldapDirectory) connect() (*ldap.LDAPConnection, error) {
+	port := d.conf.Port
+	if port == 0 {
+		port = 389
+		if d.conf.UseSSL {
+			port = 636
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         d.conf.Server,
+		InsecureSkipVerify: d.conf.SSLInsecureSkipVerify,
+	}
+
+	var conn *ldap.LDAPConnection
+	switch {
+	case d.conf.UseSSL:
+		conn = ldap.NewLDAPSSLConnection(d.conf.Server, port, tlsConfig)
+	case d.conf.StartTLS:
+		conn = ldap.NewLDAPTLSConnection(d.conf.Server, port, tlsConfig)
+	default:
+		conn = ldap.NewLDAPConnection(d.conf.Server, port)
+	}
+
+	timeout := d.conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultLDAPAuthTimeout
+	}
+
+	conn.NetworkConnectTimeout = time.Duration(timeout) * time.Second
+	conn.ReadTimeout = time.Duration(timeout) * time.Second
+
+	if err := conn.Connect(); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Authenticate binds as the user and reads the user entry to resolve its groups and attributes.
+func (d *ldapDirectory) Authenticate(username, password string) (*ldapIdentity, error) {
+	// an empty password would result in an unauthenticated bind which most servers accept
+	if username == "" || password == "" {
+		return nil, errLDAPInvalidCredentials
+	}
+
+	conn, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	identity := &ldapIdentity{}
+
+	if d.conf.UserDNTemplate != "" {
+		identity.DN = strings.ReplaceAll(d.conf.UserDNTemplate, ldapUsernamePlaceholder, ldapEscapeDN(username))
+		if err := d.bind(conn, identity.DN, password); err != nil {
+			return nil, err
+		}
+
+		if d.conf.BaseDN == "" {
+			return identity, nil
+		}
+
+		entry, err := d.searchUser(conn, username)
+		if err != nil {
+			return nil, err
+		}
+
+		d.fillIdentity(identity, entry)
+		return identity, nil
+	}
+
+	if err := conn.Bind(d.conf.SearchBindDN, d.conf.SearchBindPassword); err != nil {
+		return nil, fmt.Errorf("search bind failed: %w", err)
+	}
+
+	entry, err := d.searchUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	identity.DN = entry.DN
+	if err := d.bind(conn, identity.DN, password); err != nil {
+		return nil, err
+	}
+
+	d.fillIdentity(identity, entry)
+	return identity, nil
+}
+
+func (d *ldapDirectory) bind(conn *ldap.LDAPConnection, dn, password string) error {
+	err := conn.Bind(dn, password)
+
+	var ldapErr *ldap.LDAPError
+	if errors.As(err, &ldapErr) && ldapErr.ResultCode == ldap.LDAPResultInvalidCredentials {
+		return errLDAPInvalidCredentials
+	}
+
+	return err
+}
+
+func (d *ldapDirectory) searchUser(conn *ldap.LDAPConnection, username string) (*ldap.Entry, error) {
+	filter := d.conf.UserFilter
+	if filter == "" {
+		filter = defaultLDAPUserFilter
+	}
+	filter = strings.ReplaceAll(filter, ldapUsernamePlaceholder, ldap.EscapeFilterValue(username))
+
+	attributes := append([]string{d.groupAttribute()}, d.conf.MetadataAttributes...)
+	req := ldap.NewSearchRequest(
+		d.conf.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		filter,
+		attributes,
+		nil)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// an unknown user is reported the same way as a wrong password
+	if len(res.Entries) != 1 {
+		return nil, errLDAPInvalidCredentials
+	}
+
+	return res.Entries[0], nil
+}
+
+func (d *ldapDirectory) fillIdentity(identity *ldapIdentity, entry *ldap.Entry) {
+	identity.Groups = entry.GetAttributeValues(d.groupAttribute())
+	identity.Attributes = make(map[string][]string, len(d.conf.MetadataAttributes))
+	for _, attr := range d.conf.MetadataAttributes {
+		identity.Attributes[attr] = entry.GetAttributeValues(attr)
+	}
+}
+
+func (d *ldapDirectory) groupAttribute() string {
+	if d.conf.GroupAttribute == "" {
+		return defaultLDAPGroupAttribute
+	}
+
+	return d.conf.GroupAttribute
+}
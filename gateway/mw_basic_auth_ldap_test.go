@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/test"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+type fakeLDAPDirectory struct {
+	users map[string]string
+	ident map[string]*ldapIdentity
+	calls int32
+}
+
+func (f *fakeLDAPDirectory) Authenticate(username, password string) (*ldapIdentity, error) {
+	atomic.AddInt32(&f.calls, 1)
+
+	if pass, ok := f.users[username]; !ok || pass != password {
+		return nil, errLDAPInvalidCredentials
+	}
+
+	return f.ident[username], nil
+}
+
+func TestBasicAuthLDAP(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	directory := &fakeLDAPDirectory{
+		users: map[string]string{
+			"alice": "alice-pass",
+			"bob":   "bob-pass",
+			"eve":   "eve-pass",
+		},
+		ident: map[string]*ldapIdentity{
+			"alice": {
+				DN:         "uid=alice,ou=people,dc=example,dc=com",
+				Groups:     []string{"cn=admins,ou=groups,dc=example,dc=com"},
+				Attributes: map[string][]string{"mail": {"alice@example.com"}},
+			},
+			"bob": {
+				DN:     "uid=bob,ou=people,dc=example,dc=com",
+				Groups: []string{"CN=Developers,OU=Groups,DC=example,DC=com"},
+			},
+			"eve": {
+				DN: "uid=eve,ou=people,dc=example,dc=com",
+			},
+		},
+	}
+
+	original := newLDAPAuthenticator
+	newLDAPAuthenticator = func(apidef.LDAPAuthConfig) ldapAuthenticator {
+		return directory
+	}
+	defer func() {
+		newLDAPAuthenticator = original
+	}()
+
+	const apiID = "ldap-api"
+	accessRights := map[string]user.AccessDefinition{apiID: {APIID: apiID}}
+
+	adminsPolicy := ts.CreatePolicy(func(p *user.Policy) {
+		p.AccessRights = accessRights
+		p.Rate = 100
+	})
+	devsPolicy := ts.CreatePolicy(func(p *user.Policy) {
+		p.AccessRights = accessRights
+		p.Rate = 10
+	})
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = apiID
+		spec.OrgID = "default"
+		spec.UseBasicAuth = true
+		spec.UseKeylessAccess = false
+		spec.Proxy.ListenPath = "/"
+		spec.BasicAuth.LDAP = apidef.LDAPAuthConfig{
+			Enabled: true,
+			Server:  "ldap.example.com",
+			GroupPolicies: map[string]string{
+				"cn=admins,ou=groups,dc=example,dc=com": adminsPolicy,
+				"developers":                            devsPolicy,
+			},
+			MetadataAttributes: []string{"mail"},
+		}
+	})
+
+	ts.Run(t, []test.TestCase{
+		{Method: http.MethodGet, Path: "/", Code: http.StatusUnauthorized, BodyMatch: "Authorization field missing"},
+		{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("alice", "wrong")}, Code: http.StatusUnauthorized},
+		{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("mallory", "pass")}, Code: http.StatusUnauthorized},
+		{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("alice", "alice-pass")}, Code: http.StatusOK},
+		{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("bob", "bob-pass")}, Code: http.StatusOK},
+		// no group mapped to a policy
+		{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("eve", "eve-pass")}, Code: http.StatusForbidden},
+	}...)
+
+	t.Run("virtual session", func(t *testing.T) {
+		sessionID := ts.Gw.generateToken("default", "6384e2b2184bcbf58eccf10ca7a6563c") // md5("alice")
+		session, found := ts.Gw.GlobalSessionManager.SessionDetail("default", sessionID, false)
+		assert.True(t, found)
+		assert.Equal(t, []string{adminsPolicy}, session.PolicyIDs())
+		assert.Equal(t, "alice", session.Alias)
+		assert.Equal(t, "uid=alice,ou=people,dc=example,dc=com", session.MetaData["ldap_dn"])
+		assert.Equal(t, "alice@example.com", session.MetaData["mail"])
+		assert.Equal(t, float64(100), session.Rate)
+	})
+
+	t.Run("successful binds are cached", func(t *testing.T) {
+		calls := atomic.LoadInt32(&directory.calls)
+
+		_, _ = ts.Run(t, test.TestCase{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("alice", "alice-pass")}, Code: http.StatusOK})
+		assert.Equal(t, calls, atomic.LoadInt32(&directory.calls))
+
+		_, _ = ts.Run(t, test.TestCase{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("alice", "wrong")}, Code: http.StatusUnauthorized})
+		assert.Equal(t, calls+1, atomic.LoadInt32(&directory.calls))
+	})
+}
+
+func TestLDAPHelpers(t *testing.T) {
+	assert.Equal(t, "admins", ldapCommonName("cn=admins,ou=groups,dc=example,dc=com"))
+	assert.Equal(t, "admins", ldapCommonName("admins"))
+
+	assert.Equal(t, `john\, doe`, ldapEscapeDN("john, doe"))
+	assert.Equal(t, `\#admin\=1`, ldapEscapeDN("#admin=1"))
+	assert.Equal(t, `\ x\ `, ldapEscapeDN(" x "))
+
+	_, err := (&ldapDirectory{}).Authenticate("user", "")
+	assert.ErrorIs(t, err, errLDAPInvalidCredentials)
+}
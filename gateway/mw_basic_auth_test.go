@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/TykTechnologies/tyk/config"
 	"github.com/TykTechnologies/tyk/storage"
 	"github.com/TykTechnologies/tyk/test"
 	"github.com/TykTechnologies/tyk/user"
@@ -151,6 +152,8 @@ func TestBasicAuthHashKeyFunc(t *testing.T) {
 		{"murmur32", "murmur32"},
 		{"murmur64", "murmur64"},
 		{"murmur128", "murmur128"},
+		{"argon2id", "argon2id"},
+		{"scrypt", "scrypt"},
 		{"invalid", "bcrypt"},
 	}
 
@@ -182,6 +185,93 @@ func TestBasicAuthHashKeyFunc(t *testing.T) {
 
 }
 
+func TestBasicAuthRehashOnLogin(t *testing.T) {
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.BasicAuthHashKeyFunction = "sha256"
+		globalConf.BasicAuthHashOptions = config.BasicAuthHashOptionsConfig{
+			Argon2Memory:      1024,
+			Argon2Iterations:  1,
+			Argon2Parallelism: 1,
+			RehashOnLogin:     true,
+		}
+	})
+	defer ts.Close()
+
+	session := ts.testPrepareBasicAuth(false)
+	validPassword := map[string]string{"Authorization": genAuthHeader("user", "password")}
+
+	ts.Run(t, []test.TestCase{
+		{Method: http.MethodPost, Path: "/tyk/keys/defaultuser", Data: session, AdminAuth: true, Code: http.StatusOK},
+		{Method: http.MethodGet, Path: "/", Headers: validPassword, Code: http.StatusOK},
+	}...)
+
+	getHash := func() user.BasicAuthData {
+		t.Helper()
+		sess, found := ts.Gw.GlobalSessionManager.SessionDetail("default", "defaultuser", false)
+		assert.True(t, found)
+		return sess.BasicAuthData
+	}
+
+	assert.Equal(t, user.HashType(user.HashSha256), getHash().Hash)
+
+	conf := ts.Gw.GetConfig()
+	conf.BasicAuthHashKeyFunction = "argon2id"
+	ts.Gw.SetConfig(conf)
+
+	_, _ = ts.Run(t, test.TestCase{Method: http.MethodGet, Path: "/", Headers: validPassword, Code: http.StatusOK})
+
+	data := getHash()
+	assert.Equal(t, user.HashType(user.HashArgon2id), data.Hash)
+	assert.True(t, strings.HasPrefix(data.Password, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	// cost change triggers another upgrade
+	conf.BasicAuthHashOptions.Argon2Iterations = 2
+	ts.Gw.SetConfig(conf)
+
+	ts.Run(t, []test.TestCase{
+		{Method: http.MethodGet, Path: "/", Headers: validPassword, Code: http.StatusOK},
+		{Method: http.MethodGet, Path: "/", Headers: map[string]string{"Authorization": genAuthHeader("user", "wrong")}, Code: http.StatusUnauthorized},
+	}...)
+
+	assert.True(t, strings.HasPrefix(getHash().Password, "$argon2id$v=19$m=1024,t=2,p=1$"))
+
+	// a failing hash keeps the stored one instead of saving the plain password
+	conf.BasicAuthHashKeyFunction = "scrypt"
+	conf.BasicAuthHashOptions.ScryptN = 1000
+	ts.Gw.SetConfig(conf)
+
+	_, _ = ts.Run(t, test.TestCase{Method: http.MethodGet, Path: "/", Headers: validPassword, Code: http.StatusOK})
+
+	data = getHash()
+	assert.Equal(t, user.HashType(user.HashArgon2id), data.Hash)
+	assert.True(t, strings.HasPrefix(data.Password, "$argon2id$v=19$m=1024,t=2,p=1$"))
+}
+
+func TestBasicAuthLockout(t *testing.T) {
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.BasicAuthLockout = config.BasicAuthLockoutConfig{
+			Enabled:     true,
+			MaxAttempts: 2,
+			Window:      60,
+		}
+	})
+	defer ts.Close()
+
+	session := ts.testPrepareBasicAuth(true)
+	validPassword := map[string]string{"Authorization": genAuthHeader("user", "password")}
+	wrongPassword := map[string]string{"Authorization": genAuthHeader("user", "wrong")}
+
+	ts.Run(t, []test.TestCase{
+		{Method: http.MethodPost, Path: "/tyk/keys/defaultuser", Data: session, AdminAuth: true, Code: http.StatusOK},
+		{Method: http.MethodGet, Path: "/", Headers: wrongPassword, Code: http.StatusUnauthorized},
+		// a successful login resets the counter
+		{Method: http.MethodGet, Path: "/", Headers: validPassword, Code: http.StatusOK},
+		{Method: http.MethodGet, Path: "/", Headers: wrongPassword, Code: http.StatusUnauthorized},
+		{Method: http.MethodGet, Path: "/", Headers: wrongPassword, Code: http.StatusUnauthorized},
+		{Method: http.MethodGet, Path: "/", Headers: validPassword, Code: http.StatusForbidden, BodyMatch: "locked out"},
+	}...)
+}
+
 func TestBasicAuthCachedUserCollision(t *testing.T) {
 	ts := StartTest(nil)
 	defer ts.Close()
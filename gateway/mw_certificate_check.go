@@ -1,7 +1,10 @@
 package gateway
 
 import (
+	"crypto/x509"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/TykTechnologies/tyk/certs"
 	"github.com/TykTechnologies/tyk/internal/crypto"
@@ -10,6 +13,8 @@ import (
 // CertificateCheckMW is used if domain was not detected or multiple APIs bind on the same domain. In this case authentification check happens not on TLS side but on HTTP level using this middleware
 type CertificateCheckMW struct {
 	*BaseMiddleware
+
+	revocation *crypto.RevocationChecker
 }
 
 func (m *CertificateCheckMW) Name() string {
@@ -17,7 +22,12 @@ func (m *CertificateCheckMW) Name() string {
 }
 
 func (m *CertificateCheckMW) EnabledForSpec() bool {
-	return m.Spec.UseMutualTLSAuth
+	conf := m.Spec.ClientCertificateRevocation
+	if conf.Enabled() {
+		m.revocation = crypto.NewRevocationChecker(time.Duration(conf.Timeout)*time.Second, time.Duration(conf.CacheTTL)*time.Second)
+	}
+
+	return m.Spec.UseMutualTLSAuth || m.revocation != nil
 }
 
 func (m *CertificateCheckMW) ProcessRequest(w http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
@@ -25,12 +35,78 @@ func (m *CertificateCheckMW) ProcessRequest(w http.ResponseWriter, r *http.Reque
 		return nil, http.StatusOK
 	}
 
+	certIDs := append(m.Spec.ClientCertificates, m.Spec.GlobalConfig.Security.Certificates.API...)
+
 	if m.Spec.UseMutualTLSAuth {
-		certIDs := append(m.Spec.ClientCertificates, m.Spec.GlobalConfig.Security.Certificates.API...)
 		apiCerts := m.Gw.CertificateManager.List(certIDs, certs.CertificatePublic)
 		if err := crypto.ValidateRequestCerts(r, apiCerts); err != nil {
 			return err, http.StatusForbidden
 		}
 	}
+
+	if m.revocation != nil && r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		if err := m.checkRevocation(r, certIDs); err != nil {
+			return err, http.StatusForbidden
+		}
+	}
+
 	return nil, http.StatusOK
 }
+
+// checkRevocation checks the client certificate against the configured CRLs and OCSP responder.
+// When the revocation status can't be determined the request is rejected, unless FailOpen is set.
+func (m *CertificateCheckMW) checkRevocation(r *http.Request, certIDs []string) error {
+	conf := m.Spec.ClientCertificateRevocation
+	logger := m.Logger()
+
+	cert := r.TLS.PeerCertificates[0]
+	issuer := m.certificateIssuer(r, certIDs)
+
+	var err error
+	if issuer == nil {
+		err = crypto.ErrRevocationUnknown
+	}
+
+	if err == nil && conf.CheckCRL {
+		err = m.revocation.CheckCRL(cert, issuer, conf.CRLURLs)
+	}
+
+	if err == nil && conf.CheckOCSP {
+		err = m.revocation.CheckOCSP(cert, issuer, conf.OCSPResponderURL)
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, crypto.ErrCertRevoked):
+		logger.WithField("serial", cert.SerialNumber.String()).Warning("Attempted access with revoked client certificate.")
+		return errors.New("Certificate with SHA256 " + crypto.HexSHA256(cert.Raw) + " has been revoked")
+	case conf.FailOpen:
+		logger.WithError(err).Warning("Could not check client certificate revocation status, allowing request.")
+		return nil
+	default:
+		logger.WithError(err).Error("Could not check client certificate revocation status.")
+		return errors.New("Certificate revocation status could not be verified")
+	}
+}
+
+// certificateIssuer finds the issuer of the client certificate in the verified chain, the
+// chain presented by the client or the CA certificates allowed for the API.
+func (m *CertificateCheckMW) certificateIssuer(r *http.Request, certIDs []string) *x509.Certificate {
+	var candidates []*x509.Certificate
+	for _, chain := range r.TLS.VerifiedChains {
+		if len(chain) > 1 {
+			candidates = append(candidates, chain[1])
+		}
+	}
+
+	candidates = append(candidates, r.TLS.PeerCertificates[1:]...)
+
+	for _, cert := range m.Gw.CertificateManager.List(certIDs, certs.CertificatePublic) {
+		if cert != nil && cert.Leaf != nil {
+			candidates = append(candidates, cert.Leaf)
+		}
+	}
+
+	return crypto.CertificateIssuer(r.TLS.PeerCertificates[0], candidates)
+}
@@ -0,0 +1,233 @@
+package gateway
+
+import (
+	"crypto/md5"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/cache"
+)
+
+// certIdentityRule is a compiled apidef.CertificateIdentityRule.
+type certIdentityRule struct {
+	apidef.CertificateIdentityRule
+	match *regexp.Regexp
+}
+
+// CertificateIdentityMW authenticates requests with the client certificate presented over mutual TLS.
+// A virtual session is derived from the certificate fields, so no key has to be created per certificate.
+type CertificateIdentityMW struct {
+	*BaseMiddleware
+
+	rules []certIdentityRule
+}
+
+func (k *CertificateIdentityMW) Name() string {
+	return "CertificateIdentityMW"
+}
+
+func (k *CertificateIdentityMW) EnabledForSpec() bool {
+	conf := k.Spec.ClientCertificateIdentity
+	if !conf.Enabled {
+		return false
+	}
+
+	if !k.Spec.UseMutualTLSAuth {
+		k.Logger().Error("Client certificate identity mapping requires mutual TLS to be enabled")
+		return false
+	}
+
+	k.rules = make([]certIdentityRule, 0, len(conf.Rules))
+	for _, rule := range conf.Rules {
+		compiled := certIdentityRule{CertificateIdentityRule: rule}
+		if rule.Match != "" {
+			re, err := regexp.Compile(rule.Match)
+			if err != nil {
+				k.Logger().WithError(err).WithField("field", rule.Field).Error("Invalid client certificate identity rule, skipping")
+				continue
+			}
+			compiled.match = re
+		}
+		k.rules = append(k.rules, compiled)
+	}
+
+	return true
+}
+
+func (k *CertificateIdentityMW) ProcessRequest(w http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
+	if ctxGetRequestStatus(r) == StatusOkAndIgnore {
+		return nil, http.StatusOK
+	}
+
+	logger := k.Logger()
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		logger.Info("Attempted access without client certificate.")
+		AuthFailed(k, r, "")
+		return errors.New("Client TLS certificate is required"), http.StatusUnauthorized
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+
+	idField := k.Spec.ClientCertificateIdentity.IdentityField
+	if idField == "" {
+		idField = apidef.CertFieldSubjectCN
+	}
+
+	identities := certificateFieldValues(cert, idField)
+	if len(identities) == 0 {
+		logger.WithField("field", idField).Warning("Client certificate has no identity.")
+		AuthFailed(k, r, "")
+		return errors.New("Client certificate has no identity"), http.StatusUnauthorized
+	}
+
+	identity := identities[0]
+	logger = logger.WithField("identity", identity)
+
+	policies, metadata := k.mapCertificate(cert)
+	if len(policies) == 0 {
+		logger.Warning("No policy mapped to client certificate.")
+		AuthFailed(k, r, identity)
+		return errors.New("key not authorized: no matching policy found"), http.StatusForbidden
+	}
+
+	metadata["cert_identity"] = identity
+	metadata["cert_subject"] = cert.Subject.String()
+	metadata["cert_serial"] = fmt.Sprintf("%x", cert.SerialNumber)
+
+	// the session is keyed by the identity, so rotated certificates share it
+	sessionID := k.Gw.generateToken(k.Spec.OrgID, fmt.Sprintf("%x", md5.Sum([]byte(idField+":"+identity))))
+	session, exists := k.CheckSessionAndIdentityForValidKey(sessionID, r)
+	sessionID = session.KeyID
+	updateSession := false
+
+	if !exists {
+		var err error
+		session, err = k.Gw.generateSessionFromPolicy(policies[0], k.Spec.OrgID, true)
+		if err != nil {
+			logger.WithError(err).Error("Could not find a valid policy to apply to this client certificate")
+			AuthFailed(k, r, identity)
+			return errors.New("key not authorized: no matching policy"), http.StatusForbidden
+		}
+
+		updateSession = true
+	}
+
+	if !session.PoliciesEqualTo(policies) {
+		session.SetPolicies(policies...)
+		updateSession = true
+	}
+
+	if updateSession {
+		if err := k.ApplyPolicies(&session); err != nil {
+			logger.WithError(err).Error("Could not apply policies to client certificate session")
+			AuthFailed(k, r, identity)
+			return errors.New("key not authorized: could not apply policies"), http.StatusForbidden
+		}
+	}
+
+	if !reflect.DeepEqual(session.MetaData, metadata) {
+		session.MetaData = metadata
+		updateSession = true
+	}
+
+	if session.Alias != identity {
+		session.Alias = identity
+		updateSession = true
+	}
+
+	session.KeyID = sessionID
+	switch k.Spec.BaseIdentityProvidedBy {
+	case apidef.ClientCert, apidef.UnsetAuth:
+		ctxSetSession(r, &session, updateSession, k.Gw.GetConfig().HashKeys)
+		if updateSession {
+			k.Gw.SessionCache.Set(session.KeyHash(), session.Clone(), cache.DefaultExpiration)
+		}
+	}
+
+	return nil, http.StatusOK
+}
+
+// mapCertificate returns the policies and metadata of all rules matching the certificate,
+// followed by the default policies.
+func (k *CertificateIdentityMW) mapCertificate(cert *x509.Certificate) ([]string, map[string]interface{}) {
+	var (
+		policies []string
+		seen     = map[string]bool{}
+		metadata = map[string]interface{}{}
+	)
+
+	addPolicies := func(ids []string) {
+		for _, id := range ids {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				policies = append(policies, id)
+			}
+		}
+	}
+
+	for _, rule := range k.rules {
+		for _, value := range certificateFieldValues(cert, rule.Field) {
+			if rule.match == nil {
+				addPolicies(rule.Policies)
+				for key, val := range rule.Metadata {
+					metadata[key] = val
+				}
+				break
+			}
+
+			submatch := rule.match.FindStringSubmatchIndex(value)
+			if submatch == nil {
+				continue
+			}
+
+			addPolicies(rule.Policies)
+			for key, val := range rule.Metadata {
+				metadata[key] = string(rule.match.ExpandString(nil, val, value, submatch))
+			}
+			break
+		}
+	}
+
+	addPolicies(k.Spec.ClientCertificateIdentity.DefaultPolicies)
+
+	return policies, metadata
+}
+
+// certificateFieldValues returns the values of the given field of the certificate.
+// Serial numbers are returned as lower case hex.
+func certificateFieldValues(cert *x509.Certificate, field string) []string {
+	switch field {
+	case apidef.CertFieldSubjectCN:
+		if cert.Subject.CommonName == "" {
+			return nil
+		}
+		return []string{cert.Subject.CommonName}
+	case apidef.CertFieldSubjectO:
+		return cert.Subject.Organization
+	case apidef.CertFieldSubjectOU:
+		return cert.Subject.OrganizationalUnit
+	case apidef.CertFieldSANURI:
+		values := make([]string, 0, len(cert.URIs))
+		for _, uri := range cert.URIs {
+			values = append(values, uri.String())
+		}
+		return values
+	case apidef.CertFieldSANDNS:
+		return cert.DNSNames
+	case apidef.CertFieldSANEmail:
+		return cert.EmailAddresses
+	case apidef.CertFieldSerialNumber:
+		if cert.SerialNumber == nil {
+			return nil
+		}
+		return []string{fmt.Sprintf("%x", cert.SerialNumber)}
+	}
+
+	return nil
+}
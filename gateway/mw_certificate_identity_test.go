@@ -0,0 +1,279 @@
+package gateway
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/certs"
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/internal/crypto"
+	"github.com/TykTechnologies/tyk/test"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+// testClientCA is a certificate authority issuing client certificates in tests.
+type testClientCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+	pem  []byte
+}
+
+func newTestClientCA(t *testing.T) *testClientCA {
+	t.Helper()
+
+	certPEM, keyPEM, err := crypto.GenerateRootCertAndKey(t)
+	require.NoError(t, err)
+
+	certBlock, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	require.NoError(t, err)
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	require.NoError(t, err)
+
+	return &testClientCA{cert: cert, key: key, pem: certPEM}
+}
+
+func (ca *testClientCA) issue(t *testing.T, serial int64, template *x509.Certificate) tls.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der, ca.cert.Raw}, PrivateKey: key, Leaf: leaf}
+}
+
+// startClientCATest starts a TLS gateway and loads an API which allows client certificates issued by ca.
+func startClientCATest(t *testing.T, ca *testClientCA, apiGen func(spec *APISpec)) *Test {
+	t.Helper()
+
+	_, _, combinedPEM, _ := crypto.GenServerCertificate()
+	certID, _, err := certs.GetCertIDAndChainPEM(combinedPEM, "")
+	require.NoError(t, err)
+
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.Security.ControlAPIUseMutualTLS = false
+		globalConf.HttpServerOptions.UseSSL = true
+		globalConf.HttpServerOptions.SSLInsecureSkipVerify = true
+		globalConf.HttpServerOptions.SSLCertificates = []string{"default" + certID}
+		globalConf.SuppressRedisSignalReload = true
+	})
+
+	_, err = ts.Gw.CertificateManager.Add(combinedPEM, "default")
+	require.NoError(t, err)
+	ts.ReloadGatewayProxy()
+
+	caCertID, err := ts.Gw.CertificateManager.Add(ca.pem, "default")
+	require.NoError(t, err)
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = "mtls-api"
+		spec.OrgID = "default"
+		spec.UseKeylessAccess = false
+		spec.UseMutualTLSAuth = true
+		spec.ClientCertificates = []string{caCertID}
+		spec.Proxy.ListenPath = "/"
+		apiGen(spec)
+	})
+
+	return ts
+}
+
+func TestCertificateIdentity(t *testing.T) {
+	ca := newTestClientCA(t)
+
+	spiffeID, err := url.Parse("spiffe://example.org/ns/payments/sa/billing")
+	require.NoError(t, err)
+
+	billing := ca.issue(t, 100, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "billing", OrganizationalUnit: []string{"payments"}},
+		URIs:    []*url.URL{spiffeID},
+	})
+	rotated := ca.issue(t, 101, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "billing-v2", OrganizationalUnit: []string{"payments"}},
+		URIs:    []*url.URL{spiffeID},
+	})
+	otherID, err := url.Parse("spiffe://other.org/sa/reports")
+	require.NoError(t, err)
+
+	unmapped := ca.issue(t, 102, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "reports"},
+		URIs:    []*url.URL{otherID},
+	})
+	anonymous := ca.issue(t, 103, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "anonymous", OrganizationalUnit: []string{"payments"}},
+	})
+
+	var paymentsPolicy, spiffePolicy string
+	ts := startClientCATest(t, ca, func(spec *APISpec) {
+		spec.ClientCertificateIdentity = apidef.ClientCertificateIdentity{
+			Enabled:       true,
+			IdentityField: apidef.CertFieldSANURI,
+			Rules: []apidef.CertificateIdentityRule{
+				{
+					Field:    apidef.CertFieldSubjectOU,
+					Match:    "^payments$",
+					Policies: []string{"payments"},
+				},
+				{
+					Field:    apidef.CertFieldSANURI,
+					Match:    `^spiffe://example\.org/ns/(?P<ns>[^/]+)/sa/(?P<sa>[^/]+)$`,
+					Policies: []string{"spiffe"},
+					Metadata: map[string]string{"service": "${ns}.${sa}"},
+				},
+			},
+		}
+	})
+	defer ts.Close()
+
+	accessRights := map[string]user.AccessDefinition{"mtls-api": {APIID: "mtls-api"}}
+	paymentsPolicy = ts.CreatePolicy(func(p *user.Policy) {
+		p.ID = "payments"
+		p.AccessRights = accessRights
+		p.Rate = 100
+	})
+	spiffePolicy = ts.CreatePolicy(func(p *user.Policy) {
+		p.ID = "spiffe"
+		p.AccessRights = accessRights
+		p.Rate = 50
+		p.Tags = []string{"spiffe"}
+	})
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Domain: "localhost", Client: GetTLSClient(&billing, nil), Path: "/", Code: http.StatusOK},
+		{Domain: "localhost", Client: GetTLSClient(&rotated, nil), Path: "/", Code: http.StatusOK},
+		// no rule matches
+		{Domain: "localhost", Client: GetTLSClient(&unmapped, nil), Path: "/", Code: http.StatusForbidden},
+		// no SAN URI to use as identity
+		{Domain: "localhost", Client: GetTLSClient(&anonymous, nil), Path: "/", Code: http.StatusUnauthorized},
+	}...)
+
+	t.Run("virtual session", func(t *testing.T) {
+		sessionID := ts.Gw.generateToken("default", fmt.Sprintf("%x", md5.Sum([]byte(apidef.CertFieldSANURI+":"+spiffeID.String()))))
+		session, found := ts.Gw.GlobalSessionManager.SessionDetail("default", sessionID, false)
+		require.True(t, found)
+
+		assert.Equal(t, []string{paymentsPolicy, spiffePolicy}, session.PolicyIDs())
+		assert.Equal(t, spiffeID.String(), session.Alias)
+		assert.Equal(t, float64(100), session.Rate)
+		assert.Equal(t, "payments.billing", session.MetaData["service"])
+		// the session is shared by rotated certificates, metadata reflects the last one
+		assert.Equal(t, "65", session.MetaData["cert_serial"])
+		assert.Equal(t, "CN=billing-v2,OU=payments", session.MetaData["cert_subject"])
+	})
+
+	t.Run("default policies", func(t *testing.T) {
+		spec := ts.Gw.getApiSpec("mtls-api")
+		spec.ClientCertificateIdentity.IdentityField = apidef.CertFieldSubjectCN
+		spec.ClientCertificateIdentity.DefaultPolicies = []string{paymentsPolicy}
+		ts.Gw.LoadAPI(spec)
+
+		_, _ = ts.Run(t, test.TestCase{Domain: "localhost", Client: GetTLSClient(&unmapped, nil), Path: "/", Code: http.StatusOK})
+	})
+}
+
+func TestCertificateFieldValues(t *testing.T) {
+	spiffeID, err := url.Parse("spiffe://example.org/sa/a")
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{
+		SerialNumber:   big.NewInt(255),
+		Subject:        pkix.Name{CommonName: "cn", Organization: []string{"o"}, OrganizationalUnit: []string{"ou1", "ou2"}},
+		URIs:           []*url.URL{spiffeID},
+		DNSNames:       []string{"svc.example.org"},
+		EmailAddresses: []string{"svc@example.org"},
+	}
+
+	assert.Equal(t, []string{"cn"}, certificateFieldValues(cert, apidef.CertFieldSubjectCN))
+	assert.Equal(t, []string{"o"}, certificateFieldValues(cert, apidef.CertFieldSubjectO))
+	assert.Equal(t, []string{"ou1", "ou2"}, certificateFieldValues(cert, apidef.CertFieldSubjectOU))
+	assert.Equal(t, []string{"spiffe://example.org/sa/a"}, certificateFieldValues(cert, apidef.CertFieldSANURI))
+	assert.Equal(t, []string{"svc.example.org"}, certificateFieldValues(cert, apidef.CertFieldSANDNS))
+	assert.Equal(t, []string{"svc@example.org"}, certificateFieldValues(cert, apidef.CertFieldSANEmail))
+	assert.Equal(t, []string{"ff"}, certificateFieldValues(cert, apidef.CertFieldSerialNumber))
+	assert.Nil(t, certificateFieldValues(cert, "unknown"))
+	assert.Nil(t, certificateFieldValues(&x509.Certificate{}, apidef.CertFieldSubjectCN))
+}
+
+func TestClientCertificateRevocation(t *testing.T) {
+	ca := newTestClientCA(t)
+
+	good := ca.issue(t, 200, &x509.Certificate{Subject: pkix.Name{CommonName: "good"}})
+	revoked := ca.issue(t, 201, &x509.Certificate{Subject: pkix.Name{CommonName: "revoked"}})
+
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: big.NewInt(201), RevocationTime: time.Now().Add(-time.Minute)},
+		},
+	}, ca.cert, ca.key)
+	require.NoError(t, err)
+
+	crlServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/crl" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(crl)
+	}))
+	defer crlServer.Close()
+
+	ts := startClientCATest(t, ca, func(spec *APISpec) {
+		spec.UseKeylessAccess = true
+		spec.ClientCertificateRevocation = apidef.ClientCertificateRevocation{
+			CheckCRL: true,
+			CRLURLs:  []string{crlServer.URL + "/crl"},
+		}
+	})
+	defer ts.Close()
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Domain: "localhost", Client: GetTLSClient(&good, nil), Path: "/", Code: http.StatusOK},
+		{Domain: "localhost", Client: GetTLSClient(&revoked, nil), Path: "/", Code: http.StatusForbidden, BodyMatch: "has been revoked"},
+	}...)
+
+	t.Run("unknown status", func(t *testing.T) {
+		spec := ts.Gw.getApiSpec("mtls-api")
+		spec.ClientCertificateRevocation.CRLURLs = []string{crlServer.URL + "/missing"}
+		ts.Gw.LoadAPI(spec)
+
+		_, _ = ts.Run(t, test.TestCase{Domain: "localhost", Client: GetTLSClient(&good, nil), Path: "/", Code: http.StatusForbidden, BodyMatch: "could not be verified"})
+
+		spec = ts.Gw.getApiSpec("mtls-api")
+		spec.ClientCertificateRevocation.FailOpen = true
+		ts.Gw.LoadAPI(spec)
+
+		_, _ = ts.Run(t, test.TestCase{Domain: "localhost", Client: GetTLSClient(&good, nil), Path: "/", Code: http.StatusOK})
+	})
+}
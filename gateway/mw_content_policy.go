@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/contentpolicy"
+)
+
+// ContentPolicyMiddleware enforces the content policies of the endpoints. Bodies are checked while they are
+// read, the request is rejected at the first violation without reading the rest of the body. Compressed bodies are
+// decoded by their Content-Encoding for the check.
+type ContentPolicyMiddleware struct {
+	*BaseMiddleware
+}
+
+func (m *ContentPolicyMiddleware) Name() string {
+	return "ContentPolicyMiddleware"
+}
+
+func (m *ContentPolicyMiddleware) EnabledForSpec() bool {
+	for _, version := range m.Spec.VersionData.Versions {
+		for _, meta := range version.ExtendedPaths.ContentPolicy {
+			if !meta.Disabled {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (m *ContentPolicyMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
+	vInfo, _ := m.Spec.Version(r)
+	found, meta := m.Spec.CheckSpecMatchesStatus(r, m.Spec.RxPaths[vInfo.Name], ContentPolicyEnforced)
+	if !found {
+		return nil, http.StatusOK
+	}
+
+	policy := contentpolicy.New(*meta.(*apidef.ContentPolicyMeta))
+	hasBody := r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+
+	if err := policy.CheckRequest(r, hasBody); err != nil {
+		return m.reject(err)
+	}
+
+	contentType := r.Header.Get(header.ContentType)
+	if !hasBody || !policy.ChecksBody(contentType) {
+		return nil, http.StatusOK
+	}
+
+	// The body is kept as it is read, to be restored for the following middleware.
+	var buf bytes.Buffer
+	src := unbufferedRequestBody(r)
+	body := io.TeeReader(src, &buf)
+
+	// Compressed bodies are checked decoded, they are decompressed for the upstream later in the chain.
+	checked := body
+	var decoded *io.LimitedReader
+	if encoding := r.Header.Get(header.ContentEncoding); encoding != "" && encoding != "identity" {
+		reader, err := decompressRequestBody(encoding, body)
+		if err != nil {
+			src.Close()
+			if errors.Is(err, errUnsupportedEncoding) {
+				return err, http.StatusUnsupportedMediaType
+			}
+			return errMalformedCompression, http.StatusBadRequest
+		}
+		defer reader.Close()
+
+		limit := m.Spec.Compression.MaxDecompressedSize
+		if limit <= 0 {
+			limit = defaultMaxDecompressedSize
+		}
+		decoded = &io.LimitedReader{R: reader, N: limit + 1}
+		checked = decoded
+	}
+
+	if err := policy.Check(checked, contentType); err != nil {
+		src.Close()
+		if decoded != nil && decoded.N == 0 {
+			return errDecompressedTooLarge, http.StatusRequestEntityTooLarge
+		}
+		return m.reject(err)
+	}
+
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		src.Close()
+		return m.reject(contentpolicy.ErrInvalidBody)
+	}
+
+	src.Close()
+	r.Body = io.NopCloser(&buf)
+	nopCloseRequestBody(r)
+
+	return nil, http.StatusOK
+}
+
+// unbufferedRequestBody returns the request body without the lazy buffering of nopCloserBuffer, which reads the
+// whole body on the first read and rewinds on EOF.
+func unbufferedRequestBody(r *http.Request) io.ReadCloser {
+	nc, ok := r.Body.(*nopCloserBuffer)
+	if !ok {
+		return r.Body
+	}
+
+	if nc.reader != nil {
+		return nc.reader
+	}
+
+	return io.NopCloser(bytes.NewReader(nc.buf.Bytes()))
+}
+
+func (m *ContentPolicyMiddleware) reject(err error) (error, int) {
+	m.Logger().WithError(err).Info("Request blocked by content policy")
+
+	var violation *contentpolicy.Violation
+	switch {
+	case errors.Is(err, contentpolicy.ErrUnsupportedContentType):
+		return err, http.StatusUnsupportedMediaType
+	case errors.As(err, &violation) && violation.Limit == contentpolicy.LimitHeaders:
+		return err, http.StatusRequestHeaderFieldsTooLarge
+	case errors.As(err, &violation) && violation.Limit == contentpolicy.LimitMultipartPartSize:
+		return err, http.StatusRequestEntityTooLarge
+	default:
+		return err, http.StatusBadRequest
+	}
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func TestContentPolicy(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	api := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.Proxy.ListenPath = "/"
+		spec.Compression.DecompressRequests = true
+		UpdateAPIVersion(spec, "v1", func(v *apidef.VersionInfo) {
+			v.UseExtendedPaths = true
+			v.ExtendedPaths.ContentPolicy = []apidef.ContentPolicyMeta{{
+				Method:              http.MethodPost,
+				Path:                "/orders",
+				AllowedContentTypes: []string{header.ApplicationJSON},
+				MaxJSONDepth:        2,
+				MaxJSONArrayLength:  2,
+				MaxQueryParams:      1,
+			}}
+		})
+	})[0]
+
+	jsonHeaders := map[string]string{header.ContentType: header.ApplicationJSON}
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodPost, Path: "/orders", Data: `{"items":[1,2]}`, Headers: jsonHeaders, Code: http.StatusOK,
+			BodyMatch: `"Body":"{\\"items\\":\[1,2\]}"`},
+		{Method: http.MethodPost, Path: "/orders", Data: `<order/>`, Headers: map[string]string{header.ContentType: "application/xml"},
+			Code: http.StatusUnsupportedMediaType},
+		{Method: http.MethodPost, Path: "/orders", Data: `{"items":[[1]]}`, Headers: jsonHeaders, Code: http.StatusBadRequest,
+			BodyMatch: "JSON depth limit of 2"},
+		{Method: http.MethodPost, Path: "/orders", Data: `{"items":[1,2,3]}`, Headers: jsonHeaders, Code: http.StatusBadRequest,
+			BodyMatch: "JSON array length limit of 2"},
+		{Method: http.MethodPost, Path: "/orders?a=1&b=2", Data: `{}`, Headers: jsonHeaders, Code: http.StatusBadRequest,
+			BodyMatch: "query parameters limit of 1"},
+		{Method: http.MethodPost, Path: "/other", Data: strings.Repeat("[", 10), Headers: jsonHeaders, Code: http.StatusOK},
+	}...)
+
+	t.Run("compressed", func(t *testing.T) {
+		gzipHeaders := map[string]string{header.ContentType: header.ApplicationJSON, header.ContentEncoding: encodingGzip}
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Path: "/orders", Data: compressBody(t, encodingGzip, `{"items":[1,2]}`), Headers: gzipHeaders,
+				Code: http.StatusOK, BodyMatch: `"Body":"{\\"items\\":\[1,2\]}"`},
+			{Method: http.MethodPost, Path: "/orders", Data: compressBody(t, encodingGzip, `{"items":[[1]]}`), Headers: gzipHeaders,
+				Code: http.StatusBadRequest, BodyMatch: "JSON depth limit of 2"},
+			{Method: http.MethodPost, Path: "/orders", Data: `{"items":[1,2]}`, Headers: gzipHeaders, Code: http.StatusBadRequest},
+		}...)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		UpdateAPIVersion(api, "v1", func(v *apidef.VersionInfo) {
+			v.ExtendedPaths.ContentPolicy[0].Disabled = true
+		})
+		ts.Gw.LoadAPI(api)
+
+		_, _ = ts.Run(t, test.TestCase{Method: http.MethodPost, Path: "/orders", Data: `{"items":[[1,2,3]]}`, Headers: jsonHeaders, Code: http.StatusOK})
+	})
+}
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -27,6 +28,7 @@ import (
 var (
 	ProxyingRequestFailedErr     = errors.New("there was a problem proxying the request")
 	GraphQLDepthLimitExceededErr = errors.New("depth limit exceeded")
+	GraphQLQueryCostExceededErr  = errors.New("query cost limit exceeded")
 )
 
 type GraphQLMiddleware struct {
@@ -196,11 +198,51 @@ func (m *GraphQLMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Reques
 		return nil, http.StatusSwitchingProtocols
 	}
 
+	gqlRequest, err := readGraphQLRequest(r)
+	switch {
+	case errors.Is(err, graphengine.ErrOperationNotAllowedOverGET):
+		w.Header().Set(header.Allow, http.MethodPost)
+		return err, http.StatusMethodNotAllowed
+	case errors.Is(err, graphengine.ErrInvalidHTTPRequest):
+		return err, http.StatusBadRequest
+	case err != nil:
+		m.Logger().WithError(err).Error("error reading GraphQL request")
+		return errors.New("error reading the request"), http.StatusBadRequest
+	}
+
+	if gqlRequest.Upload && !isGraphQLProxyOnly(m.Spec) {
+		return errors.New("file uploads are only supported in proxy only mode"), http.StatusBadRequest
+	}
+
+	// uploads and incrementally delivered responses are passed through to the upstream of proxy only APIs, the
+	// engine only validates the request
+	if isGraphQLProxyOnly(m.Spec) && (gqlRequest.Upload || gqlRequest.Incremental) {
+		ctxSetGraphQLPassthrough(r, true)
+	}
+
+	body := r.Body
+	r.Body = io.NopCloser(bytes.NewReader(gqlRequest.Body))
+	err, code := m.Spec.GraphEngine.ProcessAndStoreGraphQLRequest(w, r)
+
 	// With current in memory server approach we need body to be readable again
 	// as for proxy only API we are sending it as is
+	r.Body = body
 	nopCloseRequestBody(r)
 
-	return m.Spec.GraphEngine.ProcessAndStoreGraphQLRequest(w, r)
+	return err, code
+}
+
+// readGraphQLRequest reads the GraphQL request, the body is then restored from the part that was read followed by
+// the rest. Only the operations and the map of upload requests are read, the files are streamed to the upstream.
+func readGraphQLRequest(r *http.Request) (*graphengine.HTTPRequest, error) {
+	src := unbufferedRequestBody(r)
+
+	var read bytes.Buffer
+	r.Body = io.NopCloser(io.TeeReader(src, &read))
+	gqlRequest, err := graphengine.ReadHTTPRequest(r)
+	r.Body = readCloser{io.MultiReader(&read, src), src}
+
+	return gqlRequest, err
 }
 
 func (m *GraphQLMiddleware) websocketUpgradeUsesGraphQLProtocol(r *http.Request) bool {
@@ -17,10 +17,13 @@ const (
 	ComplexityFailReasonNone ComplexityFailReason = iota
 	ComplexityFailReasonInternalError
 	ComplexityFailReasonDepthLimitExceeded
+	ComplexityFailReasonCostLimitExceeded
 )
 
 type GraphQLComplexityMiddleware struct {
 	*BaseMiddleware
+
+	costCalculator *graphengine.CostCalculator
 }
 
 func (m *GraphQLComplexityMiddleware) Name() string {
@@ -31,9 +34,23 @@ func (m *GraphQLComplexityMiddleware) EnabledForSpec() bool {
 	return m.Spec.GraphQL.Enabled
 }
 
+func (m *GraphQLComplexityMiddleware) Init() {
+	if !m.Spec.GraphQL.CostAnalysis.Enabled {
+		return
+	}
+
+	costCalculator, err := graphengine.NewCostCalculator(m.Spec.GraphQL.Schema, m.Spec.GraphQL.CostAnalysis)
+	if err != nil {
+		m.Logger().WithError(err).Error("Error while creating the GraphQL cost calculator")
+		return
+	}
+	m.costCalculator = costCalculator
+}
+
 // ProcessRequest will run any checks on the request on the way through the system, return an error to have the chain fail
 func (m *GraphQLComplexityMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
-	accessDef, _, err := GetAccessDefinitionByAPIIDOrSession(ctxGetSession(r), m.Spec)
+	session := ctxGetSession(r)
+	accessDef, allowanceScope, err := GetAccessDefinitionByAPIIDOrSession(session, m.Spec)
 	if err != nil {
 		m.Logger().Debugf("Error while calculating GraphQL complexity: '%s'", err)
 		return m.handleComplexityFailReason(ComplexityFailReasonInternalError)
@@ -56,7 +73,52 @@ func (m *GraphQLComplexityMiddleware) ProcessRequest(w http.ResponseWriter, r *h
 		})
 	}
 
-	return m.Spec.GraphEngine.ProcessGraphQLComplexity(r, graphEngineComplexityAccessDefinition)
+	if err, code := m.Spec.GraphEngine.ProcessGraphQLComplexity(r, graphEngineComplexityAccessDefinition); err != nil {
+		return err, code
+	}
+
+	return m.processQueryCost(r, session, accessDef, allowanceScope)
+}
+
+// processQueryCost enforces the max query cost of the key, and charges the cost of the request against its quota
+// when configured.
+func (m *GraphQLComplexityMiddleware) processQueryCost(r *http.Request, session *user.SessionState, accessDef *user.AccessDefinition, allowanceScope string) (error, int) {
+	if m.costCalculator == nil {
+		return nil, http.StatusOK
+	}
+
+	chargeQuota := m.Spec.GraphQL.CostAnalysis.ChargeQuota && !m.Spec.DisableQuota && accessDef.Limit.QuotaMax > 0 && ctxCheckLimits(r)
+	// If MaxQueryCost is -1 or 0, it means unlimited.
+	if accessDef.Limit.MaxQueryCost <= 0 && !chargeQuota {
+		return nil, http.StatusOK
+	}
+
+	query, operationName, variables, ok := graphQLRequestOperation(r)
+	if !ok {
+		return nil, http.StatusOK
+	}
+
+	cost, err := m.costCalculator.Calculate(query, operationName, variables)
+	if err != nil {
+		m.Logger().Errorf("Error while calculating the cost of GraphQL request: '%s'", err)
+		return m.handleComplexityFailReason(ComplexityFailReasonInternalError)
+	}
+
+	if accessDef.Limit.MaxQueryCost > 0 && cost > accessDef.Limit.MaxQueryCost {
+		m.Logger().Debugf("Cost '%d' of the request is higher than the allowed limit '%d'", cost, accessDef.Limit.MaxQueryCost)
+		return m.handleComplexityFailReason(ComplexityFailReasonCostLimitExceeded)
+	}
+
+	if chargeQuota {
+		// the request itself has already been counted by the quota check
+		rateLimitKey, quotaKey := m.Gw.rateLimitKeys(r, session)
+		if m.Gw.SessionLimiter.RedisQuotaCharge(session, quotaKey, allowanceScope, &accessDef.Limit, m.Gw.GetConfig().HashKeys, int64(cost)-1) {
+			quotaCheck := &RateLimitAndQuotaCheck{BaseMiddleware: m.BaseMiddleware}
+			return quotaCheck.handleQuotaFailure(r, rateLimitKey)
+		}
+	}
+
+	return nil, http.StatusOK
 }
 
 func (m *GraphQLComplexityMiddleware) handleComplexityFailReason(failReason ComplexityFailReason) (error, int) {
@@ -65,11 +127,26 @@ func (m *GraphQLComplexityMiddleware) handleComplexityFailReason(failReason Comp
 		return ProxyingRequestFailedErr, http.StatusInternalServerError
 	case ComplexityFailReasonDepthLimitExceeded:
 		return GraphQLDepthLimitExceededErr, http.StatusForbidden
+	case ComplexityFailReasonCostLimitExceeded:
+		return GraphQLQueryCostExceededErr, http.StatusForbidden
 	}
 
 	return nil, http.StatusOK
 }
 
+// graphQLRequestOperation returns the operation of the GraphQL request parsed by the GraphQL middleware.
+func graphQLRequestOperation(r *http.Request) (query, operationName string, variables []byte, ok bool) {
+	if gqlRequest := ctxGetGraphQLRequest(r); gqlRequest != nil {
+		return gqlRequest.Query, gqlRequest.OperationName, gqlRequest.Variables, true
+	}
+
+	if gqlRequest := ctxGetGraphQLRequestV2(r); gqlRequest != nil {
+		return gqlRequest.Query, gqlRequest.OperationName, gqlRequest.Variables, true
+	}
+
+	return "", "", nil, false
+}
+
 type GraphqlComplexityChecker struct {
 	logger *logrus.Entry
 }
@@ -10,6 +10,8 @@ import (
 
 	"github.com/TykTechnologies/graphql-go-tools/pkg/graphql"
 
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/test"
 	"github.com/TykTechnologies/tyk/user"
 )
 
@@ -257,7 +259,7 @@ func TestGraphQLComplexityMiddleware_ProcessRequest_GraphqlLimits(t *testing.T)
 		spec.GraphQL.Schema = gqlCountriesSchema
 	})[0]
 	m := GraphQLComplexityMiddleware{
-		&BaseMiddleware{
+		BaseMiddleware: &BaseMiddleware{
 			Spec: apiSpec,
 		},
 	}
@@ -331,6 +333,70 @@ func TestGraphQLComplexityMiddleware_ProcessRequest_GraphqlLimits(t *testing.T)
 	}
 }
 
+func TestGraphQLComplexityMiddleware_QueryCost(t *testing.T) {
+	ts := StartTest(nil)
+	t.Cleanup(ts.Close)
+
+	api := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.UseKeylessAccess = false
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = testGraphQLProxyUpstream
+		spec.GraphQL.Enabled = true
+		spec.GraphQL.ExecutionMode = apidef.GraphQLExecutionModeProxyOnly
+		spec.GraphQL.Version = apidef.GraphQLConfigVersion2
+		spec.GraphQL.Schema = gqlProxyUpstreamSchema
+		spec.GraphQL.CostAnalysis = apidef.GraphQLCostAnalysisConfig{
+			Enabled:     true,
+			Weights:     map[string]int{"Query.hello": 3},
+			ChargeQuota: true,
+		}
+	})[0]
+
+	createKey := func(limit user.APILimit) string {
+		_, key := ts.CreateSession(func(s *user.SessionState) {
+			s.AccessRights = map[string]user.AccessDefinition{
+				api.APIID: {APIID: api.APIID, APIName: api.Name, Limit: limit},
+			}
+		})
+		return key
+	}
+
+	request := graphql.Request{
+		Query:     `query ($a: String!) { hello(name: $a) httpMethod }`,
+		Variables: []byte(`{"a":"World"}`),
+	}
+
+	t.Run("max query cost", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Data: request, Headers: map[string]string{"Authorization": createKey(user.APILimit{MaxQueryCost: 2})},
+				Code: http.StatusForbidden, BodyMatch: GraphQLQueryCostExceededErr.Error()},
+			{Method: http.MethodPost, Data: request, Headers: map[string]string{"Authorization": createKey(user.APILimit{MaxQueryCost: 3})},
+				Code: http.StatusOK},
+		}...)
+	})
+
+	t.Run("charge quota", func(t *testing.T) {
+		authHeaders := map[string]string{"Authorization": createKey(user.APILimit{QuotaMax: 5, QuotaRenewalRate: 60})}
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Data: request, Headers: authHeaders, Code: http.StatusOK},
+			{Method: http.MethodPost, Data: request, Headers: authHeaders, Code: http.StatusForbidden, BodyMatch: "Quota exceeded"},
+		}...)
+	})
+
+	t.Run("charged quota remaining doesn't go below zero", func(t *testing.T) {
+		limit := user.APILimit{QuotaMax: 5, QuotaRenewalRate: 60}
+		session, _ := ts.CreateSession(func(s *user.SessionState) {
+			s.AccessRights = map[string]user.AccessDefinition{
+				api.APIID: {APIID: api.APIID, APIName: api.Name, Limit: limit},
+			}
+		})
+
+		assert.True(t, ts.Gw.SessionLimiter.RedisQuotaCharge(session, "", "", &limit, false, 10))
+		assert.Equal(t, int64(0), session.QuotaRemaining)
+		assert.Equal(t, int64(0), session.AccessRights[api.APIID].Limit.QuotaRemaining)
+	})
+}
+
 const gqlSchemaIntrospectionQueryWithMultipleFields = `query IntrospectionQuery {
   countries {
     name
@@ -0,0 +1,280 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/graphengine"
+	"github.com/TykTechnologies/tyk/internal/persistedquery"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+var errWebsocketNotSafelisted = fmt.Errorf("%w: websocket connections are not allowed", persistedquery.ErrNotSafelisted)
+
+// GraphQLPersistedQueryMiddleware resolves the persisted queries of GraphQL requests and enforces the operation
+// safelist of the API, or of the policies of the key.
+type GraphQLPersistedQueryMiddleware struct {
+	*BaseMiddleware
+
+	store *persistedquery.Store
+}
+
+func (m *GraphQLPersistedQueryMiddleware) Name() string {
+	return "GraphQLPersistedQueryMiddleware"
+}
+
+func (m *GraphQLPersistedQueryMiddleware) EnabledForSpec() bool {
+	if !m.Spec.GraphQL.Enabled {
+		return false
+	}
+
+	conf := m.Spec.GraphQL.PersistedQueries
+	return conf.Automatic || conf.Safelist || !m.Spec.UseKeylessAccess
+}
+
+func (m *GraphQLPersistedQueryMiddleware) Init() {
+	m.store = m.Gw.persistedQueryStore(m.Spec.APIID)
+}
+
+func (m *GraphQLPersistedQueryMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		return nil, http.StatusOK
+	}
+
+	conf := persistedquery.Config{
+		Automatic: m.Spec.GraphQL.PersistedQueries.Automatic,
+		TTL:       m.Spec.GraphQL.PersistedQueries.TTL,
+		Safelist:  m.Spec.GraphQL.PersistedQueries.Safelist || m.sessionEnforcesSafelist(r),
+	}
+	if !conf.Automatic && !conf.Safelist {
+		return nil, http.StatusOK
+	}
+
+	// the operations of websocket connections are sent after the upgrade, they can't be checked against the safelist
+	if websocket.IsWebSocketUpgrade(r) {
+		if conf.Safelist {
+			return m.writeError(w, errWebsocketNotSafelisted)
+		}
+		return nil, http.StatusOK
+	}
+
+	if r.Method == http.MethodGet {
+		return m.resolveQueryParameters(w, r, conf)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get(header.ContentType)); mediaType == graphengine.MediaTypeMultipartFormData {
+		return m.resolveUpload(w, r, conf)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		m.Logger().WithError(err).Error("error reading request")
+		return errors.New("error reading the request"), http.StatusBadRequest
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	nopCloseRequestBody(r)
+
+	var gqlReq persistedquery.Request
+	if err := json.Unmarshal(body, &gqlReq); err != nil {
+		return m.malformedRequest(conf)
+	}
+
+	query, err := m.store.Resolve(gqlReq, conf)
+	if err != nil {
+		return m.writeError(w, err)
+	}
+
+	if query == gqlReq.Query {
+		return nil, http.StatusOK
+	}
+
+	if body, err = setGraphQLQuery(body, query); err != nil {
+		m.Logger().WithError(err).Error("error setting the persisted query")
+		return ProxyingRequestFailedErr, http.StatusInternalServerError
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	nopCloseRequestBody(r)
+
+	return nil, http.StatusOK
+}
+
+// resolveQueryParameters resolves the query of a GraphQL request sent with GET, replacing its query parameter.
+func (m *GraphQLPersistedQueryMiddleware) resolveQueryParameters(w http.ResponseWriter, r *http.Request, conf persistedquery.Config) (error, int) {
+	values := r.URL.Query()
+
+	var gqlReq persistedquery.Request
+	gqlReq.Query = values.Get("query")
+	if extensions := values.Get("extensions"); extensions != "" {
+		if err := json.Unmarshal([]byte(extensions), &gqlReq.Extensions); err != nil {
+			return m.malformedRequest(conf)
+		}
+	}
+
+	query, err := m.store.Resolve(gqlReq, conf)
+	if err != nil {
+		return m.writeError(w, err)
+	}
+
+	if query != gqlReq.Query {
+		values.Set("query", query)
+		r.URL.RawQuery = values.Encode()
+	}
+
+	return nil, http.StatusOK
+}
+
+// malformedRequest lets the GraphQL middleware report the malformed requests, unless the safelist is enforced as
+// the operation of the request can't be checked.
+func (m *GraphQLPersistedQueryMiddleware) malformedRequest(conf persistedquery.Config) (error, int) {
+	if conf.Safelist {
+		return graphengine.ErrInvalidHTTPRequest, http.StatusBadRequest
+	}
+
+	return nil, http.StatusOK
+}
+
+// resolveUpload checks the operation of an upload request. The operations are passed to the upstream as they are
+// sent, the persisted queries sent without their query are reported as not found for the clients to send it.
+func (m *GraphQLPersistedQueryMiddleware) resolveUpload(w http.ResponseWriter, r *http.Request, conf persistedquery.Config) (error, int) {
+	upload, err := readGraphQLRequest(r)
+	if err != nil {
+		return m.malformedRequest(conf)
+	}
+
+	var gqlReq persistedquery.Request
+	if err := json.Unmarshal(upload.Body, &gqlReq); err != nil {
+		return m.malformedRequest(conf)
+	}
+
+	query, err := m.store.Resolve(gqlReq, conf)
+	if err != nil {
+		return m.writeError(w, err)
+	}
+
+	if query != gqlReq.Query {
+		return m.writeError(w, persistedquery.ErrNotFound)
+	}
+
+	return nil, http.StatusOK
+}
+
+func (m *GraphQLPersistedQueryMiddleware) sessionEnforcesSafelist(r *http.Request) bool {
+	session := ctxGetSession(r)
+	if session == nil {
+		return false
+	}
+
+	rights, ok := session.AccessRights[m.Spec.APIID]
+	return ok && rights.EnforceOperationSafelist
+}
+
+// writeError writes the error as a GraphQL response, with the error codes expected by the clients.
+func (m *GraphQLPersistedQueryMiddleware) writeError(w http.ResponseWriter, err error) (error, int) {
+	var code string
+	var status int
+	switch {
+	case errors.Is(err, persistedquery.ErrNotFound):
+		code, status = "PERSISTED_QUERY_NOT_FOUND", http.StatusOK
+	case errors.Is(err, persistedquery.ErrHashMismatch):
+		code, status = "INVALID_PERSISTED_QUERY_HASH", http.StatusBadRequest
+	case errors.Is(err, persistedquery.ErrNotSafelisted):
+		code, status = "OPERATION_NOT_SAFELISTED", http.StatusForbidden
+	default:
+		m.Logger().WithError(err).Error("error resolving the persisted query")
+		return ProxyingRequestFailedErr, http.StatusInternalServerError
+	}
+
+	m.Logger().WithError(err).Debug("GraphQL request rejected")
+
+	type gqlError struct {
+		Message    string            `json:"message"`
+		Extensions map[string]string `json:"extensions"`
+	}
+
+	w.Header().Set(header.ContentType, header.ApplicationJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string][]gqlError{
+		"errors": {{Message: err.Error(), Extensions: map[string]string{"code": code}}},
+	})
+
+	return errCustomBodyResponse, status
+}
+
+// setGraphQLQuery sets the query of the GraphQL request body, keeping its other fields.
+func setGraphQLQuery(body []byte, query string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	rawQuery, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	fields["query"] = rawQuery
+
+	return json.Marshal(fields)
+}
+
+func (gw *Gateway) persistedQueryStore(apiID string) *persistedquery.Store {
+	handler := &storage.RedisCluster{KeyPrefix: persistedquery.KeyPrefix, ConnectionHandler: gw.StorageConnectionHandler}
+	return persistedquery.NewStore(handler, apiID)
+}
+
+// graphQLOperationsHandler lists and registers the safelisted operations of a GraphQL API.
+func (gw *Gateway) graphQLOperationsHandler(w http.ResponseWriter, r *http.Request) {
+	apiID := mux.Vars(r)["apiID"]
+	if spec := gw.getApiSpec(apiID); spec == nil || !spec.GraphQL.Enabled {
+		doJSONWrite(w, http.StatusNotFound, apiError("GraphQL API not found"))
+		return
+	}
+
+	store := gw.persistedQueryStore(apiID)
+	if r.Method == http.MethodGet {
+		ops, err := store.Operations()
+		if err != nil {
+			doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to list operations"))
+			return
+		}
+		doJSONWrite(w, http.StatusOK, ops)
+		return
+	}
+
+	var op persistedquery.Operation
+	if err := json.NewDecoder(r.Body).Decode(&op); err != nil {
+		doJSONWrite(w, http.StatusBadRequest, apiError("Request malformed"))
+		return
+	}
+
+	registered, err := store.Register(op)
+	switch {
+	case errors.Is(err, persistedquery.ErrInvalidOperation), errors.Is(err, persistedquery.ErrHashMismatch):
+		doJSONWrite(w, http.StatusBadRequest, apiError(err.Error()))
+	case err != nil:
+		doJSONWrite(w, http.StatusInternalServerError, apiError("Failed to register operation"))
+	default:
+		doJSONWrite(w, http.StatusOK, registered)
+	}
+}
+
+// graphQLOperationHandler removes a safelisted operation of a GraphQL API.
+func (gw *Gateway) graphQLOperationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !gw.persistedQueryStore(vars["apiID"]).Unregister(vars["hash"]) {
+		doJSONWrite(w, http.StatusNotFound, apiError("Operation not found"))
+		return
+	}
+
+	doJSONWrite(w, http.StatusOK, apiOk("Operation deleted"))
+}
@@ -0,0 +1,142 @@
+package gateway
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/persistedquery"
+	"github.com/TykTechnologies/tyk/test"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+const persistedHelloQuery = `query ($a: String!) { hello(name: $a) httpMethod }`
+
+func persistedQueryRequest(query string, hash string) map[string]interface{} {
+	req := map[string]interface{}{"variables": map[string]string{"a": "World"}}
+	if query != "" {
+		req["query"] = query
+	}
+	if hash != "" {
+		req["extensions"] = map[string]interface{}{"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash}}
+	}
+	return req
+}
+
+func TestGraphQLPersistedQueryMiddleware(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	buildSpec := func(spec *APISpec) {
+		spec.GraphQL.Enabled = true
+		spec.GraphQL.ExecutionMode = apidef.GraphQLExecutionModeProxyOnly
+		spec.GraphQL.Version = apidef.GraphQLConfigVersion2
+		spec.GraphQL.Schema = gqlProxyUpstreamSchema
+		spec.Proxy.TargetURL = testGraphQLProxyUpstream
+	}
+
+	api := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		buildSpec(spec)
+		// the automatically persisted queries are kept in Redis across the runs
+		spec.APIID = "apq-" + randStringBytes(8)
+		spec.UseKeylessAccess = true
+		spec.Proxy.ListenPath = "/apq"
+		spec.GraphQL.PersistedQueries.Automatic = true
+	}, func(spec *APISpec) {
+		buildSpec(spec)
+		spec.APIID = "safelist"
+		spec.UseKeylessAccess = false
+		spec.Proxy.ListenPath = "/safelist"
+	})[1]
+
+	hash := persistedquery.Hash(persistedHelloQuery)
+
+	t.Run("automatic persisted queries", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Path: "/apq", Data: persistedQueryRequest("", hash), Code: http.StatusOK,
+				BodyMatch: `"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}`},
+			{Method: http.MethodPost, Path: "/apq", Data: persistedQueryRequest(persistedHelloQuery, persistedquery.Hash("other")),
+				Code: http.StatusBadRequest, BodyMatch: `"code":"INVALID_PERSISTED_QUERY_HASH"`},
+			{Method: http.MethodPost, Path: "/apq", Data: persistedQueryRequest(persistedHelloQuery, hash), Code: http.StatusOK,
+				BodyMatch: `"hello":"World"`},
+			{Method: http.MethodPost, Path: "/apq", Data: persistedQueryRequest("", hash), Code: http.StatusOK,
+				BodyMatch: `"hello":"World"`},
+		}...)
+	})
+
+	_, key := ts.CreateSession(func(s *user.SessionState) {
+		s.AccessRights = map[string]user.AccessDefinition{
+			api.APIID: {APIID: api.APIID, APIName: api.Name, EnforceOperationSafelist: true},
+		}
+	})
+	_, unrestrictedKey := ts.CreateSession(func(s *user.SessionState) {
+		s.AccessRights = map[string]user.AccessDefinition{
+			api.APIID: {APIID: api.APIID, APIName: api.Name},
+		}
+	})
+
+	t.Run("safelist enforced by policy", func(t *testing.T) {
+		authHeaders := map[string]string{"Authorization": key}
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Path: "/safelist", Data: persistedQueryRequest(persistedHelloQuery, ""), Headers: authHeaders,
+				Code: http.StatusForbidden, BodyMatch: `"code":"OPERATION_NOT_SAFELISTED"`},
+			{Method: http.MethodPost, Path: "/safelist", Data: persistedQueryRequest(persistedHelloQuery, ""),
+				Headers: map[string]string{"Authorization": unrestrictedKey}, Code: http.StatusOK, BodyMatch: `"hello":"World"`},
+			{Method: http.MethodPost, Path: "/tyk/apis/safelist/graphql/operations", Data: persistedquery.Operation{Query: "{ hello"},
+				AdminAuth: true, Code: http.StatusBadRequest, BodyMatch: persistedquery.ErrInvalidOperation.Error()},
+			{Method: http.MethodPost, Path: "/tyk/apis/unknown/graphql/operations", Data: persistedquery.Operation{Query: persistedHelloQuery},
+				AdminAuth: true, Code: http.StatusNotFound},
+			{Method: http.MethodPost, Path: "/tyk/apis/safelist/graphql/operations", Data: persistedquery.Operation{Name: "Hello", Query: persistedHelloQuery},
+				AdminAuth: true, Code: http.StatusOK, BodyMatch: `"hash":"` + hash + `"`},
+			{Method: http.MethodGet, Path: "/tyk/apis/safelist/graphql/operations", AdminAuth: true, Code: http.StatusOK,
+				BodyMatch: `^\[{"hash":"` + hash + `","name":"Hello"`},
+			{Method: http.MethodPost, Path: "/safelist", Data: persistedQueryRequest(persistedHelloQuery, ""), Headers: authHeaders,
+				Code: http.StatusOK, BodyMatch: `"hello":"World"`},
+			{Method: http.MethodPost, Path: "/safelist", Data: persistedQueryRequest("", hash), Headers: authHeaders,
+				Code: http.StatusOK, BodyMatch: `"hello":"World"`},
+			{Method: http.MethodDelete, Path: "/tyk/apis/safelist/graphql/operations/" + hash, AdminAuth: true, Code: http.StatusOK},
+			{Method: http.MethodDelete, Path: "/tyk/apis/safelist/graphql/operations/" + hash, AdminAuth: true, Code: http.StatusNotFound},
+			{Method: http.MethodPost, Path: "/safelist", Data: persistedQueryRequest("", hash), Headers: authHeaders,
+				Code: http.StatusForbidden, BodyMatch: `"code":"OPERATION_NOT_SAFELISTED"`},
+		}...)
+	})
+
+	t.Run("safelist fails closed", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Path: "/safelist", Data: `{"query": 1}`, Headers: map[string]string{"Authorization": key},
+				Code: http.StatusBadRequest},
+			{Method: http.MethodGet, Path: "/safelist?extensions=invalid", Headers: map[string]string{"Authorization": key},
+				Code: http.StatusBadRequest},
+			{Method: http.MethodGet, Path: "/safelist", Headers: map[string]string{
+				"Authorization":             key,
+				header.Connection:           "Upgrade",
+				header.Upgrade:              "websocket",
+				header.SecWebSocketProtocol: "graphql-ws",
+				"Sec-WebSocket-Version":     "13",
+				"Sec-WebSocket-Key":         "123abc",
+			}, Code: http.StatusForbidden, BodyMatch: `"code":"OPERATION_NOT_SAFELISTED"`},
+		}...)
+	})
+
+	t.Run("safelist checks uploads", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		require.NoError(t, writer.WriteField("operations", `{"query":"mutation ($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`))
+		require.NoError(t, writer.WriteField("map", `{"0":["variables.file"]}`))
+		part, err := writer.CreateFormFile("0", "a.txt")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte("file content"))
+		require.NoError(t, writer.Close())
+
+		_, _ = ts.Run(t, test.TestCase{
+			Method: http.MethodPost, Path: "/safelist", Data: body.Bytes(),
+			Headers: map[string]string{"Authorization": key, header.ContentType: writer.FormDataContentType()},
+			Code:    http.StatusForbidden, BodyMatch: `"code":"OPERATION_NOT_SAFELISTED"`,
+		})
+	})
+}
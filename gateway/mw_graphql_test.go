@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"testing"
@@ -1418,6 +1422,133 @@ func TestGraphQLMiddleware_EngineMode(t *testing.T) {
 	})
 }
 
+func TestGraphQLMiddleware_GraphQLOverHTTP(t *testing.T) {
+	g := StartTest(nil)
+	defer g.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get(header.ContentType))
+		if mediaType == "multipart/form-data" {
+			file, _, err := r.FormFile("0")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(file)
+			_, _ = fmt.Fprintf(w, `{"data":{"upload":%q}}`, content)
+			return
+		}
+
+		w.Header().Set(header.ContentType, `multipart/mixed; boundary="-"`)
+		_, _ = w.Write([]byte("\r\n---\r\nContent-Type: application/json\r\n\r\n{\"data\":{\"hello\":\"World\"},\"hasNext\":true}\r\n---\r\nContent-Type: application/json\r\n\r\n{\"incremental\":[{\"data\":{\"httpMethod\":\"POST\"},\"path\":[]}],\"hasNext\":false}\r\n-----\r\n"))
+	}))
+	defer upstream.Close()
+
+	g.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = "proxy-upstream"
+		spec.UseKeylessAccess = true
+		spec.GraphQL.Enabled = true
+		spec.GraphQL.ExecutionMode = apidef.GraphQLExecutionModeProxyOnly
+		spec.GraphQL.Version = apidef.GraphQLConfigVersion2
+		spec.GraphQL.Schema = gqlProxyUpstreamSchema
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = testGraphQLProxyUpstream
+	}, func(spec *APISpec) {
+		spec.APIID = "proxy-incremental"
+		spec.UseKeylessAccess = true
+		spec.GraphQL.Enabled = true
+		spec.GraphQL.ExecutionMode = apidef.GraphQLExecutionModeProxyOnly
+		spec.GraphQL.Version = apidef.GraphQLConfigVersion2
+		spec.GraphQL.Schema = "scalar Upload\n" + gqlProxyUpstreamSchema + "\ntype Mutation { upload(file: Upload!): String! }"
+		spec.Proxy.ListenPath = "/incremental/"
+		spec.Proxy.TargetURL = upstream.URL
+	})
+
+	query := url.Values{
+		"query":     {`query ($a: String!) { hello(name: $a) httpMethod }`},
+		"variables": {`{"a":"World"}`},
+	}
+
+	t.Run("GET", func(t *testing.T) {
+		mutation := url.Values{"query": {`mutation { upload(file: "0") }`}}
+		_, _ = g.Run(t, []test.TestCase{
+			{Method: http.MethodGet, Path: "/?" + query.Encode(), Code: http.StatusOK,
+				BodyMatch: `{"data":{"hello":"World","httpMethod":"POST"}}`},
+			{Method: http.MethodGet, Path: "/incremental/?" + mutation.Encode(), Code: http.StatusMethodNotAllowed,
+				HeadersMatch: map[string]string{header.Allow: http.MethodPost}},
+			{Method: http.MethodGet, Path: "/?query=%7B&variables=%7B", Code: http.StatusBadRequest},
+		}...)
+	})
+
+	t.Run("response media type", func(t *testing.T) {
+		_, _ = g.Run(t, []test.TestCase{
+			{Method: http.MethodGet, Path: "/?" + query.Encode(), Code: http.StatusOK,
+				Headers:      map[string]string{header.Accept: "application/graphql-response+json"},
+				HeadersMatch: map[string]string{header.ContentType: "application/graphql-response+json"}},
+			{Method: http.MethodGet, Path: "/?" + query.Encode(), Code: http.StatusOK,
+				Headers:      map[string]string{header.Accept: "application/json"},
+				HeadersMatch: map[string]string{header.ContentType: header.ApplicationJSON}},
+		}...)
+	})
+
+	t.Run("multipart upload is passed through", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		require.NoError(t, writer.WriteField("operations", `{"query":"mutation ($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`))
+		require.NoError(t, writer.WriteField("map", `{"0":["variables.file"]}`))
+		part, err := writer.CreateFormFile("0", "a.txt")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte("file content"))
+		require.NoError(t, writer.Close())
+
+		_, _ = g.Run(t, test.TestCase{
+			Method: http.MethodPost, Path: "/incremental/", Data: body.Bytes(), Code: http.StatusOK,
+			Headers:   map[string]string{header.ContentType: writer.FormDataContentType()},
+			BodyMatch: `{"data":{"upload":"file content"}}`,
+		})
+	})
+
+	t.Run("incremental delivery is passed through", func(t *testing.T) {
+		request := gql.Request{
+			Query:     `query ($a: String!) { hello(name: $a) ... @defer { httpMethod } }`,
+			Variables: []byte(`{"a":"World"}`),
+		}
+
+		_, _ = g.Run(t, test.TestCase{
+			Method: http.MethodPost, Path: "/incremental/", Data: request, Code: http.StatusOK,
+			Headers:      map[string]string{header.Accept: "multipart/mixed;deferSpec=20220824, application/json"},
+			HeadersMatch: map[string]string{header.ContentType: `multipart/mixed; boundary="-"`},
+			BodyMatch:    `"hasNext":false`,
+		})
+	})
+}
+
+func TestReadGraphQLRequest(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("operations", `{"query":"mutation ($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`))
+	require.NoError(t, writer.WriteField("map", `{"0":["variables.file"]}`))
+	part, err := writer.CreateFormFile("0", "a.txt")
+	require.NoError(t, err)
+	_, _ = part.Write(bytes.Repeat([]byte("a"), 1<<20))
+	require.NoError(t, writer.Close())
+	sent := body.Bytes()
+
+	src := bytes.NewReader(sent)
+	r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(src))
+	r.Header.Set(header.ContentType, writer.FormDataContentType())
+
+	gqlRequest, err := readGraphQLRequest(r)
+	require.NoError(t, err)
+	assert.True(t, gqlRequest.Upload)
+	assert.JSONEq(t, `{"query":"mutation ($file: Upload!) { upload(file: $file) }","variables":{"file":"0"}}`, string(gqlRequest.Body))
+	assert.Greater(t, src.Len(), len(sent)/2, "the file shouldn't be read")
+
+	restored, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Equal(t, sent, restored)
+}
+
 func TestNeedsGraphQLExecutionEngine(t *testing.T) {
 	testCases := []struct {
 		name          string
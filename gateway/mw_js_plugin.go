@@ -6,6 +6,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -181,48 +183,16 @@ func (d *DynamicMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Reques
 
 	// Run the middleware
 	middlewareClassname := d.MiddlewareClassName
-	if d.Spec.JSVM.VM == nil {
+	if !d.Spec.JSVM.initialized() {
 		logger.WithError(err).Error("JSVM isn't enabled, check your gateway settings")
 		return errors.New("Middleware error"), 500
 	}
-	vm := d.Spec.JSVM.VM.Copy()
-	vm.Interrupt = make(chan func(), 1)
 	logger.Debug("Running: ", middlewareClassname)
-	// buffered, leaving no chance of a goroutine leak since the
-	// spawned goroutine will send 0 or 1 values.
-	ret := make(chan otto.Value, 1)
-	errRet := make(chan error, 1)
-	go func() {
-		defer func() {
-			// the VM executes the panic func that gets it
-			// to stop, so we must recover here to not crash
-			// the whole Go program.
-			recover()
-		}()
-		returnRaw, err := vm.Run(middlewareClassname + `.DoProcessRequest(` + string(requestAsJson) + `, ` + string(sessionAsJson) + `, ` + specAsJson + `);`)
-		ret <- returnRaw
-		errRet <- err
-	}()
-	var returnRaw otto.Value
-	t := time.NewTimer(d.Spec.JSVM.Timeout)
-	select {
-	case returnRaw = <-ret:
-		if err := <-errRet; err != nil {
-			logger.WithError(err).Error("Failed to run JS middleware")
-			return errors.New(http.StatusText(http.StatusInternalServerError)), http.StatusInternalServerError
-		}
-		t.Stop()
-	case <-t.C:
-		t.Stop()
-		logger.Error("JS middleware timed out after ", d.Spec.JSVM.Timeout)
-		vm.Interrupt <- func() {
-			// only way to stop the VM is to send it a func
-			// that panics.
-			panic("stop")
-		}
+	returnDataStr, err := d.Spec.JSVM.run(middlewareClassname + `.DoProcessRequest(` + string(requestAsJson) + `, ` + string(sessionAsJson) + `, ` + specAsJson + `);`)
+	if err != nil {
+		logger.WithError(err).Error("Failed to run JS middleware")
 		return errors.New(http.StatusText(http.StatusInternalServerError)), http.StatusInternalServerError
 	}
-	returnDataStr, _ := returnRaw.ToString()
 
 	// Decode the return object
 	newRequestData := VMReturnObject{}
@@ -332,6 +302,8 @@ type JSVM struct {
 	Log     *logrus.Entry  `json:"-"` // logger used by the JS code
 	RawLog  *logrus.Logger `json:"-"` // logger used by `rawlog` func to avoid formatting
 	Gw      *Gateway       `json:"-"`
+
+	goja *gojaVM // set instead of VM when the API uses the goja engine
 }
 
 const defaultJSVMTimeout = 5
@@ -339,18 +311,51 @@ const defaultJSVMTimeout = 5
 // Init creates the JSVM with the core library and sets up a default
 // timeout.
 func (j *JSVM) Init(spec *APISpec, logger *logrus.Entry, gw *Gateway) {
-	vm := otto.New()
 	j.Gw = gw
 	logger = logger.WithField("prefix", "jsvm")
 
+	if spec != nil && spec.JSRuntime.Engine == apidef.JSEngineGoja {
+		j.Log = logger
+		j.RawLog = rawLog
+		j.Spec = spec
+		j.VM = nil
+
+		if err := j.initGoja(spec.JSRuntime); err != nil {
+			logger.WithError(err).Error("Could not load TykJS")
+			return
+		}
+	} else if !j.initOtto(spec, logger) {
+		return
+	}
+
+	if jsvmTimeout := gw.GetConfig().JSVMTimeout; jsvmTimeout <= 0 {
+		j.Timeout = time.Duration(defaultJSVMTimeout) * time.Second
+		logger.Debugf("Default JSVM timeout used: %v", j.Timeout)
+	} else {
+		j.Timeout = time.Duration(jsvmTimeout) * time.Second
+		logger.Debugf("Custom JSVM timeout: %v", j.Timeout)
+	}
+
+	if spec != nil && spec.JSRuntime.Timeout > 0 {
+		j.Timeout = time.Duration(spec.JSRuntime.Timeout) * time.Second
+		logger.Debugf("API JSVM timeout: %v", j.Timeout)
+	}
+
+	j.Log = logger // use the global logger by default
+	j.RawLog = rawLog
+}
+
+func (j *JSVM) initOtto(spec *APISpec, logger *logrus.Entry) bool {
+	vm := otto.New()
+
 	// Init TykJS namespace, constructors etc.
 	if _, err := vm.Run(coreJS); err != nil {
 		logger.WithError(err).Error("Could not load TykJS")
-		return
+		return false
 	}
 
 	// Load user's TykJS on top, if any
-	if path := gw.GetConfig().TykJSPath; path != "" {
+	if path := j.Gw.GetConfig().TykJSPath; path != "" {
 		f, err := os.Open(path)
 		if err == nil {
 			_, err = vm.Run(f)
@@ -363,21 +368,13 @@ func (j *JSVM) Init(spec *APISpec, logger *logrus.Entry, gw *Gateway) {
 	}
 
 	j.VM = vm
+	j.goja = nil
 	j.Spec = spec
 
 	// Add environment API
 	j.LoadTykJSApi()
 
-	if jsvmTimeout := gw.GetConfig().JSVMTimeout; jsvmTimeout <= 0 {
-		j.Timeout = time.Duration(defaultJSVMTimeout) * time.Second
-		logger.Debugf("Default JSVM timeout used: %v", j.Timeout)
-	} else {
-		j.Timeout = time.Duration(jsvmTimeout) * time.Second
-		logger.Debugf("Custom JSVM timeout: %v", j.Timeout)
-	}
-
-	j.Log = logger // use the global logger by default
-	j.RawLog = rawLog
+	return true
 }
 
 func (j *JSVM) DeInit() {
@@ -387,6 +384,65 @@ func (j *JSVM) DeInit() {
 	j.Gw = nil
 }
 
+// initialized checks if the JSVM has been initialized with either engine.
+func (j *JSVM) initialized() bool {
+	return j.VM != nil || j.goja != nil
+}
+
+// load runs the JS code of the file or blob named name in the VM.
+func (j *JSVM) load(name string, src io.Reader) error {
+	if j.goja != nil {
+		return j.goja.load(name, src)
+	}
+
+	_, err := j.VM.Run(src)
+	return err
+}
+
+// run evaluates the JS expression and returns its result as a string. The evaluation is stopped after the timeout.
+func (j *JSVM) run(expr string) (string, error) {
+	if j.goja != nil {
+		return j.goja.run(expr, j.Timeout)
+	}
+
+	vm := j.VM.Copy()
+	vm.Interrupt = make(chan func(), 1)
+	// buffered, leaving no chance of a goroutine leak since the
+	// spawned goroutine will send 0 or 1 values.
+	ret := make(chan otto.Value, 1)
+	errRet := make(chan error, 1)
+	go func() {
+		defer func() {
+			// the VM executes the panic func that gets it
+			// to stop, so we must recover here to not crash
+			// the whole Go program.
+			recover()
+		}()
+		returnRaw, err := vm.Run(expr)
+		ret <- returnRaw
+		errRet <- err
+	}()
+	var returnRaw otto.Value
+	t := time.NewTimer(j.Timeout)
+	select {
+	case returnRaw = <-ret:
+		if err := <-errRet; err != nil {
+			return "", err
+		}
+		t.Stop()
+	case <-t.C:
+		t.Stop()
+		vm.Interrupt <- func() {
+			// only way to stop the VM is to send it a func
+			// that panics.
+			panic("stop")
+		}
+		return "", fmt.Errorf("JS middleware timed out after %s", j.Timeout)
+	}
+
+	return returnRaw.ToString()
+}
+
 // LoadJSPaths will load JS classes and functionality in to the VM by file
 func (j *JSVM) LoadJSPaths(paths []string, prefix string) {
 	for _, mwPath := range paths {
@@ -404,7 +460,7 @@ func (j *JSVM) LoadJSPaths(paths []string, prefix string) {
 			j.Log.WithError(err).Error("Failed to open JS middleware file")
 			continue
 		}
-		if _, err := j.VM.Run(f); err != nil {
+		if err := j.load(mwPath, f); err != nil {
 			j.Log.WithError(err).Error("Failed to load JS middleware")
 		}
 		f.Close()
@@ -431,75 +487,204 @@ type TykJSHttpResponse struct {
 	HeadersComp map[string][]string `json:"headers"`
 }
 
+// jsLog logs a message of the JS code.
+func (j *JSVM) jsLog(msg string) {
+	j.Log.WithFields(logrus.Fields{
+		"type": "log-msg",
+	}).Info(msg)
+}
+
+// jsRawLog logs a message of the JS code without formatting it.
+func (j *JSVM) jsRawLog(msg string) {
+	j.RawLog.Print(msg + "\n")
+}
+
+// b64dec decodes standard base64, falling back to the unpadded encoding.
+func b64dec(in string) (string, error) {
+	out, err := base64.StdEncoding.DecodeString(in)
+
+	// Fallback to RawStdEncoding:
+	if err != nil {
+		out, err = base64.RawStdEncoding.DecodeString(in)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return string(out), nil
+}
+
+// httpClient returns a client making requests to host with the upstream TLS and proxy settings of the API.
+func (j *JSVM) httpClient(host string) *http.Client {
+	maxSSLVersion := j.Gw.GetConfig().ProxySSLMaxVersion
+	if j.Spec.Proxy.Transport.SSLMaxVersion > 0 {
+		maxSSLVersion = j.Spec.Proxy.Transport.SSLMaxVersion
+	}
+
+	tr := &http.Transport{TLSClientConfig: &tls.Config{
+		MaxVersion: maxSSLVersion,
+	}}
+
+	if cert := j.Gw.getUpstreamCertificate(host, j.Spec); cert != nil {
+		tr.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+	}
+
+	if j.Gw.GetConfig().ProxySSLInsecureSkipVerify {
+		tr.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	if j.Spec.Proxy.Transport.SSLInsecureSkipVerify {
+		tr.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	tr.DialTLS = j.Gw.customDialTLSCheck(j.Spec, tr.TLSClientConfig)
+
+	tr.Proxy = proxyFromAPI(j.Spec)
+
+	// using new Client each time should be ok, since we closing connection every time
+	return &http.Client{Transport: tr}
+}
+
+// makeHTTPRequest makes the request described by the JSON encoded TykJSHttpRequest and returns
+// the JSON encoded TykJSHttpResponse.
+func (j *JSVM) makeHTTPRequest(jsonHRO string) (string, error) {
+	hro := TykJSHttpRequest{}
+	if err := json.Unmarshal([]byte(jsonHRO), &hro); err != nil {
+		j.Log.WithError(err).Error("JSVM: Failed to deserialise HTTP Request object")
+		return "", err
+	}
+
+	// Make the request
+	domain := hro.Domain
+	data := url.Values{}
+	for k, v := range hro.FormData {
+		data.Set(k, v)
+	}
+
+	u, _ := url.ParseRequestURI(domain + hro.Resource)
+	urlStr := u.String() // "https://api.com/user/"
+
+	var d string
+	if hro.Body != "" {
+		d = hro.Body
+	} else if len(hro.FormData) > 0 {
+		d = data.Encode()
+	}
+
+	r, _ := http.NewRequest(hro.Method, urlStr, nil)
+
+	if d != "" {
+		r, _ = http.NewRequest(hro.Method, urlStr, strings.NewReader(d))
+	}
+
+	ignoreCanonical := j.Gw.GetConfig().IgnoreCanonicalMIMEHeaderKey
+	for k, v := range hro.Headers {
+		setCustomHeader(r.Header, k, v, ignoreCanonical)
+	}
+	r.Close = true
+
+	resp, err := j.httpClient(r.Host).Do(r)
+	if err != nil {
+		j.Log.WithError(err).Error("Request failed")
+		return "", err
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	bodyStr := string(body)
+	tykResp := TykJSHttpResponse{
+		Code:        resp.StatusCode,
+		Body:        bodyStr,
+		Headers:     resp.Header,
+		CodeComp:    resp.StatusCode,
+		BodyComp:    bodyStr,
+		HeadersComp: resp.Header,
+	}
+
+	retAsStr, _ := json.Marshal(tykResp)
+	return string(retAsStr), nil
+}
+
+// getKeyData returns the JSON encoded session of the key.
+func (j *JSVM) getKeyData(apiKey, apiID string) string {
+	obj, _ := j.Gw.handleGetDetail(apiKey, apiID, "", false)
+	bs, _ := json.Marshal(obj)
+	return string(bs)
+}
+
+// setKeyData adds or updates the key with the JSON encoded session.
+func (j *JSVM) setKeyData(apiKey, encodedSession, suppressReset string) {
+	newSession := user.SessionState{}
+	err := json.Unmarshal([]byte(encodedSession), &newSession)
+	if err != nil {
+		j.Log.WithError(err).Error("Failed to decode the sesison data")
+		return
+	}
+
+	j.Gw.doAddOrUpdate(apiKey, &newSession, suppressReset == "1", false)
+}
+
+// batchRequest runs the JSON encoded batch request.
+func (j *JSVM) batchRequest(requestSet string) (string, error) {
+	j.Log.Debug("Batch input is: ", requestSet)
+
+	unsafeBatchHandler := BatchRequestHandler{Gw: j.Gw}
+	bs, err := unsafeBatchHandler.ManualBatchRequest([]byte(requestSet))
+	if err != nil {
+		j.Log.WithError(err).Error("Batch request error")
+		return "", err
+	}
+
+	return string(bs), nil
+}
+
 func (j *JSVM) LoadTykJSApi() {
+	// toValue converts the result of a function, logging the error describing why it failed.
+	toValue := func(out string, msg string) otto.Value {
+		returnVal, err := j.VM.ToValue(out)
+		if err != nil {
+			j.Log.WithError(err).Error(msg)
+			return otto.Value{}
+		}
+		return returnVal
+	}
+
 	// Enable a log
 	j.VM.Set("log", func(call otto.FunctionCall) otto.Value {
-		j.Log.WithFields(logrus.Fields{
-			"type": "log-msg",
-		}).Info(call.Argument(0).String())
+		j.jsLog(call.Argument(0).String())
 		return otto.Value{}
 	})
 	j.VM.Set("rawlog", func(call otto.FunctionCall) otto.Value {
-		j.RawLog.Print(call.Argument(0).String() + "\n")
+		j.jsRawLog(call.Argument(0).String())
 		return otto.Value{}
 	})
 
 	// these two needed for non-utf8 bodies
 	j.VM.Set("b64dec", func(call otto.FunctionCall) otto.Value {
-		in := call.Argument(0).String()
-		out, err := base64.StdEncoding.DecodeString(in)
-
-		// Fallback to RawStdEncoding:
-		if err != nil {
-			out, err = base64.RawStdEncoding.DecodeString(in)
-			if err != nil {
-				j.Log.WithError(err).Error("Failed to base64 decode")
-				return otto.Value{}
-			}
-		}
-		returnVal, err := j.VM.ToValue(string(out))
+		out, err := b64dec(call.Argument(0).String())
 		if err != nil {
 			j.Log.WithError(err).Error("Failed to base64 decode")
 			return otto.Value{}
 		}
-		return returnVal
+		return toValue(out, "Failed to base64 decode")
 	})
 	j.VM.Set("b64enc", func(call otto.FunctionCall) otto.Value {
-		in := []byte(call.Argument(0).String())
-		out := base64.StdEncoding.EncodeToString(in)
-		returnVal, err := j.VM.ToValue(out)
-		if err != nil {
-			j.Log.WithError(err).Error("Failed to base64 encode")
-			return otto.Value{}
-		}
-		return returnVal
+		out := base64.StdEncoding.EncodeToString([]byte(call.Argument(0).String()))
+		return toValue(out, "Failed to base64 encode")
 	})
 
 	j.VM.Set("rawb64dec", func(call otto.FunctionCall) otto.Value {
-		in := call.Argument(0).String()
-		out, err := base64.RawStdEncoding.DecodeString(in)
+		out, err := base64.RawStdEncoding.DecodeString(call.Argument(0).String())
 		if err != nil {
 			j.Log.WithError(err).Error("Failed to base64 decode")
 			return otto.Value{}
 		}
-		returnVal, err := j.VM.ToValue(string(out))
-		if err != nil {
-			j.Log.WithError(err).Error("Failed to base64 decode")
-			return otto.Value{}
-		}
-		return returnVal
+		return toValue(string(out), "Failed to base64 decode")
 	})
 	j.VM.Set("rawb64enc", func(call otto.FunctionCall) otto.Value {
-		in := []byte(call.Argument(0).String())
-		out := base64.RawStdEncoding.EncodeToString(in)
-		returnVal, err := j.VM.ToValue(out)
-		if err != nil {
-			j.Log.WithError(err).Error("Failed to base64 encode")
-			return otto.Value{}
-		}
-		return returnVal
+		out := base64.RawStdEncoding.EncodeToString([]byte(call.Argument(0).String()))
+		return toValue(out, "Failed to base64 encode")
 	})
-	ignoreCanonical := j.Gw.GetConfig().IgnoreCanonicalMIMEHeaderKey
+
 	// Enable the creation of HTTP Requsts
 	j.VM.Set("TykMakeHttpRequest", func(call otto.FunctionCall) otto.Value {
 		jsonHRO := call.Argument(0).String()
@@ -507,152 +692,41 @@ func (j *JSVM) LoadTykJSApi() {
 			// Nope, return nothing
 			return otto.Value{}
 		}
-		hro := TykJSHttpRequest{}
-		if err := json.Unmarshal([]byte(jsonHRO), &hro); err != nil {
-			j.Log.WithError(err).Error("JSVM: Failed to deserialise HTTP Request object")
-			return otto.Value{}
-		}
-
-		// Make the request
-		domain := hro.Domain
-		data := url.Values{}
-		for k, v := range hro.FormData {
-			data.Set(k, v)
-		}
-
-		u, _ := url.ParseRequestURI(domain + hro.Resource)
-		urlStr := u.String() // "https://api.com/user/"
-
-		var d string
-		if hro.Body != "" {
-			d = hro.Body
-		} else if len(hro.FormData) > 0 {
-			d = data.Encode()
-		}
-
-		r, _ := http.NewRequest(hro.Method, urlStr, nil)
-
-		if d != "" {
-			r, _ = http.NewRequest(hro.Method, urlStr, strings.NewReader(d))
-		}
-
-		for k, v := range hro.Headers {
-			setCustomHeader(r.Header, k, v, ignoreCanonical)
-		}
-		r.Close = true
-
-		maxSSLVersion := j.Gw.GetConfig().ProxySSLMaxVersion
-		if j.Spec.Proxy.Transport.SSLMaxVersion > 0 {
-			maxSSLVersion = j.Spec.Proxy.Transport.SSLMaxVersion
-		}
-
-		tr := &http.Transport{TLSClientConfig: &tls.Config{
-			MaxVersion: maxSSLVersion,
-		}}
-
-		if cert := j.Gw.getUpstreamCertificate(r.Host, j.Spec); cert != nil {
-			tr.TLSClientConfig.Certificates = []tls.Certificate{*cert}
-		}
-
-		if j.Gw.GetConfig().ProxySSLInsecureSkipVerify {
-			tr.TLSClientConfig.InsecureSkipVerify = true
-		}
-
-		if j.Spec.Proxy.Transport.SSLInsecureSkipVerify {
-			tr.TLSClientConfig.InsecureSkipVerify = true
-		}
-
-		tr.DialTLS = j.Gw.customDialTLSCheck(j.Spec, tr.TLSClientConfig)
-
-		tr.Proxy = proxyFromAPI(j.Spec)
-
-		// using new Client each time should be ok, since we closing connection every time
-		client := &http.Client{Transport: tr}
-		resp, err := client.Do(r)
-		if err != nil {
-			j.Log.WithError(err).Error("Request failed")
-			return otto.Value{}
-		}
-
-		body, _ := ioutil.ReadAll(resp.Body)
-		bodyStr := string(body)
-		tykResp := TykJSHttpResponse{
-			Code:        resp.StatusCode,
-			Body:        bodyStr,
-			Headers:     resp.Header,
-			CodeComp:    resp.StatusCode,
-			BodyComp:    bodyStr,
-			HeadersComp: resp.Header,
-		}
 
-		retAsStr, _ := json.Marshal(tykResp)
-		returnVal, err := j.VM.ToValue(string(retAsStr))
+		out, err := j.makeHTTPRequest(jsonHRO)
 		if err != nil {
-			j.Log.WithError(err).Error("Failed to encode return value")
 			return otto.Value{}
 		}
-
-		return returnVal
+		return toValue(out, "Failed to encode return value")
 	})
 
 	// Expose Setters and Getters in the REST API for a key:
 	j.VM.Set("TykGetKeyData", func(call otto.FunctionCall) otto.Value {
-		apiKey := call.Argument(0).String()
-		apiId := call.Argument(1).String()
-
-		obj, _ := j.Gw.handleGetDetail(apiKey, apiId, "", false)
-		bs, _ := json.Marshal(obj)
-
-		returnVal, err := j.VM.ToValue(string(bs))
-		if err != nil {
-			j.Log.WithError(err).Error("Failed to encode return value")
-			return otto.Value{}
-		}
-
-		return returnVal
+		out := j.getKeyData(call.Argument(0).String(), call.Argument(1).String())
+		return toValue(out, "Failed to encode return value")
 	})
 
 	j.VM.Set("TykSetKeyData", func(call otto.FunctionCall) otto.Value {
-		apiKey := call.Argument(0).String()
-		encoddedSession := call.Argument(1).String()
-		suppressReset := call.Argument(2).String()
-
-		newSession := user.SessionState{}
-		err := json.Unmarshal([]byte(encoddedSession), &newSession)
-		if err != nil {
-			j.Log.WithError(err).Error("Failed to decode the sesison data")
-			return otto.Value{}
-		}
-
-		j.Gw.doAddOrUpdate(apiKey, &newSession, suppressReset == "1", false)
+		j.setKeyData(call.Argument(0).String(), call.Argument(1).String(), call.Argument(2).String())
 		return otto.Value{}
 	})
 
 	// Batch request method
-	unsafeBatchHandler := BatchRequestHandler{Gw: j.Gw}
 	j.VM.Set("TykBatchRequest", func(call otto.FunctionCall) otto.Value {
-		requestSet := call.Argument(0).String()
-		j.Log.Debug("Batch input is: ", requestSet)
-		bs, err := unsafeBatchHandler.ManualBatchRequest([]byte(requestSet))
-		if err != nil {
-			j.Log.WithError(err).Error("Batch request error")
-			return otto.Value{}
-		}
-
-		returnVal, err := j.VM.ToValue(string(bs))
+		out, err := j.batchRequest(call.Argument(0).String())
 		if err != nil {
-			j.Log.WithError(err).Error("Failed to encode return value")
 			return otto.Value{}
 		}
-
-		return returnVal
+		return toValue(out, "Failed to encode return value")
 	})
 
-	j.VM.Run(`function TykJsResponse(response, session_meta) {
-		return JSON.stringify({Response: response, SessionMeta: session_meta})
-	}`)
+	j.VM.Run(tykJSResponseJS)
 }
 
+const tykJSResponseJS = `function TykJsResponse(response, session_meta) {
+		return JSON.stringify({Response: response, SessionMeta: session_meta})
+	}`
+
 const coreJS = `
 var TykJS = {
 	TykMiddleware: {
@@ -674,6 +748,15 @@ TykJS.TykMiddleware.MiddlewareComponentMeta.prototype.DoProcessRequest = functio
 	request.Body = b64dec(request.Body)
 	var processed_request = this.ProcessRequest(request, session, config)
 
+	// async middleware return a promise of the request object
+	if (processed_request && typeof processed_request.then === "function") {
+		return processed_request.then(TykJS.TykMiddleware.EncodeProcessedRequest)
+	}
+
+	return TykJS.TykMiddleware.EncodeProcessedRequest(processed_request)
+}
+
+TykJS.TykMiddleware.EncodeProcessedRequest = function(processed_request) {
 	if (!processed_request) {
 		log("Middleware didn't return request object!")
 		return
@@ -813,6 +813,7 @@ func (gw *Gateway) generateSessionFromPolicy(policyID, orgID string, enforceOrg
 	session.ThrottleInterval = policy.ThrottleInterval
 	session.ThrottleRetryLimit = policy.ThrottleRetryLimit
 	session.MaxQueryDepth = policy.MaxQueryDepth
+	session.MaxQueryCost = policy.MaxQueryCost
 	session.QuotaMax = policy.QuotaMax
 	session.QuotaRenewalRate = policy.QuotaRenewalRate
 	session.AccessRights = make(map[string]user.AccessDefinition)
@@ -9,7 +9,10 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/buger/jsonparser"
+
 	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/apidef/adapter"
 )
 
 // PersistGraphQLOperationMiddleware lets you convert any HTTP request into a GraphQL Operation
@@ -50,7 +53,7 @@ func (i *PersistGraphQLOperationMiddleware) ProcessRequest(w http.ResponseWriter
 	ctxSetRequestMethod(r, r.Method)
 	r.Method = http.MethodPost
 
-	_, err := io.ReadAll(r.Body)
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		i.Logger().WithError(err).Error("error reading request")
 		return errors.New("error reading the request"), http.StatusBadRequest
@@ -81,6 +84,13 @@ func (i *PersistGraphQLOperationMiddleware) ProcessRequest(w http.ResponseWriter
 		variablesStr = strings.ReplaceAll(variablesStr, replacer, requestPathParts[pathIndex])
 	}
 
+	// the body variables are set last, so the values sent by the client are not replaced
+	variablesStr, err = setBodyVariables(variablesStr, mwSpec.Variables, body)
+	if err != nil {
+		i.Logger().WithError(err).Error("error reading request body variables")
+		return errors.New("the request body must be a JSON object"), http.StatusBadRequest
+	}
+
 	graphqlQuery := GraphQLRequest{
 		Query:     mwSpec.Operation,
 		Variables: []byte(variablesStr),
@@ -104,3 +114,41 @@ func (i *PersistGraphQLOperationMiddleware) ProcessRequest(w http.ResponseWriter
 
 	return nil, http.StatusOK
 }
+
+// setBodyVariables sets the "$body.<name>" variables to the values of the properties of the JSON body, keeping
+// their types. The variables of missing properties are null.
+func setBodyVariables(variablesStr string, variables map[string]interface{}, body []byte) (string, error) {
+	var (
+		properties map[string]json.RawMessage
+		parsed     bool
+		result     = []byte(variablesStr)
+	)
+
+	for name, value := range variables {
+		str, ok := value.(string)
+		if !ok || !strings.HasPrefix(str, adapter.PersistGraphQLBodyVariablePrefix) {
+			continue
+		}
+
+		if !parsed {
+			if len(bytes.TrimSpace(body)) > 0 {
+				if err := json.Unmarshal(body, &properties); err != nil {
+					return "", err
+				}
+			}
+			parsed = true
+		}
+
+		propertyValue, ok := properties[strings.TrimPrefix(str, adapter.PersistGraphQLBodyVariablePrefix)]
+		if !ok {
+			propertyValue = json.RawMessage("null")
+		}
+
+		var err error
+		if result, err = jsonparser.Set(result, propertyValue, name); err != nil {
+			return "", err
+		}
+	}
+
+	return string(result), nil
+}
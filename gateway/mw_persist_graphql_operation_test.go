@@ -236,6 +236,66 @@ func TestGraphqlPersist_Variables(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGraphqlPersist_BodyVariables(t *testing.T) {
+	ts := StartTest(nil)
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.Name = "rest-graph-body"
+		spec.OrgID = "default"
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = TestHttpAny
+		spec.EnableContextVars = true
+		spec.VersionData.NotVersioned = false
+		spec.VersionData.Versions["Default"] = apidef.VersionInfo{
+			Name:             "Default",
+			Expires:          "3000-01-02 00:00",
+			UseExtendedPaths: true,
+			ExtendedPaths: apidef.ExtendedPathsSet{
+				PersistGraphQL: []apidef.PersistGraphQLMeta{
+					{
+						Path:      "/continent",
+						Operation: testQueryContinentCode,
+						Method:    "POST",
+						Variables: map[string]interface{}{
+							"code":   "$body.code",
+							"filter": "$body.filter",
+							"limit":  "$body.limit",
+						},
+					},
+				},
+			},
+		}
+	})
+
+	variablesMatch := func(variables string) func([]byte) bool {
+		return func(bytes []byte) bool {
+			var testResp TestHttpResponse
+			if err := json.Unmarshal(bytes, &testResp); err != nil {
+				return false
+			}
+			var q GraphQLRequest
+			if err := json.Unmarshal([]byte(testResp.Body), &q); err != nil {
+				return false
+			}
+			return q.Query == testQueryContinentCode && string(q.Variables) == variables
+		}
+	}
+
+	_, err := ts.Run(t,
+		test.TestCase{Path: "/continent", Method: "POST", Data: `{"code":"AF","filter":{"in":["AF","EU"]},"limit":2}`,
+			BodyMatchFunc: variablesMatch(`{"code":"AF","filter":{"in":["AF","EU"]},"limit":2}`)},
+		test.TestCase{Path: "/continent", Method: "POST", Data: `{"code":"$tyk_context.headers_Code"}`,
+			Headers:       map[string]string{"Code": "AF"},
+			BodyMatchFunc: variablesMatch(`{"code":"$tyk_context.headers_Code","filter":null,"limit":null}`)},
+		test.TestCase{Path: "/continent", Method: "POST",
+			BodyMatchFunc: variablesMatch(`{"code":null,"filter":null,"limit":null}`)},
+		test.TestCase{Path: "/continent", Method: "POST", Data: `[]`, Code: http.StatusBadRequest},
+	)
+	assert.NoError(t, err)
+}
+
 func TestGraphQLPersist_VariablesListenPath(t *testing.T) {
 	ts := StartTest(nil)
 	t.Cleanup(func() {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/TykTechnologies/tyk/internal/event"
 	"github.com/TykTechnologies/tyk/request"
+	"github.com/TykTechnologies/tyk/user"
 )
 
 // RateLimitAndQuotaCheck will check the incomming request and key whether it is within it's quota and
@@ -54,17 +55,7 @@ func (k *RateLimitAndQuotaCheck) ProcessRequest(w http.ResponseWriter, r *http.R
 	}
 
 	session := ctxGetSession(r)
-	rateLimitKey := ctxGetAuthToken(r)
-	quotaKey := ""
-
-	if pattern, found := session.MetaData["rate_limit_pattern"]; found {
-		if patternString, ok := pattern.(string); ok && patternString != "" {
-			if customKeyValue := k.Gw.ReplaceTykVariables(r, patternString, false); customKeyValue != "" {
-				rateLimitKey = customKeyValue
-				quotaKey = customKeyValue
-			}
-		}
-	}
+	rateLimitKey, quotaKey := k.Gw.rateLimitKeys(r, session)
 
 	storeRef := k.Gw.GlobalSessionManager.Store()
 	reason := k.Gw.SessionLimiter.ForwardMessage(
@@ -146,3 +137,20 @@ func (k *RateLimitAndQuotaCheck) ProcessRequest(w http.ResponseWriter, r *http.R
 	// Request is valid, carry on
 	return nil, http.StatusOK
 }
+
+// rateLimitKeys returns the rate limit and quota keys of the request, the rate_limit_pattern of the session
+// overrides both.
+func (gw *Gateway) rateLimitKeys(r *http.Request, session *user.SessionState) (rateLimitKey string, quotaKey string) {
+	rateLimitKey = ctxGetAuthToken(r)
+
+	if pattern, found := session.MetaData["rate_limit_pattern"]; found {
+		if patternString, ok := pattern.(string); ok && patternString != "" {
+			if customKeyValue := gw.ReplaceTykVariables(r, patternString, false); customKeyValue != "" {
+				rateLimitKey = customKeyValue
+				quotaKey = customKeyValue
+			}
+		}
+	}
+
+	return rateLimitKey, quotaKey
+}
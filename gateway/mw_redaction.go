@@ -0,0 +1,221 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/redaction"
+)
+
+// RedactionMiddleware redacts the request body sent upstream with the redaction rules of the API and endpoint.
+type RedactionMiddleware struct {
+	*BaseMiddleware
+}
+
+func (m *RedactionMiddleware) Name() string {
+	return "RedactionMiddleware"
+}
+
+func (m *RedactionMiddleware) EnabledForSpec() bool {
+	return m.Spec.hasRedaction()
+}
+
+func (m *RedactionMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
+	rules := redaction.ForTarget(m.Spec.requestRedactionRules(r), apidef.RedactionTargetRequest)
+	if len(rules) == 0 || r.Body == nil {
+		return nil, http.StatusOK
+	}
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+
+	if redacted, ok := redactBody(body, r.Header, rules, m.Spec.maxRedactedSize()); ok {
+		body = redacted
+		r.ContentLength = int64(len(body))
+		r.Header.Set(header.ContentLength, strconv.Itoa(len(body)))
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	nopCloseRequestBody(r)
+
+	return nil, http.StatusOK
+}
+
+// maxRedactedSize returns the maximum decoded size of the compressed bodies redacted, as for the decompression
+// of the requests. Larger bodies are left unchanged.
+func (a *APISpec) maxRedactedSize() int64 {
+	if limit := a.Compression.MaxDecompressedSize; limit > 0 {
+		return limit
+	}
+	return defaultMaxDecompressedSize
+}
+
+// hasRedaction returns true if the API or any of its endpoints have redaction rules.
+func (a *APISpec) hasRedaction() bool {
+	if a.Redaction.Enabled && len(a.Redaction.Rules) > 0 {
+		return true
+	}
+
+	for _, version := range a.VersionData.Versions {
+		for _, meta := range version.ExtendedPaths.Redaction {
+			if !meta.Disabled {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// compileRedactionRules compiles the redaction rules, the invalid ones are logged and skipped.
+func compileRedactionRules(rules []apidef.RedactionRule) []*redaction.Rule {
+	compiled := make([]*redaction.Rule, 0, len(rules))
+	for _, rule := range rules {
+		r, err := redaction.Compile(rule)
+		if err != nil {
+			log.WithError(err).Error("Skipping invalid redaction rule")
+			continue
+		}
+		compiled = append(compiled, r)
+	}
+
+	return compiled
+}
+
+// requestRedactionRules returns the API and endpoint redaction rules applying to the session of the request.
+// The rules are resolved once and cached in the request context.
+func (a *APISpec) requestRedactionRules(r *http.Request) []*redaction.Rule {
+	if rules, ok := ctxGetRedactionRules(r); ok {
+		return rules
+	}
+
+	candidates := append([]*redaction.Rule{}, a.redactionRules...)
+	if versionInfo, _ := a.Version(r); versionInfo != nil {
+		if found, meta := a.CheckSpecMatchesStatus(r, a.RxPaths[versionInfo.Name], Redacted); found {
+			if spec, ok := meta.(*RedactionSpec); ok {
+				candidates = append(candidates, spec.rules...)
+			}
+		}
+	}
+
+	var policies, tags []string
+	if session := ctxGetSession(r); session != nil {
+		policies, tags = session.PolicyIDs(), session.Tags
+	}
+
+	rules := make([]*redaction.Rule, 0, len(candidates))
+	for _, rule := range candidates {
+		if rule.AppliesToSession(policies, tags) {
+			rules = append(rules, rule)
+		}
+	}
+
+	ctxSetRedactionRules(r, rules)
+	return rules
+}
+
+// redactBody redacts a body sent with the headers, compressed bodies are redacted in their decoded form and the
+// Content-Encoding header is removed when the body is changed. Bodies with unsupported encodings or decoded
+// to more than maxDecoded bytes are left unchanged.
+func redactBody(body []byte, h http.Header, rules []*redaction.Rule, maxDecoded int64) ([]byte, bool) {
+	if len(rules) == 0 || len(body) == 0 {
+		return body, false
+	}
+
+	decoded := body
+	encoding := h.Get(header.ContentEncoding)
+	if encoding != "" && encoding != "identity" {
+		reader, err := decompressRequestBody(encoding, bytes.NewReader(body))
+		if err != nil {
+			return body, false
+		}
+
+		decoded, err = io.ReadAll(io.LimitReader(reader, maxDecoded+1))
+		reader.Close()
+		if err != nil || int64(len(decoded)) > maxDecoded {
+			return body, false
+		}
+	}
+
+	redacted, ok := redaction.Redact(decoded, rules)
+	if !ok {
+		return body, false
+	}
+
+	h.Del(header.ContentEncoding)
+	return redacted, true
+}
+
+// redactWireRequest redacts the body of a request in wire format, as captured for analytics.
+func redactWireRequest(raw []byte, rules []*redaction.Rule, maxDecoded int64) []byte {
+	if len(rules) == 0 {
+		return raw
+	}
+
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		return raw
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return raw
+	}
+
+	redacted, ok := redactBody(body, req.Header, rules, maxDecoded)
+	if !ok {
+		return raw
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(redacted))
+	req.ContentLength = int64(len(redacted))
+	req.TransferEncoding = nil
+
+	var buf bytes.Buffer
+	if err := req.Write(&buf); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
+
+// redactWireResponse redacts the body of a response in wire format, as captured for analytics.
+func redactWireResponse(raw []byte, rules []*redaction.Rule, maxDecoded int64) []byte {
+	if len(rules) == 0 {
+		return raw
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), nil)
+	if err != nil {
+		return raw
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return raw
+	}
+
+	redacted, ok := redactBody(body, res.Header, rules, maxDecoded)
+	if !ok {
+		return raw
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(redacted))
+	res.ContentLength = int64(len(redacted))
+	res.TransferEncoding = nil
+	res.Header.Del(header.ContentLength)
+
+	var buf bytes.Buffer
+	if err := res.Write(&buf); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
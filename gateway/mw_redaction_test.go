@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TykTechnologies/tyk-pump/analytics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/redaction"
+	"github.com/TykTechnologies/tyk/test"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+const testRedactionBody = `{"email":"jane@example.com","card":"4111111111111111","ssn":"123-45-6789"}`
+
+func TestRedactionMiddleware(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodGet {
+			body = []byte(testRedactionBody)
+		}
+		w.Header().Set(header.ContentType, header.ApplicationJSON)
+		_, _ = w.Write(body)
+	}))
+	defer upstream.Close()
+
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	redisAnalyticsKeyName := analyticsKeyName + ts.Gw.Analytics.analyticsSerializer.GetSuffix()
+	ts.Gw.Analytics.Store.GetAndDeleteSet(redisAnalyticsKeyName)
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.UseKeylessAccess = false
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.EnableDetailedRecording = true
+		spec.Redaction = apidef.RedactionConfig{
+			Enabled: true,
+			Rules: []apidef.RedactionRule{{
+				Name:      "emails",
+				Detectors: []string{redaction.DetectorEmail},
+				Targets:   []apidef.RedactionTarget{apidef.RedactionTargetRequest, apidef.RedactionTargetAnalytics},
+			}, {
+				Name:     "partner cards",
+				JSONPath: []string{"$.card"},
+				KeepLast: 4,
+				Targets:  []apidef.RedactionTarget{apidef.RedactionTargetResponse},
+				Tags:     []string{"partner"},
+			}},
+		}
+		UpdateAPIVersion(spec, "v1", func(v *apidef.VersionInfo) {
+			v.ExtendedPaths.Redaction = []apidef.RedactionMeta{{
+				Path:   "/users",
+				Method: http.MethodGet,
+				Rules: []apidef.RedactionRule{{
+					JMESPath: []string{"ssn"},
+					Action:   apidef.RedactionRemove,
+				}},
+			}}
+		})
+	})
+
+	key := CreateSession(ts.Gw)
+	partnerKey := CreateSession(ts.Gw, func(s *user.SessionState) {
+		s.Tags = []string{"partner"}
+	})
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodPost, Path: "/echo", Data: testRedactionBody, Headers: map[string]string{header.Authorization: key},
+			Code: http.StatusOK, BodyMatch: `^{"card":"4111111111111111","email":"\*{16}","ssn":"123-45-6789"}$`},
+		{Method: http.MethodGet, Path: "/users", Headers: map[string]string{header.Authorization: key},
+			Code: http.StatusOK, BodyMatch: `^{"card":"4111111111111111","email":"jane@example.com"}$`},
+		{Method: http.MethodGet, Path: "/users", Headers: map[string]string{header.Authorization: partnerKey},
+			Code: http.StatusOK, BodyMatch: `^{"card":"\*{12}1111","email":"jane@example.com"}$`},
+	}...)
+
+	ts.Gw.Analytics.Flush()
+	results := ts.Gw.Analytics.Store.GetAndDeleteSet(redisAnalyticsKeyName)
+	require.Len(t, results, 3)
+
+	for _, result := range results {
+		var record analytics.AnalyticsRecord
+		require.NoError(t, ts.Gw.Analytics.analyticsSerializer.Decode([]byte(result.(string)), &record))
+
+		rawRequest, err := base64.StdEncoding.DecodeString(record.RawRequest)
+		require.NoError(t, err)
+		rawResponse, err := base64.StdEncoding.DecodeString(record.RawResponse)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(rawRequest), "jane@example.com")
+		assert.NotContains(t, string(rawResponse), "jane@example.com")
+		if record.Path == "/users" {
+			assert.NotContains(t, string(rawResponse), "123-45-6789")
+		}
+	}
+}
+
+func TestRedactWire(t *testing.T) {
+	rule, err := redaction.Compile(apidef.RedactionRule{Detectors: []string{redaction.DetectorEmail}})
+	require.NoError(t, err)
+	rules := []*redaction.Rule{rule}
+
+	req := "POST /users HTTP/1.1\r\nHost: example.com\r\nContent-Length: 24\r\n\r\n{\"email\":\"jane@acme.io\"}"
+	redacted := string(redactWireRequest([]byte(req), rules, defaultMaxDecompressedSize))
+	assert.True(t, strings.HasSuffix(redacted, `{"email":"************"}`), redacted)
+	assert.Contains(t, redacted, "Content-Length: 24")
+
+	res := "HTTP/1.1 200 OK\r\nContent-Length: 9\r\n\r\njane@acme"
+	assert.Equal(t, res, string(redactWireResponse([]byte(res), rules, defaultMaxDecompressedSize)))
+	assert.Equal(t, "invalid", string(redactWireResponse([]byte("invalid"), rules, defaultMaxDecompressedSize)))
+}
+
+func TestRedactBody_Compressed(t *testing.T) {
+	rule, err := redaction.Compile(apidef.RedactionRule{Detectors: []string{redaction.DetectorEmail}})
+	require.NoError(t, err)
+	rules := []*redaction.Rule{rule}
+
+	body := compressBody(t, "gzip", `{"email":"jane@acme.io"}`)
+
+	h := http.Header{header.ContentEncoding: []string{"gzip"}}
+	redacted, ok := redactBody(body, h, rules, 1024)
+	assert.True(t, ok)
+	assert.Equal(t, `{"email":"************"}`, string(redacted))
+	assert.Empty(t, h.Get(header.ContentEncoding))
+
+	// bodies decoded to more than the limit are left unchanged
+	h = http.Header{header.ContentEncoding: []string{"gzip"}}
+	redacted, ok = redactBody(body, h, rules, 10)
+	assert.False(t, ok)
+	assert.Equal(t, body, redacted)
+	assert.Equal(t, "gzip", h.Get(header.ContentEncoding))
+}
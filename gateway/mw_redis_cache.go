@@ -2,29 +2,45 @@ package gateway
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"hash"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/TykTechnologies/tyk-pump/analytics"
 
 	"github.com/TykTechnologies/murmur3"
 	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/graphengine"
 	"github.com/TykTechnologies/tyk/regexp"
 	"github.com/TykTechnologies/tyk/request"
 	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/storage/cachestore"
 )
 
 const (
 	cachedResponseHeader = "x-tyk-cached-response"
+
+	staleWarning        = `110 - "Response is Stale"`
+	staleIfErrorWarning = `111 - "Revalidation Failed"`
+
+	revalidatePrefix = "revalidate-"
+
+	// cacheCoalesceTimeout is the maximum time a cache miss waits for a coalesced request.
+	cacheCoalesceTimeout = 30 * time.Second
 )
 
 // RedisCacheMiddleware is a caching middleware that will pull data from Redis instead of the upstream proxy
@@ -33,6 +49,16 @@ type RedisCacheMiddleware struct {
 
 	store storage.Handler
 	sh    SuccessHandler
+
+	// entries is the storage of the cached responses, store keeps the vary headers of the responses
+	entries cachestore.Store
+	backend string
+
+	// inflight tracks cache misses and background revalidations in progress on this node
+	inflight *cacheCoalescer
+
+	// graphQLCache computes the caching policy of the GraphQL requests
+	graphQLCache *graphengine.CachePolicyCalculator
 }
 
 func (m *RedisCacheMiddleware) Name() string {
@@ -41,10 +67,20 @@ func (m *RedisCacheMiddleware) Name() string {
 
 func (m *RedisCacheMiddleware) Init() {
 	m.sh = SuccessHandler{m.BaseMiddleware}
+	m.inflight = newCacheCoalescer()
+
+	if m.Spec.graphQLCacheEnabled() {
+		graphQLCache, err := graphengine.NewCachePolicyCalculator(m.Spec.GraphQL.Schema, m.Spec.GraphQL.Cache)
+		if err != nil {
+			m.Logger().WithError(err).Error("Error while creating the GraphQL cache policy calculator")
+			return
+		}
+		m.graphQLCache = graphQLCache
+	}
 }
 
 func (m *RedisCacheMiddleware) EnabledForSpec() bool {
-	return m.Spec.CacheOptions.EnableCache
+	return m.Spec.CacheOptions.EnableCache || m.Spec.graphQLCacheEnabled()
 }
 
 func (m *RedisCacheMiddleware) CreateCheckSum(req *http.Request, keyName string, regex string, additionalKeyFromHeaders string) (string, error) {
@@ -56,6 +92,14 @@ func (m *RedisCacheMiddleware) CreateCheckSum(req *http.Request, keyName string,
 		key = key + "-" + additionalKeyFromHeaders
 	}
 
+	// responses of the requests switched by a routing rule are cached apart from the others
+	if version := ctxGetRoutedVersion(req); version != "" {
+		key = key + "-version:" + version
+	}
+	if target := ctxGetUpstreamTarget(req); target != nil {
+		key = key + "-upstream:" + target.String()
+	}
+
 	_, err := io.WriteString(h, key)
 	if err != nil {
 		return "", err
@@ -127,32 +171,69 @@ func (m *RedisCacheMiddleware) isTimeStampExpired(timestamp string) bool {
 }
 
 func (m *RedisCacheMiddleware) decodePayload(payload string) (string, string, error) {
+	data, timestamp, _, err := decodeCachePayload(payload)
+	return data, timestamp, err
+}
+
+// decodeCachePayload decodes a cache entry in the `<base64 response>|<expiry>[|<stale windows>]` format.
+func decodeCachePayload(payload string) (string, string, staleWindows, error) {
 	data := strings.Split(payload, "|")
 	switch len(data) {
 	case 1:
-		return data[0], "", nil
-	case 2:
+		return data[0], "", staleWindows{}, nil
+	case 2, 3:
 		sDec, err := base64.StdEncoding.DecodeString(data[0])
 		if err != nil {
-			return "", "", err
+			return "", "", staleWindows{}, err
 		}
 
-		return string(sDec), data[1], nil
+		var stale staleWindows
+		if len(data) == 3 {
+			stale = parseStaleWindows(data[2])
+		}
+
+		return string(sDec), data[1], stale, nil
 	}
-	return "", "", errors.New("Decoding failed, array length wrong")
+	return "", "", staleWindows{}, errors.New("Decoding failed, array length wrong")
 }
 
 // cacheOptions exists to transfer options from this middleware down the chain to the cache writer
 type cacheOptions struct {
 	key                    string
+	url                    string
 	cacheOnlyResponseCodes []int
 	timeout                int64
+	stale                  staleWindows
+
+	// staleResponse is an expired response which can be served if the upstream fails
+	staleResponse string
+	// validated is an expired response the upstream request is conditional on
+	validated string
+
+	// baseKey is the cache key before selecting the variant of a response varying on request headers
+	baseKey string
+	// header holds the request headers received by the cache, to select the variant of a response
+	header http.Header
+	// cacheControl holds the request Cache-Control directives, when following HTTP caching semantics
+	cacheControl cacheControl
+	// release completes a coalesced cache miss, waking up requests waiting for the cache entry
+	release func()
+	// graphQL is set for GraphQL requests, whose responses are not cached when they hold errors
+	graphQL bool
 }
 
 // ProcessRequest will run any checks on the request on the way through the system, return an error to have the chain fail
 func (m *RedisCacheMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
 	t1 := time.Now()
 
+	if m.Spec.graphQLCacheEnabled() {
+		options := m.graphQLCacheOptions(r)
+		if options == nil {
+			return nil, http.StatusOK
+		}
+		return m.serveFromCache(w, r, options, t1)
+	}
+
 	var stat RequestStatus
 	var cacheKeyRegex string
 	var cacheMeta *EndPointCacheMeta
@@ -186,7 +267,6 @@ func (m *RedisCacheMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Req
 		token = request.RealIP(r)
 	}
 
-	var retBlob string
 	key, err := m.CreateCheckSum(r, token, cacheKeyRegex, m.getCacheKeyFromHeaders(r))
 	if err != nil {
 		m.Logger().Debug("Error creating checksum. Skipping cache check")
@@ -195,6 +275,10 @@ func (m *RedisCacheMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Req
 
 	cacheOnlyResponseCodes := m.Spec.CacheOptions.CacheOnlyResponseCodes
 	timeout := m.Spec.CacheOptions.CacheTimeout
+	stale := staleWindows{
+		whileRevalidate: m.Spec.CacheOptions.StaleWhileRevalidate,
+		ifError:         m.Spec.CacheOptions.StaleIfError,
+	}
 	if cacheMeta != nil {
 		// override api level CacheOnlyResponseCodes by endpoint level if provided
 		if len(cacheMeta.CacheOnlyResponseCodes) > 0 {
@@ -205,37 +289,111 @@ func (m *RedisCacheMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Req
 		if cacheMeta.Timeout > 0 {
 			timeout = cacheMeta.Timeout
 		}
+
+		// override api level stale windows by endpoint level if provided
+		if cacheMeta.StaleWhileRevalidate > 0 {
+			stale.whileRevalidate = cacheMeta.StaleWhileRevalidate
+		}
+
+		if cacheMeta.StaleIfError > 0 {
+			stale.ifError = cacheMeta.StaleIfError
+		}
 	}
 
-	ctxSetCacheOptions(r, &cacheOptions{
+	options := &cacheOptions{
 		key:                    key,
+		baseKey:                key,
+		url:                    r.URL.RequestURI(),
 		cacheOnlyResponseCodes: cacheOnlyResponseCodes,
 		timeout:                timeout,
-	})
+		stale:                  stale,
+	}
 
-	retBlob, err = m.store.GetKey(key)
-	if err != nil {
-		// Record not found, continue with the middleware chain
+	return m.serveFromCache(w, r, options, t1)
+}
+
+// serveFromCache writes the cached response of the request, or sets the cache options for the response to be
+// cached by the ResponseCacheMiddleware.
+func (m *RedisCacheMiddleware) serveFromCache(w http.ResponseWriter, r *http.Request, options *cacheOptions, t1 time.Time) (error, int) {
+	key := options.key
+
+	httpSemantics := m.Spec.CacheOptions.EnableHTTPSemantics
+	if httpSemantics {
+		options.header = r.Header.Clone()
+		options.cacheControl = parseCacheControl(r.Header.Get(header.CacheControl))
+
+		// the response must neither be served from nor stored in the cache
+		if options.cacheControl.has("no-store") {
+			return nil, http.StatusOK
+		}
+
+		// the response varies on request headers, look up the variant of this request
+		if vary, err := m.store.GetKey(key + cacheVarySuffix); err == nil && vary != "" {
+			options.key = varyKey(key, strings.Split(vary, ","), r.Header)
+			key = options.key
+		}
+	}
+
+	ctxSetCacheOptions(r, options)
+
+	// the client requires a response validated by the upstream, which then refreshes the cache
+	if httpSemantics && options.mustRevalidate() {
 		return nil, http.StatusOK
 	}
 
-	cachedData, timestamp, err := m.decodePayload(retBlob)
+	entry, err := m.getCacheEntry(key)
+	if err != nil && m.Spec.CacheOptions.EnableRequestCoalescing {
+		if release, wait := m.inflight.acquire(key); release != nil {
+			options.release = release
+			// make sure waiting requests are released if the request doesn't reach the cache writer
+			go func() {
+				<-r.Context().Done()
+				release()
+			}()
+		} else if m.waitForInflight(r, wait) {
+			entry, err = m.getCacheEntry(key)
+		}
+	}
+
 	if err != nil {
-		// Tere was an issue with this cache entry - lets remove it:
-		m.store.DeleteKey(key)
+		if httpSemantics && options.cacheControl.has("only-if-cached") {
+			return errors.New("response is not cached"), http.StatusGatewayTimeout
+		}
+
+		// Record not found, continue with the middleware chain
 		return nil, http.StatusOK
 	}
 
-	if m.isTimeStampExpired(timestamp) || len(cachedData) == 0 {
-		m.store.DeleteKey(key)
-		return nil, http.StatusOK
+	var servingStale bool
+	if m.isTimeStampExpired(entry.timestamp) {
+		expiredFor := expiredSince(entry.timestamp)
+
+		switch {
+		case expiredFor < entry.stale.whileRevalidate:
+			// serve the stale response and refresh it in the background
+			servingStale = true
+			m.revalidate(r, options, entry.data)
+		case expiredFor < entry.stale.ifError:
+			// go upstream, the stale response is served if it fails
+			options.staleResponse = entry.data
+			if httpSemantics {
+				setValidators(r, options, entry.data)
+			}
+			return nil, http.StatusOK
+		case httpSemantics && setValidators(r, options, entry.data):
+			// go upstream with a conditional request, a 304 refreshes the cached response
+			return nil, http.StatusOK
+		default:
+			m.deleteCacheEntry(key)
+			return nil, http.StatusOK
+		}
 	}
 
-	bufData := bufio.NewReader(strings.NewReader(cachedData))
+	bufData := bufio.NewReader(strings.NewReader(entry.data))
 	newRes, err := http.ReadResponse(bufData, r)
 	if err != nil {
 		m.Logger().WithError(err).Error("Could not create response object")
-		m.store.DeleteKey(key)
+		m.deleteCacheEntry(key)
 		return nil, http.StatusOK
 	}
 
@@ -256,10 +414,21 @@ func (m *RedisCacheMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Req
 		newRes.Header.Set(header.XRateLimitReset, strconv.Itoa(int(quotaRenews)))
 	}
 	newRes.Header.Set(cachedResponseHeader, "1")
+	if servingStale {
+		newRes.Header.Set(header.Warning, staleWarning)
+	}
+
+	if httpSemantics {
+		newRes.Header.Set(header.Age, strconv.FormatInt(responseAge(newRes), 10))
+	}
 
 	copyHeader(w.Header(), newRes.Header, m.Gw.GetConfig().IgnoreCanonicalMIMEHeaderKey)
 
-	if reqEtag := r.Header.Get("If-None-Match"); reqEtag != "" {
+	if httpSemantics {
+		if notModified(r, newRes) {
+			newRes.StatusCode = http.StatusNotModified
+		}
+	} else if reqEtag := r.Header.Get("If-None-Match"); reqEtag != "" {
 		if respEtag := newRes.Header.Get("Etag"); respEtag != "" {
 			if strings.Contains(reqEtag, respEtag) {
 				newRes.StatusCode = http.StatusNotModified
@@ -282,6 +451,112 @@ func (m *RedisCacheMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Req
 	return nil, mwStatusRespond
 }
 
+// graphQLCacheOptions returns the cache options of a GraphQL request, keyed by the checksum of its normalised
+// operation. Private responses are keyed by the token of the request too. It returns nil when the response must not be cached.
+func (m *RedisCacheMiddleware) graphQLCacheOptions(r *http.Request) *cacheOptions {
+	if m.graphQLCache == nil || ctxGetGraphQLIsWebSocketUpgrade(r) || ctxGetGraphQLPassthrough(r) {
+		return nil
+	}
+
+	query, operationName, variables, ok := graphQLRequestOperation(r)
+	if !ok {
+		return nil
+	}
+
+	policy, err := m.graphQLCache.Policy(query, operationName, variables)
+	if err != nil {
+		m.Logger().WithError(err).Debug("Error while calculating the cache policy of the GraphQL request")
+		return nil
+	}
+
+	if policy.TTL <= 0 {
+		m.Logger().Debug("GraphQL request is not cacheable")
+		return nil
+	}
+
+	token := ""
+	if policy.Private {
+		// No authentication data? use the IP.
+		if token = ctxGetAuthToken(r); token == "" {
+			token = request.RealIP(r)
+		}
+	}
+
+	composed := token + policy.Key
+	if mediaType := graphengine.ResponseMediaType(r.Header.Get(header.Accept)); mediaType != header.ApplicationJSON {
+		composed += "-" + mediaType
+	}
+	if fromHeaders := m.getCacheKeyFromHeaders(r); fromHeaders != "" {
+		composed += "-" + fromHeaders
+	}
+
+	// the token is only stored hashed in the cache keys
+	checksum := md5.Sum([]byte(composed))
+	key := m.Spec.APIID + hex.EncodeToString(checksum[:])
+
+	return &cacheOptions{
+		key:                    key,
+		baseKey:                key,
+		url:                    r.URL.RequestURI(),
+		cacheOnlyResponseCodes: []int{http.StatusOK},
+		timeout:                policy.TTL,
+		graphQL:                true,
+	}
+}
+
+// getCacheEntry reads a cache entry from the in-memory tier, falling back to the store.
+// Entries read from the store are held in memory for the following requests.
+func (m *RedisCacheMiddleware) getCacheEntry(key string) (cacheEntry, error) {
+	local := m.Gw.localResponseCache
+	if local != nil {
+		entry, ok := local.get(m.Spec.APIID, key)
+		m.Gw.responseCacheStats.record(m.Spec.APIID, cacheTierLocal, ok)
+		if ok {
+			return entry, nil
+		}
+	}
+
+	retBlob, err := m.entries.Get(key)
+	m.Gw.responseCacheStats.record(m.Spec.APIID, m.backend, err == nil)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	var entry cacheEntry
+	entry.data, entry.timestamp, entry.stale, err = decodeCachePayload(retBlob)
+	if err == nil && len(entry.data) == 0 {
+		err = errors.New("empty cache entry")
+	}
+
+	if err != nil {
+		// There was an issue with this cache entry - lets remove it:
+		m.deleteCacheEntry(key)
+		return cacheEntry{}, err
+	}
+
+	local.set(m.Spec.APIID, key, entry)
+	return entry, nil
+}
+
+// deleteCacheEntry removes a cache entry from the store and the in-memory tier.
+func (m *RedisCacheMiddleware) deleteCacheEntry(key string) {
+	if err := m.entries.Delete(key); err != nil {
+		m.Logger().WithError(err).Warning("Could not delete cache entry")
+	}
+	m.Gw.localResponseCache.delete(m.Spec.APIID, key)
+}
+
+// mustRevalidate checks if the request directives forbid serving the cached response without
+// validating it with the upstream.
+func (o *cacheOptions) mustRevalidate() bool {
+	if o.cacheControl.has("no-cache") {
+		return true
+	}
+
+	maxAge, ok := o.cacheControl.seconds("max-age")
+	return ok && maxAge == 0
+}
+
 func isSafeMethod(method string) bool {
 	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
 }
@@ -293,3 +568,167 @@ func (m *RedisCacheMiddleware) getCacheKeyFromHeaders(r *http.Request) (key stri
 	}
 	return
 }
+
+// staleWindows holds the RFC 5861 windows in seconds after expiry during which a cached response may be served.
+type staleWindows struct {
+	whileRevalidate int64
+	ifError         int64
+}
+
+func (s staleWindows) max() int64 {
+	if s.whileRevalidate > s.ifError {
+		return s.whileRevalidate
+	}
+	return s.ifError
+}
+
+func (s staleWindows) String() string {
+	return fmt.Sprintf("%d,%d", s.whileRevalidate, s.ifError)
+}
+
+func parseStaleWindows(value string) (s staleWindows) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return s
+	}
+
+	s.whileRevalidate, _ = strconv.ParseInt(parts[0], 10, 64)
+	s.ifError, _ = strconv.ParseInt(parts[1], 10, 64)
+	return s
+}
+
+// expiredSince returns the number of seconds elapsed since the expiry timestamp.
+func expiredSince(timestamp string) int64 {
+	expiry, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return math.MaxInt64
+	}
+
+	return time.Now().Unix() - expiry
+}
+
+// revalidate refreshes a stale cache entry in the background by sending the request to the upstream. The
+// request already passed the middleware chain, so auth, rate limits and quotas aren't applied again and no
+// analytics are recorded. Only one revalidation per cache key runs at a time on this node.
+func (m *RedisCacheMiddleware) revalidate(r *http.Request, options *cacheOptions, data string) {
+	release, _ := m.inflight.acquire(revalidatePrefix + options.key)
+	if release == nil {
+		return
+	}
+
+	revalidateReq := r.Clone(context.Background())
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := readBody(r)
+		if err != nil {
+			release()
+			return
+		}
+		revalidateReq.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	revalidateOptions := *options
+	ctxSetCacheOptions(revalidateReq, &revalidateOptions)
+	if m.Spec.CacheOptions.EnableHTTPSemantics {
+		setValidators(revalidateReq, &revalidateOptions, data)
+	}
+
+	if newURL := ctxGetURLRewriteTarget(revalidateReq); newURL != nil {
+		revalidateReq.URL = newURL
+	}
+	if newMethod := ctxGetTransformRequestMethod(revalidateReq); newMethod != "" {
+		revalidateReq.Method = newMethod
+	}
+	m.Spec.SanitizeProxyPaths(revalidateReq)
+
+	go func() {
+		defer release()
+		m.Proxy.ServeHTTPForCache(httptest.NewRecorder(), revalidateReq)
+	}()
+}
+
+// waitForInflight waits for the in-flight request populating the cache. It returns false if
+// the wait was aborted because the request was cancelled or the wait timed out.
+func (m *RedisCacheMiddleware) waitForInflight(r *http.Request, wait <-chan struct{}) bool {
+	timer := time.NewTimer(cacheCoalesceTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-wait:
+		return true
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		m.Logger().Debug("Timed out waiting for coalesced request")
+		return false
+	}
+}
+
+// cacheCoalescer tracks requests in flight per cache key.
+type cacheCoalescer struct {
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+func newCacheCoalescer() *cacheCoalescer {
+	return &cacheCoalescer{inflight: map[string]chan struct{}{}}
+}
+
+// acquire registers a request in flight for key. The first caller gets a release func which must be
+// called once the request completes, later callers get a channel closed on release.
+func (c *cacheCoalescer) acquire(key string) (func(), <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if wait, ok := c.inflight[key]; ok {
+		return nil, wait
+	}
+
+	done := make(chan struct{})
+	c.inflight[key] = done
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.inflight, key)
+			c.mu.Unlock()
+			close(done)
+		})
+	}, nil
+}
+
+// serveStaleResponse writes the stale response kept for the request when the upstream failed.
+// It returns false if there is no stale response to serve.
+func serveStaleResponse(w http.ResponseWriter, r *http.Request, ignoreCanonical bool) bool {
+	options := ctxGetCacheOptions(r)
+	if options == nil || options.staleResponse == "" {
+		return false
+	}
+
+	res, err := readStaleResponse(r, options.staleResponse)
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+
+	copyHeader(w.Header(), res.Header, ignoreCanonical)
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+	return true
+}
+
+// readStaleResponse parses a stale cached response and marks it as served because the upstream failed.
+func readStaleResponse(r *http.Request, data string) (*http.Response, error) {
+	res, err := readCachedResponse(r, data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range hopHeaders {
+		res.Header.Del(h)
+	}
+
+	res.Header.Set(cachedResponseHeader, "1")
+	res.Header.Set(header.Warning, staleIfErrorWarning)
+	return res, nil
+}
@@ -6,17 +6,25 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/TykTechnologies/graphql-go-tools/pkg/graphql"
 	"github.com/TykTechnologies/tyk-pump/analytics"
 	"github.com/TykTechnologies/tyk/apidef"
 	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/storage"
 	"github.com/TykTechnologies/tyk/test"
+	"github.com/TykTechnologies/tyk/user"
 )
 
 func TestRedisCacheMiddlewareUnit(t *testing.T) {
@@ -58,6 +66,64 @@ func TestRedisCacheMiddlewareUnit(t *testing.T) {
 				}
 			},
 		},
+		{
+			Name: "decodeCachePayload",
+			Fn: func(t *testing.T) {
+				t.Helper()
+
+				data, expire, stale, err := decodeCachePayload("dGVzdGluZwo=|123|30,60")
+				assert.NoError(t, err)
+				assert.Equal(t, "testing\n", data)
+				assert.Equal(t, "123", expire)
+				assert.Equal(t, staleWindows{whileRevalidate: 30, ifError: 60}, stale)
+				assert.Equal(t, int64(60), stale.max())
+				assert.Equal(t, "30,60", stale.String())
+
+				_, _, stale, err = decodeCachePayload("dGVzdGluZwo=|123")
+				assert.NoError(t, err)
+				assert.Zero(t, stale.max())
+
+				assert.Equal(t, staleWindows{}, parseStaleWindows("invalid"))
+			},
+		},
+		{
+			Name: "upstreamStaleWindows",
+			Fn: func(t *testing.T) {
+				t.Helper()
+				fallback := staleWindows{whileRevalidate: 10, ifError: 20}
+
+				assert.Equal(t, fallback, upstreamStaleWindows("max-age=60", fallback))
+				assert.Equal(t, staleWindows{whileRevalidate: 30, ifError: 20}, upstreamStaleWindows("max-age=60, stale-while-revalidate=30", fallback))
+				assert.Equal(t, staleWindows{whileRevalidate: 10, ifError: 86400}, upstreamStaleWindows(`stale-if-error="86400"`, fallback))
+				assert.Equal(t, fallback, upstreamStaleWindows("stale-while-revalidate=-1, stale-if-error=abc", fallback))
+			},
+		},
+		{
+			Name: "cacheCoalescer",
+			Fn: func(t *testing.T) {
+				t.Helper()
+				c := newCacheCoalescer()
+
+				release, wait := c.acquire("key")
+				assert.NotNil(t, release)
+				assert.Nil(t, wait)
+
+				again, wait := c.acquire("key")
+				assert.Nil(t, again)
+				assert.NotNil(t, wait)
+
+				other, _ := c.acquire("other")
+				assert.NotNil(t, other)
+
+				release()
+				release()
+				_, ok := <-wait
+				assert.False(t, ok)
+
+				release, _ = c.acquire("key")
+				assert.NotNil(t, release, "key should be free after release")
+			},
+		},
 		{
 			Name: "encodePayload",
 			Fn: func(t *testing.T) {
@@ -360,3 +426,242 @@ func Test_addBodyHash(t *testing.T) {
 		})
 	}
 }
+
+func TestRedisCacheMiddleware_Stale(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	var (
+		hits    int32
+		failing int32
+	)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "response %d", n)
+	}))
+	defer upstream.Close()
+
+	load := func(opts apidef.CacheOptions) {
+		atomic.StoreInt32(&hits, 0)
+		atomic.StoreInt32(&failing, 0)
+
+		ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+			spec.APIID = randStringBytes(8)
+			spec.Proxy.ListenPath = "/"
+			spec.Proxy.TargetURL = upstream.URL
+			opts.EnableCache = true
+			opts.CacheAllSafeRequests = true
+			opts.CacheTimeout = 1
+			spec.CacheOptions = opts
+		})
+	}
+
+	cached := map[string]string{cachedResponseHeader: "1"}
+
+	t.Run("stale while revalidate", func(t *testing.T) {
+		load(apidef.CacheOptions{StaleWhileRevalidate: 60})
+
+		_, _ = ts.Run(t, test.TestCase{Path: "/swr", BodyMatch: "response 1", Code: http.StatusOK})
+		time.Sleep(2 * time.Second)
+
+		_, _ = ts.Run(t, test.TestCase{
+			Path:         "/swr",
+			BodyMatch:    "response 1",
+			HeadersMatch: map[string]string{cachedResponseHeader: "1", header.Warning: staleWarning},
+			Code:         http.StatusOK,
+		})
+
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&hits) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, _ = ts.Run(t, test.TestCase{
+			Path:            "/swr",
+			BodyMatch:       "response 2",
+			HeadersMatch:    cached,
+			HeadersNotMatch: map[string]string{header.Warning: staleWarning},
+			Code:            http.StatusOK,
+		})
+	})
+
+	t.Run("revalidation doesn't consume the quota", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		api := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+			spec.APIID = randStringBytes(8)
+			spec.UseKeylessAccess = false
+			spec.Proxy.ListenPath = "/"
+			spec.Proxy.TargetURL = upstream.URL
+			spec.CacheOptions = apidef.CacheOptions{EnableCache: true, CacheAllSafeRequests: true, CacheTimeout: 1, StaleWhileRevalidate: 60}
+		})[0]
+
+		_, key := ts.CreateSession(func(s *user.SessionState) {
+			s.QuotaMax = 10
+			s.QuotaRemaining = 10
+			s.AccessRights = map[string]user.AccessDefinition{api.APIID: {APIID: api.APIID}}
+		})
+		authHeaders := map[string]string{header.Authorization: key}
+
+		_, _ = ts.Run(t, test.TestCase{Path: "/quota", Headers: authHeaders, BodyMatch: "response 1", Code: http.StatusOK})
+		time.Sleep(2 * time.Second)
+
+		_, _ = ts.Run(t, test.TestCase{Path: "/quota", Headers: authHeaders, BodyMatch: "response 1", Code: http.StatusOK})
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&hits) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, _ = ts.Run(t, test.TestCase{
+			Path:         "/quota",
+			Headers:      authHeaders,
+			BodyMatch:    "response 2",
+			HeadersMatch: map[string]string{header.XRateLimitRemaining: "7"},
+			Code:         http.StatusOK,
+		})
+	})
+
+	t.Run("stale if error", func(t *testing.T) {
+		load(apidef.CacheOptions{StaleIfError: 60})
+
+		_, _ = ts.Run(t, test.TestCase{Path: "/sie", BodyMatch: "response 1", Code: http.StatusOK})
+		time.Sleep(2 * time.Second)
+
+		atomic.StoreInt32(&failing, 1)
+		_, _ = ts.Run(t, test.TestCase{
+			Path:         "/sie",
+			BodyMatch:    "response 1",
+			HeadersMatch: map[string]string{cachedResponseHeader: "1", header.Warning: staleIfErrorWarning},
+			Code:         http.StatusOK,
+		})
+		assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("expired without stale windows", func(t *testing.T) {
+		load(apidef.CacheOptions{})
+
+		_, _ = ts.Run(t, test.TestCase{Path: "/expired", BodyMatch: "response 1", Code: http.StatusOK})
+		time.Sleep(2 * time.Second)
+
+		atomic.StoreInt32(&failing, 1)
+		_, _ = ts.Run(t, test.TestCase{Path: "/expired", Code: http.StatusInternalServerError})
+	})
+}
+
+func TestRedisCacheMiddleware_RequestCoalescing(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, "response %d", n)
+	}))
+	defer upstream.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = randStringBytes(8)
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.CacheOptions.EnableCache = true
+		spec.CacheOptions.CacheAllSafeRequests = true
+		spec.CacheOptions.CacheTimeout = 60
+		spec.CacheOptions.EnableRequestCoalescing = true
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = ts.Run(t, test.TestCase{Path: "/coalesce", BodyMatch: "response 1", Code: http.StatusOK})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestRedisCacheMiddleware_GraphQL(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set(header.ContentType, header.ApplicationJSON)
+		switch {
+		case strings.Contains(string(body), "fail"):
+			_, _ = fmt.Fprint(w, `{"data":null,"errors":[{"message":"failed"}]}`)
+		case strings.Contains(string(body), "profile"):
+			_, _ = fmt.Fprintf(w, `{"data":{"profile":{"name":"profile %d"}}}`, n)
+		default:
+			_, _ = fmt.Fprintf(w, `{"data":{"hello":"hello %d"}}`, n)
+		}
+	}))
+	defer upstream.Close()
+
+	api := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.UseKeylessAccess = false
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.GraphQL.Enabled = true
+		spec.GraphQL.ExecutionMode = apidef.GraphQLExecutionModeProxyOnly
+		spec.GraphQL.Version = apidef.GraphQLConfigVersion2
+		spec.GraphQL.Schema = `
+directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT
+enum CacheControlScope { PUBLIC PRIVATE }
+type Query { hello(name: String!): String! profile: Profile }
+type Profile @cacheControl(maxAge: 30, scope: PRIVATE) { name: String! }`
+		spec.GraphQL.Cache = apidef.GraphQLCacheConfig{Enabled: true, DefaultTTL: 60}
+	})[0]
+
+	createKey := func() map[string]string {
+		_, key := ts.CreateSession(func(s *user.SessionState) {
+			s.AccessRights = map[string]user.AccessDefinition{api.APIID: {APIID: api.APIID, APIName: api.Name}}
+		})
+		return map[string]string{header.Authorization: key}
+	}
+	firstKey, secondKey := createKey(), createKey()
+	cached := map[string]string{cachedResponseHeader: "1"}
+
+	t.Run("public responses are shared", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Headers: firstKey, Data: graphql.Request{Query: `{ hello(name: "a") }`},
+				BodyMatch: `"hello 1"`, HeadersNotMatch: cached},
+			{Method: http.MethodPost, Headers: secondKey, Data: graphql.Request{Query: "query {\n  hello(name: \"a\")\n}"},
+				BodyMatch: `"hello 1"`, HeadersMatch: cached},
+			{Method: http.MethodPost, Headers: firstKey, Data: graphql.Request{Query: `{ hello(name: "b") }`},
+				BodyMatch: `"hello 2"`, HeadersNotMatch: cached},
+		}...)
+	})
+
+	t.Run("private responses are cached per key", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Headers: firstKey, Data: graphql.Request{Query: `{ profile { name } }`},
+				BodyMatch: `"profile 3"`, HeadersNotMatch: cached},
+			{Method: http.MethodPost, Headers: firstKey, Data: graphql.Request{Query: `{ profile { name } }`},
+				BodyMatch: `"profile 3"`, HeadersMatch: cached},
+			{Method: http.MethodPost, Headers: secondKey, Data: graphql.Request{Query: `{ profile { name } }`},
+				BodyMatch: `"profile 4"`, HeadersNotMatch: cached},
+		}...)
+
+		store := storage.RedisCluster{KeyPrefix: "cache-", IsCache: true, ConnectionHandler: ts.Gw.StorageConnectionHandler}
+		keys := store.GetKeys(api.APIID)
+		assert.NotEmpty(t, keys)
+		for _, key := range keys {
+			assert.NotContains(t, key, firstKey[header.Authorization], "tokens shouldn't be stored in the cache keys")
+		}
+	})
+
+	t.Run("responses with errors are not cached", func(t *testing.T) {
+		request := graphql.Request{Query: `{ hello(name: "fail") }`}
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodPost, Headers: firstKey, Data: request, BodyMatch: `"errors"`, HeadersNotMatch: cached},
+			{Method: http.MethodPost, Headers: firstKey, Data: request, BodyMatch: `"errors"`, HeadersNotMatch: cached},
+		}...)
+	})
+}
@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/TykTechnologies/tyk/header"
+)
+
+const defaultMaxDecompressedSize = 10 << 20
+
+var (
+	errDecompressedTooLarge = errors.New("decompressed request body is too large")
+	errMalformedCompression = errors.New("request body couldn't be decompressed")
+)
+
+// RequestDecompressionMiddleware decompresses the request body, so the middleware following it such as body
+// transforms and validation work on the original content. It's called after authentication, so request signatures
+// are checked against the body as it was sent.
+type RequestDecompressionMiddleware struct {
+	*BaseMiddleware
+}
+
+func (m *RequestDecompressionMiddleware) Name() string {
+	return "RequestDecompressionMiddleware"
+}
+
+func (m *RequestDecompressionMiddleware) EnabledForSpec() bool {
+	return m.Spec.Compression.DecompressRequests
+}
+
+func (m *RequestDecompressionMiddleware) ProcessRequest(_ http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
+	encoding := r.Header.Get(header.ContentEncoding)
+	if encoding == "" || encoding == "identity" || r.Body == nil {
+		return nil, http.StatusOK
+	}
+
+	reader, err := decompressRequestBody(encoding, r.Body)
+	if errors.Is(err, errUnsupportedEncoding) {
+		return err, http.StatusUnsupportedMediaType
+	}
+	if err != nil {
+		m.Logger().WithError(err).Debug("Couldn't decompress the request body")
+		return errMalformedCompression, http.StatusBadRequest
+	}
+	defer reader.Close()
+
+	limit := m.Spec.Compression.MaxDecompressedSize
+	if limit <= 0 {
+		limit = defaultMaxDecompressedSize
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		m.Logger().WithError(err).Debug("Couldn't decompress the request body")
+		return errMalformedCompression, http.StatusBadRequest
+	}
+
+	if int64(len(body)) > limit {
+		return errDecompressedTooLarge, http.StatusRequestEntityTooLarge
+	}
+
+	// the body is read again by the following middleware
+	r.Body, _ = newNopCloserBuffer(io.NopCloser(bytes.NewReader(body)))
+	r.ContentLength = int64(len(body))
+	r.Header.Set(header.ContentLength, strconv.Itoa(len(body)))
+	r.Header.Del(header.ContentEncoding)
+
+	return nil, http.StatusOK
+}
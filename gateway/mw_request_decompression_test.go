@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func compressBody(t *testing.T, encoding string, body string) []byte {
+	t.Helper()
+
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+
+	switch encoding {
+	case encodingGzip:
+		w = gzip.NewWriter(&buf)
+	case encodingBrotli:
+		w = brotli.NewWriter(&buf)
+	case encodingZstd:
+		var err error
+		w, err = zstd.NewWriter(&buf)
+		require.NoError(t, err)
+	}
+
+	_, err := io.WriteString(w, body)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestRequestDecompressionMiddleware(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Encoding", r.Header.Get(header.ContentEncoding))
+		_, _ = w.Write(body)
+	}))
+	defer upstream.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		UpdateAPIVersion(spec, "v1", func(v *apidef.VersionInfo) {
+			v.ExtendedPaths.ValidateJSON = []apidef.ValidatePathMeta{{
+				Path:   "/v",
+				Method: http.MethodPost,
+				Schema: map[string]interface{}{
+					"type":     "object",
+					"required": []interface{}{"firstName"},
+				},
+			}}
+		})
+
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.Compression.DecompressRequests = true
+		spec.Compression.MaxDecompressedSize = 1024
+	})
+
+	valid := `{"firstName":"Tyk"}`
+
+	for _, encoding := range []string{encodingGzip, encodingBrotli, encodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			_, _ = ts.Run(t, []test.TestCase{
+				{
+					Method: http.MethodPost, Path: "/v", Data: compressBody(t, encoding, valid),
+					Headers: map[string]string{header.ContentEncoding: encoding}, Code: http.StatusOK,
+					BodyMatch: valid, HeadersMatch: map[string]string{"X-Content-Encoding": ""},
+				},
+				{
+					Method: http.MethodPost, Path: "/v", Data: compressBody(t, encoding, `{"lastName":"Tyk"}`),
+					Headers: map[string]string{header.ContentEncoding: encoding}, Code: http.StatusUnprocessableEntity,
+				},
+			}...)
+		})
+	}
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{
+			Method: http.MethodPost, Path: "/v", Data: compressBody(t, encodingGzip, `{"firstName":"`+strings.Repeat("a", 1024)+`"}`),
+			Headers: map[string]string{header.ContentEncoding: encodingGzip}, Code: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Method: http.MethodPost, Path: "/v", Data: valid,
+			Headers: map[string]string{header.ContentEncoding: encodingGzip}, Code: http.StatusBadRequest,
+		},
+		{
+			Method: http.MethodPost, Path: "/v", Data: valid,
+			Headers: map[string]string{header.ContentEncoding: "compress"}, Code: http.StatusUnsupportedMediaType,
+		},
+		{Method: http.MethodPost, Path: "/v", Data: valid, Code: http.StatusOK, BodyMatch: valid},
+	}...)
+}
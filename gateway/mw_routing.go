@@ -0,0 +1,309 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/routing"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+// RoutingMiddleware applies the action of the first routing rule of the endpoint or API matching the request.
+// Endpoint rules are evaluated before the rules of the API.
+type RoutingMiddleware struct {
+	*BaseMiddleware
+}
+
+func (m *RoutingMiddleware) Name() string {
+	return "RoutingMiddleware"
+}
+
+func (m *RoutingMiddleware) EnabledForSpec() bool {
+	if m.Spec.Routing.Enabled && len(m.Spec.Routing.Rules) > 0 {
+		return true
+	}
+
+	for _, version := range m.Spec.VersionData.Versions {
+		for _, meta := range version.ExtendedPaths.Routing {
+			if !meta.Disabled {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (m *RoutingMiddleware) ProcessRequest(w http.ResponseWriter, r *http.Request, _ interface{}) (error, int) {
+	rule := routing.First(m.Spec.requestRoutingRules(r), newRoutingRequest(r))
+	if rule == nil {
+		return nil, http.StatusOK
+	}
+
+	m.Logger().WithField("rule", rule.Name()).Debug("Routing rule matched")
+
+	action := rule.Action()
+	for name, value := range action.SetHeaders {
+		r.Header.Set(name, m.Gw.ReplaceTykVariables(r, value, false))
+	}
+
+	if action.Response.Code != 0 {
+		for name, value := range action.Response.Headers {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(action.Response.Code)
+		_, _ = w.Write([]byte(action.Response.Body))
+		return nil, mwStatusRespond
+	}
+
+	if action.Version != "" {
+		if err, code := m.switchVersion(w, r, action.Version); err != nil || code != http.StatusOK {
+			return err, code
+		}
+	}
+
+	if action.UpstreamURL != "" {
+		target, err := url.Parse(m.Gw.ReplaceTykVariables(r, action.UpstreamURL, false))
+		if err != nil || target.Host == "" {
+			m.Logger().WithError(err).WithField("upstream", action.UpstreamURL).Error("Invalid routing upstream URL")
+			return errors.New(http.StatusText(http.StatusInternalServerError)), http.StatusInternalServerError
+		}
+		ctxSetUpstreamTarget(r, target)
+	}
+
+	return nil, http.StatusOK
+}
+
+// switchVersion switches the request to the version. Versions of OAS APIs are separate APIs that serve the request
+// through their own chain. Versions of classic APIs are switched in the request context, the version and access
+// rights checks that already ran for the requested version are run again for the target version.
+func (m *RoutingMiddleware) switchVersion(w http.ResponseWriter, r *http.Request, name string) (error, int) {
+	if m.Spec.VersionDefinition.Enabled {
+		if name == apidef.Self || name == m.Spec.VersionDefinition.Name {
+			return nil, http.StatusOK
+		}
+
+		if id, ok := m.Spec.VersionDefinition.Versions[name]; ok {
+			handler, _, found := m.Gw.findInternalHttpHandlerByNameOrID(id)
+			if !found {
+				return errors.New(string(VersionDoesNotExist)), http.StatusNotFound
+			}
+
+			m.Spec.SanitizeProxyPaths(r)
+			handler.ServeHTTP(w, r)
+			return nil, mwStatusRespond
+		}
+	}
+
+	version, ok := m.Spec.VersionData.Versions[name]
+	if !ok {
+		return errors.New(string(VersionDoesNotExist)), http.StatusNotFound
+	}
+
+	ctxSetVersionInfo(r, &version)
+	ctxSetVersionName(r, &name)
+	ctxSetRoutedVersion(r, name)
+	ctxSetRequestStatus(r, StatusOk)
+
+	versionCheck := &VersionCheck{BaseMiddleware: m.BaseMiddleware}
+	versionCheck.Init()
+	if err, code := versionCheck.ProcessRequest(w, r, nil); err != nil || code != http.StatusOK {
+		return err, code
+	}
+
+	if ctxGetSession(r) == nil {
+		return nil, http.StatusOK
+	}
+
+	accessRights := &AccessRightsCheck{BaseMiddleware: m.BaseMiddleware}
+	return accessRights.ProcessRequest(w, r, nil)
+}
+
+// compileRoutingRules compiles the routing rules, the invalid ones are logged and skipped.
+func compileRoutingRules(rules []apidef.RoutingRule) []*routing.Rule {
+	compiled := make([]*routing.Rule, 0, len(rules))
+	for _, rule := range rules {
+		r, err := routing.Compile(rule)
+		if err != nil {
+			log.WithError(err).Error("Skipping invalid routing rule")
+			continue
+		}
+		compiled = append(compiled, r)
+	}
+
+	return compiled
+}
+
+// requestRoutingRules returns the routing rules of the endpoint matching the request, followed by the rules of the API.
+func (a *APISpec) requestRoutingRules(r *http.Request) []*routing.Rule {
+	var rules []*routing.Rule
+	if versionInfo, _ := a.Version(r); versionInfo != nil {
+		if found, meta := a.CheckSpecMatchesStatus(r, a.RxPaths[versionInfo.Name], Routed); found {
+			if spec, ok := meta.(*RoutingSpec); ok {
+				rules = append(rules, spec.rules...)
+			}
+		}
+	}
+
+	return append(rules, a.routingRules...)
+}
+
+// routingRequest provides the values of a request to the routing conditions, the JSON body is decoded once when
+// a condition reads it.
+type routingRequest struct {
+	r           *http.Request
+	body        interface{}
+	bodyDecoded bool
+}
+
+func newRoutingRequest(r *http.Request) *routingRequest {
+	return &routingRequest{r: r}
+}
+
+func (rr *routingRequest) Value(source apidef.RoutingSource, key string) (interface{}, bool) {
+	r := rr.r
+
+	switch source {
+	case apidef.RoutingSourceHeader:
+		values := r.Header.Values(key)
+		if len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	case apidef.RoutingSourceQuery:
+		values, ok := r.URL.Query()[key]
+		if !ok || len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	case apidef.RoutingSourcePath:
+		return r.URL.Path, true
+	case apidef.RoutingSourceMethod:
+		return r.Method, true
+	case apidef.RoutingSourceHost:
+		return r.Host, true
+	case apidef.RoutingSourceSessionMeta:
+		session := ctxGetSession(r)
+		if session == nil {
+			return nil, false
+		}
+		value, ok := session.MetaData[key]
+		return value, ok
+	case apidef.RoutingSourceContext:
+		value, ok := ctxGetData(r)[key]
+		return value, ok
+	case apidef.RoutingSourceBody:
+		return routing.Lookup(rr.decodedBody(), key)
+	default:
+		return nil, false
+	}
+}
+
+// decodedBody decodes the JSON request body, the body is restored for the following middleware.
+func (rr *routingRequest) decodedBody() interface{} {
+	if rr.bodyDecoded {
+		return rr.body
+	}
+	rr.bodyDecoded = true
+
+	r := rr.r
+	if r.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	nopCloseRequestBody(r)
+	if err != nil || len(body) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&rr.body); err != nil {
+		rr.body = nil
+	}
+
+	return rr.body
+}
+
+// routingEvaluateRequest evaluates the routing rules of an API, or the given rules, against a sample request.
+type routingEvaluateRequest struct {
+	APIID       string                 `json:"api_id"`
+	Rules       []apidef.RoutingRule   `json:"rules"`
+	Request     *traceHttpRequest      `json:"request"`
+	SessionMeta map[string]interface{} `json:"session_meta"`
+	Context     map[string]interface{} `json:"context"`
+}
+
+// routingEvaluateResponse holds the action of the first matching rule and the evaluation result of every rule.
+type routingEvaluateResponse struct {
+	Matched bool                  `json:"matched"`
+	Rule    string                `json:"rule,omitempty"`
+	Action  *apidef.RoutingAction `json:"action,omitempty"`
+	Rules   []routing.Result      `json:"rules"`
+}
+
+func (gw *Gateway) routingEvaluateHandler(w http.ResponseWriter, r *http.Request) {
+	var evalReq routingEvaluateRequest
+	if err := json.NewDecoder(r.Body).Decode(&evalReq); err != nil {
+		doJSONWrite(w, http.StatusBadRequest, apiError("Request malformed"))
+		return
+	}
+
+	if evalReq.Request == nil {
+		doJSONWrite(w, http.StatusBadRequest, apiError("Request field is missing"))
+		return
+	}
+
+	req, err := evalReq.Request.toRequest(gw.GetConfig().IgnoreCanonicalMIMEHeaderKey)
+	if err != nil {
+		doJSONWrite(w, http.StatusBadRequest, apiError("Request malformed: "+err.Error()))
+		return
+	}
+
+	if evalReq.SessionMeta != nil {
+		ctxSetSession(req, &user.SessionState{MetaData: evalReq.SessionMeta}, false, false)
+	}
+	if evalReq.Context != nil {
+		ctxSetData(req, evalReq.Context)
+	}
+
+	var rules []*routing.Rule
+	if evalReq.APIID != "" {
+		spec := gw.getApiSpec(evalReq.APIID)
+		if spec == nil {
+			doJSONWrite(w, http.StatusNotFound, apiError(apidef.ErrAPINotFound.Error()))
+			return
+		}
+		rules = spec.requestRoutingRules(req)
+	} else {
+		for _, conf := range evalReq.Rules {
+			rule, err := routing.Compile(conf)
+			if err != nil {
+				doJSONWrite(w, http.StatusBadRequest, apiError(err.Error()))
+				return
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	res := routingEvaluateResponse{Rules: []routing.Result{}}
+	routingReq := newRoutingRequest(req)
+	for _, rule := range rules {
+		result := rule.Evaluate(routingReq)
+		if result.Matched && !res.Matched {
+			action := rule.Action()
+			res.Matched, res.Rule, res.Action = true, rule.Name(), &action
+		}
+		res.Rules = append(res.Rules, result)
+	}
+
+	doJSONWrite(w, http.StatusOK, res)
+}
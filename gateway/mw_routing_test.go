@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/test"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+func TestRoutingMiddleware(t *testing.T) {
+	alternative := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("alternative " + r.URL.Path))
+	}))
+	defer alternative.Close()
+
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.Proxy.ListenPath = "/"
+		spec.EnableContextVars = true
+		spec.VersionData.NotVersioned = false
+		spec.VersionData.DefaultVersion = "v1"
+		spec.VersionDefinition.Location = apidef.HeaderLocation
+		spec.VersionDefinition.Key = "version"
+		spec.VersionData.Versions = map[string]apidef.VersionInfo{
+			"v1": {Name: "v1", UseExtendedPaths: true, ExtendedPaths: apidef.ExtendedPathsSet{
+				Routing: []apidef.RoutingMeta{{
+					Path:   "/blocked",
+					Method: http.MethodGet,
+					Rules: []apidef.RoutingRule{{
+						Name:   "block",
+						Action: apidef.RoutingAction{Response: apidef.RoutingResponse{Code: http.StatusForbidden, Body: "blocked"}},
+					}},
+				}},
+			}},
+			"v2": {Name: "v2", OverrideTarget: TestHttpAny + "/v2"},
+		}
+		spec.Routing = apidef.RoutingConfig{
+			Enabled: true,
+			Rules: []apidef.RoutingRule{{
+				Name:  "gold",
+				Match: apidef.Any,
+				Conditions: []apidef.RoutingCondition{
+					{Source: apidef.RoutingSourceHeader, Key: "X-Tier", Operator: apidef.RoutingEquals, Value: "gold"},
+					{Source: apidef.RoutingSourceBody, Key: "order.total", Operator: apidef.RoutingGreater, Value: "100"},
+				},
+				Action: apidef.RoutingAction{UpstreamURL: alternative.URL},
+			}, {
+				Name:       "beta",
+				Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourceQuery, Key: "beta", Operator: apidef.RoutingExists}},
+				Action: apidef.RoutingAction{
+					Version:    "v2",
+					SetHeaders: map[string]string{"X-Routed": "beta-$tyk_context.request_data_beta"},
+				},
+			}},
+		}
+	})
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Path: "/orders", Code: http.StatusOK, BodyMatch: `"Url":"/orders"`},
+		{Path: "/orders", Headers: map[string]string{"X-Tier": "gold"}, Code: http.StatusOK, BodyMatch: `^alternative /orders$`},
+		{Method: http.MethodPost, Path: "/orders", Data: `{"order":{"total":120}}`, Code: http.StatusOK, BodyMatch: `^alternative /orders$`},
+		{Method: http.MethodPost, Path: "/orders", Data: `{"order":{"total":80}}`, Code: http.StatusOK, BodyMatch: `"Body":"{\\"order\\":{\\"total\\":80}}"`},
+		{Path: "/orders?beta=1", Code: http.StatusOK, BodyMatch: `"Url":"/v2/orders\?beta=1"`},
+		{Path: "/orders?beta=1", Code: http.StatusOK, BodyMatch: `"X-Routed":"beta-1"`},
+		{Path: "/blocked", Headers: map[string]string{"X-Tier": "gold"}, Code: http.StatusForbidden, BodyMatch: `^blocked$`},
+	}...)
+}
+
+func TestRoutingEvaluateHandler(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	api := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.Proxy.ListenPath = "/"
+		spec.Routing = apidef.RoutingConfig{
+			Enabled: true,
+			Rules: []apidef.RoutingRule{{
+				Name:       "gold",
+				Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourceSessionMeta, Key: "tier", Operator: apidef.RoutingEquals, Value: "gold"}},
+				Action:     apidef.RoutingAction{UpstreamURL: "http://gold.internal"},
+			}},
+		}
+	})[0]
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodPost, Path: "/tyk/routing/evaluate", AdminAuth: true, Code: http.StatusBadRequest, BodyMatch: "Request malformed"},
+		{Method: http.MethodPost, Path: "/tyk/routing/evaluate", Data: `{}`, AdminAuth: true, Code: http.StatusBadRequest, BodyMatch: "Request field is missing"},
+		{Method: http.MethodPost, Path: "/tyk/routing/evaluate", Data: routingEvaluateRequest{APIID: "unknown", Request: &traceHttpRequest{Path: "/"}},
+			AdminAuth: true, Code: http.StatusNotFound},
+		{Method: http.MethodPost, Path: "/tyk/routing/evaluate", Data: routingEvaluateRequest{APIID: api.APIID, Request: &traceHttpRequest{Path: "/"},
+			SessionMeta: map[string]interface{}{"tier": "gold"}},
+			AdminAuth: true, Code: http.StatusOK, BodyMatch: `"matched":true,"rule":"gold","action":{"upstream_url":"http://gold.internal"`},
+		{Method: http.MethodPost, Path: "/tyk/routing/evaluate", Data: routingEvaluateRequest{APIID: api.APIID, Request: &traceHttpRequest{Path: "/"}},
+			AdminAuth: true, Code: http.StatusOK, BodyMatch: `"matched":false,.*"actual":null,"found":false,"matched":false`},
+		{Method: http.MethodPost, Path: "/tyk/routing/evaluate", Data: routingEvaluateRequest{
+			Rules:   []apidef.RoutingRule{{Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourcePath, Operator: apidef.RoutingRegex, Value: "("}}}},
+			Request: &traceHttpRequest{Path: "/"}},
+			AdminAuth: true, Code: http.StatusBadRequest, BodyMatch: "invalid condition pattern"},
+	}...)
+}
+
+func TestRoutingMiddleware_VersionChecks(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	api := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.UseKeylessAccess = false
+		spec.Proxy.ListenPath = "/"
+		spec.VersionData.NotVersioned = false
+		spec.VersionData.DefaultVersion = "v1"
+		spec.VersionDefinition.Location = apidef.HeaderLocation
+		spec.VersionDefinition.Key = "version"
+		spec.VersionData.Versions = map[string]apidef.VersionInfo{
+			"v1":      {Name: "v1"},
+			"v2":      {Name: "v2"},
+			"expired": {Name: "expired", Expires: "2006-01-02 15:04"},
+		}
+		spec.Routing = apidef.RoutingConfig{
+			Enabled: true,
+			Rules: []apidef.RoutingRule{{
+				Name:       "v2",
+				Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourceQuery, Key: "to", Operator: apidef.RoutingEquals, Value: "v2"}},
+				Action:     apidef.RoutingAction{Version: "v2"},
+			}, {
+				Name:       "expired",
+				Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourceQuery, Key: "to", Operator: apidef.RoutingEquals, Value: "expired"}},
+				Action:     apidef.RoutingAction{Version: "expired"},
+			}},
+		}
+	})[0]
+
+	_, v1Key := ts.CreateSession(func(s *user.SessionState) {
+		s.AccessRights = map[string]user.AccessDefinition{api.APIID: {APIID: api.APIID, Versions: []string{"v1", "expired"}}}
+	})
+	_, v2Key := ts.CreateSession(func(s *user.SessionState) {
+		s.AccessRights = map[string]user.AccessDefinition{api.APIID: {APIID: api.APIID, Versions: []string{"v2"}}}
+	})
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Path: "/orders", Headers: map[string]string{"Authorization": v1Key}, Code: http.StatusOK},
+		{Path: "/orders?to=v2", Headers: map[string]string{"Authorization": v1Key}, Code: http.StatusForbidden},
+		{Path: "/orders?to=v2", Headers: map[string]string{"Authorization": v2Key, "version": "v2"}, Code: http.StatusOK},
+		{Path: "/orders?to=expired", Headers: map[string]string{"Authorization": v1Key}, Code: http.StatusForbidden, BodyMatch: string(VersionExpired)},
+	}...)
+}
+
+func TestRoutingMiddleware_Cache(t *testing.T) {
+	alternative := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("alternative " + r.URL.Path))
+	}))
+	defer alternative.Close()
+
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = randStringBytes(8)
+		spec.Proxy.ListenPath = "/"
+		spec.CacheOptions.EnableCache = true
+		spec.CacheOptions.CacheAllSafeRequests = true
+		spec.CacheOptions.CacheTimeout = 60
+		spec.VersionData.NotVersioned = false
+		spec.VersionData.DefaultVersion = "v1"
+		spec.VersionDefinition.Location = apidef.HeaderLocation
+		spec.VersionDefinition.Key = "version"
+		spec.VersionData.Versions = map[string]apidef.VersionInfo{
+			"v1": {Name: "v1"},
+			"v2": {Name: "v2", OverrideTarget: TestHttpAny + "/v2"},
+		}
+		spec.Routing = apidef.RoutingConfig{
+			Enabled: true,
+			Rules: []apidef.RoutingRule{{
+				Name:       "gold",
+				Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourceHeader, Key: "X-Tier", Operator: apidef.RoutingEquals, Value: "gold"}},
+				Action:     apidef.RoutingAction{UpstreamURL: alternative.URL},
+			}, {
+				Name:       "beta",
+				Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourceHeader, Key: "X-Beta", Operator: apidef.RoutingExists}},
+				Action:     apidef.RoutingAction{Version: "v2"},
+			}},
+		}
+	})
+
+	cached := map[string]string{cachedResponseHeader: "1"}
+
+	// the responses of the routed requests are cached apart from each other and from the other requests
+	_, _ = ts.Run(t, []test.TestCase{
+		{Path: "/orders", Code: http.StatusOK, BodyMatch: `"Url":"/orders"`, HeadersNotMatch: cached, Delay: 10 * time.Millisecond},
+		{Path: "/orders", Headers: map[string]string{"X-Tier": "gold"}, Code: http.StatusOK, BodyMatch: `^alternative /orders$`, HeadersNotMatch: cached, Delay: 10 * time.Millisecond},
+		{Path: "/orders", Headers: map[string]string{"X-Beta": "1"}, Code: http.StatusOK, BodyMatch: `"Url":"/v2/orders"`, HeadersNotMatch: cached, Delay: 10 * time.Millisecond},
+		{Path: "/orders", Code: http.StatusOK, BodyMatch: `"Url":"/orders"`, HeadersMatch: cached},
+		{Path: "/orders", Headers: map[string]string{"X-Tier": "gold"}, Code: http.StatusOK, BodyMatch: `^alternative /orders$`, HeadersMatch: cached},
+		{Path: "/orders", Headers: map[string]string{"X-Beta": "1"}, Code: http.StatusOK, BodyMatch: `"Url":"/v2/orders"`, HeadersMatch: cached},
+	}...)
+}
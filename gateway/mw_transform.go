@@ -12,6 +12,7 @@ import (
 	"golang.org/x/net/html/charset"
 
 	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
 )
 
 func WrappedCharsetReader(s string, i io.Reader) (io.Reader, error) {
@@ -55,11 +56,40 @@ func transformBody(r *http.Request, tmeta *TransformSpec, t *TransformMiddleware
 	body, _ := ioutil.ReadAll(r.Body)
 	defer r.Body.Close()
 
+	if isBodyConversion(tmeta.TemplateData) {
+		if len(body) == 0 {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			return nil
+		}
+
+		converted, contentType, err := convertBody(body, r.Header.Get(header.ContentType), tmeta.TemplateData)
+		if err != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			return fmt.Errorf("failed to convert request body: %w", err)
+		}
+
+		r.Header.Set(header.ContentType, contentType)
+		r.Body = io.NopCloser(bytes.NewReader(converted))
+		r.ContentLength = int64(len(converted))
+		nopCloseRequestBody(r)
+
+		return nil
+	}
+
 	// Put into an interface:
 	bodyData := make(map[string]interface{})
 
 	switch tmeta.TemplateData.Input {
 	case apidef.RequestXML:
+		if hasXMLOptions(tmeta.TemplateData.XML) {
+			var err error
+			bodyData, err = decodeXML(body, tmeta.TemplateData.XML)
+			if err != nil {
+				return fmt.Errorf("error unmarshalling XML: %w", err)
+			}
+			break
+		}
+
 		if len(body) == 0 {
 			body = []byte("<_/>")
 		}
@@ -69,6 +99,12 @@ func transformBody(r *http.Request, tmeta *TransformSpec, t *TransformMiddleware
 		if err != nil {
 			return fmt.Errorf("error unmarshalling XML: %w", err)
 		}
+	case apidef.RequestForm, apidef.RequestMultipart:
+		var err error
+		bodyData, err = decodeForm(body, r.Header.Get(header.ContentType))
+		if err != nil {
+			return fmt.Errorf("error parsing form: %w", err)
+		}
 	case apidef.RequestJSON:
 		if len(body) == 0 {
 			body = []byte("{}")
@@ -110,7 +110,7 @@ func (v *VersionCheck) ProcessRequest(w http.ResponseWriter, r *http.Request, _
 outside:
 
 	// For OAS route matching
-	if v.Spec.HasMock || v.Spec.HasValidateRequest {
+	if v.Spec.HasMock || v.Spec.HasValidateRequest || v.Spec.HasValidateResponse {
 		findRouteAndOperation(v.Spec, r)
 	}
 
@@ -16,8 +16,8 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/lonelycode/osin"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/TykTechnologies/tyk/internal/crypto"
 	internalerrors "github.com/TykTechnologies/tyk/internal/errors"
 	"github.com/TykTechnologies/tyk/internal/uuid"
 	"github.com/TykTechnologies/tyk/request"
@@ -412,8 +412,9 @@ func (o *OAuthManager) HandleAccess(r *http.Request) *osin.Response {
 				log.Warning("Attempted access with non-existent user (OAuth password flow).")
 			} else {
 				var passMatch bool
-				if session.BasicAuthData.Hash == user.HashBCrypt {
-					err := bcrypt.CompareHashAndPassword([]byte(session.BasicAuthData.Password), []byte(password))
+				switch session.BasicAuthData.Hash {
+				case user.HashBCrypt, user.HashArgon2id, user.HashScrypt:
+					err := crypto.ComparePassword(string(session.BasicAuthData.Hash), session.BasicAuthData.Password, password)
 					if err == nil {
 						passMatch = true
 					}
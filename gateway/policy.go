@@ -22,15 +22,16 @@ var (
 )
 
 type DBAccessDefinition struct {
-	APIName              string                       `json:"api_name"`
-	APIID                string                       `json:"api_id"`
-	Versions             []string                     `json:"versions"`
-	AllowedURLs          []user.AccessSpec            `bson:"allowed_urls" json:"allowed_urls"` // mapped string MUST be a valid regex
-	RestrictedTypes      []graphql.Type               `json:"restricted_types"`
-	AllowedTypes         []graphql.Type               `json:"allowed_types"`
-	DisableIntrospection bool                         `json:"disable_introspection"`
-	FieldAccessRights    []user.FieldAccessDefinition `json:"field_access_rights"`
-	Limit                *user.APILimit               `json:"limit"`
+	APIName                  string                       `json:"api_name"`
+	APIID                    string                       `json:"api_id"`
+	Versions                 []string                     `json:"versions"`
+	AllowedURLs              []user.AccessSpec            `bson:"allowed_urls" json:"allowed_urls"` // mapped string MUST be a valid regex
+	RestrictedTypes          []graphql.Type               `json:"restricted_types"`
+	AllowedTypes             []graphql.Type               `json:"allowed_types"`
+	DisableIntrospection     bool                         `json:"disable_introspection"`
+	EnforceOperationSafelist bool                         `json:"enforce_operation_safelist"`
+	FieldAccessRights        []user.FieldAccessDefinition `json:"field_access_rights"`
+	Limit                    *user.APILimit               `json:"limit"`
 
 	// Endpoints contains endpoint rate limit settings.
 	Endpoints user.Endpoints `json:"endpoints,omitempty"`
@@ -38,15 +39,16 @@ type DBAccessDefinition struct {
 
 func (d *DBAccessDefinition) ToRegularAD() user.AccessDefinition {
 	ad := user.AccessDefinition{
-		APIName:              d.APIName,
-		APIID:                d.APIID,
-		Versions:             d.Versions,
-		AllowedURLs:          d.AllowedURLs,
-		RestrictedTypes:      d.RestrictedTypes,
-		AllowedTypes:         d.AllowedTypes,
-		DisableIntrospection: d.DisableIntrospection,
-		FieldAccessRights:    d.FieldAccessRights,
-		Endpoints:            d.Endpoints,
+		APIName:                  d.APIName,
+		APIID:                    d.APIID,
+		Versions:                 d.Versions,
+		AllowedURLs:              d.AllowedURLs,
+		RestrictedTypes:          d.RestrictedTypes,
+		AllowedTypes:             d.AllowedTypes,
+		DisableIntrospection:     d.DisableIntrospection,
+		EnforceOperationSafelist: d.EnforceOperationSafelist,
+		FieldAccessRights:        d.FieldAccessRights,
+		Endpoints:                d.Endpoints,
 	}
 
 	if d.Limit != nil {
@@ -41,6 +41,12 @@ const (
 	OAuthPurgeLapsedTokens       NotificationCommand = "OAuthPurgeLapsedTokens"
 	// NoticeDeleteAPICache is the command with which event is emitted from dashboard to invalidate cache for an API.
 	NoticeDeleteAPICache NotificationCommand = "DeleteAPICache"
+	// NoticeFlushAPICache is the command with which a gateway which purged the shared cache stores of an API
+	// notifies the other gateways to flush their in-memory tier and their memory or disk cache store.
+	NoticeFlushAPICache NotificationCommand = "FlushAPICache"
+	// NoticeInvalidateAPICache is the command with which a gateway which purged cache entries of an API by tag
+	// or URL notifies the other gateways to evict them from their in-memory tier and their memory or disk cache store.
+	NoticeInvalidateAPICache NotificationCommand = "InvalidateAPICache"
 )
 
 // Notification is a type that encodes a message published to a pub sub channel (shared between implementations)
@@ -157,6 +163,10 @@ func (gw *Gateway) handleRedisEvent(v interface{}, handled func(NotificationComm
 		if ok := gw.invalidateAPICache(notif.Payload); !ok {
 			log.WithError(err).Errorf("cache invalidation failed for: %s", notif.Payload)
 		}
+	case NoticeFlushAPICache:
+		gw.handleFlushAPICache(notif.Payload)
+	case NoticeInvalidateAPICache:
+		gw.handleInvalidateCacheEntries(notif.Payload)
 	default:
 		pubSubLog.Warnf("Unknown notification command: %q", notif.Command)
 		return
@@ -182,6 +192,34 @@ func (gw *Gateway) handleKeySpaceEventCacheFlush(payload string) {
 	}
 }
 
+func (gw *Gateway) handleFlushAPICache(apiID string) {
+	// the shared stores were purged by the gateway handling the request
+	gw.localResponseCache.flush(apiID)
+
+	if entries := gw.nodeCacheStore(apiID); entries != nil {
+		if err := entries.DeletePrefix(""); err != nil {
+			pubSubLog.WithError(err).Errorf("cache invalidation failed for: %s", apiID)
+		}
+	}
+}
+
+func (gw *Gateway) handleInvalidateCacheEntries(payload string) {
+	var inv cacheInvalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+		pubSubLog.WithError(err).Error("Unmarshalling cache invalidation failed")
+		return
+	}
+
+	// the shared stores were purged by the gateway handling the request
+	gw.localResponseCache.delete(inv.APIID, inv.Keys...)
+
+	if entries := gw.nodeCacheStore(inv.APIID); entries != nil && len(inv.Keys) > 0 {
+		if err := entries.Delete(inv.Keys...); err != nil {
+			pubSubLog.WithError(err).Errorf("cache invalidation failed for: %s", inv.APIID)
+		}
+	}
+}
+
 var redisInsecureWarn sync.Once
 
 func isPayloadSignatureValid(notification Notification) bool {
@@ -4,13 +4,18 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/buger/jsonparser"
 	"github.com/sirupsen/logrus"
 
+	"github.com/TykTechnologies/tyk/header"
 	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/storage/cachestore"
 	"github.com/TykTechnologies/tyk/user"
 )
 
@@ -23,6 +28,9 @@ const (
 type ResponseCacheMiddleware struct {
 	BaseTykResponseHandler
 	store storage.Handler
+
+	// entries is the storage of the cached responses, store keeps the vary headers and indexes of the responses
+	entries cachestore.Store
 }
 
 func (m *ResponseCacheMiddleware) Base() *BaseTykResponseHandler {
@@ -42,7 +50,7 @@ func (h *ResponseCacheMiddleware) HandleError(rw http.ResponseWriter, req *http.
 }
 
 func (m *ResponseCacheMiddleware) EnabledForSpec() bool {
-	return m.Spec.CacheOptions.EnableCache
+	return m.Spec.CacheOptions.EnableCache || m.Spec.graphQLCacheEnabled()
 }
 
 func (m *ResponseCacheMiddleware) getTimeTTL(cacheTTL int64) int64 {
@@ -79,8 +87,36 @@ func (m *ResponseCacheMiddleware) HandleResponse(w http.ResponseWriter, res *htt
 		return nil
 	}
 
+	if options.release != nil {
+		defer options.release()
+	}
+
+	// Serve the stale response instead of an upstream error
+	if res.StatusCode >= http.StatusInternalServerError && options.staleResponse != "" {
+		if stale, err := readStaleResponse(r, options.staleResponse); err == nil {
+			m.Logger().WithField("status", res.StatusCode).Debug("Upstream failed, serving stale response")
+			res.Body.Close()
+			res.StatusCode = stale.StatusCode
+			res.Status = stale.Status
+			res.Header = stale.Header
+			res.Body = stale.Body
+			res.ContentLength = stale.ContentLength
+			return nil
+		}
+	}
+
+	httpSemantics := m.Spec.CacheOptions.EnableHTTPSemantics
+
+	// The upstream confirmed the expired response is still valid, refresh it
+	if httpSemantics && res.StatusCode == http.StatusNotModified && options.validated != "" {
+		if refreshFromNotModified(res, r, options.validated) {
+			m.Logger().Debug("Cached response revalidated by upstream")
+		}
+	}
+
 	cacheThisRequest := true
 	cacheTTL := options.timeout
+	stale := options.stale
 
 	// make sure the status codes match if specified
 	if len(options.cacheOnlyResponseCodes) > 0 {
@@ -114,6 +150,33 @@ func (m *ResponseCacheMiddleware) HandleResponse(w http.ResponseWriter, res *htt
 				cacheTTL = int64(cacheAsInt)
 			}
 		}
+
+		// Get stale windows from the RFC 5861 Cache-Control extensions
+		stale = upstreamStaleWindows(res.Header.Get(header.CacheControl), stale)
+	}
+
+	key := options.key
+	var vary []string
+	var revalidatable bool
+
+	if httpSemantics && cacheThisRequest {
+		cacheControl := res.Header.Get(header.CacheControl)
+		resCC := parseCacheControl(cacheControl)
+
+		cacheTTL = responseTTL(resCC, cacheTTL)
+		stale = upstreamStaleWindows(cacheControl, stale)
+
+		var ok bool
+		vary, ok = varyHeaderNames(res.Header)
+		cacheThisRequest = ok && cacheableResponse(options.cacheControl, resCC)
+
+		// store the variant of the request, or the response itself if it no longer varies
+		key = options.baseKey
+		if len(vary) > 0 {
+			key = varyKey(options.baseKey, vary, options.header)
+		}
+
+		revalidatable = res.Header.Get(header.ETag) != "" || res.Header.Get(header.LastModified) != ""
 	}
 
 	var toStore string
@@ -126,6 +189,20 @@ func (m *ResponseCacheMiddleware) HandleResponse(w http.ResponseWriter, res *htt
 			return nil
 		}
 
+		if options.graphQL && graphQLResponseHasErrors(res.Body) {
+			m.Logger().Debug("GraphQL response holds errors, not caching")
+			return nil
+		}
+
+		if httpSemantics && res.Header.Get(header.ETag) == "" {
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				m.Logger().WithError(err).Error("error reading cache body")
+				return nil
+			}
+			res.Header.Set(header.ETag, generateETag(body))
+		}
+
 		var wireFormatReq bytes.Buffer
 		if err := res.Write(&wireFormatReq); err != nil {
 			m.Logger().WithError(err).Error("error encoding cache")
@@ -135,12 +212,49 @@ func (m *ResponseCacheMiddleware) HandleResponse(w http.ResponseWriter, res *htt
 		ts := m.getTimeTTL(cacheTTL)
 		toStore = m.encodePayload(wireFormatReq.String(), ts)
 
-		go func() {
-			err := m.store.SetKey(options.key, toStore, cacheTTL)
+		// keep the entry in the store while it can still be served stale
+		storeTTL := cacheTTL
+		if stale.max() > 0 {
+			toStore += "|" + stale.String()
+			storeTTL += stale.max()
+		}
+
+		// keep the entry in the store while it can be revalidated with the upstream
+		if revalidatable {
+			storeTTL += options.timeout
+		}
+
+		if httpSemantics && storeTTL <= 0 {
+			m.Logger().Debug("Response is stale on arrival, not caching")
+			return nil
+		}
+
+		tags := cacheTags(res.Header)
+		store := func() {
+			err := m.entries.Set(key, toStore, storeTTL)
 			if err != nil {
 				m.Logger().WithError(err).Error("could not save key in cache store")
+				return
+			}
+
+			if httpSemantics {
+				m.storeVary(options, vary, storeTTL)
 			}
-		}()
+
+			m.indexCacheEntry(options.url, key, tags, storeTTL)
+			m.Gw.localResponseCache.set(m.Spec.APIID, key, cacheEntry{
+				data:      wireFormatReq.String(),
+				timestamp: strconv.FormatInt(ts, 10),
+				stale:     stale,
+			})
+		}
+
+		// coalesced requests are waiting for the entry, store it before releasing them
+		if options.release != nil {
+			store()
+		} else {
+			go store()
+		}
 	}
 
 	/*
@@ -154,3 +268,49 @@ func (m *ResponseCacheMiddleware) HandleResponse(w http.ResponseWriter, res *htt
 
 	return nil
 }
+
+// graphQLResponseHasErrors checks if the GraphQL response holds errors, reading the body.
+func graphQLResponseHasErrors(body io.Reader) bool {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return true
+	}
+
+	_, dataType, _, err := jsonparser.Get(data, "errors")
+	return err == nil && dataType != jsonparser.Null
+}
+
+// storeVary records the request headers the response varies on, so following requests
+// look up their own variant.
+func (m *ResponseCacheMiddleware) storeVary(options *cacheOptions, vary []string, ttl int64) {
+	varyKey := options.baseKey + cacheVarySuffix
+
+	if len(vary) == 0 {
+		// the variant was looked up, but the response no longer varies
+		if options.key != options.baseKey {
+			m.store.DeleteKey(varyKey)
+		}
+		return
+	}
+
+	if err := m.store.SetKey(varyKey, strings.Join(vary, ","), ttl); err != nil {
+		m.Logger().WithError(err).Error("could not save vary headers in cache store")
+	}
+}
+
+// upstreamStaleWindows reads the `stale-while-revalidate` and `stale-if-error` directives from
+// the Cache-Control header, falling back to the given windows when not set.
+func upstreamStaleWindows(value string, fallback staleWindows) staleWindows {
+	stale := fallback
+	cc := parseCacheControl(value)
+
+	if seconds, ok := cc.seconds("stale-while-revalidate"); ok {
+		stale.whileRevalidate = seconds
+	}
+
+	if seconds, ok := cc.seconds("stale-if-error"); ok {
+		stale.ifError = seconds
+	}
+
+	return stale
+}
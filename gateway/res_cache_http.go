@@ -0,0 +1,248 @@
+package gateway
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TykTechnologies/tyk/header"
+)
+
+// cacheVarySuffix suffixes the key holding the request headers a cached response varies on.
+const cacheVarySuffix = "-vary"
+
+// cacheControl holds the directives of a Cache-Control header.
+type cacheControl map[string]string
+
+func parseCacheControl(value string) cacheControl {
+	cc := cacheControl{}
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name == "" {
+			continue
+		}
+		cc[strings.ToLower(name)] = strings.Trim(arg, `"`)
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// seconds returns the delta-seconds argument of the directive.
+func (cc cacheControl) seconds(directive string) (int64, bool) {
+	arg, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return seconds, true
+}
+
+// varyHeaderNames returns the canonical request header names listed in the Vary header.
+// It returns false if the response varies on `*` and can't be cached.
+func varyHeaderNames(h http.Header) ([]string, bool) {
+	var names []string
+	for _, value := range h.Values(header.Vary) {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			switch name {
+			case "":
+				continue
+			case "*":
+				return nil, false
+			}
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+
+	sort.Strings(names)
+	return names, true
+}
+
+// varyKey derives the cache key of a response variant from the values of the request headers it varies on.
+func varyKey(key string, names []string, h http.Header) string {
+	hash := md5.New()
+	for _, name := range names {
+		_, _ = io.WriteString(hash, name+":"+strings.Join(h.Values(name), ",")+"\n")
+	}
+	return key + "-" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// generateETag returns a strong entity tag for the body.
+func generateETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatches checks if the etag is in the If-None-Match list, using the weak comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return false
+	}
+
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified evaluates the conditional headers of the request against a cached response.
+// If-Modified-Since is only evaluated when If-None-Match is absent.
+func notModified(r *http.Request, res *http.Response) bool {
+	if ifNoneMatch := r.Header.Get(header.IfNoneMatch); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, res.Header.Get(header.ETag))
+	}
+
+	since, err := http.ParseTime(r.Header.Get(header.IfModifiedSince))
+	if err != nil {
+		return false
+	}
+
+	lastModified, err := http.ParseTime(res.Header.Get(header.LastModified))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.After(since)
+}
+
+// responseAge returns the age of a cached response in seconds, derived from its Date header.
+func responseAge(res *http.Response) int64 {
+	date, err := http.ParseTime(res.Header.Get(header.Date))
+	if err != nil {
+		return 0
+	}
+
+	age := int64(time.Since(date).Seconds())
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// responseTTL returns the freshness lifetime set by the response Cache-Control directives.
+// s-maxage takes precedence over max-age for a shared cache, no-cache makes the response stale immediately.
+func responseTTL(cc cacheControl, fallback int64) int64 {
+	if cc.has("no-cache") {
+		return 0
+	}
+
+	if ttl, ok := cc.seconds("s-maxage"); ok {
+		return ttl
+	}
+
+	if ttl, ok := cc.seconds("max-age"); ok {
+		return ttl
+	}
+
+	return fallback
+}
+
+// cacheableResponse checks the Cache-Control directives forbidding a shared cache to store the response.
+func cacheableResponse(reqCC, resCC cacheControl) bool {
+	return !reqCC.has("no-store") && !resCC.has("no-store") && !resCC.has("private")
+}
+
+// readCachedResponse parses a cached response in wire format.
+func readCachedResponse(r *http.Request, data string) (*http.Response, error) {
+	res, err := http.ReadResponse(bufio.NewReader(strings.NewReader(data)), r)
+	if err != nil {
+		return nil, err
+	}
+
+	nopCloseResponseBody(res)
+	return res, nil
+}
+
+// validators returns the validators of a cached response to revalidate it with the upstream.
+// Entity tags generated by the gateway are unknown to the upstream and are not returned.
+func validators(r *http.Request, data string) (etag, lastModified string) {
+	res, err := readCachedResponse(r, data)
+	if err != nil {
+		return "", ""
+	}
+	defer res.Body.Close()
+
+	etag = res.Header.Get(header.ETag)
+	if etag != "" {
+		body, err := io.ReadAll(res.Body)
+		if err != nil || generateETag(body) == etag {
+			etag = ""
+		}
+	}
+
+	return etag, res.Header.Get(header.LastModified)
+}
+
+// setValidators makes the request to the upstream conditional on the cached response, so an
+// unchanged response is refreshed with a 304. Requests with their own conditions are left as they are.
+func setValidators(r *http.Request, options *cacheOptions, data string) bool {
+	if r.Header.Get(header.IfNoneMatch) != "" || r.Header.Get(header.IfModifiedSince) != "" {
+		return false
+	}
+
+	etag, lastModified := validators(r, data)
+	if etag == "" && lastModified == "" {
+		return false
+	}
+
+	if etag != "" {
+		r.Header.Set(header.IfNoneMatch, etag)
+	}
+
+	if lastModified != "" {
+		r.Header.Set(header.IfModifiedSince, lastModified)
+	}
+
+	options.validated = data
+	return true
+}
+
+// refreshFromNotModified replaces a 304 response of the upstream with the cached response it validated,
+// updated with the headers of the 304 response (RFC 9111 section 4.3.4).
+func refreshFromNotModified(res *http.Response, r *http.Request, data string) bool {
+	cached, err := readCachedResponse(r, data)
+	if err != nil {
+		return false
+	}
+
+	for name, values := range res.Header {
+		switch name {
+		case header.ContentLength, header.ContentEncoding, header.TransferEncoding:
+			continue
+		}
+		cached.Header[name] = values
+	}
+
+	res.Body.Close()
+	res.StatusCode = cached.StatusCode
+	res.Status = cached.Status
+	res.Header = cached.Header
+	res.Body = cached.Body
+	res.ContentLength = cached.ContentLength
+	res.TransferEncoding = cached.TransferEncoding
+	return true
+}
@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func TestCacheControl(t *testing.T) {
+	cc := parseCacheControl(`public, Max-Age=60, s-maxage="120", no-cache, max-stale=abc`)
+
+	assert.True(t, cc.has("public"))
+	assert.True(t, cc.has("no-cache"))
+	assert.False(t, cc.has("private"))
+
+	seconds, ok := cc.seconds("max-age")
+	assert.True(t, ok)
+	assert.Equal(t, int64(60), seconds)
+
+	seconds, ok = cc.seconds("s-maxage")
+	assert.True(t, ok)
+	assert.Equal(t, int64(120), seconds)
+
+	_, ok = cc.seconds("max-stale")
+	assert.False(t, ok)
+
+	assert.Equal(t, int64(0), responseTTL(cc, 10))
+	assert.Equal(t, int64(120), responseTTL(parseCacheControl("max-age=60, s-maxage=120"), 10))
+	assert.Equal(t, int64(60), responseTTL(parseCacheControl("max-age=60"), 10))
+	assert.Equal(t, int64(10), responseTTL(parseCacheControl(""), 10))
+
+	assert.True(t, cacheableResponse(parseCacheControl(""), parseCacheControl("public")))
+	assert.False(t, cacheableResponse(parseCacheControl(""), parseCacheControl("private")))
+	assert.False(t, cacheableResponse(parseCacheControl(""), parseCacheControl("no-store")))
+	assert.False(t, cacheableResponse(parseCacheControl("no-store"), parseCacheControl("")))
+}
+
+func TestVary(t *testing.T) {
+	h := http.Header{}
+	h.Add(header.Vary, "accept-language, Accept")
+	h.Add(header.Vary, "Origin")
+
+	names, ok := varyHeaderNames(h)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Accept", "Accept-Language", "Origin"}, names)
+
+	_, ok = varyHeaderNames(http.Header{header.Vary: {"Accept, *"}})
+	assert.False(t, ok)
+
+	en := http.Header{"Accept-Language": {"en"}}
+	de := http.Header{"Accept-Language": {"de"}}
+	assert.Equal(t, varyKey("key", names, en), varyKey("key", names, en.Clone()))
+	assert.NotEqual(t, varyKey("key", names, en), varyKey("key", names, de))
+}
+
+func TestConditionalCacheRequests(t *testing.T) {
+	etag := generateETag([]byte("body"))
+	assert.Equal(t, etag, generateETag([]byte("body")))
+	assert.NotEqual(t, etag, generateETag([]byte("other")))
+
+	assert.True(t, etagMatches(`"a", "b"`, `"b"`))
+	assert.True(t, etagMatches(`W/"a"`, `"a"`))
+	assert.True(t, etagMatches(`*`, `"a"`))
+	assert.False(t, etagMatches(`"ab"`, `"a"`))
+	assert.False(t, etagMatches(`*`, ""))
+
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set(header.ETag, `"v1"`)
+	res.Header.Set(header.LastModified, lastModified.Format(http.TimeFormat))
+
+	check := func(name, value string, expected bool) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(name, value)
+		assert.Equal(t, expected, notModified(r, res), "%s: %s", name, value)
+	}
+
+	check(header.IfNoneMatch, `"v1"`, true)
+	check(header.IfNoneMatch, `"v2"`, false)
+	check(header.IfModifiedSince, lastModified.Format(http.TimeFormat), true)
+	check(header.IfModifiedSince, lastModified.Add(-time.Hour).Format(http.TimeFormat), false)
+	check(header.IfModifiedSince, "invalid", false)
+}
+
+func TestRedisCacheMiddleware_HTTPSemantics(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	var hits, revalidations int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+
+		switch r.URL.Path {
+		case "/vary":
+			w.Header().Set(header.Vary, "Accept-Language")
+			_, _ = fmt.Fprintf(w, "%s %d", r.Header.Get("Accept-Language"), n)
+			return
+		case "/private":
+			w.Header().Set(header.CacheControl, "private")
+		case "/no-store":
+			w.Header().Set(header.CacheControl, "no-store")
+		case "/s-maxage":
+			w.Header().Set(header.CacheControl, "max-age=0, s-maxage=60")
+		case "/conditional":
+			w.Header().Set(header.CacheControl, "max-age=2")
+			w.Header().Set(header.ETag, `"v1"`)
+			if r.Header.Get(header.IfNoneMatch) == `"v1"` {
+				atomic.AddInt32(&revalidations, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		_, _ = fmt.Fprintf(w, "response %d", n)
+	}))
+	defer upstream.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = randStringBytes(8)
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.CacheOptions.EnableCache = true
+		spec.CacheOptions.CacheAllSafeRequests = true
+		spec.CacheOptions.CacheTimeout = 60
+		spec.CacheOptions.EnableHTTPSemantics = true
+	})
+
+	cached := map[string]string{cachedResponseHeader: "1"}
+	delay := 10 * time.Millisecond
+
+	t.Run("vary", func(t *testing.T) {
+		en := map[string]string{"Accept-Language": "en"}
+		de := map[string]string{"Accept-Language": "de"}
+
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/vary", Headers: en, BodyMatch: "en", HeadersNotMatch: cached, Code: http.StatusOK, Delay: delay},
+			{Path: "/vary", Headers: de, BodyMatch: "de", HeadersNotMatch: cached, Code: http.StatusOK, Delay: delay},
+			{Path: "/vary", Headers: en, BodyMatch: "en", HeadersMatch: cached, Code: http.StatusOK},
+			{Path: "/vary", Headers: de, BodyMatch: "de", HeadersMatch: cached, Code: http.StatusOK},
+		}...)
+	})
+
+	t.Run("response directives", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/private", Code: http.StatusOK, Delay: delay},
+			{Path: "/private", HeadersNotMatch: cached, Code: http.StatusOK},
+			{Path: "/no-store", Code: http.StatusOK, Delay: delay},
+			{Path: "/no-store", HeadersNotMatch: cached, Code: http.StatusOK},
+			{Path: "/s-maxage", Code: http.StatusOK, Delay: delay},
+			{Path: "/s-maxage", HeadersMatch: cached, Code: http.StatusOK},
+		}...)
+	})
+
+	t.Run("request directives", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/request", BodyMatch: "response", Code: http.StatusOK, Delay: delay},
+			{Path: "/request", HeadersMatch: cached, Code: http.StatusOK},
+			{Path: "/request", Headers: map[string]string{header.CacheControl: "no-cache"}, HeadersNotMatch: cached, Code: http.StatusOK},
+			{Path: "/request", Headers: map[string]string{header.CacheControl: "max-age=0"}, HeadersNotMatch: cached, Code: http.StatusOK},
+			{Path: "/request", Headers: map[string]string{header.CacheControl: "no-store"}, HeadersNotMatch: cached, Code: http.StatusOK},
+			{Path: "/missing", Headers: map[string]string{header.CacheControl: "only-if-cached"}, Code: http.StatusGatewayTimeout},
+		}...)
+	})
+
+	t.Run("generated etag", func(t *testing.T) {
+		resp, _ := ts.Run(t, test.TestCase{Path: "/etag", Code: http.StatusOK, Delay: delay})
+		etag := resp.Header.Get(header.ETag)
+		assert.NotEmpty(t, etag)
+
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/etag", HeadersMatch: map[string]string{header.ETag: etag, cachedResponseHeader: "1"}, Code: http.StatusOK},
+			{Path: "/etag", Headers: map[string]string{header.IfNoneMatch: etag}, Code: http.StatusNotModified},
+		}...)
+	})
+
+	t.Run("conditional revalidation", func(t *testing.T) {
+		_, _ = ts.Run(t, test.TestCase{Path: "/conditional", BodyMatch: "response", Code: http.StatusOK, Delay: delay})
+		resp, _ := ts.Run(t, test.TestCase{Path: "/conditional", HeadersMatch: cached, Code: http.StatusOK})
+		b, _ := io.ReadAll(resp.Body)
+		body := string(b)
+
+		time.Sleep(3 * time.Second)
+
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/conditional", BodyMatch: body, HeadersNotMatch: cached, Code: http.StatusOK, Delay: delay},
+			{Path: "/conditional", BodyMatch: body, HeadersMatch: cached, Code: http.StatusOK},
+		}...)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&revalidations))
+	})
+}
@@ -0,0 +1,196 @@
+package gateway
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocraft/health"
+
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/internal/cache"
+	"github.com/TykTechnologies/tyk/storage/cachestore"
+)
+
+const (
+	defaultLocalResponseCacheSize = 64 << 20
+	defaultLocalResponseCacheTTL  = 5
+)
+
+// Response cache tiers, as reported in the cache statistics. The store tier is reported
+// as the name of its backend.
+const (
+	cacheTierLocal = "local"
+	cacheTierRedis = cachestore.BackendRedis
+)
+
+// cacheEntry is a decoded response cache entry.
+type cacheEntry struct {
+	data      string
+	timestamp string
+	stale     staleWindows
+}
+
+// localResponseCache is the per node in-memory tier in front of the Redis response cache.
+// A nil *localResponseCache is a disabled tier, all its methods are no-ops.
+type localResponseCache struct {
+	lru *cache.LRU
+	ttl int64
+}
+
+func newLocalResponseCache(conf config.LocalResponseCacheConf) *localResponseCache {
+	if !conf.Enabled {
+		return nil
+	}
+
+	size := conf.MaxSize
+	if size <= 0 {
+		size = defaultLocalResponseCacheSize
+	}
+
+	ttl := conf.TTL
+	if ttl <= 0 {
+		ttl = defaultLocalResponseCacheTTL
+	}
+
+	return &localResponseCache{lru: cache.NewLRU(size), ttl: ttl}
+}
+
+func localCacheKey(apiID, key string) string {
+	return apiID + "|" + key
+}
+
+func (c *localResponseCache) get(apiID, key string) (cacheEntry, bool) {
+	if c == nil {
+		return cacheEntry{}, false
+	}
+
+	entry, ok := c.lru.Get(localCacheKey(apiID, key))
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	return entry.(cacheEntry), true
+}
+
+// set holds the entry in memory until the local ttl elapses or the entry expires, whichever comes first.
+// Expired entries are not held, so stale responses are always served from Redis.
+func (c *localResponseCache) set(apiID, key string, entry cacheEntry) {
+	if c == nil {
+		return
+	}
+
+	expiry, err := strconv.ParseInt(entry.timestamp, 10, 64)
+	if err != nil {
+		return
+	}
+
+	ttl := c.ttl
+	if untilExpiry := expiry - time.Now().Unix(); untilExpiry < ttl {
+		ttl = untilExpiry
+	}
+
+	c.lru.Set(localCacheKey(apiID, key), entry, int64(len(entry.data)), time.Duration(ttl)*time.Second)
+}
+
+func (c *localResponseCache) delete(apiID string, keys ...string) {
+	if c == nil {
+		return
+	}
+
+	for _, key := range keys {
+		c.lru.Delete(localCacheKey(apiID, key))
+	}
+}
+
+func (c *localResponseCache) flush(apiID string) {
+	if c == nil {
+		return
+	}
+
+	c.lru.DeletePrefix(localCacheKey(apiID, ""))
+}
+
+// responseCacheStats counts the response cache hits and misses of each tier.
+type responseCacheStats struct {
+	mu    sync.RWMutex
+	tiers map[string]*cacheTierCounters
+}
+
+type cacheTierCounters struct {
+	hits   uint64
+	misses uint64
+}
+
+func newResponseCacheStats() *responseCacheStats {
+	return &responseCacheStats{tiers: map[string]*cacheTierCounters{
+		cacheTierRedis: {},
+	}}
+}
+
+func (s *responseCacheStats) tier(name string) *cacheTierCounters {
+	s.mu.RLock()
+	counters, ok := s.tiers[name]
+	s.mu.RUnlock()
+	if ok {
+		return counters
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if counters, ok = s.tiers[name]; !ok {
+		counters = &cacheTierCounters{}
+		s.tiers[name] = counters
+	}
+	return counters
+}
+
+// cacheTierStats are the statistics of a response cache tier.
+type cacheTierStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+}
+
+// record counts a lookup in the tier and reports it to the instrumentation sink.
+func (s *responseCacheStats) record(apiID, tier string, hit bool) {
+	counters := s.tier(tier)
+	counter, event := &counters.hits, tier+".hit"
+	if !hit {
+		counter, event = &counters.misses, tier+".miss"
+	}
+
+	atomic.AddUint64(counter, 1)
+
+	if instrumentationEnabled {
+		instrument.NewJob("ResponseCache").EventKv(event, health.Kvs{"api_id": apiID})
+	}
+}
+
+func (gw *Gateway) responseCacheStatsHandler(w http.ResponseWriter, _ *http.Request) {
+	s := gw.responseCacheStats
+
+	stats := map[string]cacheTierStats{}
+
+	s.mu.RLock()
+	for name, counters := range s.tiers {
+		stats[name] = cacheTierStats{
+			Hits:   atomic.LoadUint64(&counters.hits),
+			Misses: atomic.LoadUint64(&counters.misses),
+		}
+	}
+	s.mu.RUnlock()
+
+	if c := gw.localResponseCache; c != nil {
+		local := stats[cacheTierLocal]
+		local.Entries = c.lru.Len()
+		local.Size = c.lru.Size()
+		stats[cacheTierLocal] = local
+	}
+
+	doJSONWrite(w, http.StatusOK, stats)
+}
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func TestLocalResponseCache(t *testing.T) {
+	assert.Nil(t, newLocalResponseCache(config.LocalResponseCacheConf{}))
+
+	var disabled *localResponseCache
+	disabled.set("api", "key", cacheEntry{data: "data", timestamp: "1"})
+	_, ok := disabled.get("api", "key")
+	assert.False(t, ok)
+
+	c := newLocalResponseCache(config.LocalResponseCacheConf{Enabled: true})
+	future := strconv.FormatInt(time.Now().Unix()+60, 10)
+
+	c.set("api", "key", cacheEntry{data: "data", timestamp: future})
+	entry, ok := c.get("api", "key")
+	assert.True(t, ok)
+	assert.Equal(t, "data", entry.data)
+
+	c.set("api", "expired", cacheEntry{data: "data", timestamp: strconv.FormatInt(time.Now().Unix()-1, 10)})
+	_, ok = c.get("api", "expired")
+	assert.False(t, ok, "expired entries should not be held in memory")
+
+	c.set("api2", "key", cacheEntry{data: "data", timestamp: future})
+	c.flush("api")
+	_, ok = c.get("api", "key")
+	assert.False(t, ok)
+	_, ok = c.get("api2", "key")
+	assert.True(t, ok)
+
+	c.delete("api2", "key")
+	_, ok = c.get("api2", "key")
+	assert.False(t, ok)
+}
+
+func TestRedisCacheMiddleware_LocalTier(t *testing.T) {
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.LocalResponseCache.Enabled = true
+	})
+	defer ts.Close()
+
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, "response %d", atomic.AddInt32(&hits, 1))
+	}))
+	defer upstream.Close()
+
+	apiID := randStringBytes(8)
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.APIID = apiID
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		spec.CacheOptions.EnableCache = true
+		spec.CacheOptions.CacheAllSafeRequests = true
+		spec.CacheOptions.CacheTimeout = 60
+	})
+
+	cached := map[string]string{cachedResponseHeader: "1"}
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Path: "/local", BodyMatch: "response 1", HeadersNotMatch: cached, Code: http.StatusOK, Delay: 10 * time.Millisecond},
+		{Path: "/local", BodyMatch: "response 1", HeadersMatch: cached, Code: http.StatusOK},
+	}...)
+
+	getStats := func(t *testing.T) map[string]cacheTierStats {
+		t.Helper()
+		resp, err := ts.Run(t, test.TestCase{Path: "/tyk/cache/stats", AdminAuth: true, Code: http.StatusOK})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		stats := map[string]cacheTierStats{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+		return stats
+	}
+
+	stats := getStats(t)
+	assert.Equal(t, uint64(1), stats[cacheTierLocal].Hits)
+	assert.Equal(t, uint64(1), stats[cacheTierLocal].Misses)
+	assert.Equal(t, uint64(1), stats[cacheTierRedis].Misses)
+	assert.Equal(t, uint64(0), stats[cacheTierRedis].Hits)
+	assert.Equal(t, 1, stats[cacheTierLocal].Entries)
+
+	t.Run("served from memory", func(t *testing.T) {
+		store := storage.RedisCluster{IsCache: true, ConnectionHandler: ts.Gw.StorageConnectionHandler}
+		store.DeleteScanMatch("cache-" + apiID + "*")
+
+		_, _ = ts.Run(t, test.TestCase{Path: "/local", BodyMatch: "response 1", HeadersMatch: cached, Code: http.StatusOK})
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("evicted on invalidation", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Method: http.MethodDelete, Path: "/tyk/cache/" + apiID, AdminAuth: true, Code: http.StatusOK},
+			{Path: "/local", BodyMatch: "response 2", HeadersNotMatch: cached, Code: http.StatusOK, Delay: 10 * time.Millisecond},
+			{Method: http.MethodDelete, Path: "/tyk/cache/" + apiID + "?url=/local", AdminAuth: true, Code: http.StatusOK},
+			{Path: "/local", BodyMatch: "response 3", HeadersNotMatch: cached, Code: http.StatusOK},
+		}...)
+	})
+
+	t.Run("evicted by pub/sub", func(t *testing.T) {
+		ts.Gw.localResponseCache.set(apiID, "key", cacheEntry{data: "data", timestamp: strconv.FormatInt(time.Now().Unix()+60, 10)})
+
+		payload, err := json.Marshal(cacheInvalidation{APIID: apiID, Keys: []string{"key"}})
+		require.NoError(t, err)
+		ts.Gw.handleInvalidateCacheEntries(string(payload))
+
+		_, ok := ts.Gw.localResponseCache.get(apiID, "key")
+		assert.False(t, ok)
+	})
+
+	t.Run("flushed by pub/sub without purging the shared stores", func(t *testing.T) {
+		ts.Gw.localResponseCache.set(apiID, "key", cacheEntry{data: "data", timestamp: strconv.FormatInt(time.Now().Unix()+60, 10)})
+		store := storage.RedisCluster{KeyPrefix: "cache-" + apiID, IsCache: true, ConnectionHandler: ts.Gw.StorageConnectionHandler}
+		require.NoError(t, store.SetKey("shared", "data", 60))
+
+		notification := Notification{Command: NoticeFlushAPICache, Payload: apiID, Gw: ts.Gw}
+		notification.Sign()
+		msg, err := json.Marshal(notification)
+		require.NoError(t, err)
+		ts.Gw.handleRedisEvent(&testMessageAdapter{Msg: string(msg)}, nil, nil)
+
+		_, ok := ts.Gw.localResponseCache.get(apiID, "key")
+		assert.False(t, ok)
+		_, err = store.GetKey("shared")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/storage/cachestore"
+)
+
+// cacheBackends holds the response cache storage backends other than Redis. They're shared by
+// the APIs selecting them and created when first used.
+type cacheBackends struct {
+	mu     sync.Mutex
+	stores map[string]cachestore.Store
+}
+
+// sharedCacheStore returns the store of a backend other than Redis.
+func (gw *Gateway) sharedCacheStore(backend string) (cachestore.Store, error) {
+	gw.cacheBackends.mu.Lock()
+	defer gw.cacheBackends.mu.Unlock()
+
+	if store, ok := gw.cacheBackends.stores[backend]; ok {
+		return store, nil
+	}
+
+	conf := gw.GetConfig().ResponseCacheStorage
+
+	var (
+		store cachestore.Store
+		err   error
+	)
+
+	switch backend {
+	case cachestore.BackendMemory:
+		store = cachestore.NewMemory(conf.Memory)
+	case cachestore.BackendDisk:
+		store, err = cachestore.NewDisk(conf.Disk)
+	case cachestore.BackendS3:
+		store, err = cachestore.NewS3(conf.S3)
+	default:
+		err = fmt.Errorf("unknown cache backend %q", backend)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if gw.cacheBackends.stores == nil {
+		gw.cacheBackends.stores = map[string]cachestore.Store{}
+	}
+	gw.cacheBackends.stores[backend] = store
+
+	return store, nil
+}
+
+// apiCacheStore returns the store of the cached responses of an API using a backend other than Redis.
+func (gw *Gateway) apiCacheStore(apiID, backend string) (cachestore.Store, error) {
+	store, err := gw.sharedCacheStore(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	return cachestore.WithPrefix(store, apiID+"/"), nil
+}
+
+// responseCacheStore returns the store of the cached responses of the API and the name of its backend.
+// Redis is used if the API doesn't select a backend or its backend can't be created.
+func (gw *Gateway) responseCacheStore(spec *APISpec, redis storage.Handler) (cachestore.Store, string) {
+	backend := spec.CacheOptions.Backend
+	if backend == "" || backend == cachestore.BackendRedis {
+		return cachestore.NewRedis(redis), cachestore.BackendRedis
+	}
+
+	store, err := gw.apiCacheStore(spec.APIID, backend)
+	if err != nil {
+		mainLog.WithError(err).WithField("api_id", spec.APIID).Errorf("Couldn't use the %s cache backend, using redis", backend)
+		return cachestore.NewRedis(redis), cachestore.BackendRedis
+	}
+
+	return store, backend
+}
+
+// nonRedisCacheStore returns the store of the cached responses of an API if they're not kept in Redis,
+// where they're purged with the other cache keys of the API.
+func (gw *Gateway) nonRedisCacheStore(apiID string) cachestore.Store {
+	spec := gw.getApiSpec(apiID)
+	if spec == nil {
+		return nil
+	}
+
+	backend := spec.CacheOptions.Backend
+	if backend == "" || backend == cachestore.BackendRedis {
+		return nil
+	}
+
+	store, err := gw.apiCacheStore(apiID, backend)
+	if err != nil {
+		return nil
+	}
+
+	return store
+}
+
+// nodeCacheStore returns the store of the cached responses of an API if they're kept by this gateway,
+// in memory or on disk, where the gateway purging the API's cache can't reach them.
+func (gw *Gateway) nodeCacheStore(apiID string) cachestore.Store {
+	spec := gw.getApiSpec(apiID)
+	if spec == nil {
+		return nil
+	}
+
+	backend := spec.CacheOptions.Backend
+	if backend != cachestore.BackendMemory && backend != cachestore.BackendDisk {
+		return nil
+	}
+
+	store, err := gw.apiCacheStore(apiID, backend)
+	if err != nil {
+		return nil
+	}
+
+	return store
+}
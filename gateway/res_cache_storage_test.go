@@ -0,0 +1,172 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/internal/uuid"
+	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/storage/cachestore"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+func TestRedisCacheMiddleware_Backends(t *testing.T) {
+	dir := t.TempDir()
+	ts := StartTest(func(globalConf *config.Config) {
+		globalConf.ResponseCacheStorage.Disk.Path = dir
+	})
+	defer ts.Close()
+
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, "response %d", atomic.AddInt32(&hits, 1))
+	}))
+	defer upstream.Close()
+
+	load := func(backend string) string {
+		apiID := uuid.New()
+		atomic.StoreInt32(&hits, 0)
+
+		ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+			spec.APIID = apiID
+			spec.Proxy.ListenPath = "/"
+			spec.Proxy.TargetURL = upstream.URL
+			spec.CacheOptions.EnableCache = true
+			spec.CacheOptions.CacheAllSafeRequests = true
+			spec.CacheOptions.CacheTimeout = 60
+			spec.CacheOptions.Backend = backend
+		})
+
+		return apiID
+	}
+
+	getStats := func(t *testing.T) map[string]cacheTierStats {
+		t.Helper()
+		resp, err := ts.Run(t, test.TestCase{Path: "/tyk/cache/stats", AdminAuth: true, Code: http.StatusOK})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		stats := map[string]cacheTierStats{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+		return stats
+	}
+
+	cached := map[string]string{cachedResponseHeader: "1"}
+	delay := 10 * time.Millisecond
+
+	for _, backend := range []string{cachestore.BackendMemory, cachestore.BackendDisk} {
+		t.Run(backend, func(t *testing.T) {
+			apiID := load(backend)
+
+			_, _ = ts.Run(t, []test.TestCase{
+				{Path: "/a", BodyMatch: "response 1", HeadersNotMatch: cached, Code: http.StatusOK, Delay: delay},
+				{Path: "/a", BodyMatch: "response 1", HeadersMatch: cached, Code: http.StatusOK},
+			}...)
+
+			stats := getStats(t)
+			assert.Equal(t, uint64(1), stats[backend].Hits)
+			assert.Equal(t, uint64(1), stats[backend].Misses)
+
+			// only the cache index is kept in redis
+			redis := storage.RedisCluster{KeyPrefix: "cache-" + apiID, IsCache: true, ConnectionHandler: ts.Gw.StorageConnectionHandler}
+			assert.Equal(t, []string{cacheIndexURLs}, redis.GetKeys(""))
+
+			_, _ = ts.Run(t, []test.TestCase{
+				{Path: "/b", BodyMatch: "response 2", Code: http.StatusOK, Delay: delay},
+				{Method: http.MethodDelete, Path: "/tyk/cache/" + apiID + "?url=/a", AdminAuth: true, Code: http.StatusOK},
+				{Path: "/a", BodyMatch: "response 3", HeadersNotMatch: cached, Code: http.StatusOK, Delay: delay},
+				{Path: "/b", BodyMatch: "response 2", HeadersMatch: cached, Code: http.StatusOK},
+				{Method: http.MethodDelete, Path: "/tyk/cache/" + apiID, AdminAuth: true, Code: http.StatusOK},
+				{Path: "/b", BodyMatch: "response 4", HeadersNotMatch: cached, Code: http.StatusOK},
+			}...)
+		})
+	}
+
+	t.Run("unavailable backend falls back to redis", func(t *testing.T) {
+		load(cachestore.BackendS3)
+
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/s3", BodyMatch: "response 1", HeadersNotMatch: cached, Code: http.StatusOK, Delay: delay},
+			{Path: "/s3", BodyMatch: "response 1", HeadersMatch: cached, Code: http.StatusOK},
+		}...)
+
+		_, ok := getStats(t)[cachestore.BackendS3]
+		assert.False(t, ok)
+	})
+}
+
+func TestRedisCacheMiddleware_BackendsInvalidatedAcrossGateways(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, "response %d", atomic.AddInt32(&hits, 1))
+	}))
+	defer upstream.Close()
+
+	ts1 := StartTest(func(globalConf *config.Config) {
+		globalConf.ResponseCacheStorage.Disk.Path = t.TempDir()
+	})
+	defer ts1.Close()
+
+	// the gateways share the redis database holding the cache index
+	ts2 := StartTest(func(globalConf *config.Config) {
+		globalConf.Storage.Database = ts1.Gw.GetConfig().Storage.Database
+		globalConf.ResponseCacheStorage.Disk.Path = t.TempDir()
+	})
+	defer ts2.Close()
+
+	cached := map[string]string{cachedResponseHeader: "1"}
+
+	for _, backend := range []string{cachestore.BackendMemory, cachestore.BackendDisk} {
+		t.Run(backend, func(t *testing.T) {
+			apiID := uuid.New()
+			for _, ts := range []*Test{ts1, ts2} {
+				ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+					spec.APIID = apiID
+					spec.Proxy.ListenPath = "/"
+					spec.Proxy.TargetURL = upstream.URL
+					spec.CacheOptions.EnableCache = true
+					spec.CacheOptions.CacheAllSafeRequests = true
+					spec.CacheOptions.CacheTimeout = 60
+					spec.CacheOptions.Backend = backend
+				})
+			}
+
+			// evicted eventually reports whether the response cached by the second gateway is
+			// evicted once it handled the notice of the first gateway
+			evicted := func(t *testing.T) bool {
+				t.Helper()
+				return assert.Eventually(t, func() bool {
+					resp, err := ts2.Run(t, test.TestCase{Path: "/a", Code: http.StatusOK})
+					if err != nil {
+						return false
+					}
+					defer resp.Body.Close()
+
+					return resp.Header.Get(cachedResponseHeader) == ""
+				}, time.Second, 50*time.Millisecond)
+			}
+
+			_, _ = ts2.Run(t, []test.TestCase{
+				{Path: "/a", HeadersNotMatch: cached, Code: http.StatusOK, Delay: 10 * time.Millisecond},
+				{Path: "/a", HeadersMatch: cached, Code: http.StatusOK, Delay: 10 * time.Millisecond},
+			}...)
+
+			_, _ = ts1.Run(t, test.TestCase{Method: http.MethodDelete, Path: "/tyk/cache/" + apiID + "?url=/a", AdminAuth: true, Code: http.StatusOK})
+			evicted(t)
+
+			_, _ = ts2.Run(t, test.TestCase{Path: "/a", HeadersMatch: cached, Code: http.StatusOK, Delay: 10 * time.Millisecond})
+
+			_, _ = ts1.Run(t, test.TestCase{Method: http.MethodDelete, Path: "/tyk/cache/" + apiID, AdminAuth: true, Code: http.StatusOK})
+			evicted(t)
+		})
+	}
+}
@@ -58,24 +58,28 @@ func (h *HeaderInjector) HandleResponse(rw http.ResponseWriter, res *http.Respon
 	found, meta := h.Spec.CheckSpecMatchesStatus(req, versionPaths, HeaderInjectedResponse)
 	if found {
 		hmeta := meta.(*apidef.HeaderInjectionMeta)
-		for _, dKey := range hmeta.DeleteHeaders {
-			res.Header.Del(dKey)
-		}
-		for nKey, nVal := range hmeta.AddHeaders {
-			setCustomHeader(res.Header, nKey, h.Gw.ReplaceTykVariables(req, nVal, false), ignoreCanonical)
+		if responseConditionMatches(hmeta.ResponseCondition, res) {
+			for _, dKey := range hmeta.DeleteHeaders {
+				res.Header.Del(dKey)
+			}
+			for nKey, nVal := range hmeta.AddHeaders {
+				setCustomHeader(res.Header, nKey, h.Gw.ReplaceTykVariables(req, nVal, false), ignoreCanonical)
+			}
 		}
 	}
 
 	// Manage global response header options with versionInfo
 	if !vInfo.GlobalResponseHeadersDisabled {
-		for _, key := range vInfo.GlobalResponseHeadersRemove {
-			log.Debug("Removing: ", key)
-			res.Header.Del(key)
-		}
-
-		for key, val := range vInfo.GlobalResponseHeaders {
-			log.Debug("Adding: ", key)
-			setCustomHeader(res.Header, key, h.Gw.ReplaceTykVariables(req, val, false), ignoreCanonical)
+		if responseConditionMatches(vInfo.GlobalResponseHeadersCondition, res) {
+			for _, key := range vInfo.GlobalResponseHeadersRemove {
+				log.Debug("Removing: ", key)
+				res.Header.Del(key)
+			}
+
+			for key, val := range vInfo.GlobalResponseHeaders {
+				log.Debug("Adding: ", key)
+				setCustomHeader(res.Header, key, h.Gw.ReplaceTykVariables(req, val, false), ignoreCanonical)
+			}
 		}
 
 		// Manage global response header options with response_processors
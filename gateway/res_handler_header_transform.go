@@ -7,6 +7,7 @@ import (
 
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/TykTechnologies/tyk/apidef"
 	"github.com/TykTechnologies/tyk/user"
 )
 
@@ -17,6 +18,8 @@ type RevProxyTransform struct {
 
 type HeaderTransformOptions struct {
 	RevProxyTransform RevProxyTransform `mapstructure:"rev_proxy_header_cleanup" bson:"rev_proxy_header_cleanup" json:"rev_proxy_header_cleanup"`
+	// ResponseCondition restricts the transform to the matching responses.
+	ResponseCondition *apidef.ResponseCondition `mapstructure:"response_condition" bson:"response_condition" json:"response_condition,omitempty"`
 }
 
 type HeaderTransform struct {
@@ -46,6 +49,10 @@ func (h *HeaderTransform) HandleError(rw http.ResponseWriter, req *http.Request)
 func (h *HeaderTransform) HandleResponse(rw http.ResponseWriter,
 	res *http.Response, req *http.Request, ses *user.SessionState) error {
 
+	if !responseConditionMatches(h.config.ResponseCondition, res) {
+		return nil
+	}
+
 	// Parse target_host parameter from configuration
 	target_url, err := url.Parse(h.config.RevProxyTransform.Target_host)
 	if err != nil {
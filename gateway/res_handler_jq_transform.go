@@ -35,9 +35,12 @@ func (h *ResponseTransformJQMiddleware) HandleResponse(rw http.ResponseWriter, r
 		return nil
 	}
 
-	defer res.Body.Close()
-
 	ts := meta.(*TransformJQSpec)
+	if !responseConditionMatches(ts.ResponseCondition, res) {
+		return nil
+	}
+
+	defer res.Body.Close()
 
 	var bodyObj interface{}
 	dec := json.NewDecoder(res.Body)
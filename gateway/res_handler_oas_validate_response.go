@@ -0,0 +1,198 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/sirupsen/logrus"
+
+	"github.com/TykTechnologies/tyk/apidef/oas"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+const (
+	responseValidationFailedTag = "response-validation-failed"
+	errResponseValidationFailed = "Upstream response failed validation"
+
+	defaultResponseValidationMaxBodySize = 10 << 20
+)
+
+var errResponseBodyTooLarge = errors.New("response body exceeds the maximum size validated")
+
+// ValidateResponse validates the upstream responses against the responses of the OAS operation.
+type ValidateResponse struct {
+	BaseTykResponseHandler
+}
+
+func (h *ValidateResponse) Base() *BaseTykResponseHandler {
+	return &h.BaseTykResponseHandler
+}
+
+func (*ValidateResponse) Name() string {
+	return "ValidateResponse"
+}
+
+func (h *ValidateResponse) Enabled() bool {
+	return h.Spec.HasValidateResponse
+}
+
+func (h *ValidateResponse) Init(_ interface{}, spec *APISpec) error {
+	h.Spec = spec
+	return nil
+}
+
+func (h *ValidateResponse) HandleError(_ http.ResponseWriter, _ *http.Request) {}
+
+func (h *ValidateResponse) HandleResponse(_ http.ResponseWriter, res *http.Response, req *http.Request, _ *user.SessionState) error {
+	operation := ctxGetOperation(req)
+	if operation == nil || operation.ValidateResponse == nil || !operation.ValidateResponse.Enabled {
+		return nil
+	}
+
+	err := h.validate(res, req, operation)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, errResponseBodyTooLarge) {
+		log.WithFields(logrus.Fields{
+			"prefix": "validate-response",
+			"api_id": h.Spec.APIID,
+			"path":   req.URL.Path,
+		}).Warning("Upstream response not validated, the body exceeds the maximum size")
+		return nil
+	}
+
+	log.WithFields(logrus.Fields{
+		"prefix": "validate-response",
+		"api_id": h.Spec.APIID,
+		"path":   req.URL.Path,
+	}).WithError(err).Warning("Upstream response failed validation")
+
+	ctxSetResponseValidationFailed(req)
+	h.Spec.FireEvent(EventResponseValidationFailed, EventResponseValidationFailedMeta{
+		EventMetaDefault: EventMetaDefault{
+			Message:            "Upstream response failed validation.",
+			OriginatingRequest: EncodeRequestToEvent(req),
+		},
+		Path:       req.URL.Path,
+		Method:     req.Method,
+		StatusCode: res.StatusCode,
+		Reason:     err.Error(),
+	})
+
+	if operation.ValidateResponse.Mode != oas.ValidateResponseModeLog {
+		h.block(res)
+	}
+
+	return nil
+}
+
+// validate validates the response, the body is read and restored. It returns errResponseBodyTooLarge
+// without validating the response when the body, or the decompressed body, exceeds the maximum size.
+func (h *ValidateResponse) validate(res *http.Response, req *http.Request, operation *Operation) error {
+	options := &openapi3filter.Options{
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+
+	maxBodySize := operation.ValidateResponse.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultResponseValidationMaxBodySize
+	}
+
+	var body []byte
+	if res.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(res.Body, maxBodySize+1))
+		if err != nil {
+			res.Body.Close()
+			res.Body = io.NopCloser(bytes.NewReader(body))
+			return err
+		}
+
+		if int64(len(body)) > maxBodySize {
+			// the rest of the body is streamed to the client
+			res.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}
+			return errResponseBodyTooLarge
+		}
+
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// compressed bodies are validated in their decoded form
+	decoded := body
+	if encoding := res.Header.Get(header.ContentEncoding); encoding != "" && len(body) > 0 {
+		reader, err := decompressRequestBody(encoding, bytes.NewReader(body))
+		switch {
+		case errors.Is(err, errUnsupportedEncoding):
+			options.ExcludeResponseBody = true
+		case err != nil:
+			return err
+		default:
+			decoded, err = io.ReadAll(io.LimitReader(reader, maxBodySize+1))
+			reader.Close()
+			if err != nil {
+				return err
+			}
+			if int64(len(decoded)) > maxBodySize {
+				return errResponseBodyTooLarge
+			}
+		}
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: operation.pathParams,
+			Route:      operation.route,
+		},
+		Status:  res.StatusCode,
+		Header:  res.Header,
+		Body:    io.NopCloser(bytes.NewReader(decoded)),
+		Options: options,
+	}
+
+	return openapi3filter.ValidateResponse(context.Background(), input)
+}
+
+// block replaces the response with a 502 Bad Gateway error.
+func (h *ValidateResponse) block(res *http.Response) {
+	tmpl := h.Gw.templates.Lookup("error_" + strconv.Itoa(http.StatusBadGateway) + "." + defaultTemplateFormat)
+	if tmpl == nil {
+		tmpl = h.Gw.templates.Lookup(defaultTemplateName + "." + defaultTemplateFormat)
+	}
+
+	var body bytes.Buffer
+	if tmpl != nil {
+		apiError := APIError{htmltemplate.HTML(htmltemplate.JSEscapeString(errResponseValidationFailed))}
+		if err := tmpl.Execute(&body, &apiError); err != nil {
+			log.WithError(err).Error("Couldn't render the response validation error")
+		}
+	}
+
+	res.StatusCode = http.StatusBadGateway
+	res.Status = strconv.Itoa(http.StatusBadGateway) + " " + http.StatusText(http.StatusBadGateway)
+	res.Header = http.Header{}
+	res.Header.Set(header.ContentType, header.ApplicationJSON)
+	res.Header.Set(header.ContentLength, strconv.Itoa(body.Len()))
+	if !h.Spec.GlobalConfig.HideGeneratorHeader {
+		res.Header.Set(header.XGenerator, "tyk.io")
+	}
+	res.ContentLength = int64(body.Len())
+	res.Body = io.NopCloser(&body)
+}
+
+// readCloser reads from Reader and closes Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,233 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/apidef/oas"
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+const testOASForValidateResponse = `{
+  "openapi": "3.0.0",
+  "info": {
+    "title": "validate-response",
+    "version": "1.0.0"
+  },
+  "paths": {
+    "/pets/{id}": {
+      "get": {
+        "operationId": "getPet",
+        "parameters": [{
+          "name": "id",
+          "in": "path",
+          "required": true,
+          "schema": {
+            "type": "string"
+          }
+        }],
+        "responses": {
+          "200": {
+            "description": "",
+            "headers": {
+              "X-Rate": {
+                "required": true,
+                "schema": {
+                  "type": "integer"
+                }
+              }
+            },
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "required": ["name"],
+                  "properties": {
+                    "name": {
+                      "type": "string"
+                    }
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": ""
+          }
+        }
+      }
+    }
+  },
+  "servers": [
+    {
+      "url": "/"
+    }
+  ]
+}`
+
+func TestValidateResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(header.ContentType, header.ApplicationJSON)
+		switch r.URL.Path {
+		case "/pets/valid":
+			w.Header().Set("X-Rate", "5")
+			_, _ = w.Write([]byte(`{"name": "rex"}`))
+		case "/pets/invalid-body":
+			w.Header().Set("X-Rate", "5")
+			_, _ = w.Write([]byte(`{"name": 123}`))
+		case "/pets/invalid-header":
+			w.Header().Set("X-Rate", "five")
+			_, _ = w.Write([]byte(`{"name": "rex"}`))
+		case "/pets/missing-header":
+			_, _ = w.Write([]byte(`{"name": "rex"}`))
+		case "/pets/invalid-gzip":
+			var body bytes.Buffer
+			zw := gzip.NewWriter(&body)
+			_, _ = zw.Write([]byte(`{"name": 123}`))
+			_ = zw.Close()
+
+			w.Header().Set("X-Rate", "5")
+			w.Header().Set(header.ContentEncoding, "gzip")
+			_, _ = w.Write(body.Bytes())
+		case "/pets/large-invalid":
+			w.Header().Set("X-Rate", "5")
+			_, _ = w.Write([]byte(`{"name": 123, "padding": "` + strings.Repeat("x", 64) + `"}`))
+		case "/pets/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	defer upstream.Close()
+
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	oasAPI := func(mode string, maxBodySize int64) oas.OAS {
+		oasDoc, err := openapi3.NewLoader().LoadFromData([]byte(testOASForValidateResponse))
+		require.NoError(t, err)
+
+		oasAPI := oas.OAS{T: *oasDoc}
+		oasAPI.SetTykExtension(&oas.XTykAPIGateway{
+			Middleware: &oas.Middleware{
+				Operations: oas.Operations{
+					"getPet": {
+						ValidateResponse: &oas.ValidateResponse{
+							Enabled:     true,
+							Mode:        mode,
+							MaxBodySize: maxBodySize,
+						},
+					},
+				},
+			},
+		})
+		require.NoError(t, oasAPI.Validate(context.Background()))
+
+		return oasAPI
+	}
+
+	specs := ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.Name = "block"
+		spec.OAS = oasAPI(oas.ValidateResponseModeBlock, 0)
+		spec.IsOAS = true
+		spec.Proxy.ListenPath = "/block/"
+		spec.Proxy.StripListenPath = true
+		spec.Proxy.TargetURL = upstream.URL
+	}, func(spec *APISpec) {
+		spec.Name = "log"
+		spec.OAS = oasAPI(oas.ValidateResponseModeLog, 0)
+		spec.IsOAS = true
+		spec.Proxy.ListenPath = "/log/"
+		spec.Proxy.StripListenPath = true
+		spec.Proxy.TargetURL = upstream.URL
+	}, func(spec *APISpec) {
+		spec.Name = "capped"
+		spec.OAS = oasAPI(oas.ValidateResponseModeBlock, 32)
+		spec.IsOAS = true
+		spec.Proxy.ListenPath = "/capped/"
+		spec.Proxy.StripListenPath = true
+		spec.Proxy.TargetURL = upstream.URL
+	})
+
+	events := make(chan config.EventMessage, 10)
+	for _, spec := range specs {
+		spec.EventPaths = map[apidef.TykEvent][]config.TykEventHandler{
+			EventResponseValidationFailed: {&testEventHandler{func(em config.EventMessage) {
+				events <- em
+			}}},
+		}
+	}
+
+	expectEvent := func(t *testing.T, status int) {
+		t.Helper()
+
+		select {
+		case em := <-events:
+			meta, ok := em.Meta.(EventResponseValidationFailedMeta)
+			require.True(t, ok)
+			assert.Equal(t, status, meta.StatusCode)
+			assert.NotEmpty(t, meta.Reason)
+		case <-time.After(time.Second):
+			t.Fatal("ResponseValidationFailed event wasn't fired")
+		}
+	}
+
+	t.Run("block", func(t *testing.T) {
+		_, _ = ts.Run(t, test.TestCase{Path: "/block/pets/valid", Code: http.StatusOK, BodyMatch: "rex"})
+		_, _ = ts.Run(t, test.TestCase{Path: "/block/pets/missing", Code: http.StatusNotFound})
+		assert.Empty(t, events)
+
+		for _, path := range []string{"invalid-body", "invalid-header", "missing-header", "invalid-gzip", "undocumented-status"} {
+			_, _ = ts.Run(t, test.TestCase{Path: "/block/pets/" + path, Code: http.StatusBadGateway,
+				BodyMatch: errResponseValidationFailed, HeadersNotMatch: map[string]string{"X-Rate": "5"}})
+		}
+
+		expectEvent(t, http.StatusOK)
+		expectEvent(t, http.StatusOK)
+		expectEvent(t, http.StatusOK)
+		expectEvent(t, http.StatusOK)
+		expectEvent(t, http.StatusTeapot)
+	})
+
+	t.Run("log", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/log/pets/valid", Code: http.StatusOK, BodyMatch: "rex"},
+			{Path: "/log/pets/invalid-body", Code: http.StatusOK, BodyMatch: `"name": 123`},
+			{Path: "/log/pets/undocumented-status", Code: http.StatusTeapot},
+		}...)
+
+		expectEvent(t, http.StatusOK)
+		expectEvent(t, http.StatusTeapot)
+	})
+
+	t.Run("max body size", func(t *testing.T) {
+		_, _ = ts.Run(t, []test.TestCase{
+			{Path: "/capped/pets/invalid-body", Code: http.StatusBadGateway},
+			{Path: "/capped/pets/large-invalid", Code: http.StatusOK, BodyMatch: `"padding": "` + strings.Repeat("x", 64) + `"`},
+		}...)
+
+		expectEvent(t, http.StatusOK)
+		assert.Empty(t, events, "the large response isn't validated")
+	})
+
+	t.Run("analytics tag", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.False(t, ctxResponseValidationFailed(req))
+
+		ctxSetResponseValidationFailed(req)
+		assert.True(t, ctxResponseValidationFailed(req))
+	})
+}
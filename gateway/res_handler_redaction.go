@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/httputil"
+	"github.com/TykTechnologies/tyk/internal/redaction"
+	"github.com/TykTechnologies/tyk/user"
+)
+
+// ResponseRedaction redacts the upstream response body with the redaction rules of the API and endpoint.
+type ResponseRedaction struct {
+	BaseTykResponseHandler
+}
+
+func (h *ResponseRedaction) Base() *BaseTykResponseHandler {
+	return &h.BaseTykResponseHandler
+}
+
+func (*ResponseRedaction) Name() string {
+	return "ResponseRedaction"
+}
+
+func (h *ResponseRedaction) Enabled() bool {
+	return h.Spec.hasRedaction()
+}
+
+func (h *ResponseRedaction) Init(_ interface{}, spec *APISpec) error {
+	h.Spec = spec
+	return nil
+}
+
+func (h *ResponseRedaction) HandleError(_ http.ResponseWriter, _ *http.Request) {}
+
+func (h *ResponseRedaction) HandleResponse(_ http.ResponseWriter, res *http.Response, req *http.Request, _ *user.SessionState) error {
+	if res.Body == nil || httputil.IsStreamingResponse(res) {
+		return nil
+	}
+
+	rules := redaction.ForTarget(h.Spec.requestRedactionRules(req), apidef.RedactionTargetResponse)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		return err
+	}
+
+	if redacted, ok := redactBody(body, res.Header, rules, h.Spec.maxRedactedSize()); ok {
+		body = redacted
+		res.ContentLength = int64(len(body))
+		res.Header.Set(header.ContentLength, strconv.Itoa(len(body)))
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
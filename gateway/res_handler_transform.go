@@ -111,15 +111,33 @@ func (r *ResponseTransformMiddleware) HandleResponse(rw http.ResponseWriter, res
 		return nil
 	}
 	tmeta := meta.(*TransformSpec)
+	if !responseConditionMatches(tmeta.ResponseCondition, res) {
+		return nil
+	}
 
 	respBody := respBodyReader(req, res)
 	body, _ := ioutil.ReadAll(respBody)
 	defer respBody.Close()
 
+	if isBodyConversion(tmeta.TemplateData) {
+		r.convert(res, body, tmeta, logger)
+		return nil
+	}
+
 	// Put into an interface:
 	bodyData := make(map[string]interface{})
 	switch tmeta.TemplateData.Input {
 	case apidef.RequestXML:
+		if hasXMLOptions(tmeta.TemplateData.XML) {
+			xmlMap, err := decodeXML(body, tmeta.TemplateData.XML)
+			if err != nil {
+				logger.WithError(err).Error("Error unmarshalling XML")
+				break
+			}
+			bodyData = xmlMap
+			break
+		}
+
 		if len(body) == 0 {
 			body = []byte("<_/>")
 		}
@@ -136,6 +154,13 @@ func (r *ResponseTransformMiddleware) HandleResponse(rw http.ResponseWriter, res
 		for k, v := range xmlMap {
 			bodyData[k] = v
 		}
+	case apidef.RequestForm, apidef.RequestMultipart:
+		form, err := decodeForm(body, res.Header.Get(header.ContentType))
+		if err != nil {
+			logger.WithError(err).Error("Error parsing form")
+			break
+		}
+		bodyData = form
 	default: // apidef.RequestJSON
 		if len(body) == 0 {
 			body = []byte("{}")
@@ -184,3 +209,25 @@ func (r *ResponseTransformMiddleware) HandleResponse(rw http.ResponseWriter, res
 
 	return nil
 }
+
+// convert converts the response body from the input to the output format of the transform.
+// The body is left unchanged if it's empty or can't be converted.
+func (r *ResponseTransformMiddleware) convert(res *http.Response, body []byte, tmeta *TransformSpec, logger *logrus.Entry) {
+	encoding := res.Header.Get(header.ContentEncoding)
+
+	if len(body) > 0 {
+		converted, contentType, err := convertBody(body, res.Header.Get(header.ContentType), tmeta.TemplateData)
+		if err != nil {
+			logger.WithError(err).Error("Failed to convert response body")
+		} else {
+			body = converted
+			res.Header.Set(header.ContentType, contentType)
+		}
+	}
+
+	bodyBuffer := compressBuffer(*bytes.NewBuffer(body), encoding)
+
+	res.ContentLength = int64(bodyBuffer.Len())
+	res.Header.Set(header.ContentLength, strconv.Itoa(bodyBuffer.Len()))
+	res.Body = ioutil.NopCloser(&bodyBuffer)
+}
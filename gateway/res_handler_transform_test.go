@@ -293,3 +293,38 @@ func TestHeaderTransformBase(t *testing.T) {
 	// Check that the returned base is indeed the BaseTykResponseHandler of ht
 	require.Equal(t, &ht.BaseTykResponseHandler, base, "Base method did not return the expected BaseTykResponseHandler")
 }
+
+func TestTransformResponse_ResponseCondition(t *testing.T) {
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.Proxy.ListenPath = "/"
+		UpdateAPIVersion(spec, "v1", func(v *apidef.VersionInfo) {
+			v.ExtendedPaths.TransformResponse = []apidef.TemplateMeta{{
+				Path:   "/errors/{status}",
+				Method: http.MethodGet,
+				TemplateData: apidef.TemplateData{
+					Mode:           "blob",
+					TemplateSource: base64.StdEncoding.EncodeToString([]byte(`{"error":"upstream failure"}`)),
+				},
+				ResponseCondition: &apidef.ResponseCondition{StatusCodes: []string{"5xx"}},
+			}}
+			v.ExtendedPaths.TransformResponseHeader = []apidef.HeaderInjectionMeta{{
+				Path:              "/",
+				Method:            http.MethodGet,
+				AddHeaders:        map[string]string{"X-Success": "true"},
+				ResponseCondition: &apidef.ResponseCondition{StatusCodes: []string{"200-299"}},
+			}}
+			v.GlobalResponseHeaders = map[string]string{"X-Not-Found": "true"}
+			v.GlobalResponseHeadersCondition = &apidef.ResponseCondition{StatusCodes: []string{"404"}, ContentTypes: []string{"text/*"}}
+		})
+	})
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Path: "/errors/502", Code: http.StatusBadGateway, BodyMatch: `^{"error":"upstream failure"}$`,
+			HeadersNotMatch: map[string]string{"X-Success": "true", "X-Not-Found": "true"}},
+		{Path: "/errors/404", Code: http.StatusNotFound, BodyNotMatch: "upstream failure", HeadersMatch: map[string]string{"X-Not-Found": "true"}},
+		{Path: "/get", Code: http.StatusOK, HeadersMatch: map[string]string{"X-Success": "true"}, HeadersNotMatch: map[string]string{"X-Not-Found": "true"}},
+	}...)
+}
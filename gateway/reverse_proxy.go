@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net"
 	"net/http"
 	"net/textproto"
@@ -237,8 +238,14 @@ func (gw *Gateway) TykNewSingleHostReverseProxy(target *url.URL, spec *APISpec,
 		target := target
 		gw := gw
 
+		targetQuery := targetQuery
+
 		hostList := spec.Proxy.StructuredTargetList
 		switch {
+		case ctxGetUpstreamTarget(req) != nil:
+			// routed by a routing rule, load balancing and service discovery don't apply
+			target = ctxGetUpstreamTarget(req)
+			targetQuery = target.RawQuery
 		case spec.Proxy.ServiceDiscovery.UseDiscoveryService:
 			var err error
 			hostList, err = urlFromService(spec, gw)
@@ -1006,7 +1013,7 @@ func isCORSPreflight(r *http.Request) bool {
 
 func (p *ReverseProxy) handleGraphQL(roundTripper *TykRoundTripper, outreq *http.Request, w http.ResponseWriter) (res *http.Response, hijacked bool, err error) {
 	isWebSocketUpgrade := ctxGetGraphQLIsWebSocketUpgrade(outreq)
-	needsEngine := needsGraphQLExecutionEngine(p.TykAPISpec)
+	needsEngine := needsGraphQLExecutionEngine(p.TykAPISpec) && !ctxGetGraphQLPassthrough(outreq)
 
 	requestHeadersRewrite := make(map[string]apidef.RequestHeadersRewriteConfig)
 	for key, value := range p.TykAPISpec.GraphQL.Proxy.RequestHeadersRewrite {
@@ -1036,7 +1043,12 @@ func (p *ReverseProxy) handleGraphQL(roundTripper *TykRoundTripper, outreq *http
 	// This should only apply when the connection was not hijacked (= upgraded to websocket).
 	if res == nil && !hijacked {
 		res, err = p.sendRequestToUpstream(roundTripper, outreq)
-		return
+	}
+
+	if err == nil && res != nil && graphengine.ResponseMediaType(outreq.Header.Get(header.Accept)) == graphengine.MediaTypeGraphQLResponse {
+		if mediaType, _, _ := mime.ParseMediaType(res.Header.Get(header.ContentType)); mediaType == header.ApplicationJSON {
+			res.Header.Set(header.ContentType, graphengine.MediaTypeGraphQLResponse)
+		}
 	}
 
 	return res, hijacked, err
@@ -1232,6 +1244,9 @@ func (p *ReverseProxy) WrappedServeHTTP(rw http.ResponseWriter, req *http.Reques
 	if breakerEnforced {
 		if !breakerConf.CB.Ready() {
 			p.logger.Debug("ON REQUEST: Circuit Breaker is in OPEN state")
+			if serveStaleResponse(rw, req, p.Gw.GetConfig().IgnoreCanonicalMIMEHeaderKey) {
+				return ProxyResponse{}
+			}
 			p.ErrorHandler.HandleError(rw, logreq, "Service temporarily unavailable.", 503, true)
 			return ProxyResponse{}
 		}
@@ -1270,6 +1285,11 @@ func (p *ReverseProxy) WrappedServeHTTP(rw http.ResponseWriter, req *http.Reques
 			return ProxyResponse{UpstreamLatency: upstreamLatency}
 		}
 
+		if !strings.Contains(err.Error(), "context canceled") && serveStaleResponse(rw, req, p.Gw.GetConfig().IgnoreCanonicalMIMEHeaderKey) {
+			p.logger.Debug("Upstream failed, serving stale response")
+			return ProxyResponse{UpstreamLatency: upstreamLatency}
+		}
+
 		if strings.Contains(err.Error(), "timeout awaiting response headers") || strings.Contains(err.Error(), "context deadline exceeded") {
 			p.ErrorHandler.HandleError(rw, logreq, "Upstream service reached hard timeout.", http.StatusGatewayTimeout, true)
 
@@ -1439,6 +1459,11 @@ func (p *ReverseProxy) flushInterval(res *http.Response) time.Duration {
 		return -1 // negative means immediately
 	}
 
+	// Incrementally delivered GraphQL responses are flushed part by part.
+	if strings.HasPrefix(resCT, graphengine.MediaTypeMultipartMixed) {
+		return -1
+	}
+
 	// We might have the case of streaming for which Content-Length might be unset.
 	if res.ContentLength == -1 {
 		return -1
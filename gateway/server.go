@@ -143,6 +143,11 @@ type Gateway struct {
 	UtilCache cache.Repository
 	// ServiceCache is the service discovery cache
 	ServiceCache cache.Repository
+	// localResponseCache is the in-memory tier of the response cache
+	localResponseCache *localResponseCache
+	responseCacheStats *responseCacheStats
+	// cacheBackends are the response cache storage backends other than Redis
+	cacheBackends cacheBackends
 
 	// Nonce to use when interacting with the dashboard service
 	ServiceNonce      string
@@ -167,6 +172,9 @@ type Gateway struct {
 	RedisPurgeOnce sync.Once
 	RpcPurgeOnce   sync.Once
 
+	webhookDispatcherOnce sync.Once
+	webhookDispatcher     *webhookDispatcher
+
 	// OnConnect this is a callback which is called whenever we transition redis Disconnected to connected
 	OnConnect func()
 
@@ -237,6 +245,8 @@ func NewGateway(config config.Config, ctx context.Context) *Gateway {
 
 	gw.ServiceCache = cache.New(timeout, 15)
 
+	gw.responseCacheStats = newResponseCacheStats()
+
 	gw.apisByID = map[string]*APISpec{}
 	gw.apisHandlesByID = new(sync.Map)
 
@@ -417,6 +427,9 @@ func (gw *Gateway) setupGlobals() {
 		}
 	}
 
+	// Resume the deliveries left in the webhook outbox.
+	gw.webhookDeliveries()
+
 	if conf := gw.GetConfig(); conf.AnalyticsConfig.NormaliseUrls.Enabled {
 		mainLog.Info("Setting up analytics normaliser")
 		conf.AnalyticsConfig.NormaliseUrls.CompiledPatternSet = gw.initNormalisationPatterns()
@@ -685,7 +698,20 @@ func (gw *Gateway) loadControlAPIEndpoints(muxer *mux.Router) {
 	}
 
 	r.HandleFunc("/debug", gw.traceHandler).Methods("POST")
+	r.HandleFunc("/routing/evaluate", gw.routingEvaluateHandler).Methods(http.MethodPost)
+	r.HandleFunc("/cache/stats", gw.responseCacheStatsHandler).Methods("GET")
 	r.HandleFunc("/cache/{apiID}", gw.invalidateCacheHandler).Methods("DELETE")
+	r.HandleFunc("/apis/{apiID}/graphql/operations", gw.graphQLOperationsHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/apis/{apiID}/graphql/operations/{hash}", gw.graphQLOperationHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/apis/{apiID}/graphql/schemas", gw.graphQLSchemasHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/apis/{apiID}/graphql/schemas/{version}", gw.graphQLSchemaHandler).Methods(http.MethodGet)
+	r.HandleFunc("/apis/{apiID}/graphql/schemas/{version}/rollback", gw.graphQLSchemaRollbackHandler).Methods(http.MethodPost)
+	r.HandleFunc("/apis/{apiID}/graphql/rest", gw.graphQLRESTHandler).Methods(http.MethodGet)
+	r.HandleFunc("/apis/oas/{apiID}/graphql", gw.apiOASGraphQLHandler).Methods(http.MethodGet)
+	r.HandleFunc("/webhooks/outbox", gw.webhookOutboxHandler).Methods(http.MethodGet)
+	r.HandleFunc("/webhooks/dead-letters", gw.webhookDeadLettersHandler).Methods(http.MethodGet)
+	r.HandleFunc("/webhooks/dead-letters/{id}", gw.webhookDiscardHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/webhooks/dead-letters/{id}/replay", gw.webhookReplayHandler).Methods(http.MethodPost)
 	r.HandleFunc("/keys", gw.keyHandler).Methods("POST", "PUT", "GET", "DELETE")
 	r.HandleFunc("/keys/preview", gw.previewKeyHandler).Methods("POST")
 	r.HandleFunc("/keys/{keyName:[^/]*}", gw.keyHandler).Methods("POST", "PUT", "GET", "DELETE")
@@ -890,7 +916,9 @@ func (gw *Gateway) createResponseMiddlewareChain(spec *APISpec, responseFuncs []
 		responseMWChain []TykResponseHandler
 		baseHandler     = BaseTykResponseHandler{Spec: spec, Gw: gw}
 	)
+	gw.responseMWAppendEnabled(&responseMWChain, &ValidateResponse{BaseTykResponseHandler: baseHandler})
 	gw.responseMWAppendEnabled(&responseMWChain, &ResponseTransformMiddleware{BaseTykResponseHandler: baseHandler})
+	gw.responseMWAppendEnabled(&responseMWChain, &ResponseRedaction{BaseTykResponseHandler: baseHandler})
 
 	headerInjector := &HeaderInjector{BaseTykResponseHandler: baseHandler}
 	headerInjectorAdded := gw.responseMWAppendEnabled(&responseMWChain, headerInjector)
@@ -947,8 +975,10 @@ func (gw *Gateway) createResponseMiddlewareChain(spec *APISpec, responseFuncs []
 	cacheStore := &storage.RedisCluster{KeyPrefix: keyPrefix, IsCache: true, ConnectionHandler: gw.StorageConnectionHandler}
 	cacheStore.Connect()
 
+	entries, _ := gw.responseCacheStore(spec, cacheStore)
+
 	// Add cache writer as the final step of the response middleware chain
-	processor := &ResponseCacheMiddleware{BaseTykResponseHandler: baseHandler, store: cacheStore}
+	processor := &ResponseCacheMiddleware{BaseTykResponseHandler: baseHandler, store: cacheStore, entries: entries}
 	if err := processor.Init(nil, spec); err != nil {
 		mainLog.WithError(err).Debug("Failed to init processor")
 	}
@@ -1328,6 +1358,7 @@ func (gw *Gateway) initSystem() error {
 	config.Global = gw.GetConfig
 	gw.getHostDetails(gw.GetConfig().PIDFileLocation)
 	gw.initRPCCache()
+	gw.localResponseCache = newLocalResponseCache(gw.GetConfig().LocalResponseCache)
 	gw.setupInstrumentation()
 
 	// cleanIdleMemConnProviders checks memconn.Provider (a part of internal API handling)
@@ -1424,6 +1455,11 @@ func (gw *Gateway) afterConfSetup() {
 		conf.HealthCheckEndpointName = "hello"
 	}
 
+	if err := gw.basicAuthHashParams().Validate(); err != nil {
+		log.WithError(err).Error("Invalid basic auth hash options, using the defaults")
+		conf.BasicAuthHashOptions = config.BasicAuthHashOptionsConfig{RehashOnLogin: conf.BasicAuthHashOptions.RehashOnLogin}
+	}
+
 	var err error
 
 	conf.Secret, err = gw.kvStore(conf.Secret)
@@ -393,23 +393,10 @@ func (l *SessionLimiter) RedisQuotaExceeded(r *http.Request, session *user.Sessi
 	// don't use the requests cancellation context
 	ctx := context.Background()
 
-	quotaScope := ""
-	if scope != "" {
-		quotaScope = scope + "-"
-	}
-
-	key := session.KeyID
-	if hashKeys {
-		key = storage.HashStr(session.KeyID)
-	}
-	if quotaKey != "" {
-		key = quotaKey
-	}
-
 	now := time.Now()
 
 	// rawKey is the redis key for quota
-	rawKey := QuotaKeyPrefix + quotaScope + key
+	rawKey := quotaRawKey(session, quotaKey, scope, hashKeys)
 
 	var quotaRenewalRate time.Duration
 	if limit.QuotaRenewalRate > 0 {
@@ -513,6 +500,7 @@ func GetAccessDefinitionByAPIIDOrSession(session *user.SessionState, api *APISpe
 			accessDef.RestrictedTypes = rights.RestrictedTypes
 			accessDef.AllowedTypes = rights.AllowedTypes
 			accessDef.DisableIntrospection = rights.DisableIntrospection
+			accessDef.EnforceOperationSafelist = rights.EnforceOperationSafelist
 			accessDef.Endpoints = rights.Endpoints
 			allowanceScope = rights.AllowanceScope
 		}
@@ -524,6 +512,72 @@ func GetAccessDefinitionByAPIIDOrSession(session *user.SessionState, api *APISpe
 	return accessDef, allowanceScope, nil
 }
 
+// RedisQuotaCharge counts extra units of a request against the quota, once the request itself has been counted by
+// RedisQuotaExceeded. It returns true if the request should be blocked as over quota.
+func (l *SessionLimiter) RedisQuotaCharge(session *user.SessionState, quotaKey, scope string, limit *user.APILimit, hashKeys bool, units int64) bool {
+	if limit.QuotaMax <= 0 || units <= 0 {
+		return false
+	}
+
+	// don't use the requests cancellation context
+	ctx := context.Background()
+
+	rawKey := quotaRawKey(session, quotaKey, scope, hashKeys)
+	logger := log.WithFields(logrus.Fields{
+		"quotaMax": limit.QuotaMax,
+		"rawKey":   rawKey,
+		"units":    units,
+	})
+
+	var (
+		res *redis.IntCmd
+		ttl *redis.DurationCmd
+	)
+	_, err := l.limiterStorage.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		res = pipe.IncrBy(ctx, rawKey, units)
+		ttl = pipe.PTTL(ctx, rawKey)
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("error charging quota key")
+		return true
+	}
+
+	quota := res.Val()
+	blocked := quota > limit.QuotaMax
+	remaining := limit.QuotaMax - quota
+	if blocked {
+		remaining = 0
+	}
+
+	logger.WithFields(logrus.Fields{
+		"quota":     quota,
+		"blocked":   blocked,
+		"remaining": remaining,
+	}).Debug("[QUOTA] Charge quota key")
+
+	l.updateSessionQuota(session, scope, remaining, time.Now().Add(ttl.Val()).Unix())
+	return blocked
+}
+
+// quotaRawKey returns the redis key for the quota of the session.
+func quotaRawKey(session *user.SessionState, quotaKey, scope string, hashKeys bool) string {
+	quotaScope := ""
+	if scope != "" {
+		quotaScope = scope + "-"
+	}
+
+	key := session.KeyID
+	if hashKeys {
+		key = storage.HashStr(session.KeyID)
+	}
+	if quotaKey != "" {
+		key = quotaKey
+	}
+
+	return QuotaKeyPrefix + quotaScope + key
+}
+
 // updateSessionQuota updates session attached access rights.
 //
 // When limits are defined, QuotaRemaining and QuotaRenews is updated for a matching
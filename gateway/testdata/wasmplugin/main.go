@@ -0,0 +1,85 @@
+// Command wasmplugin is a plugin of the wasm driver used by the tests, built with GOOS=wasip1 GOARCH=wasm.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type object map[string]interface{}
+
+func (o object) child(key string) object {
+	if child, ok := o[key].(map[string]interface{}); ok {
+		return child
+	}
+	child := object{}
+	o[key] = map[string]interface{}(child)
+	return child
+}
+
+func main() {
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	obj := object{}
+	if err := json.Unmarshal(input, &obj); err != nil {
+		os.Exit(1)
+	}
+
+	request := obj.child("request")
+
+	switch os.Args[1] {
+	case "AddHeader":
+		request.child("set_headers")["X-Wasm-Plugin"] = "pre"
+	case "Reject":
+		request["return_overrides"] = map[string]interface{}{
+			"response_code":  403,
+			"response_error": "rejected by wasm",
+		}
+	case "Auth":
+		headers := request.child("headers")
+		if headers["Authorization"] != "wasm-token" {
+			request["return_overrides"] = map[string]interface{}{
+				"response_code":  401,
+				"response_error": "invalid token",
+			}
+			break
+		}
+		obj.child("metadata")["token"] = "wasm-token"
+		obj["session"] = map[string]interface{}{
+			"rate":     100,
+			"per":      1,
+			"expires":  time.Now().Add(time.Hour).Unix(),
+			"metadata": map[string]string{"plugin": "wasm"},
+		}
+	case "PostAuth":
+		session := obj.child("session")
+		metadata := session.child("metadata")
+		request.child("set_headers")["X-Wasm-Session"] = fmt.Sprint(metadata["plugin"])
+	case "Response":
+		response := obj.child("response")
+		response.child("headers")["X-Wasm-Response"] = "modified"
+		response["raw_body"] = []byte("from wasm")
+	case "Loop":
+		for {
+		}
+	case "Alloc":
+		blocks := [][]byte{}
+		for i := 0; i < 1024; i++ {
+			blocks = append(blocks, make([]byte, 1<<20))
+		}
+		fmt.Fprintln(os.Stderr, len(blocks))
+	case "Exit":
+		fmt.Fprintln(os.Stderr, "failing")
+		os.Exit(3)
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(obj); err != nil {
+		os.Exit(1)
+	}
+}
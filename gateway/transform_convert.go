@@ -0,0 +1,730 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+)
+
+const (
+	soapEnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+
+	// xmlAttrPrefix prefixes the keys of the attributes of the XML elements converted to maps.
+	xmlAttrPrefix = "-"
+	// xmlTextKey is the key of the text of the XML elements converted to maps.
+	xmlTextKey = "#text"
+	// xmlDefaultRoot is the root element of the XML converted from values having no single root key.
+	xmlDefaultRoot = "doc"
+	// xmlArrayItem is the element of the items of the arrays converted to XML without a key.
+	xmlArrayItem = "item"
+	// xmlMaxDepth is the maximum nesting depth of the XML elements decoded.
+	xmlMaxDepth = 256
+
+	// formMaxMemory is the maximum memory used to parse multipart forms, the rest is stored on disk.
+	formMaxMemory = 32 << 20
+)
+
+var (
+	errUnsupportedBodyFormat = errors.New("unsupported body format")
+	errXMLTooDeep            = fmt.Errorf("XML elements are nested deeper than %d levels", xmlMaxDepth)
+)
+
+// isBodyConversion reports whether the transform converts the body between formats, without template.
+func isBodyConversion(data apidef.TemplateData) bool {
+	return data.TemplateSource == "" && data.Output != ""
+}
+
+// hasXMLOptions reports whether XML conversion options are set.
+func hasXMLOptions(options apidef.XMLOptions) bool {
+	return options.RootElement != "" || len(options.ArrayPaths) > 0 || len(options.Namespaces) > 0 ||
+		options.KeepNamespaces || options.SOAPEnvelope
+}
+
+// convertBody converts the body from the input to the output format of the transform,
+// it returns the converted body and its content type.
+func convertBody(body []byte, contentType string, data apidef.TemplateData) ([]byte, string, error) {
+	value, err := decodeBody(body, contentType, data.Input, data.XML)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return encodeBody(value, data.Output, data.XML)
+}
+
+// decodeBody decodes the body in the given format.
+// JSON bodies are decoded to any JSON value, other formats are decoded to maps.
+func decodeBody(body []byte, contentType string, format apidef.RequestInputType, options apidef.XMLOptions) (interface{}, error) {
+	switch format {
+	case apidef.RequestJSON:
+		if len(body) == 0 {
+			return map[string]interface{}{}, nil
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+		}
+		return value, nil
+	case apidef.RequestXML:
+		value, err := decodeXML(body, options)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling XML: %w", err)
+		}
+		return value, nil
+	case apidef.RequestForm, apidef.RequestMultipart:
+		value, err := decodeForm(body, contentType)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing form: %w", err)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnsupportedBodyFormat, format)
+	}
+}
+
+// encodeBody encodes the value in the given format, it returns the encoded body and its content type.
+func encodeBody(value interface{}, format apidef.RequestInputType, options apidef.XMLOptions) ([]byte, string, error) {
+	switch format {
+	case apidef.RequestJSON:
+		body, err := json.Marshal(value)
+		return body, header.ApplicationJSON, err
+	case apidef.RequestXML:
+		body, err := encodeXML(value, options)
+		if options.SOAPEnvelope {
+			return body, header.TextXML, err
+		}
+		return body, header.ApplicationXML, err
+	case apidef.RequestForm:
+		return []byte(encodeForm(value).Encode()), header.FormURLEncoded, nil
+	case apidef.RequestMultipart:
+		return encodeMultipart(value)
+	default:
+		return nil, "", fmt.Errorf("%w: %q", errUnsupportedBodyFormat, format)
+	}
+}
+
+// decodeXML decodes the XML document to a map keyed by the name of its root element.
+//
+// Attributes are keys prefixed with `-`, the text of the elements having attributes or children is the `#text` key,
+// and repeated elements are arrays. The namespace prefixes are removed, unless options.KeepNamespaces is set.
+func decodeXML(body []byte, options apidef.XMLOptions) (map[string]interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	d := &xmlDecoder{
+		dec:     xml.NewDecoder(bytes.NewReader(body)),
+		options: options,
+	}
+	d.dec.CharsetReader = WrappedCharsetReader
+
+	prefixes := make(map[string]string, len(options.Namespaces))
+	for prefix, uri := range options.Namespaces {
+		prefixes[uri] = prefix
+	}
+	d.prefixes = prefixes
+
+	result := map[string]interface{}{}
+	for {
+		tok, err := d.dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			name, value, err := d.element(start)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = value
+			break
+		}
+	}
+
+	if options.SOAPEnvelope {
+		result = unwrapSOAPEnvelope(result)
+	}
+
+	for _, path := range options.ArrayPaths {
+		forceArray(result, strings.Split(path, "."))
+	}
+
+	return result, nil
+}
+
+type xmlDecoder struct {
+	dec     *xml.Decoder
+	options apidef.XMLOptions
+	// prefixes are the configured namespace prefixes, by URI.
+	prefixes map[string]string
+	// scopes are the namespaces declared by the open elements, by prefix.
+	scopes []map[string]string
+}
+
+// element decodes the element started by start, it returns the name and value of the element.
+// It returns errXMLTooDeep for elements nested deeper than xmlMaxDepth.
+func (d *xmlDecoder) element(start xml.StartElement) (string, interface{}, error) {
+	if len(d.scopes) >= xmlMaxDepth {
+		return "", nil, errXMLTooDeep
+	}
+
+	scope := map[string]string{}
+	for _, attr := range start.Attr {
+		switch {
+		case attr.Name.Space == "xmlns":
+			scope[attr.Name.Local] = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			scope[""] = attr.Value
+		}
+	}
+	d.scopes = append(d.scopes, scope)
+	defer func() {
+		d.scopes = d.scopes[:len(d.scopes)-1]
+	}()
+
+	fields := map[string]interface{}{}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			continue
+		}
+
+		fields[xmlAttrPrefix+d.name(attr.Name, false)] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		tok, err := d.dec.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			name, value, err := d.element(tok)
+			if err != nil {
+				return "", nil, err
+			}
+
+			switch existing := fields[name].(type) {
+			case nil:
+				fields[name] = value
+			case []interface{}:
+				fields[name] = append(existing, value)
+			default:
+				fields[name] = []interface{}{existing, value}
+			}
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(fields) == 0 {
+				return d.name(start.Name, true), content, nil
+			}
+
+			if content != "" {
+				fields[xmlTextKey] = content
+			}
+			return d.name(start.Name, true), fields, nil
+		}
+	}
+}
+
+// name returns the name of the element or attribute, with its namespace prefix if namespaces are kept.
+// The configured prefix of the namespace is preferred over the prefix used by the document.
+func (d *xmlDecoder) name(name xml.Name, element bool) string {
+	if !d.options.KeepNamespaces {
+		return name.Local
+	}
+
+	// unprefixed attributes have no namespace
+	if name.Space == "" && !element {
+		return name.Local
+	}
+
+	if uri := d.namespace(name.Space); uri != "" {
+		if prefix, ok := d.prefixes[uri]; ok {
+			if prefix == "" {
+				return name.Local
+			}
+			return prefix + ":" + name.Local
+		}
+	}
+
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// namespace returns the URI of the namespace declared for the prefix.
+func (d *xmlDecoder) namespace(prefix string) string {
+	for i := len(d.scopes) - 1; i >= 0; i-- {
+		if uri, ok := d.scopes[i][prefix]; ok {
+			return uri
+		}
+	}
+
+	return ""
+}
+
+// unwrapSOAPEnvelope returns the content of the body of the SOAP envelope, or the document if it isn't an envelope.
+func unwrapSOAPEnvelope(doc map[string]interface{}) map[string]interface{} {
+	envelope, ok := childByLocalName(doc, "Envelope").(map[string]interface{})
+	if !ok {
+		return doc
+	}
+
+	switch body := childByLocalName(envelope, "Body").(type) {
+	case map[string]interface{}:
+		return body
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func childByLocalName(fields map[string]interface{}, local string) interface{} {
+	for name, value := range fields {
+		if name == local || strings.HasSuffix(name, ":"+local) {
+			return value
+		}
+	}
+
+	return nil
+}
+
+// forceArray converts the value at the path to an array, if it's not one already.
+func forceArray(value interface{}, path []string) {
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			forceArray(item, path)
+		}
+	case map[string]interface{}:
+		child, ok := value[path[0]]
+		if !ok {
+			return
+		}
+
+		if len(path) > 1 {
+			forceArray(child, path[1:])
+			return
+		}
+
+		if _, ok := child.([]interface{}); !ok {
+			value[path[0]] = []interface{}{child}
+		}
+	}
+}
+
+// encodeXML encodes the value to an XML document, see decodeXML for the mapping of values to XML.
+//
+// The root element is options.RootElement, the single key of the value, or `doc`.
+func encodeXML(value interface{}, options apidef.XMLOptions) ([]byte, error) {
+	root := options.RootElement
+	if fields, ok := value.(map[string]interface{}); ok && len(fields) == 1 {
+		for name, child := range fields {
+			if root == "" || root == name {
+				root, value = name, child
+			}
+		}
+	}
+	if root == "" {
+		root = xmlDefaultRoot
+	}
+
+	if items, ok := value.([]interface{}); ok {
+		value = map[string]interface{}{xmlArrayItem: items}
+	}
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	envelope := xml.StartElement{
+		Name: xml.Name{Local: "soap:Envelope"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns:soap"}, Value: soapEnvelopeNamespace}},
+	}
+	body := xml.StartElement{Name: xml.Name{Local: "soap:Body"}}
+
+	if options.SOAPEnvelope {
+		if err := enc.EncodeToken(envelope); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeToken(body); err != nil {
+			return nil, err
+		}
+	}
+
+	prefixes := make([]string, 0, len(options.Namespaces))
+	for prefix := range options.Namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	declarations := make([]xml.Attr, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		name := "xmlns"
+		if prefix != "" {
+			name += ":" + prefix
+		}
+		declarations = append(declarations, xml.Attr{Name: xml.Name{Local: name}, Value: options.Namespaces[prefix]})
+	}
+
+	if err := encodeXMLElement(enc, root, value, declarations); err != nil {
+		return nil, err
+	}
+
+	if options.SOAPEnvelope {
+		if err := enc.EncodeToken(body.End()); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeToken(envelope.End()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeXMLElement(enc *xml.Encoder, name string, value interface{}, attrs []xml.Attr) error {
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if err := encodeXMLElement(enc, name, item, attrs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}, Attr: attrs}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if text := xmlText(value); text != "" {
+			if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var children []string
+	for _, key := range keys {
+		switch {
+		case key == xmlTextKey:
+		case strings.HasPrefix(key, xmlAttrPrefix):
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: xmlName(strings.TrimPrefix(key, xmlAttrPrefix))},
+				Value: xmlText(fields[key]),
+			})
+		default:
+			children = append(children, key)
+		}
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if text := xmlText(fields[xmlTextKey]); text != "" {
+		if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range children {
+		if err := encodeXMLElement(enc, key, fields[key], nil); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// xmlName returns a valid XML name, invalid characters are replaced by underscores.
+func xmlName(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	var b strings.Builder
+	for i, r := range name {
+		valid := unicode.IsLetter(r) || r == '_' || r == ':'
+		if i > 0 {
+			valid = valid || unicode.IsDigit(r) || r == '-' || r == '.'
+		}
+
+		switch {
+		case valid:
+			b.WriteRune(r)
+		case i == 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	return b.String()
+}
+
+// xmlText returns the text representation of a scalar value.
+func xmlText(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// decodeForm decodes an URL encoded or multipart form, depending on the content type.
+// Fields with a single value are strings, and fields with multiple values are arrays.
+// Files are maps with the `filename`, `content_type` and base64 encoded `content` keys.
+func decodeForm(body []byte, contentType string) (map[string]interface{}, error) {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		return formValues(values, nil), nil
+	}
+
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, errors.New("multipart boundary not set")
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), boundary).ReadForm(formMaxMemory)
+	if err != nil {
+		return nil, err
+	}
+	defer form.RemoveAll()
+
+	files := make(map[string][]interface{}, len(form.File))
+	for name, headers := range form.File {
+		for _, fh := range headers {
+			file, err := fh.Open()
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			files[name] = append(files[name], map[string]interface{}{
+				"filename":     fh.Filename,
+				"content_type": fh.Header.Get(header.ContentType),
+				"content":      base64.StdEncoding.EncodeToString(content),
+			})
+		}
+	}
+
+	return formValues(form.Value, files), nil
+}
+
+func formValues(values map[string][]string, files map[string][]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values)+len(files))
+	for name, vals := range values {
+		items := make([]interface{}, 0, len(vals))
+		for _, v := range vals {
+			items = append(items, v)
+		}
+		fields[name] = items
+	}
+
+	for name, items := range files {
+		if existing, ok := fields[name].([]interface{}); ok {
+			items = append(existing, items...)
+		}
+		fields[name] = items
+	}
+
+	for name, items := range fields {
+		if items := items.([]interface{}); len(items) == 1 {
+			fields[name] = items[0]
+		}
+	}
+
+	return fields
+}
+
+// encodeForm flattens the value to form values, nested keys are joined with dots and arrays are repeated values.
+func encodeForm(value interface{}) url.Values {
+	values := url.Values{}
+	flattenForm(values, "", value)
+	return values
+}
+
+func flattenForm(values url.Values, key string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, child := range value {
+			if key != "" {
+				name = key + "." + name
+			}
+			flattenForm(values, name, child)
+		}
+	case []interface{}:
+		if key == "" {
+			key = xmlArrayItem
+		}
+		for _, item := range value {
+			flattenForm(values, key, item)
+		}
+	default:
+		if key == "" {
+			key = xmlArrayItem
+		}
+		values.Add(key, xmlText(value))
+	}
+}
+
+// encodeMultipart encodes the value to a multipart form, file maps as produced by decodeForm are encoded as file parts.
+func encodeMultipart(value interface{}) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	var files []string
+	fileParts := map[string][]map[string]interface{}{}
+	plain := map[string]interface{}{}
+
+	if fields, ok := value.(map[string]interface{}); ok {
+		for name, child := range fields {
+			var items []interface{}
+			if list, ok := child.([]interface{}); ok {
+				items = list
+			} else {
+				items = []interface{}{child}
+			}
+
+			for _, item := range items {
+				if file, ok := item.(map[string]interface{}); ok && isFormFile(file) {
+					if _, ok := fileParts[name]; !ok {
+						files = append(files, name)
+					}
+					fileParts[name] = append(fileParts[name], file)
+					continue
+				}
+
+				if existing, ok := plain[name]; ok {
+					if list, ok := existing.([]interface{}); ok {
+						plain[name] = append(list, item)
+					} else {
+						plain[name] = []interface{}{existing, item}
+					}
+				} else {
+					plain[name] = item
+				}
+			}
+		}
+	} else {
+		plain[xmlArrayItem] = value
+	}
+
+	values := encodeForm(plain)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, v := range values[key] {
+			if err := w.WriteField(key, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	sort.Strings(files)
+	for _, name := range files {
+		for _, file := range fileParts[name] {
+			if err := writeFormFile(w, name, file); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+func isFormFile(fields map[string]interface{}) bool {
+	_, hasName := fields["filename"].(string)
+	_, hasContent := fields["content"].(string)
+	return hasName && hasContent
+}
+
+func writeFormFile(w *multipart.Writer, name string, file map[string]interface{}) error {
+	contentType, _ := file["content_type"].(string)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     name,
+		"filename": file["filename"].(string),
+	}))
+	h.Set(header.ContentType, contentType)
+
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	content := []byte(file["content"].(string))
+	if decoded, err := base64.StdEncoding.DecodeString(string(content)); err == nil {
+		content = decoded
+	}
+
+	_, err = part.Write(content)
+	return err
+}
@@ -0,0 +1,222 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/test"
+)
+
+const testSOAPResponse = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <m:GetUsersResponse xmlns:m="http://example.com/users">
+      <m:User id="1"><m:Name>Alice</m:Name></m:User>
+    </m:GetUsersResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestDecodeXML(t *testing.T) {
+	t.Run("attributes, text and repeated elements", func(t *testing.T) {
+		value, err := decodeXML([]byte(`<users count="2"><user>a</user><user>b</user><note lang="en">hi</note><empty/></users>`), apidef.XMLOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"users": map[string]interface{}{
+				"-count": "2",
+				"user":   []interface{}{"a", "b"},
+				"note":   map[string]interface{}{"-lang": "en", "#text": "hi"},
+				"empty":  "",
+			},
+		}, value)
+	})
+
+	t.Run("SOAP envelope, namespaces and array paths", func(t *testing.T) {
+		value, err := decodeXML([]byte(testSOAPResponse), apidef.XMLOptions{
+			SOAPEnvelope: true,
+			ArrayPaths:   []string{"GetUsersResponse.User"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"GetUsersResponse": map[string]interface{}{
+				"User": []interface{}{map[string]interface{}{"-id": "1", "Name": "Alice"}},
+			},
+		}, value)
+
+		value, err = decodeXML([]byte(testSOAPResponse), apidef.XMLOptions{
+			SOAPEnvelope:   true,
+			KeepNamespaces: true,
+			Namespaces:     map[string]string{"u": "http://example.com/users"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"u:GetUsersResponse": map[string]interface{}{
+				"u:User": map[string]interface{}{"-id": "1", "u:Name": "Alice"},
+			},
+		}, value)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := decodeXML([]byte(`<users><user>`), apidef.XMLOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("nesting depth", func(t *testing.T) {
+		nested := func(depth int) []byte {
+			return []byte(strings.Repeat("<a>", depth) + "x" + strings.Repeat("</a>", depth))
+		}
+
+		_, err := decodeXML(nested(xmlMaxDepth), apidef.XMLOptions{})
+		assert.NoError(t, err)
+
+		_, err = decodeXML(nested(xmlMaxDepth+1), apidef.XMLOptions{})
+		assert.ErrorIs(t, err, errXMLTooDeep)
+
+		// the decoder stops at the limit, without reading the rest of the document
+		_, err = decodeXML([]byte(strings.Repeat("<a>", 1<<20)), apidef.XMLOptions{})
+		assert.ErrorIs(t, err, errXMLTooDeep)
+	})
+}
+
+func TestEncodeXML(t *testing.T) {
+	body, err := encodeXML(map[string]interface{}{
+		"user": map[string]interface{}{"-id": 1.0, "name": "Alice", "tags": []interface{}{"a", "b"}, "1st": true},
+	}, apidef.XMLOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `<user id="1"><_1st>true</_1st><name>Alice</name><tags>a</tags><tags>b</tags></user>`, string(body))
+
+	body, err = encodeXML([]interface{}{"a", "b"}, apidef.XMLOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `<doc><item>a</item><item>b</item></doc>`, string(body))
+
+	body, err = encodeXML(map[string]interface{}{"name": "Alice"}, apidef.XMLOptions{
+		RootElement:  "m:GetUser",
+		Namespaces:   map[string]string{"m": "http://example.com/users"},
+		SOAPEnvelope: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`+
+		`<m:GetUser xmlns:m="http://example.com/users"><name>Alice</name></m:GetUser></soap:Body></soap:Envelope>`, string(body))
+}
+
+func TestConvertForm(t *testing.T) {
+	t.Run("urlencoded", func(t *testing.T) {
+		body, contentType, err := convertBody([]byte("name=Alice&tag=a&tag=b"), header.FormURLEncoded, apidef.TemplateData{
+			Input:  apidef.RequestForm,
+			Output: apidef.RequestJSON,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, header.ApplicationJSON, contentType)
+		assert.JSONEq(t, `{"name":"Alice","tag":["a","b"]}`, string(body))
+
+		body, contentType, err = convertBody([]byte(`{"user":{"name":"Alice"},"tag":["a","b"]}`), "", apidef.TemplateData{
+			Input:  apidef.RequestJSON,
+			Output: apidef.RequestForm,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, header.FormURLEncoded, contentType)
+		assert.Equal(t, "tag=a&tag=b&user.name=Alice", string(body))
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		body, contentType, err := convertBody([]byte(`{"name":"Alice","avatar":{"filename":"a.txt","content_type":"text/plain","content":"aGk="}}`), "",
+			apidef.TemplateData{Input: apidef.RequestJSON, Output: apidef.RequestMultipart})
+		require.NoError(t, err)
+
+		form, err := decodeForm(body, contentType)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"name":   "Alice",
+			"avatar": map[string]interface{}{"filename": "a.txt", "content_type": "text/plain", "content": "aGk="},
+		}, form)
+	})
+}
+
+func TestTransformBodyConversion(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/users":
+			w.Header().Set(header.ContentType, header.TextXML)
+			_, _ = w.Write([]byte(testSOAPResponse))
+		default:
+			w.Header().Set("X-Content-Type", r.Header.Get(header.ContentType))
+			_, _ = w.Write(body)
+		}
+	}))
+	defer upstream.Close()
+
+	ts := StartTest(nil)
+	defer ts.Close()
+
+	ts.Gw.BuildAndLoadAPI(func(spec *APISpec) {
+		spec.Proxy.ListenPath = "/"
+		spec.Proxy.TargetURL = upstream.URL
+		UpdateAPIVersion(spec, "v1", func(v *apidef.VersionInfo) {
+			v.ExtendedPaths.Transform = []apidef.TemplateMeta{{
+				Path:   "/soap",
+				Method: http.MethodPost,
+				TemplateData: apidef.TemplateData{
+					Input:  apidef.RequestJSON,
+					Output: apidef.RequestXML,
+					XML:    apidef.XMLOptions{RootElement: "GetUser", SOAPEnvelope: true},
+				},
+			}, {
+				Path:   "/form",
+				Method: http.MethodPost,
+				TemplateData: apidef.TemplateData{
+					Input:  apidef.RequestMultipart,
+					Output: apidef.RequestJSON,
+				},
+			}, {
+				Path:   "/template",
+				Method: http.MethodPost,
+				TemplateData: apidef.TemplateData{
+					Input:          apidef.RequestForm,
+					Mode:           apidef.UseBlob,
+					TemplateSource: base64.StdEncoding.EncodeToString([]byte(`{"name":"{{.name}}"}`)),
+				},
+			}}
+			v.ExtendedPaths.TransformResponse = []apidef.TemplateMeta{{
+				Path:   "/users",
+				Method: http.MethodGet,
+				TemplateData: apidef.TemplateData{
+					Input:  apidef.RequestXML,
+					Output: apidef.RequestJSON,
+					XML:    apidef.XMLOptions{SOAPEnvelope: true, ArrayPaths: []string{"GetUsersResponse.User"}},
+				},
+			}}
+		})
+	})
+
+	var multipartBody bytes.Buffer
+	w := multipart.NewWriter(&multipartBody)
+	require.NoError(t, w.WriteField("name", "Alice"))
+	require.NoError(t, w.Close())
+
+	_, _ = ts.Run(t, []test.TestCase{
+		{Method: http.MethodPost, Path: "/soap", Data: `{"name":"Alice"}`, Code: http.StatusOK,
+			BodyMatch:    `^<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><GetUser><name>Alice</name></GetUser></soap:Body></soap:Envelope>$`,
+			HeadersMatch: map[string]string{"X-Content-Type": header.TextXML}},
+		{Method: http.MethodPost, Path: "/soap", Code: http.StatusOK, BodyMatch: `^$`},
+		{Method: http.MethodPost, Path: "/form", Data: multipartBody.String(),
+			Headers: map[string]string{header.ContentType: w.FormDataContentType()}, Code: http.StatusOK,
+			BodyMatch: `^{"name":"Alice"}$`, HeadersMatch: map[string]string{"X-Content-Type": header.ApplicationJSON}},
+		{Method: http.MethodPost, Path: "/template", Data: "name=Alice",
+			Headers: map[string]string{header.ContentType: header.FormURLEncoded}, Code: http.StatusOK,
+			BodyMatch: `^{"name":"Alice"}$`},
+		{Method: http.MethodGet, Path: "/users", Code: http.StatusOK,
+			BodyMatch:    `^{"GetUsersResponse":{"User":\[{"-id":"1","Name":"Alice"}\]}}$`,
+			HeadersMatch: map[string]string{header.ContentType: header.ApplicationJSON}},
+	}...)
+}
@@ -14,6 +14,18 @@ const (
 	Expires                 = "Expires"
 	Connection              = "Connection"
 	WWWAuthenticate         = "WWW-Authenticate"
+	Warning                 = "Warning"
+	SurrogateKey            = "Surrogate-Key"
+	CacheTag                = "Cache-Tag"
+	ETag                    = "ETag"
+	LastModified            = "Last-Modified"
+	IfNoneMatch             = "If-None-Match"
+	IfModifiedSince         = "If-Modified-Since"
+	Vary                    = "Vary"
+	Age                     = "Age"
+	Date                    = "Date"
+	TransferEncoding        = "Transfer-Encoding"
+	Allow                   = "Allow"
 )
 
 const (
@@ -21,6 +33,7 @@ const (
 	ApplicationJSON = "application/json"
 	ApplicationXML  = "application/xml"
 	TextXML         = "text/xml"
+	FormURLEncoded  = "application/x-www-form-urlencoded"
 )
 
 const (
@@ -39,6 +52,8 @@ const (
 	XTykHostname        = "x-tyk-hostname"
 	XGenerator          = "X-Generator"
 	XTykAuthorization   = "X-Tyk-Authorization"
+	XTykSignature       = "X-Tyk-Signature"
+	XTykDeliveryID      = "X-Tyk-Delivery-Id"
 )
 
 // upgrade and websocket
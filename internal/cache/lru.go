@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRU is a least recently used cache bounded by the total size of its items.
+// Items expire individually. It is safe for concurrent use.
+type LRU struct {
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	ll      *list.List
+	items   map[string]*list.Element
+
+	// OnEvict is called with the items removed from the cache, whether deleted, replaced, expired
+	// or evicted. It's called with the cache locked and must not use the cache.
+	OnEvict func(key string, value interface{})
+
+	now func() time.Time
+}
+
+type lruItem struct {
+	key     string
+	value   interface{}
+	size    int64
+	expires time.Time
+}
+
+// NewLRU creates a new LRU cache holding items up to maxSize in total.
+func NewLRU(maxSize int64) *LRU {
+	return &LRU{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   map[string]*list.Element{},
+		now:     time.Now,
+	}
+}
+
+// Get retrieves an item by key, marking it as recently used.
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := el.Value.(*lruItem)
+	if !item.expires.After(c.now()) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	return item.value, true
+}
+
+// Set writes an item of the given size with a ttl, evicting the least recently used
+// items to stay within the size bound. Items larger than the bound are not stored.
+func (c *LRU) Set(key string, value interface{}, size int64, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	if size > c.maxSize || ttl <= 0 {
+		return
+	}
+
+	for c.size+size > c.maxSize {
+		c.remove(c.ll.Back())
+	}
+
+	c.items[key] = c.ll.PushFront(&lruItem{
+		key:     key,
+		value:   value,
+		size:    size,
+		expires: c.now().Add(ttl),
+	})
+	c.size += size
+}
+
+// Delete removes an item by key.
+func (c *LRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeletePrefix removes all items with keys starting with prefix and returns their count.
+func (c *LRU) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var deleted int
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// Len returns the number of items in the cache, including expired items not yet evicted.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// Size returns the total size of the items in the cache.
+func (c *LRU) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// Flush removes all items.
+func (c *LRU) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.OnEvict != nil {
+		for el := c.ll.Front(); el != nil; el = el.Next() {
+			item := el.Value.(*lruItem)
+			c.OnEvict(item.key, item.value)
+		}
+	}
+
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+	c.size = 0
+}
+
+func (c *LRU) remove(el *list.Element) {
+	item := c.ll.Remove(el).(*lruItem)
+	delete(c.items, item.key)
+	c.size -= item.size
+
+	if c.OnEvict != nil {
+		c.OnEvict(item.key, item.value)
+	}
+}
@@ -0,0 +1,107 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TykTechnologies/tyk/internal/cache"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	lru := cache.NewLRU(10)
+
+	lru.Set("a", "a", 4, time.Minute)
+	lru.Set("b", "b", 4, time.Minute)
+	assert.Equal(t, 2, lru.Len())
+	assert.Equal(t, int64(8), lru.Size())
+
+	// a becomes the most recently used item, b is evicted
+	_, ok := lru.Get("a")
+	assert.True(t, ok)
+
+	lru.Set("c", "c", 4, time.Minute)
+	_, ok = lru.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, int64(8), lru.Size())
+
+	val, ok := lru.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, "c", val)
+
+	// replacing an item updates the size
+	lru.Set("c", "cc", 6, time.Minute)
+	assert.Equal(t, int64(10), lru.Size())
+
+	// items larger than the bound are not stored
+	lru.Set("d", "d", 11, time.Minute)
+	_, ok = lru.Get("d")
+	assert.False(t, ok)
+
+	lru.Delete("c")
+	assert.Equal(t, int64(4), lru.Size())
+
+	lru.Flush()
+	assert.Equal(t, 0, lru.Len())
+	assert.Equal(t, int64(0), lru.Size())
+}
+
+func TestLRU_Expiry(t *testing.T) {
+	t.Parallel()
+
+	lru := cache.NewLRU(10)
+
+	lru.Set("a", "a", 1, 10*time.Millisecond)
+	lru.Set("b", "b", 1, 0)
+
+	_, ok := lru.Get("b")
+	assert.False(t, ok, "items without ttl should not be stored")
+
+	_, ok = lru.Get("a")
+	assert.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok = lru.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), lru.Size())
+}
+
+func TestLRU_DeletePrefix(t *testing.T) {
+	t.Parallel()
+
+	lru := cache.NewLRU(100)
+
+	lru.Set("api1|a", "a", 1, time.Minute)
+	lru.Set("api1|b", "b", 1, time.Minute)
+	lru.Set("api10|a", "a", 1, time.Minute)
+
+	assert.Equal(t, 2, lru.DeletePrefix("api1|"))
+	assert.Equal(t, 1, lru.Len())
+
+	_, ok := lru.Get("api10|a")
+	assert.True(t, ok)
+}
+
+func TestLRU_OnEvict(t *testing.T) {
+	t.Parallel()
+
+	var evicted []string
+
+	lru := cache.NewLRU(2)
+	lru.OnEvict = func(key string, _ interface{}) {
+		evicted = append(evicted, key)
+	}
+
+	lru.Set("a", "a", 1, time.Minute)
+	lru.Set("a", "a", 1, time.Minute)
+	lru.Set("b", "b", 1, time.Minute)
+	lru.Set("c", "c", 1, time.Minute)
+	lru.Delete("b")
+	lru.Flush()
+
+	assert.Equal(t, []string{"a", "a", "b", "c"}, evicted)
+}
@@ -0,0 +1,203 @@
+// Package contentpolicy enforces content policies on requests. Bodies are checked while they are read, so the
+// check stops at the first violation without decoding the rest of the body.
+package contentpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/httputil"
+)
+
+var (
+	// ErrInvalidBody is returned when a body can't be parsed according to its content type.
+	ErrInvalidBody = errors.New("request body is malformed")
+	// ErrUnsupportedContentType is returned when the content type of a body is not allowed.
+	ErrUnsupportedContentType = errors.New("request content type is not allowed")
+)
+
+// Violation is returned when a request exceeds a limit of the policy.
+type Violation struct {
+	// Limit names the exceeded limit.
+	Limit string
+	// Max is the value of the exceeded limit.
+	Max int64
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("request exceeds the %s limit of %d", v.Limit, v.Max)
+}
+
+// Limits exceeded by the requests.
+const (
+	LimitHeaders           = "headers"
+	LimitQueryParams       = "query parameters"
+	LimitJSONDepth         = "JSON depth"
+	LimitJSONArray         = "JSON array length"
+	LimitJSONString        = "JSON string length"
+	LimitMultipartParts    = "multipart parts"
+	LimitMultipartPartSize = "multipart part size"
+)
+
+// Policy is the content policy of an endpoint.
+type Policy struct {
+	apidef.ContentPolicyMeta
+}
+
+// New returns the policy of the endpoint configuration.
+func New(meta apidef.ContentPolicyMeta) *Policy {
+	return &Policy{ContentPolicyMeta: meta}
+}
+
+// CheckRequest checks the headers, query parameters and content type of the request. The body is checked by Check.
+func (p *Policy) CheckRequest(r *http.Request, hasBody bool) error {
+	if p.MaxHeaders > 0 && len(r.Header) > p.MaxHeaders {
+		return &Violation{Limit: LimitHeaders, Max: int64(p.MaxHeaders)}
+	}
+
+	if p.MaxQueryParams > 0 {
+		count := 0
+		for _, values := range r.URL.Query() {
+			count += len(values)
+		}
+		if count > p.MaxQueryParams {
+			return &Violation{Limit: LimitQueryParams, Max: int64(p.MaxQueryParams)}
+		}
+	}
+
+	if len(p.AllowedContentTypes) > 0 && hasBody && !httputil.MatchMediaType(r.Header.Get("Content-Type"), p.AllowedContentTypes) {
+		return ErrUnsupportedContentType
+	}
+
+	return nil
+}
+
+// Check reads and checks the body of the content type, it returns at the first violation.
+func (p *Policy) Check(body io.Reader, contentType string) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case isJSON(mediaType) && p.checksJSON():
+		return p.checkJSON(body)
+	case strings.HasPrefix(mediaType, "multipart/") && p.checksMultipart():
+		return p.checkMultipart(body, params["boundary"])
+	default:
+		return nil
+	}
+}
+
+// ChecksBody returns true if the policy limits the bodies of the content type.
+func (p *Policy) ChecksBody(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return (isJSON(mediaType) && p.checksJSON()) || (strings.HasPrefix(mediaType, "multipart/") && p.checksMultipart())
+}
+
+func (p *Policy) checksJSON() bool {
+	return p.MaxJSONDepth > 0 || p.MaxJSONArrayLength > 0 || p.MaxJSONStringLength > 0
+}
+
+func (p *Policy) checksMultipart() bool {
+	return p.MaxMultipartParts > 0 || p.MaxMultipartPartSize > 0
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// checkJSON walks the tokens of the JSON document, tracking the item count of the open arrays.
+func (p *Policy) checkJSON(body io.Reader) error {
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+
+	// arrays holds the item count of each open container, -1 for objects.
+	var arrays []int
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if len(arrays) > 0 {
+				return ErrInvalidBody
+			}
+			return nil
+		}
+		if err != nil {
+			return ErrInvalidBody
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			arrays = arrays[:len(arrays)-1]
+			continue
+		}
+
+		if n := len(arrays); n > 0 && arrays[n-1] >= 0 {
+			arrays[n-1]++
+			if p.MaxJSONArrayLength > 0 && arrays[n-1] > p.MaxJSONArrayLength {
+				return &Violation{Limit: LimitJSONArray, Max: int64(p.MaxJSONArrayLength)}
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			if p.MaxJSONDepth > 0 && len(arrays) >= p.MaxJSONDepth {
+				return &Violation{Limit: LimitJSONDepth, Max: int64(p.MaxJSONDepth)}
+			}
+			if v == '[' {
+				arrays = append(arrays, 0)
+			} else {
+				arrays = append(arrays, -1)
+			}
+		case string:
+			if p.MaxJSONStringLength > 0 && utf8.RuneCountInString(v) > p.MaxJSONStringLength {
+				return &Violation{Limit: LimitJSONString, Max: int64(p.MaxJSONStringLength)}
+			}
+		}
+	}
+}
+
+func (p *Policy) checkMultipart(body io.Reader, boundary string) error {
+	if boundary == "" {
+		return ErrInvalidBody
+	}
+
+	reader := multipart.NewReader(body, boundary)
+	for parts := 1; ; parts++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrInvalidBody
+		}
+
+		if p.MaxMultipartParts > 0 && parts > p.MaxMultipartParts {
+			return &Violation{Limit: LimitMultipartParts, Max: int64(p.MaxMultipartParts)}
+		}
+
+		var src io.Reader = part
+		if p.MaxMultipartPartSize > 0 {
+			src = io.LimitReader(part, p.MaxMultipartPartSize+1)
+		}
+
+		size, err := io.Copy(io.Discard, src)
+		if err != nil {
+			return ErrInvalidBody
+		}
+		if p.MaxMultipartPartSize > 0 && size > p.MaxMultipartPartSize {
+			return &Violation{Limit: LimitMultipartPartSize, Max: p.MaxMultipartPartSize}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package contentpolicy_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/contentpolicy"
+)
+
+func assertViolation(t *testing.T, err error, limit string) {
+	t.Helper()
+
+	var violation *contentpolicy.Violation
+	require.True(t, errors.As(err, &violation), "expected violation, got %v", err)
+	assert.Equal(t, limit, violation.Limit)
+}
+
+func TestPolicy_CheckRequest(t *testing.T) {
+	policy := contentpolicy.New(apidef.ContentPolicyMeta{
+		AllowedContentTypes: []string{"application/json", "image/*"},
+		MaxQueryParams:      2,
+		MaxHeaders:          3,
+	})
+
+	newRequest := func(target string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, target, nil)
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		return r
+	}
+
+	assert.NoError(t, policy.CheckRequest(newRequest("/?a=1&b=2", map[string]string{"Content-Type": "application/json; charset=utf-8"}), true))
+	assert.NoError(t, policy.CheckRequest(newRequest("/", map[string]string{"Content-Type": "image/png"}), true))
+	assert.NoError(t, policy.CheckRequest(newRequest("/", nil), false), "requests without body have no content type")
+	assert.ErrorIs(t, policy.CheckRequest(newRequest("/", map[string]string{"Content-Type": "text/xml"}), true), contentpolicy.ErrUnsupportedContentType)
+	assert.ErrorIs(t, policy.CheckRequest(newRequest("/", nil), true), contentpolicy.ErrUnsupportedContentType)
+	assertViolation(t, policy.CheckRequest(newRequest("/?a=1&a=2&b=3", nil), false), contentpolicy.LimitQueryParams)
+	assertViolation(t, policy.CheckRequest(newRequest("/", map[string]string{"A": "1", "B": "2", "C": "3", "D": "4"}), false), contentpolicy.LimitHeaders)
+}
+
+func TestPolicy_CheckJSON(t *testing.T) {
+	policy := contentpolicy.New(apidef.ContentPolicyMeta{
+		MaxJSONDepth:        3,
+		MaxJSONArrayLength:  3,
+		MaxJSONStringLength: 5,
+	})
+
+	check := func(body string) error {
+		return policy.Check(strings.NewReader(body), "application/vnd.api+json")
+	}
+
+	assert.NoError(t, check(`{"a":{"b":[1,2,"short"]}}`))
+	assert.NoError(t, check(``))
+	assertViolation(t, check(`{"a":{"b":[[1]]}}`), contentpolicy.LimitJSONDepth)
+	assertViolation(t, check(`{"a":[1,[2,3],{"b":4},5]}`), contentpolicy.LimitJSONArray)
+	assertViolation(t, check(`{"a":"longer"}`), contentpolicy.LimitJSONString)
+	assertViolation(t, check(`{"longer":1}`), contentpolicy.LimitJSONString)
+	assert.ErrorIs(t, check(`{"a":`), contentpolicy.ErrInvalidBody)
+
+	t.Run("stops at the first violation", func(t *testing.T) {
+		body := `[[[[` + strings.Repeat(`"x",`, 1<<16)
+		reader := strings.NewReader(body)
+		assertViolation(t, policy.Check(reader, "application/json"), contentpolicy.LimitJSONDepth)
+		assert.Positive(t, reader.Len(), "the rest of the body should not be read")
+	})
+
+	assert.NoError(t, policy.Check(strings.NewReader(`[[[[1]]]]`), "text/plain"), "other content types are not checked")
+}
+
+func TestPolicy_CheckMultipart(t *testing.T) {
+	policy := contentpolicy.New(apidef.ContentPolicyMeta{
+		MaxMultipartParts:    2,
+		MaxMultipartPartSize: 4,
+	})
+
+	newBody := func(parts ...string) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		for i, part := range parts {
+			field, err := writer.CreateFormField(string(rune('a' + i)))
+			require.NoError(t, err)
+			_, err = field.Write([]byte(part))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+		return &buf, writer.FormDataContentType()
+	}
+
+	body, contentType := newBody("1234", "5")
+	assert.NoError(t, policy.Check(body, contentType))
+
+	body, contentType = newBody("1", "2", "3")
+	assertViolation(t, policy.Check(body, contentType), contentpolicy.LimitMultipartParts)
+
+	body, contentType = newBody("12345")
+	assertViolation(t, policy.Check(body, contentType), contentpolicy.LimitMultipartPartSize)
+
+	assert.ErrorIs(t, policy.Check(strings.NewReader("data"), "multipart/form-data"), contentpolicy.ErrInvalidBody)
+}
@@ -0,0 +1,290 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// PasswordHashBCrypt is the identifier of the bcrypt password hash.
+	PasswordHashBCrypt = "bcrypt"
+	// PasswordHashArgon2id is the identifier of the argon2id password hash.
+	PasswordHashArgon2id = "argon2id"
+	// PasswordHashScrypt is the identifier of the scrypt password hash.
+	PasswordHashScrypt = "scrypt"
+)
+
+const (
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+)
+
+var (
+	// ErrPasswordMismatch is returned when a password doesn't match the stored hash.
+	ErrPasswordMismatch = errors.New("password does not match hash")
+	// ErrInvalidPasswordHash is returned when a stored hash can't be decoded.
+	ErrInvalidPasswordHash = errors.New("invalid password hash format")
+)
+
+// PasswordHashParams holds the tunable cost parameters for password hashing.
+// Zero values are replaced with the defaults from DefaultPasswordHashParams.
+type PasswordHashParams struct {
+	// BCryptCost is the bcrypt cost factor.
+	BCryptCost int
+	// Argon2Memory is the argon2id memory in KiB.
+	Argon2Memory uint32
+	// Argon2Iterations is the argon2id number of passes over the memory.
+	Argon2Iterations uint32
+	// Argon2Parallelism is the argon2id degree of parallelism.
+	Argon2Parallelism uint8
+	// ScryptN is the scrypt CPU/memory cost, must be a power of two.
+	ScryptN int
+	// ScryptR is the scrypt block size.
+	ScryptR int
+	// ScryptP is the scrypt parallelization factor.
+	ScryptP int
+}
+
+// DefaultPasswordHashParams returns the default password hashing parameters.
+func DefaultPasswordHashParams() PasswordHashParams {
+	return PasswordHashParams{
+		BCryptCost:        10,
+		Argon2Memory:      64 * 1024,
+		Argon2Iterations:  3,
+		Argon2Parallelism: 2,
+		ScryptN:           1 << 15,
+		ScryptR:           8,
+		ScryptP:           1,
+	}
+}
+
+// WithDefaults returns a copy of p with zero values replaced by defaults.
+func (p PasswordHashParams) WithDefaults() PasswordHashParams {
+	d := DefaultPasswordHashParams()
+	if p.BCryptCost == 0 {
+		p.BCryptCost = d.BCryptCost
+	}
+	if p.Argon2Memory == 0 {
+		p.Argon2Memory = d.Argon2Memory
+	}
+	if p.Argon2Iterations == 0 {
+		p.Argon2Iterations = d.Argon2Iterations
+	}
+	if p.Argon2Parallelism == 0 {
+		p.Argon2Parallelism = d.Argon2Parallelism
+	}
+	if p.ScryptN == 0 {
+		p.ScryptN = d.ScryptN
+	}
+	if p.ScryptR == 0 {
+		p.ScryptR = d.ScryptR
+	}
+	if p.ScryptP == 0 {
+		p.ScryptP = d.ScryptP
+	}
+	return p
+}
+
+// Validate checks that the parameters, with the defaults applied, can be used to hash passwords.
+func (p PasswordHashParams) Validate() error {
+	p = p.WithDefaults()
+
+	if p.BCryptCost < bcrypt.MinCost || p.BCryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if p.Argon2Memory < 8*uint32(p.Argon2Parallelism) {
+		return errors.New("argon2id memory must be at least 8 KiB per thread")
+	}
+	if p.ScryptN <= 1 || p.ScryptN&(p.ScryptN-1) != 0 {
+		return errors.New("scrypt N must be a power of two greater than 1")
+	}
+	if p.ScryptR <= 0 || p.ScryptP <= 0 || uint64(p.ScryptR)*uint64(p.ScryptP) >= 1<<30 {
+		return errors.New("scrypt r and p must be positive and r*p must be less than 2^30")
+	}
+
+	return nil
+}
+
+// HashPassword hashes password with the given algorithm. Argon2id and scrypt
+// hashes are encoded in the PHC string format, e.g.
+// `$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>`.
+func HashPassword(algo, password string, params PasswordHashParams) (string, error) {
+	params = params.WithDefaults()
+
+	switch algo {
+	case PasswordHashBCrypt:
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), params.BCryptCost)
+		return string(hashed), err
+	case PasswordHashArgon2id:
+		salt, err := passwordSalt()
+		if err != nil {
+			return "", err
+		}
+
+		key := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, passwordKeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+			params.Argon2Memory, params.Argon2Iterations, params.Argon2Parallelism,
+			encodePasswordPart(salt), encodePasswordPart(key)), nil
+	case PasswordHashScrypt:
+		salt, err := passwordSalt()
+		if err != nil {
+			return "", err
+		}
+
+		key, err := scrypt.Key([]byte(password), salt, params.ScryptN, params.ScryptR, params.ScryptP, passwordKeyLength)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("$scrypt$n=%d,r=%d,p=%d$%s$%s",
+			params.ScryptN, params.ScryptR, params.ScryptP,
+			encodePasswordPart(salt), encodePasswordPart(key)), nil
+	}
+
+	return "", fmt.Errorf("unsupported password hash algorithm: %q", algo)
+}
+
+// ComparePassword checks password against a hash produced by HashPassword.
+// It returns ErrPasswordMismatch if the password doesn't match.
+func ComparePassword(algo, hash, password string) error {
+	switch algo {
+	case PasswordHashBCrypt:
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrPasswordMismatch
+			}
+			return err
+		}
+		return nil
+	case PasswordHashArgon2id, PasswordHashScrypt:
+		parsed, err := parsePasswordHash(hash)
+		if err != nil {
+			return err
+		}
+		if parsed.algo != algo {
+			return ErrInvalidPasswordHash
+		}
+
+		var key []byte
+		if algo == PasswordHashArgon2id {
+			key = argon2.IDKey([]byte(password), parsed.salt, parsed.params.Argon2Iterations, parsed.params.Argon2Memory, parsed.params.Argon2Parallelism, uint32(len(parsed.key)))
+		} else {
+			key, err = scrypt.Key([]byte(password), parsed.salt, parsed.params.ScryptN, parsed.params.ScryptR, parsed.params.ScryptP, len(parsed.key))
+			if err != nil {
+				return err
+			}
+		}
+
+		if subtle.ConstantTimeCompare(key, parsed.key) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported password hash algorithm: %q", algo)
+}
+
+// PasswordNeedsRehash reports whether a hash stored with storedAlgo should be
+// regenerated to match the wanted algorithm and cost parameters.
+func PasswordNeedsRehash(storedAlgo, hash, wantAlgo string, params PasswordHashParams) bool {
+	if storedAlgo != wantAlgo {
+		return true
+	}
+
+	params = params.WithDefaults()
+
+	switch wantAlgo {
+	case PasswordHashBCrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != params.BCryptCost
+	case PasswordHashArgon2id:
+		parsed, err := parsePasswordHash(hash)
+		return err != nil ||
+			parsed.params.Argon2Memory != params.Argon2Memory ||
+			parsed.params.Argon2Iterations != params.Argon2Iterations ||
+			parsed.params.Argon2Parallelism != params.Argon2Parallelism
+	case PasswordHashScrypt:
+		parsed, err := parsePasswordHash(hash)
+		return err != nil ||
+			parsed.params.ScryptN != params.ScryptN ||
+			parsed.params.ScryptR != params.ScryptR ||
+			parsed.params.ScryptP != params.ScryptP
+	}
+
+	return false
+}
+
+type passwordHash struct {
+	algo   string
+	params PasswordHashParams
+	salt   []byte
+	key    []byte
+}
+
+func parsePasswordHash(hash string) (*passwordHash, error) {
+	parts := strings.Split(hash, "$")
+
+	var (
+		res    = &passwordHash{}
+		err    error
+		params string
+		salt   string
+		key    string
+	)
+
+	switch {
+	case len(parts) == 6 && parts[1] == PasswordHashArgon2id:
+		var version int
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return nil, ErrInvalidPasswordHash
+		}
+
+		res.algo = PasswordHashArgon2id
+		params, salt, key = parts[3], parts[4], parts[5]
+		if _, err := fmt.Sscanf(params, "m=%d,t=%d,p=%d", &res.params.Argon2Memory, &res.params.Argon2Iterations, &res.params.Argon2Parallelism); err != nil {
+			return nil, ErrInvalidPasswordHash
+		}
+	case len(parts) == 5 && parts[1] == PasswordHashScrypt:
+		res.algo = PasswordHashScrypt
+		params, salt, key = parts[2], parts[3], parts[4]
+		if _, err := fmt.Sscanf(params, "n=%d,r=%d,p=%d", &res.params.ScryptN, &res.params.ScryptR, &res.params.ScryptP); err != nil {
+			return nil, ErrInvalidPasswordHash
+		}
+	default:
+		return nil, ErrInvalidPasswordHash
+	}
+
+	if res.salt, err = decodePasswordPart(salt); err != nil {
+		return nil, ErrInvalidPasswordHash
+	}
+
+	if res.key, err = decodePasswordPart(key); err != nil || len(res.key) == 0 {
+		return nil, ErrInvalidPasswordHash
+	}
+
+	return res, nil
+}
+
+func passwordSalt() ([]byte, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func encodePasswordPart(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func decodePasswordPart(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPasswordHashParams() PasswordHashParams {
+	return PasswordHashParams{
+		BCryptCost:        4,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		ScryptN:           1 << 4,
+		ScryptR:           8,
+		ScryptP:           1,
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	params := testPasswordHashParams()
+
+	for _, algo := range []string{PasswordHashBCrypt, PasswordHashArgon2id, PasswordHashScrypt} {
+		t.Run(algo, func(t *testing.T) {
+			hash, err := HashPassword(algo, "secret", params)
+			assert.NoError(t, err)
+			assert.NotContains(t, hash, "secret")
+
+			assert.NoError(t, ComparePassword(algo, hash, "secret"))
+			assert.ErrorIs(t, ComparePassword(algo, hash, "wrong"), ErrPasswordMismatch)
+
+			other, err := HashPassword(algo, "secret", params)
+			assert.NoError(t, err)
+			assert.NotEqual(t, hash, other, "hashes should be salted")
+		})
+	}
+
+	t.Run("encoding", func(t *testing.T) {
+		hash, err := HashPassword(PasswordHashArgon2id, "secret", params)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+		hash, err = HashPassword(PasswordHashScrypt, "secret", params)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$scrypt$n=16,r=8,p=1$"))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := HashPassword("md5", "secret", params)
+		assert.Error(t, err)
+		assert.Error(t, ComparePassword("md5", "x", "secret"))
+	})
+
+	t.Run("invalid hash", func(t *testing.T) {
+		assert.ErrorIs(t, ComparePassword(PasswordHashArgon2id, "$argon2id$v=19$m=x$a$b", "secret"), ErrInvalidPasswordHash)
+		assert.ErrorIs(t, ComparePassword(PasswordHashScrypt, "plain", "secret"), ErrInvalidPasswordHash)
+
+		hash, err := HashPassword(PasswordHashScrypt, "secret", params)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, ComparePassword(PasswordHashArgon2id, hash, "secret"), ErrInvalidPasswordHash)
+	})
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	params := testPasswordHashParams()
+
+	argonHash, err := HashPassword(PasswordHashArgon2id, "secret", params)
+	assert.NoError(t, err)
+
+	scryptHash, err := HashPassword(PasswordHashScrypt, "secret", params)
+	assert.NoError(t, err)
+
+	bcryptHash, err := HashPassword(PasswordHashBCrypt, "secret", params)
+	assert.NoError(t, err)
+
+	assert.False(t, PasswordNeedsRehash(PasswordHashArgon2id, argonHash, PasswordHashArgon2id, params))
+	assert.False(t, PasswordNeedsRehash(PasswordHashScrypt, scryptHash, PasswordHashScrypt, params))
+	assert.False(t, PasswordNeedsRehash(PasswordHashBCrypt, bcryptHash, PasswordHashBCrypt, params))
+
+	assert.True(t, PasswordNeedsRehash(PasswordHashBCrypt, bcryptHash, PasswordHashArgon2id, params))
+	assert.True(t, PasswordNeedsRehash("sha256", "abc", PasswordHashScrypt, params))
+
+	changed := params
+	changed.Argon2Iterations = 2
+	changed.ScryptN = 1 << 5
+	changed.BCryptCost = 5
+
+	assert.True(t, PasswordNeedsRehash(PasswordHashArgon2id, argonHash, PasswordHashArgon2id, changed))
+	assert.True(t, PasswordNeedsRehash(PasswordHashScrypt, scryptHash, PasswordHashScrypt, changed))
+	assert.True(t, PasswordNeedsRehash(PasswordHashBCrypt, bcryptHash, PasswordHashBCrypt, changed))
+}
+
+func TestPasswordHashParams_Validate(t *testing.T) {
+	assert.NoError(t, PasswordHashParams{}.Validate())
+	assert.NoError(t, testPasswordHashParams().Validate())
+
+	invalid := map[string]PasswordHashParams{
+		"bcrypt cost":     {BCryptCost: 64},
+		"argon2id memory": {Argon2Memory: 8, Argon2Parallelism: 4},
+		"scrypt N":        {ScryptN: 1000},
+		"scrypt r*p":      {ScryptR: 1 << 15, ScryptP: 1 << 15},
+	}
+	for name, params := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, params.Validate())
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/TykTechnologies/tyk/internal/cache"
+)
+
+var (
+	// ErrCertRevoked is returned when a certificate is found in a CRL or OCSP reports it as revoked.
+	ErrCertRevoked = errors.New("certificate has been revoked")
+	// ErrRevocationUnknown is returned when the revocation status of a certificate can't be determined.
+	ErrRevocationUnknown = errors.New("certificate revocation status unknown")
+)
+
+const (
+	defaultRevocationCacheTTL = 5 * time.Minute
+	maxRevocationResponseSize = 10 << 20
+
+	// maxCachedCRLSize bounds the total size of the cached CRLs, in bytes.
+	maxCachedCRLSize = 64 << 20
+	// maxCachedOCSPResponses bounds the number of cached OCSP responses.
+	maxCachedOCSPResponses = 10000
+)
+
+// RevocationChecker checks certificates against CRLs and OCSP responders.
+// Downloaded CRLs and OCSP responses are cached until their next update time,
+// capped by CacheTTL, in caches bounded by size.
+type RevocationChecker struct {
+	// Client is the HTTP client used to fetch CRLs and query OCSP responders.
+	Client *http.Client
+	// CacheTTL is the maximum time a CRL or OCSP response is cached.
+	CacheTTL time.Duration
+
+	crls *cache.LRU
+	ocsp *cache.LRU
+}
+
+// NewRevocationChecker creates a RevocationChecker with the given HTTP timeout and cache TTL.
+// Zero values use a timeout of 5 seconds and a cache TTL of 5 minutes.
+func NewRevocationChecker(timeout, cacheTTL time.Duration) *RevocationChecker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	if cacheTTL <= 0 {
+		cacheTTL = defaultRevocationCacheTTL
+	}
+
+	return &RevocationChecker{
+		Client:   &http.Client{Timeout: timeout},
+		CacheTTL: cacheTTL,
+		crls:     cache.NewLRU(maxCachedCRLSize),
+		ocsp:     cache.NewLRU(maxCachedOCSPResponses),
+	}
+}
+
+// CheckCRL checks the certificate against the CRLs at the given URLs. When urls is empty,
+// the CRL distribution points of the certificate are used. The CRL signature is verified
+// with issuer.
+func (c *RevocationChecker) CheckCRL(cert, issuer *x509.Certificate, urls []string) error {
+	if len(urls) == 0 {
+		urls = cert.CRLDistributionPoints
+	}
+
+	if len(urls) == 0 {
+		return fmt.Errorf("%w: no CRL distribution point", ErrRevocationUnknown)
+	}
+
+	for _, url := range urls {
+		list, err := c.crl(url, issuer)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrRevocationUnknown, err)
+		}
+
+		for _, entry := range list.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return ErrCertRevoked
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckOCSP queries the OCSP responder for the status of the certificate. When responderURL
+// is empty, the first OCSP server of the certificate is used.
+func (c *RevocationChecker) CheckOCSP(cert, issuer *x509.Certificate, responderURL string) error {
+	if responderURL == "" && len(cert.OCSPServer) > 0 {
+		responderURL = cert.OCSPServer[0]
+	}
+
+	if responderURL == "" {
+		return fmt.Errorf("%w: no OCSP responder", ErrRevocationUnknown)
+	}
+
+	cacheKey := responderURL + "|" + HexSHA256(issuer.Raw) + "|" + cert.SerialNumber.String()
+
+	status := ocsp.Unknown
+	if cached, ok := c.ocsp.Get(cacheKey); ok {
+		status = cached.(int)
+	} else {
+		resp, err := c.queryOCSP(cert, issuer, responderURL)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrRevocationUnknown, err)
+		}
+
+		status = resp.Status
+		c.ocsp.Set(cacheKey, status, 1, c.ttl(resp.NextUpdate))
+	}
+
+	switch status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return ErrCertRevoked
+	default:
+		return ErrRevocationUnknown
+	}
+}
+
+func (c *RevocationChecker) queryOCSP(cert, issuer *x509.Certificate, responderURL string) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := c.Client.Post(responderURL, "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP responder returned status %d", httpResp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, maxRevocationResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return ocsp.ParseResponseForCert(body, cert, issuer)
+}
+
+func (c *RevocationChecker) crl(url string, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	cacheKey := url + "|" + HexSHA256(issuer.Raw)
+	if cached, ok := c.crls.Get(cacheKey); ok {
+		return cached.(*x509.RevocationList), nil
+	}
+
+	resp, err := c.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CRL download returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := list.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("invalid CRL signature: %w", err)
+	}
+
+	c.crls.Set(cacheKey, list, int64(len(body)), c.ttl(list.NextUpdate))
+
+	return list, nil
+}
+
+// ttl returns how long to cache a response with the given next update time. It's not positive,
+// and the response isn't cached, when the next update time has passed.
+func (c *RevocationChecker) ttl(nextUpdate time.Time) time.Duration {
+	if !nextUpdate.IsZero() {
+		if ttl := time.Until(nextUpdate); ttl < c.CacheTTL {
+			return ttl
+		}
+	}
+
+	return c.CacheTTL
+}
+
+// CertificateIssuer returns the certificate from candidates that signed cert, or nil if none did.
+func CertificateIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if candidate == nil || !candidate.IsCA {
+			continue
+		}
+
+		if bytes.Equal(cert.RawIssuer, candidate.RawSubject) && cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+
+	return nil
+}
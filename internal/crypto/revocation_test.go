@@ -0,0 +1,199 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64) *x509.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func (ca *testCA) crl(t *testing.T, nextUpdate time.Time, revoked ...int64) []byte {
+	t.Helper()
+
+	list := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-2 * time.Minute),
+		NextUpdate: nextUpdate,
+	}
+
+	for _, serial := range revoked {
+		list.RevokedCertificateEntries = append(list.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, list, ca.cert, ca.key)
+	require.NoError(t, err)
+
+	return der
+}
+
+func TestRevocationChecker_CheckCRL(t *testing.T) {
+	ca := newTestCA(t)
+	good, revoked := ca.issue(t, 10), ca.issue(t, 11)
+
+	var downloads int32
+	crl := ca.crl(t, time.Now().Add(time.Hour), 11)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		_, _ = w.Write(crl)
+	}))
+	defer srv.Close()
+
+	checker := NewRevocationChecker(0, 0)
+
+	assert.NoError(t, checker.CheckCRL(good, ca.cert, []string{srv.URL}))
+	assert.ErrorIs(t, checker.CheckCRL(revoked, ca.cert, []string{srv.URL}), ErrCertRevoked)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads), "CRL should be cached")
+
+	t.Run("no distribution point", func(t *testing.T) {
+		assert.ErrorIs(t, checker.CheckCRL(good, ca.cert, nil), ErrRevocationUnknown)
+	})
+
+	t.Run("signed by another CA", func(t *testing.T) {
+		other := newTestCA(t)
+		assert.ErrorIs(t, checker.CheckCRL(good, other.cert, []string{srv.URL}), ErrRevocationUnknown)
+	})
+
+	t.Run("past next update", func(t *testing.T) {
+		var downloads int32
+		stale := ca.crl(t, time.Now().Add(-time.Minute), 11)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			atomic.AddInt32(&downloads, 1)
+			_, _ = w.Write(stale)
+		}))
+		defer srv.Close()
+
+		assert.NoError(t, checker.CheckCRL(good, ca.cert, []string{srv.URL}))
+		assert.NoError(t, checker.CheckCRL(good, ca.cert, []string{srv.URL}))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&downloads), "CRL past its next update should not be cached")
+	})
+}
+
+func TestRevocationChecker_CheckOCSP(t *testing.T) {
+	ca := newTestCA(t)
+	good, revoked := ca.issue(t, 20), ca.issue(t, 21)
+
+	var queries int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		req, err := ocsp.ParseRequest(body)
+		require.NoError(t, err)
+
+		status := ocsp.Good
+		if req.SerialNumber.Cmp(revoked.SerialNumber) == 0 {
+			status = ocsp.Revoked
+		}
+
+		resp, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, crypto.Signer(ca.key))
+		require.NoError(t, err)
+
+		_, _ = w.Write(resp)
+	}))
+	defer srv.Close()
+
+	checker := NewRevocationChecker(0, 0)
+
+	assert.NoError(t, checker.CheckOCSP(good, ca.cert, srv.URL))
+	assert.NoError(t, checker.CheckOCSP(good, ca.cert, srv.URL))
+	assert.ErrorIs(t, checker.CheckOCSP(revoked, ca.cert, srv.URL), ErrCertRevoked)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&queries), "OCSP responses should be cached")
+
+	t.Run("no responder", func(t *testing.T) {
+		assert.ErrorIs(t, checker.CheckOCSP(good, ca.cert, ""), ErrRevocationUnknown)
+	})
+
+	t.Run("responder error", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+
+		assert.ErrorIs(t, checker.CheckOCSP(good, ca.cert, failing.URL), ErrRevocationUnknown)
+	})
+}
+
+func TestCertificateIssuer(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	cert := ca.issue(t, 30)
+
+	assert.Equal(t, ca.cert, CertificateIssuer(cert, []*x509.Certificate{nil, other.cert, ca.cert}))
+	assert.Nil(t, CertificateIssuer(cert, []*x509.Certificate{other.cert}))
+	assert.Nil(t, CertificateIssuer(cert, []*x509.Certificate{cert}))
+}
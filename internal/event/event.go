@@ -42,6 +42,8 @@ const (
 	TokenUpdated Event = "TokenUpdated"
 	// TokenDeleted is the event triggered when a token is deleted.
 	TokenDeleted Event = "TokenDeleted"
+	// ResponseValidationFailed is the event triggered when an upstream response doesn't match the OAS operation responses.
+	ResponseValidationFailed Event = "ResponseValidationFailed"
 )
 
 // Rate limiter events
@@ -85,6 +87,12 @@ const (
 	JSVMHandler HandlerName = "eh_dynamic_handler"
 	// CoProcessHandler is the HandlerName used in classic API definition for coprocess event handler.
 	CoProcessHandler HandlerName = "cp_dynamic_handler"
+	// KafkaHandler is the HandlerName used in classic API definition for the event handler publishing to Kafka.
+	KafkaHandler HandlerName = "eh_kafka_handler"
+	// NATSHandler is the HandlerName used in classic API definition for the event handler publishing to NATS.
+	NATSHandler HandlerName = "eh_nats_handler"
+	// AMQPHandler is the HandlerName used in classic API definition for the event handler publishing to AMQP brokers.
+	AMQPHandler HandlerName = "eh_amqp_handler"
 )
 
 // Kind is the action to be performed when an event is triggered, to be used in OAS API definition.
@@ -0,0 +1,77 @@
+package eventsink
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// amqpChannel is the part of *amqp.Channel used to publish.
+type amqpChannel interface {
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+type amqpPublisher struct {
+	conn       io.Closer
+	channel    amqpChannel
+	exchange   string
+	routingKey string
+}
+
+// DialAMQP returns the dialer of the AMQP broker, publishing persistent messages to the exchange with the routing
+// key. The addresses are tried in order until a connection succeeds. TLS is used by the amqps:// addresses.
+func DialAMQP(addresses []string, exchange, routingKey string, tlsConfig *tls.Config) Dialer {
+	return func() (Publisher, error) {
+		conf := amqp.Config{
+			TLSClientConfig: tlsConfig,
+			Dial:            amqp.DefaultDial(dialTimeout),
+			Properties:      amqp.Table{"connection_name": clientID},
+		}
+
+		err := errors.New("no AMQP address")
+		for _, address := range addresses {
+			var conn *amqp.Connection
+			conn, err = amqp.DialConfig(address, conf)
+			if err != nil {
+				continue
+			}
+
+			channel, err := conn.Channel()
+			if err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+
+			return &amqpPublisher{conn: conn, channel: channel, exchange: exchange, routingKey: routingKey}, nil
+		}
+
+		return nil, err
+	}
+}
+
+func (a *amqpPublisher) Publish(ctx context.Context, messages []Message) error {
+	for _, message := range messages {
+		err := a.channel.PublishWithContext(ctx, a.exchange, a.routingKey, false, false, amqp.Publishing{
+			ContentType:  message.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+			AppId:        clientID,
+			Body:         message.Body,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *amqpPublisher) Close() error {
+	_ = a.channel.Close()
+	return a.conn.Close()
+}
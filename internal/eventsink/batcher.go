@@ -0,0 +1,145 @@
+package eventsink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// queueSize is the number of messages waiting to be published, above which messages are dropped.
+	queueSize = 1000
+	// publishTimeout is the timeout of the publication of a batch.
+	publishTimeout = 10 * time.Second
+)
+
+// ErrQueueFull is returned when a message is dropped as the queue of messages to publish is full.
+var ErrQueueFull = errors.New("event queue is full")
+
+// Batcher publishes the queued messages in batches, when a batch is complete or when the flush interval has elapsed
+// since the last publication. It connects to the broker on the first publication, and reconnects after a failed one.
+type Batcher struct {
+	dial     Dialer
+	size     int
+	interval time.Duration
+	onError  func(err error, dropped int)
+
+	queue     chan Message
+	done      chan struct{}
+	closeOnce sync.Once
+	publisher Publisher
+}
+
+// NewBatcher returns a batcher publishing batches of size messages. onError is called with the number of dropped
+// messages when a batch can't be published.
+func NewBatcher(dial Dialer, size int, interval time.Duration, onError func(err error, dropped int)) *Batcher {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Batcher{
+		dial:     dial,
+		size:     size,
+		interval: interval,
+		onError:  onError,
+		queue:    make(chan Message, queueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// Add queues the message to publish. It returns ErrQueueFull when the message is dropped.
+func (b *Batcher) Add(message Message) error {
+	select {
+	case b.queue <- message:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops the batcher once the queued messages are published.
+func (b *Batcher) Close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+// Run publishes the queued messages until the context is done or the batcher is closed.
+func (b *Batcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]Message, 0, b.size)
+	for {
+		select {
+		case message := <-b.queue:
+			batch = append(batch, message)
+			if len(batch) >= b.size {
+				batch = b.flush(batch)
+			}
+		case <-ticker.C:
+			batch = b.flush(batch)
+		case <-ctx.Done():
+			b.stop(batch)
+			return
+		case <-b.done:
+			b.stop(batch)
+			return
+		}
+	}
+}
+
+// stop publishes the remaining messages and closes the connection.
+func (b *Batcher) stop(batch []Message) {
+	for {
+		select {
+		case message := <-b.queue:
+			batch = append(batch, message)
+			if len(batch) >= b.size {
+				batch = b.flush(batch)
+			}
+		default:
+			b.flush(batch)
+			if b.publisher != nil {
+				_ = b.publisher.Close()
+				b.publisher = nil
+			}
+			return
+		}
+	}
+}
+
+func (b *Batcher) flush(batch []Message) []Message {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := b.publish(batch); err != nil && b.onError != nil {
+		b.onError(err, len(batch))
+	}
+
+	return batch[:0]
+}
+
+func (b *Batcher) publish(batch []Message) error {
+	if b.publisher == nil {
+		publisher, err := b.dial()
+		if err != nil {
+			return err
+		}
+		b.publisher = publisher
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := b.publisher.Publish(ctx, batch); err != nil {
+		// The connection is reopened for the next batch.
+		_ = b.publisher.Close()
+		b.publisher = nil
+		return err
+	}
+
+	return nil
+}
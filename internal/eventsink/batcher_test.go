@@ -0,0 +1,140 @@
+package eventsink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPublisher struct {
+	mu      sync.Mutex
+	batches [][]Message
+	fail    bool
+	closed  int
+}
+
+func (p *testPublisher) Publish(_ context.Context, messages []Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fail {
+		return errors.New("broker unavailable")
+	}
+	p.batches = append(p.batches, append([]Message(nil), messages...))
+	return nil
+}
+
+func (p *testPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed++
+	return nil
+}
+
+func (p *testPublisher) sizes() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sizes := make([]int, 0, len(p.batches))
+	for _, batch := range p.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestBatcher(t *testing.T) {
+	t.Run("batches", func(t *testing.T) {
+		publisher := &testPublisher{}
+		batcher := NewBatcher(func() (Publisher, error) { return publisher, nil }, 3, time.Hour, nil)
+
+		stopped := make(chan struct{})
+		go func() {
+			batcher.Run(context.Background())
+			close(stopped)
+		}()
+
+		for i := 0; i < 7; i++ {
+			require.NoError(t, batcher.Add(Message{Body: []byte{byte(i)}}))
+		}
+
+		assert.Eventually(t, func() bool {
+			return len(publisher.sizes()) == 2
+		}, time.Second, 10*time.Millisecond)
+
+		batcher.Close()
+		<-stopped
+		assert.Equal(t, []int{3, 3, 1}, publisher.sizes(), "the remaining messages are published on close")
+		assert.Equal(t, 1, publisher.closed)
+	})
+
+	t.Run("flush interval", func(t *testing.T) {
+		publisher := &testPublisher{}
+		batcher := NewBatcher(func() (Publisher, error) { return publisher, nil }, 10, 20*time.Millisecond, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go batcher.Run(ctx)
+
+		require.NoError(t, batcher.Add(Message{}))
+		assert.Eventually(t, func() bool {
+			return assert.ObjectsAreEqual([]int{1}, publisher.sizes())
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("reconnects after a failure", func(t *testing.T) {
+		publisher := &testPublisher{fail: true}
+		var (
+			mu      sync.Mutex
+			dials   int
+			dropped int
+		)
+		batcher := NewBatcher(func() (Publisher, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dials++
+			return publisher, nil
+		}, 1, time.Hour, func(_ error, n int) {
+			mu.Lock()
+			defer mu.Unlock()
+			dropped += n
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go batcher.Run(ctx)
+
+		require.NoError(t, batcher.Add(Message{}))
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return dropped == 1
+		}, time.Second, 10*time.Millisecond)
+
+		publisher.mu.Lock()
+		publisher.fail = false
+		publisher.mu.Unlock()
+
+		require.NoError(t, batcher.Add(Message{}))
+		assert.Eventually(t, func() bool {
+			return len(publisher.sizes()) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		mu.Lock()
+		assert.Equal(t, 2, dials)
+		mu.Unlock()
+	})
+
+	t.Run("full queue", func(t *testing.T) {
+		batcher := NewBatcher(nil, 1, time.Hour, nil)
+		for i := 0; i < queueSize; i++ {
+			require.NoError(t, batcher.Add(Message{}))
+		}
+		assert.ErrorIs(t, batcher.Add(Message{}), ErrQueueFull)
+	})
+}
@@ -0,0 +1,92 @@
+// Package eventsink publishes the gateway events to message brokers. The events are serialized as JSON or as
+// CloudEvents, queued and published in batches to Kafka, NATS or AMQP brokers.
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/uuid"
+)
+
+const (
+	// FormatJSON serializes the events as the JSON of config.EventMessage.
+	FormatJSON = "json"
+	// FormatCloudEvents serializes the events as CloudEvents in the structured JSON mode.
+	FormatCloudEvents = "cloudevents"
+
+	// CloudEventsTypePrefix is the prefix of the type of the CloudEvents, followed by the event name.
+	CloudEventsTypePrefix = "io.tyk.event."
+	// MediaTypeCloudEvents is the content type of the CloudEvents in the structured JSON mode.
+	MediaTypeCloudEvents = "application/cloudevents+json"
+
+	clientID    = "tyk-gateway"
+	dialTimeout = 10 * time.Second
+)
+
+// Message is a serialized event to publish.
+type Message struct {
+	// Key is the Kafka key of the message.
+	Key         string
+	ContentType string
+	Body        []byte
+}
+
+// Publisher publishes messages to a broker.
+type Publisher interface {
+	// Publish publishes the messages, in order.
+	Publish(ctx context.Context, messages []Message) error
+	// Close closes the connection to the broker.
+	Close() error
+}
+
+// Dialer connects to a broker.
+type Dialer func() (Publisher, error)
+
+type cloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// Encode serializes the event in the format, JSON when the format is empty. The source is the source of the
+// CloudEvents.
+func Encode(format string, em config.EventMessage, source, key string, now time.Time) (Message, error) {
+	var (
+		value       interface{}
+		contentType string
+	)
+
+	switch format {
+	case "", FormatJSON:
+		value, contentType = em, header.ApplicationJSON
+	case FormatCloudEvents:
+		value = cloudEvent{
+			SpecVersion:     "1.0",
+			ID:              uuid.NewHex(),
+			Source:          source,
+			Type:            CloudEventsTypePrefix + string(em.Type),
+			Time:            now.UTC(),
+			DataContentType: header.ApplicationJSON,
+			Data:            em.Meta,
+		}
+		contentType = MediaTypeCloudEvents
+	default:
+		return Message{}, fmt.Errorf("unsupported event format %q", format)
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Key: key, ContentType: contentType, Body: body}, nil
+}
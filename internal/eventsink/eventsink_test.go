@@ -0,0 +1,84 @@
+package eventsink
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/crypto"
+	"github.com/TykTechnologies/tyk/internal/event"
+)
+
+func TestEncode(t *testing.T) {
+	em := config.EventMessage{
+		Type:      event.QuotaExceeded,
+		Meta:      map[string]string{"key": "abc"},
+		TimeStamp: "2024-01-02 03:04:05",
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("JSON", func(t *testing.T) {
+		for _, format := range []string{"", FormatJSON} {
+			message, err := Encode(format, em, "/tyk/apis/api", "api", now)
+			require.NoError(t, err)
+			assert.Equal(t, "api", message.Key)
+			assert.Equal(t, header.ApplicationJSON, message.ContentType)
+			assert.JSONEq(t, `{"Type":"QuotaExceeded","Meta":{"key":"abc"},"TimeStamp":"2024-01-02 03:04:05"}`, string(message.Body))
+		}
+	})
+
+	t.Run("CloudEvents", func(t *testing.T) {
+		message, err := Encode(FormatCloudEvents, em, "/tyk/apis/api", "api", now)
+		require.NoError(t, err)
+		assert.Equal(t, MediaTypeCloudEvents, message.ContentType)
+
+		var cloudEvent map[string]interface{}
+		require.NoError(t, json.Unmarshal(message.Body, &cloudEvent))
+		assert.NotEmpty(t, cloudEvent["id"])
+		delete(cloudEvent, "id")
+		assert.Equal(t, map[string]interface{}{
+			"specversion":     "1.0",
+			"source":          "/tyk/apis/api",
+			"type":            "io.tyk.event.QuotaExceeded",
+			"time":            "2024-01-02T03:04:05Z",
+			"datacontenttype": "application/json",
+			"data":            map[string]interface{}{"key": "abc"},
+		}, cloudEvent)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Encode("xml", em, "", "", now)
+		assert.Error(t, err)
+	})
+}
+
+func TestTLSConfig(t *testing.T) {
+	tlsConfig, err := TLSConfig(apidef.EventSinkTLSConf{})
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	certPEM, keyPEM, _, _ := crypto.GenServerCertificate()
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+
+	tlsConfig, err = TLSConfig(apidef.EventSinkTLSConf{Enabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Len(t, tlsConfig.Certificates, 1)
+
+	_, err = TLSConfig(apidef.EventSinkTLSConf{Enabled: true, CAFile: keyFile})
+	assert.Error(t, err, "the CA file has no certificate")
+
+	_, err = TLSConfig(apidef.EventSinkTLSConf{Enabled: true, CertFile: certFile})
+	assert.Error(t, err, "the key of the client certificate is missing")
+}
@@ -0,0 +1,117 @@
+// Package eventsinktest provides the test servers of the event sinks, for the tests of the packages publishing to them.
+package eventsinktest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+// NATSServer is an in-process stand-in for a NATS server, which records the messages published by its clients.
+type NATSServer struct {
+	// URL is the URL of the server.
+	URL string
+	// Messages receives the published messages.
+	Messages chan *nats.Msg
+
+	listener net.Listener
+}
+
+// NewNATSServer starts a NATS test server, stopped at the end of the test.
+func NewNATSServer(tb testing.TB) *NATSServer {
+	tb.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err)
+
+	s := &NATSServer{
+		URL:      "nats://" + listener.Addr().String(),
+		Messages: make(chan *nats.Msg, 100),
+		listener: listener,
+	}
+	tb.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go s.serve()
+	return s
+}
+
+func (s *NATSServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *NATSServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	_, _ = fmt.Fprint(conn, `INFO {"server_id":"test","version":"2.10.0","proto":1,"headers":true,"max_payload":1048576}`+"\r\n")
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			_, _ = fmt.Fprint(conn, "PONG\r\n")
+		case "PUB":
+			// PUB <subject> [reply-to] <size>
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload, err := readPayload(reader, size)
+			if err != nil {
+				return
+			}
+			s.Messages <- &nats.Msg{Subject: fields[1], Data: payload}
+		case "HPUB":
+			// HPUB <subject> [reply-to] <header size> <total size>
+			headerSize, _ := strconv.Atoi(fields[len(fields)-2])
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload, err := readPayload(reader, size)
+			if err != nil || headerSize > size {
+				return
+			}
+			s.Messages <- &nats.Msg{Subject: fields[1], Header: readHeader(payload[:headerSize]), Data: payload[headerSize:]}
+		}
+	}
+}
+
+func readPayload(reader *bufio.Reader, size int) ([]byte, error) {
+	payload := make([]byte, size+2) // the payload is followed by CRLF
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+	return payload[:size], nil
+}
+
+// readHeader parses the header block, a NATS/1.0 version line followed by MIME headers.
+func readHeader(block []byte) nats.Header {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(block)))
+	if _, err := reader.ReadLine(); err != nil {
+		return nil
+	}
+
+	mimeHeader, _ := reader.ReadMIMEHeader()
+	return nats.Header(mimeHeader)
+}
@@ -0,0 +1,63 @@
+package eventsink
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/IBM/sarama"
+
+	"github.com/TykTechnologies/tyk/header"
+)
+
+type kafkaPublisher struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+// DialKafka returns the dialer of the Kafka brokers, publishing the messages to the topic. The messages are
+// acknowledged by all the in-sync replicas.
+func DialKafka(addresses []string, topic string, tlsConfig *tls.Config) Dialer {
+	return func() (Publisher, error) {
+		conf := sarama.NewConfig()
+		conf.ClientID = clientID
+		conf.Net.DialTimeout = dialTimeout
+		conf.Producer.RequiredAcks = sarama.WaitForAll
+		conf.Producer.Return.Successes = true
+		if tlsConfig != nil {
+			conf.Net.TLS.Enable = true
+			conf.Net.TLS.Config = tlsConfig
+		}
+
+		producer, err := sarama.NewSyncProducer(addresses, conf)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewKafkaPublisher(producer, topic), nil
+	}
+}
+
+// NewKafkaPublisher returns the publisher of the messages to the topic with the producer.
+func NewKafkaPublisher(producer sarama.SyncProducer, topic string) Publisher {
+	return &kafkaPublisher{producer: producer, topic: topic}
+}
+
+func (k *kafkaPublisher) Publish(_ context.Context, messages []Message) error {
+	producerMessages := make([]*sarama.ProducerMessage, 0, len(messages))
+	for _, message := range messages {
+		producerMessages = append(producerMessages, &sarama.ProducerMessage{
+			Topic: k.topic,
+			Key:   sarama.StringEncoder(message.Key),
+			Value: sarama.ByteEncoder(message.Body),
+			Headers: []sarama.RecordHeader{
+				{Key: []byte(header.ContentType), Value: []byte(message.ContentType)},
+			},
+		})
+	}
+
+	return k.producer.SendMessages(producerMessages)
+}
+
+func (k *kafkaPublisher) Close() error {
+	return k.producer.Close()
+}
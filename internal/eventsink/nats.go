@@ -0,0 +1,53 @@
+package eventsink
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/TykTechnologies/tyk/header"
+)
+
+type natsPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// DialNATS returns the dialer of the NATS servers, publishing the messages to the subject.
+func DialNATS(addresses []string, subject string, tlsConfig *tls.Config) Dialer {
+	return func() (Publisher, error) {
+		options := []nats.Option{nats.Name(clientID), nats.Timeout(dialTimeout)}
+		if tlsConfig != nil {
+			options = append(options, nats.Secure(tlsConfig))
+		}
+
+		conn, err := nats.Connect(strings.Join(addresses, ","), options...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &natsPublisher{conn: conn, subject: subject}, nil
+	}
+}
+
+// Publish publishes the messages and waits for the server to process them.
+func (n *natsPublisher) Publish(ctx context.Context, messages []Message) error {
+	for _, message := range messages {
+		msg := nats.NewMsg(n.subject)
+		msg.Header.Set(header.ContentType, message.ContentType)
+		msg.Data = message.Body
+
+		if err := n.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+
+	return n.conn.FlushWithContext(ctx)
+}
+
+func (n *natsPublisher) Close() error {
+	n.conn.Close()
+	return nil
+}
@@ -0,0 +1,120 @@
+package eventsink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/eventsink/eventsinktest"
+)
+
+var testMessages = []Message{
+	{Key: "api", ContentType: header.ApplicationJSON, Body: []byte(`{"Type":"QuotaExceeded"}`)},
+	{Key: "api", ContentType: MediaTypeCloudEvents, Body: []byte(`{"specversion":"1.0"}`)},
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, mocks.NewTestConfig())
+	for _, message := range testMessages {
+		expected := message
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			assert.Equal(t, "tyk-events", msg.Topic)
+
+			key, err := msg.Key.Encode()
+			require.NoError(t, err)
+			assert.Equal(t, expected.Key, string(key))
+
+			value, err := msg.Value.Encode()
+			require.NoError(t, err)
+			assert.Equal(t, expected.Body, value)
+
+			assert.Equal(t, []sarama.RecordHeader{{Key: []byte(header.ContentType), Value: []byte(expected.ContentType)}}, msg.Headers)
+			return nil
+		})
+	}
+
+	publisher := NewKafkaPublisher(producer, "tyk-events")
+	require.NoError(t, publisher.Publish(context.Background(), testMessages))
+	require.NoError(t, publisher.Close())
+}
+
+func TestNATSPublisher(t *testing.T) {
+	server := eventsinktest.NewNATSServer(t)
+
+	publisher, err := DialNATS([]string{server.URL}, "tyk.events", nil)()
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, publisher.Publish(ctx, testMessages))
+
+	for _, expected := range testMessages {
+		select {
+		case msg := <-server.Messages:
+			assert.Equal(t, "tyk.events", msg.Subject)
+			assert.Equal(t, expected.Body, msg.Data)
+			assert.Equal(t, expected.ContentType, msg.Header.Get(header.ContentType))
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	}
+
+	_, err = DialNATS([]string{"nats://127.0.0.1:1"}, "tyk.events", nil)()
+	assert.Error(t, err)
+}
+
+type testAMQPChannel struct {
+	exchange, key string
+	published     []amqp.Publishing
+	closed        bool
+}
+
+func (c *testAMQPChannel) PublishWithContext(_ context.Context, exchange, key string, _, _ bool, msg amqp.Publishing) error {
+	c.exchange, c.key = exchange, key
+	c.published = append(c.published, msg)
+	return nil
+}
+
+func (c *testAMQPChannel) Close() error {
+	c.closed = true
+	return nil
+}
+
+type testCloser struct {
+	closed bool
+}
+
+func (c *testCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestAMQPPublisher(t *testing.T) {
+	channel, conn := &testAMQPChannel{}, &testCloser{}
+	publisher := &amqpPublisher{conn: conn, channel: channel, exchange: "tyk", routingKey: "events"}
+
+	require.NoError(t, publisher.Publish(context.Background(), testMessages))
+	assert.Equal(t, "tyk", channel.exchange)
+	assert.Equal(t, "events", channel.key)
+	require.Len(t, channel.published, 2)
+	for i, expected := range testMessages {
+		assert.Equal(t, expected.Body, channel.published[i].Body)
+		assert.Equal(t, expected.ContentType, channel.published[i].ContentType)
+		assert.Equal(t, amqp.Persistent, channel.published[i].DeliveryMode)
+	}
+
+	require.NoError(t, publisher.Close())
+	assert.True(t, channel.closed)
+	assert.True(t, conn.closed)
+
+	_, err := DialAMQP([]string{"amqp://127.0.0.1:1"}, "tyk", "events", nil)()
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package eventsink
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+// TLSConfig returns the TLS configuration of the connection to the broker, nil when TLS is disabled.
+func TLSConfig(conf apidef.EventSinkTLSConf) (*tls.Config, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+
+	if conf.CAFile != "" {
+		caCerts, err := os.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA file: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCerts) {
+			return nil, errors.New("no certificate found in the CA file")
+		}
+	}
+
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,245 @@
+package graphengine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/ast"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astnormalization"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astprinter"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+const (
+	cacheControlDirectiveName = "cacheControl"
+	cacheScopePrivate         = "PRIVATE"
+)
+
+// CachePolicy is the caching policy of the response of a GraphQL request.
+type CachePolicy struct {
+	// Key identifies the normalised operation of the request and its variables.
+	Key string
+	// TTL is the lifetime in seconds of the response, the response is not cached when it is 0.
+	TTL int64
+	// Private is true when the response holds per-session data.
+	Private bool
+}
+
+// CachePolicyCalculator computes the caching policy of GraphQL requests. The lifetime of a field is read from:
+//   - the maxAge of its @cacheControl directive, unless it sets inheritMaxAge.
+//   - the TTL of the type it returns in the configuration, then the maxAge of the @cacheControl directive of the type.
+//   - the default TTL for root fields and fields returning objects, other fields inherit the lifetime of their parent.
+//
+// The response is private when a field or a type it holds has a PRIVATE scope, or is a private type of the
+// configuration. Only queries are cached.
+type CachePolicyCalculator struct {
+	definition   *ast.Document
+	conf         apidef.GraphQLCacheConfig
+	privateTypes map[string]bool
+}
+
+// NewCachePolicyCalculator returns a calculator for the requests to the schema.
+func NewCachePolicyCalculator(schema string, conf apidef.GraphQLCacheConfig) (*CachePolicyCalculator, error) {
+	definition, err := parseDefinition(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	privateTypes := make(map[string]bool, len(conf.PrivateTypes))
+	for _, typeName := range conf.PrivateTypes {
+		privateTypes[typeName] = true
+	}
+
+	return &CachePolicyCalculator{
+		definition:   definition,
+		conf:         conf,
+		privateTypes: privateTypes,
+	}, nil
+}
+
+// Policy returns the caching policy of the operation of the query.
+func (c *CachePolicyCalculator) Policy(query, operationName string, variables []byte) (*CachePolicy, error) {
+	operation, report := astparser.ParseGraphqlDocumentString(query)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	// the normalizer is not safe for concurrent use
+	normalizer := astnormalization.NewWithOpts(astnormalization.WithRemoveFragmentDefinitions())
+	normalizer.NormalizeOperation(&operation, c.definition, &report)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	operationRef, err := selectOperation(&operation, operationName)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := c.key(&operation, operationName, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &CachePolicy{Key: key}
+	if operation.OperationDefinitions[operationRef].OperationType != ast.OperationTypeQuery {
+		return policy, nil
+	}
+
+	op := &cachePolicyOperation{CachePolicyCalculator: c, operation: &operation, ttl: math.MaxInt64}
+	op.selectionSet(operation.OperationDefinitions[operationRef].SelectionSet, rootTypeName(c.definition, &operation, operationRef), true)
+
+	policy.TTL = op.ttl
+	if policy.TTL == math.MaxInt64 {
+		policy.TTL = c.conf.DefaultTTL
+	}
+	if policy.TTL < 0 {
+		policy.TTL = 0
+	}
+	policy.Private = op.private
+
+	return policy, nil
+}
+
+// key returns the hash of the normalised operation and of the variables, with their fields sorted.
+func (c *CachePolicyCalculator) key(operation *ast.Document, operationName string, variables []byte) (string, error) {
+	printed, err := astprinter.PrintString(operation, c.definition)
+	if err != nil {
+		return "", err
+	}
+
+	var value interface{}
+	if len(variables) > 0 {
+		if err := json.Unmarshal(variables, &value); err != nil {
+			return "", err
+		}
+	}
+
+	canonicalVariables, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(operationName))
+	h.Write([]byte{0})
+	h.Write([]byte(printed))
+	h.Write([]byte{0})
+	h.Write(canonicalVariables)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type cachePolicyOperation struct {
+	*CachePolicyCalculator
+	operation *ast.Document
+	ttl       int64
+	private   bool
+}
+
+func (o *cachePolicyOperation) selectionSet(selectionSet int, typeName string, root bool) {
+	for _, selectionRef := range o.operation.SelectionSets[selectionSet].SelectionRefs {
+		selection := o.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			o.field(selection.Ref, typeName, root)
+		case ast.SelectionKindInlineFragment:
+			fragment := o.operation.InlineFragments[selection.Ref]
+			if !fragment.HasSelections {
+				continue
+			}
+
+			fragmentTypeName := typeName
+			if o.operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				fragmentTypeName = o.operation.InlineFragmentTypeConditionNameString(selection.Ref)
+			}
+			o.selectionSet(fragment.SelectionSet, fragmentTypeName, root)
+		}
+	}
+}
+
+func (o *cachePolicyOperation) field(fieldRef int, typeName string, root bool) {
+	fieldName := o.operation.FieldNameString(fieldRef)
+	if fieldName == "__typename" {
+		return
+	}
+
+	typeNode, ok := o.definition.Index.FirstNodeByNameStr(typeName)
+	if !ok {
+		return
+	}
+
+	fieldDefinition, ok := o.definition.NodeFieldDefinitionByName(typeNode, []byte(fieldName))
+	if !ok {
+		// unknown fields are reported by the validation
+		return
+	}
+
+	fieldTypeName := o.definition.ResolveTypeNameString(o.definition.FieldDefinitionType(fieldDefinition))
+	fieldTypeNode, _ := o.definition.Index.FirstNodeByNameStr(fieldTypeName)
+	composite := fieldTypeNode.Kind == ast.NodeKindObjectTypeDefinition ||
+		fieldTypeNode.Kind == ast.NodeKindInterfaceTypeDefinition ||
+		fieldTypeNode.Kind == ast.NodeKindUnionTypeDefinition
+
+	if o.privateTypes[fieldTypeName] {
+		o.private = true
+	}
+
+	var (
+		typeMaxAge    int64
+		typeHasMaxAge bool
+	)
+	if composite {
+		typeMaxAge, typeHasMaxAge, _ = o.cacheControl(o.definition.NodeDirectives(fieldTypeNode))
+	}
+
+	maxAge, hasMaxAge, inherit := o.cacheControl(o.definition.FieldDefinitionDirectives(fieldDefinition))
+	if !hasMaxAge && !inherit {
+		if ttl, ok := o.conf.TypeTTLs[fieldTypeName]; ok {
+			maxAge, hasMaxAge = ttl, true
+		} else if typeHasMaxAge {
+			maxAge, hasMaxAge = typeMaxAge, true
+		}
+	}
+
+	if !hasMaxAge && !inherit && (root || composite) {
+		maxAge, hasMaxAge = o.conf.DefaultTTL, true
+	}
+
+	if hasMaxAge && maxAge < o.ttl {
+		o.ttl = maxAge
+	}
+
+	if field := o.operation.Fields[fieldRef]; field.HasSelections {
+		o.selectionSet(field.SelectionSet, fieldTypeName, false)
+	}
+}
+
+// cacheControl reads the @cacheControl directive, a PRIVATE scope makes the response private.
+func (o *cachePolicyOperation) cacheControl(directives []int) (maxAge int64, hasMaxAge bool, inherit bool) {
+	for _, directive := range directives {
+		if o.definition.DirectiveNameString(directive) != cacheControlDirectiveName {
+			continue
+		}
+
+		if value, ok := o.definition.DirectiveArgumentValueByName(directive, []byte("scope")); ok &&
+			value.Kind == ast.ValueKindEnum && o.definition.EnumValueNameString(value.Ref) == cacheScopePrivate {
+			o.private = true
+		}
+
+		if value, ok := o.definition.DirectiveArgumentValueByName(directive, []byte("inheritMaxAge")); ok &&
+			value.Kind == ast.ValueKindBoolean && bool(o.definition.BooleanValue(value.Ref)) {
+			inherit = true
+		}
+
+		if value, ok := o.definition.DirectiveArgumentValueByName(directive, []byte("maxAge")); ok && value.Kind == ast.ValueKindInteger {
+			maxAge, hasMaxAge = o.definition.IntValueAsInt(value.Ref), true
+		}
+	}
+
+	return maxAge, hasMaxAge, inherit
+}
@@ -0,0 +1,97 @@
+package graphengine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+const cacheTestSchema = `
+enum CacheControlScope { PUBLIC PRIVATE }
+directive @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+
+type Query {
+	countries: [Country!]! @cacheControl(maxAge: 300)
+	country(code: String!): Country
+	me: User
+	news: [String!]! @cacheControl(maxAge: 10)
+	stats: Stats @cacheControl(inheritMaxAge: true)
+}
+
+type Mutation {
+	createCountry(name: String!): Country
+}
+
+type Country @cacheControl(maxAge: 600) {
+	code: String!
+	name: String!
+	capital: City
+}
+
+type City {
+	name: String!
+}
+
+type User @cacheControl(maxAge: 30, scope: PRIVATE) {
+	name: String!
+}
+
+type Stats {
+	count: Int!
+}
+`
+
+func TestCachePolicyCalculator_Policy(t *testing.T) {
+	calculator, err := NewCachePolicyCalculator(cacheTestSchema, apidef.GraphQLCacheConfig{
+		Enabled:      true,
+		DefaultTTL:   60,
+		TypeTTLs:     map[string]int64{"City": 120},
+		PrivateTypes: []string{"Stats"},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		query   string
+		ttl     int64
+		private bool
+	}{
+		{name: "field hint", query: `{ countries { code name } }`, ttl: 300},
+		{name: "type hint", query: `{ country(code: "DE") { code } }`, ttl: 600},
+		{name: "type TTL", query: `{ country(code: "DE") { capital { name } } }`, ttl: 120},
+		{name: "lowest lifetime", query: `{ countries { code } news }`, ttl: 10},
+		{name: "private scope", query: `{ me { name } }`, ttl: 30, private: true},
+		{name: "private type", query: `{ stats { count } }`, ttl: 60, private: true},
+		{name: "typename only", query: `{ __typename }`, ttl: 60},
+		{name: "mutation", query: `mutation { createCountry(name: "X") { code } }`, ttl: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := calculator.Policy(tc.query, "", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.ttl, policy.TTL)
+			assert.Equal(t, tc.private, policy.Private)
+		})
+	}
+
+	t.Run("key of normalised operation", func(t *testing.T) {
+		key := func(query, operationName, variables string) string {
+			policy, err := calculator.Policy(query, operationName, []byte(variables))
+			require.NoError(t, err)
+			return policy.Key
+		}
+
+		expected := key(`query Q($c: String!) { country(code: $c) { code name } }`, "", `{"c":"DE"}`)
+		assert.Equal(t, expected, key(`query Q($c: String!) {
+			country(code: $c) { ...F }
+		}
+		fragment F on Country { code name }`, "", `{ "c": "DE" }`))
+		assert.Equal(t, expected, key(`query Q($c: String!) { country(code: $c) { code code name } }`, "", `{"c":"DE"}`))
+		assert.NotEqual(t, expected, key(`query Q($c: String!) { country(code: $c) { code name } }`, "", `{"c":"FR"}`))
+		assert.NotEqual(t, expected, key(`query Q($c: String!) { country(code: $c) { code } }`, "", `{"c":"DE"}`))
+	})
+}
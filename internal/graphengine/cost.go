@@ -0,0 +1,274 @@
+package graphengine
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/ast"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astnormalization"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+const (
+	costDirectiveName     = "cost"
+	listSizeDirectiveName = "listSize"
+	maxQueryCost          = math.MaxInt32
+)
+
+// CostCalculator computes the static cost of GraphQL requests. The cost of a field is its weight plus the cost of its
+// selections, multiplied by the size of the list it returns:
+//   - the weight is read from the weights of the configuration, then from the @cost directive of the field or of
+//     its type. Without weight, object fields cost 1 and scalar fields cost 0.
+//   - the list size is the greatest slicing argument of the field, then the assumedSize of its @listSize directive,
+//     then the default list size.
+//
+// The selections of inline fragments on different types cost the most expensive fragment.
+type CostCalculator struct {
+	definition *ast.Document
+	conf       apidef.GraphQLCostAnalysisConfig
+}
+
+// NewCostCalculator returns a calculator for the requests to the schema.
+func NewCostCalculator(schema string, conf apidef.GraphQLCostAnalysisConfig) (*CostCalculator, error) {
+	definition, err := parseDefinition(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.DefaultListSize <= 0 {
+		conf.DefaultListSize = 1
+	}
+
+	return &CostCalculator{definition: definition, conf: conf}, nil
+}
+
+// Calculate returns the cost of the operation of the query. Variables are used to read the slicing arguments.
+func (c *CostCalculator) Calculate(query, operationName string, variables []byte) (int, error) {
+	operation, report := astparser.ParseGraphqlDocumentString(query)
+	if report.HasErrors() {
+		return 0, report
+	}
+
+	astnormalization.NormalizeOperation(&operation, c.definition, &report)
+	if report.HasErrors() {
+		return 0, report
+	}
+
+	operationRef, err := selectOperation(&operation, operationName)
+	if err != nil {
+		return 0, err
+	}
+
+	op := &costOperation{CostCalculator: c, operation: &operation, variables: variables}
+	cost := op.selectionSetCost(operation.OperationDefinitions[operationRef].SelectionSet, rootTypeName(c.definition, &operation, operationRef))
+
+	return int(cost), nil
+}
+
+type costOperation struct {
+	*CostCalculator
+	operation *ast.Document
+	variables []byte
+}
+
+func (o *costOperation) selectionSetCost(selectionSet int, typeName string) int64 {
+	var (
+		cost          int64
+		fragmentsCost int64
+	)
+
+	for _, selectionRef := range o.operation.SelectionSets[selectionSet].SelectionRefs {
+		selection := o.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			cost = addCost(cost, o.fieldCost(selection.Ref, typeName))
+		case ast.SelectionKindInlineFragment:
+			fragment := o.operation.InlineFragments[selection.Ref]
+			if !fragment.HasSelections {
+				continue
+			}
+
+			fragmentTypeName := typeName
+			if o.operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				fragmentTypeName = o.operation.InlineFragmentTypeConditionNameString(selection.Ref)
+			}
+
+			fragmentCost := o.selectionSetCost(fragment.SelectionSet, fragmentTypeName)
+			if fragmentTypeName == typeName {
+				cost = addCost(cost, fragmentCost)
+			} else if fragmentCost > fragmentsCost {
+				fragmentsCost = fragmentCost
+			}
+		}
+	}
+
+	return addCost(cost, fragmentsCost)
+}
+
+func (o *costOperation) fieldCost(fieldRef int, typeName string) int64 {
+	fieldName := o.operation.FieldNameString(fieldRef)
+	if strings.HasPrefix(fieldName, "__") {
+		return 0
+	}
+
+	typeNode, ok := o.definition.Index.FirstNodeByNameStr(typeName)
+	if !ok {
+		return 0
+	}
+
+	fieldDefinition, ok := o.definition.NodeFieldDefinitionByName(typeNode, []byte(fieldName))
+	if !ok {
+		// unknown fields are reported by the validation
+		return 0
+	}
+
+	fieldType := o.definition.FieldDefinitionType(fieldDefinition)
+	fieldTypeName := o.definition.ResolveTypeNameString(fieldType)
+
+	cost := o.fieldWeight(typeName, fieldName, fieldDefinition, fieldTypeName)
+	if field := o.operation.Fields[fieldRef]; field.HasSelections {
+		cost = addCost(cost, o.selectionSetCost(field.SelectionSet, fieldTypeName))
+	}
+
+	if o.definition.TypeIsList(fieldType) {
+		cost = multiplyCost(cost, o.listSize(fieldRef, fieldDefinition))
+	}
+
+	return cost
+}
+
+func (o *costOperation) fieldWeight(typeName, fieldName string, fieldDefinition int, fieldTypeName string) int64 {
+	if weight, ok := o.conf.Weights[typeName+"."+fieldName]; ok {
+		return int64(weight)
+	}
+
+	if weight, ok := o.directiveWeight(o.definition.FieldDefinitionDirectives(fieldDefinition)); ok {
+		return weight
+	}
+
+	if weight, ok := o.conf.Weights[fieldTypeName]; ok {
+		return int64(weight)
+	}
+
+	typeNode, ok := o.definition.Index.FirstNodeByNameStr(fieldTypeName)
+	if !ok {
+		return 0
+	}
+
+	if weight, ok := o.directiveWeight(o.definition.NodeDirectives(typeNode)); ok {
+		return weight
+	}
+
+	switch typeNode.Kind {
+	case ast.NodeKindScalarTypeDefinition, ast.NodeKindEnumTypeDefinition:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func (o *costOperation) directiveWeight(directives []int) (int64, bool) {
+	for _, directive := range directives {
+		if o.definition.DirectiveNameString(directive) != costDirectiveName {
+			continue
+		}
+
+		value, ok := o.definition.DirectiveArgumentValueByName(directive, []byte("weight"))
+		if !ok {
+			continue
+		}
+
+		return o.intValue(o.definition, value)
+	}
+
+	return 0, false
+}
+
+// listSize returns the size of the list returned by the field.
+func (o *costOperation) listSize(fieldRef, fieldDefinition int) int64 {
+	slicingArguments := o.conf.SlicingArguments
+	var assumedSize int64 = -1
+
+	for _, directive := range o.definition.FieldDefinitionDirectives(fieldDefinition) {
+		if o.definition.DirectiveNameString(directive) != listSizeDirectiveName {
+			continue
+		}
+
+		if value, ok := o.definition.DirectiveArgumentValueByName(directive, []byte("assumedSize")); ok {
+			if size, ok := o.intValue(o.definition, value); ok {
+				assumedSize = size
+			}
+		}
+
+		if value, ok := o.definition.DirectiveArgumentValueByName(directive, []byte("slicingArguments")); ok && value.Kind == ast.ValueKindList {
+			slicingArguments = nil
+			for _, ref := range o.definition.ListValues[value.Ref].Refs {
+				if item := o.definition.Value(ref); item.Kind == ast.ValueKindString {
+					slicingArguments = append(slicingArguments, o.definition.StringValueContentString(item.Ref))
+				}
+			}
+		}
+	}
+
+	var size int64 = -1
+	for _, name := range slicingArguments {
+		argument, ok := o.operation.FieldArgument(fieldRef, []byte(name))
+		if !ok {
+			continue
+		}
+
+		if value, ok := o.intValue(o.operation, o.operation.ArgumentValue(argument)); ok && value > size {
+			size = value
+		}
+	}
+
+	switch {
+	case size >= 0:
+		return size
+	case assumedSize >= 0:
+		return assumedSize
+	default:
+		return int64(o.conf.DefaultListSize)
+	}
+}
+
+// intValue returns the value of an int literal, or of the variable it references.
+func (o *costOperation) intValue(document *ast.Document, value ast.Value) (int64, bool) {
+	switch value.Kind {
+	case ast.ValueKindInteger:
+		return document.IntValueAsInt(value.Ref), true
+	case ast.ValueKindVariable:
+		raw, dataType, _, err := jsonparser.Get(o.variables, document.VariableValueNameString(value.Ref))
+		if err != nil || dataType != jsonparser.Number {
+			return 0, false
+		}
+
+		size, err := strconv.ParseInt(string(raw), 10, 64)
+		return size, err == nil
+	}
+
+	return 0, false
+}
+
+func addCost(a, b int64) int64 {
+	if a+b > maxQueryCost {
+		return maxQueryCost
+	}
+	return a + b
+}
+
+func multiplyCost(cost, size int64) int64 {
+	if size <= 0 || cost <= 0 {
+		return 0
+	}
+
+	if cost > maxQueryCost/size {
+		return maxQueryCost
+	}
+	return cost * size
+}
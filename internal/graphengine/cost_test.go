@@ -0,0 +1,97 @@
+package graphengine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+const costTestSchema = `
+directive @cost(weight: Int!) on FIELD_DEFINITION | OBJECT
+directive @listSize(assumedSize: Int, slicingArguments: [String!]) on FIELD_DEFINITION
+
+type Query {
+	country(code: String!): Country
+	countries(first: Int): [Country!]! @listSize(slicingArguments: ["first"])
+	continents: [Continent!]! @listSize(assumedSize: 7)
+	search(limit: Int): [Result!]!
+}
+
+type Mutation {
+	createCountry(name: String!): Country @cost(weight: 10)
+}
+
+type Country {
+	code: String!
+	name: String!
+	languages: [Language!]!
+}
+
+type Language @cost(weight: 3) {
+	code: String!
+}
+
+type Continent {
+	code: String!
+	countries: [Country!]!
+}
+
+union Result = Country | Continent
+`
+
+func TestCostCalculator_Calculate(t *testing.T) {
+	calculator, err := NewCostCalculator(costTestSchema, apidef.GraphQLCostAnalysisConfig{
+		Enabled:          true,
+		Weights:          map[string]int{"Continent.countries": 2},
+		SlicingArguments: []string{"limit"},
+		DefaultListSize:  10,
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		query         string
+		operationName string
+		variables     string
+		cost          int
+	}{
+		{name: "scalar fields", query: `{ country(code: "DE") { code name } }`, cost: 1},
+		{name: "type weight", query: `{ country(code: "DE") { languages { code } } }`, cost: 1 + 3*10},
+		{name: "slicing argument", query: `{ countries(first: 5) { name } }`, cost: 5},
+		{name: "slicing argument variable", query: `query ($n: Int) { countries(first: $n) { name } }`, variables: `{"n":20}`, cost: 20},
+		{name: "default list size", query: `{ countries { name } }`, cost: 10},
+		{name: "assumed size", query: `{ continents { code } }`, cost: 7},
+		{name: "weights config", query: `{ continents { countries { code } } }`, cost: 7 * (1 + 2*10)},
+		{name: "field weight", query: `mutation { createCountry(name: "X") { code } }`, cost: 10},
+		{
+			name:  "inline fragments",
+			query: `{ search(limit: 2) { ... on Country { languages { code } } ... on Continent { code } } }`,
+			cost:  2 * (1 + 3*10),
+		},
+		{name: "fragment spreads", query: `{ country(code: "DE") { ...C } } fragment C on Country { languages { code } }`, cost: 1 + 3*10},
+		{name: "named operation", query: `query A { countries { name } } query B { country(code: "DE") { name } }`, operationName: "B", cost: 1},
+		{name: "introspection", query: `{ __schema { types { name } } }`, cost: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, err := calculator.Calculate(tc.query, tc.operationName, []byte(tc.variables))
+			require.NoError(t, err)
+			assert.Equal(t, tc.cost, cost)
+		})
+	}
+
+	t.Run("missing operation name", func(t *testing.T) {
+		_, err := calculator.Calculate(`query A { countries { name } } query B { country(code: "DE") { name } }`, "", nil)
+		assert.ErrorIs(t, err, errOperationNotFound)
+	})
+
+	t.Run("cost is capped", func(t *testing.T) {
+		cost, err := calculator.Calculate(`{ countries(first: 2147483647) { languages { code } } }`, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, maxQueryCost, cost)
+	})
+}
@@ -67,9 +67,21 @@ func NewEngineV3(options EngineV3Options) (*EngineV3, error) {
 		}
 	}
 
+	dataSourceHttpClient := options.HttpClient
+	if conf := options.ApiDefinition.GraphQL.Cache.DataSourceFetches; conf.Enabled && dataSourceHttpClient != nil {
+		ttl := conf.TTL
+		if ttl <= 0 {
+			ttl = options.ApiDefinition.GraphQL.Cache.DefaultTTL
+		}
+
+		cachingClient := *dataSourceHttpClient
+		cachingClient.Transport = NewFetchCache(cachingClient.Transport, ttl)
+		dataSourceHttpClient = &cachingClient
+	}
+
 	// TODO check the streaming client usage here
 	configAdapter := adapter.NewGraphQLConfigAdapter(options.ApiDefinition,
-		adapter.WithHttpClient(options.HttpClient),
+		adapter.WithHttpClient(dataSourceHttpClient),
 		adapter.WithV2Schema(options.Schema),
 		adapter.WithStreamingClient(options.StreamingClient),
 	)
@@ -211,7 +223,7 @@ func (e *EngineV3) ProcessRequest(ctx context.Context, w http.ResponseWriter, r
 	}
 
 	if normalizationResult.Errors != nil && normalizationResult.Errors.Count() > 0 {
-		return writeGraphQLError(e.logger, w, normalizationResult.Errors)
+		return writeGraphQLError(e.logger, w, r, normalizationResult.Errors)
 	}
 
 	validationResult, err := gqlRequest.ValidateForSchema(e.schema)
@@ -221,7 +233,7 @@ func (e *EngineV3) ProcessRequest(ctx context.Context, w http.ResponseWriter, r
 	}
 
 	if validationResult.Errors != nil && validationResult.Errors.Count() > 0 {
-		return writeGraphQLError(e.logger, w, validationResult.Errors)
+		return writeGraphQLError(e.logger, w, r, validationResult.Errors)
 	}
 
 	inputValidationResult, err := gqlRequest.ValidateInput(e.schema)
@@ -230,7 +242,7 @@ func (e *EngineV3) ProcessRequest(ctx context.Context, w http.ResponseWriter, r
 		return ProxyingRequestFailedErr, http.StatusInternalServerError
 	}
 	if inputValidationResult.Errors != nil && inputValidationResult.Errors.Count() > 0 {
-		return writeGraphQLError(e.logger, w, inputValidationResult.Errors)
+		return writeGraphQLError(e.logger, w, r, inputValidationResult.Errors)
 	}
 	return nil, http.StatusOK
 }
@@ -0,0 +1,157 @@
+package graphengine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/ast"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+
+	"github.com/TykTechnologies/tyk/internal/cache"
+)
+
+// fetchCacheMaxSize bounds the total size of the fetched bodies held by a fetch cache.
+const fetchCacheMaxSize = 64 << 20
+
+// FetchCache is a transport caching the responses of the data source fetches of the execution engine. Fetches are
+// keyed by method, URL, headers and body. Only GET fetches and the POST fetches of GraphQL queries are cached, and
+// only their successful responses.
+type FetchCache struct {
+	transport http.RoundTripper
+	ttl       time.Duration
+	entries   *cache.LRU
+}
+
+type fetchCacheEntry struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// NewFetchCache returns a fetch cache keeping the responses of the transport for ttl seconds.
+func NewFetchCache(transport http.RoundTripper, ttl int64) *FetchCache {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &FetchCache{
+		transport: transport,
+		ttl:       time.Duration(ttl) * time.Second,
+		entries:   cache.NewLRU(fetchCacheMaxSize),
+	}
+}
+
+func (c *FetchCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok, err := c.key(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return c.transport.RoundTrip(req)
+	}
+
+	if value, found := c.entries.Get(key); found {
+		entry := value.(*fetchCacheEntry)
+		return &http.Response{
+			Status:        http.StatusText(entry.statusCode),
+			StatusCode:    entry.statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := c.transport.RoundTrip(req)
+	if err != nil || res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := &fetchCacheEntry{statusCode: res.StatusCode, header: res.Header.Clone(), body: body}
+	c.entries.Set(key, entry, int64(len(body)), c.ttl)
+
+	return res, nil
+}
+
+// key returns the cache key of the fetch, and whether it can be cached.
+func (c *FetchCache) key(req *http.Request) (string, bool, error) {
+	var body []byte
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if req.Body == nil || req.Body == http.NoBody {
+			return "", false, nil
+		}
+
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return "", false, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !isGraphQLQuery(body) {
+			return "", false, nil
+		}
+	default:
+		return "", false, nil
+	}
+
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.String() + "\n"))
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			h.Write([]byte(name + ": " + value + "\n"))
+		}
+	}
+
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), true, nil
+}
+
+// isGraphQLQuery checks that the body is a GraphQL request holding only query operations.
+func isGraphQLQuery(body []byte) bool {
+	query, err := jsonparser.GetString(body, "query")
+	if err != nil || query == "" {
+		return false
+	}
+
+	document, report := astparser.ParseGraphqlDocumentString(query)
+	if report.HasErrors() {
+		return false
+	}
+
+	for _, operation := range document.OperationDefinitions {
+		if operation.OperationType != ast.OperationTypeQuery {
+			return false
+		}
+	}
+
+	return len(document.OperationDefinitions) > 0
+}
@@ -0,0 +1,70 @@
+package graphengine
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchCache(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"hello":"world"}}`))
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: NewFetchCache(nil, 60)}
+
+	fetch := func(method, path, body string) string {
+		t.Helper()
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req, err := http.NewRequest(method, upstream.URL+path, reader)
+		require.NoError(t, err)
+
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		resBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(resBody)
+	}
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		hits   int32
+	}{
+		{name: "GET", method: http.MethodGet, path: "/countries", hits: 1},
+		{name: "query", method: http.MethodPost, body: `{"query":"{ hello }"}`, hits: 1},
+		{name: "mutation", method: http.MethodPost, body: `{"query":"mutation { hello }"}`, hits: 2},
+		{name: "not GraphQL", method: http.MethodPost, body: `{"name":"x"}`, hits: 2},
+		{name: "error", method: http.MethodGet, path: "/error", hits: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&hits, 0)
+			first := fetch(tc.method, tc.path, tc.body)
+			assert.Equal(t, first, fetch(tc.method, tc.path, tc.body))
+			assert.Equal(t, tc.hits, atomic.LoadInt32(&hits))
+		})
+	}
+}
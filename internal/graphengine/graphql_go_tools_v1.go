@@ -213,7 +213,7 @@ func (g *graphqlRequestProcessorV1) ProcessRequest(ctx context.Context, w http.R
 	}
 
 	if normalizationResult.Errors != nil && normalizationResult.Errors.Count() > 0 {
-		return writeGraphQLError(g.logger, w, normalizationResult.Errors)
+		return writeGraphQLError(g.logger, w, r, normalizationResult.Errors)
 	}
 
 	validationResult, err := gqlRequest.ValidateForSchema(g.schema)
@@ -223,7 +223,7 @@ func (g *graphqlRequestProcessorV1) ProcessRequest(ctx context.Context, w http.R
 	}
 
 	if validationResult.Errors != nil && validationResult.Errors.Count() > 0 {
-		return writeGraphQLError(g.logger, w, validationResult.Errors)
+		return writeGraphQLError(g.logger, w, r, validationResult.Errors)
 	}
 
 	inputValidationResult, err := gqlRequest.ValidateInput(g.schema)
@@ -232,7 +232,7 @@ func (g *graphqlRequestProcessorV1) ProcessRequest(ctx context.Context, w http.R
 		return ProxyingRequestFailedErr, http.StatusInternalServerError
 	}
 	if inputValidationResult.Errors != nil && inputValidationResult.Errors.Count() > 0 {
-		return writeGraphQLError(g.logger, w, inputValidationResult.Errors)
+		return writeGraphQLError(g.logger, w, r, inputValidationResult.Errors)
 	}
 	return nil, http.StatusOK
 }
@@ -259,7 +259,7 @@ func (g *graphqlRequestProcessorWithOTelV1) ProcessRequest(ctx context.Context,
 		g.logger.Error("error while normalizing GraphqlRequest", abstractlogger.Error(err))
 		var reqErr graphql.RequestErrors
 		if errors.As(err, &reqErr) {
-			return writeGraphQLError(g.logger, w, reqErr)
+			return writeGraphQLError(g.logger, w, r, reqErr)
 		}
 		return ProxyingRequestFailedErr, http.StatusInternalServerError
 	}
@@ -270,7 +270,7 @@ func (g *graphqlRequestProcessorWithOTelV1) ProcessRequest(ctx context.Context,
 		g.logger.Error("error while validating GraphQL request", abstractlogger.Error(err))
 		var reqErr graphql.RequestErrors
 		if errors.As(err, &reqErr) {
-			return writeGraphQLError(g.logger, w, reqErr)
+			return writeGraphQLError(g.logger, w, r, reqErr)
 		}
 		return ProxyingRequestFailedErr, http.StatusInternalServerError
 	}
@@ -281,7 +281,7 @@ func (g *graphqlRequestProcessorWithOTelV1) ProcessRequest(ctx context.Context,
 		g.logger.Error("error while validating variables for request", abstractlogger.Error(err))
 		var reqErr graphql.RequestErrors
 		if errors.As(err, &reqErr) {
-			return writeGraphQLError(g.logger, w, reqErr)
+			return writeGraphQLError(g.logger, w, r, reqErr)
 		}
 		return ProxyingRequestFailedErr, http.StatusInternalServerError
 	}
@@ -0,0 +1,303 @@
+package graphengine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/ast"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astprinter"
+
+	"github.com/TykTechnologies/tyk/header"
+)
+
+// Media types of the GraphQL over HTTP, multipart request and incremental delivery specifications.
+const (
+	MediaTypeGraphQLResponse   = "application/graphql-response+json"
+	MediaTypeMultipartMixed    = "multipart/mixed"
+	MediaTypeMultipartFormData = "multipart/form-data"
+)
+
+const (
+	deferDirectiveName  = "defer"
+	streamDirectiveName = "stream"
+)
+
+var (
+	// ErrInvalidHTTPRequest is returned when the GraphQL request can't be read from the HTTP request.
+	ErrInvalidHTTPRequest = errors.New("invalid GraphQL request")
+	// ErrOperationNotAllowedOverGET is returned for GET requests of mutations and subscriptions.
+	ErrOperationNotAllowedOverGET = errors.New("only queries can be sent with GET requests")
+)
+
+// HTTPRequest is a GraphQL request read from an HTTP request.
+type HTTPRequest struct {
+	// Body is the GraphQL request as a JSON document, the files of an upload request are replaced by placeholders
+	// and the @defer and @stream directives are removed from the query.
+	Body []byte
+	// Upload is true for requests of the multipart request specification, uploading files.
+	Upload bool
+	// Incremental is true when the query uses @defer or @stream and the client accepts incremental delivery.
+	Incremental bool
+}
+
+// ReadHTTPRequest reads the GraphQL request from a JSON body, from the query parameters of a GET request or
+// from the operations of a multipart upload request. The body of the HTTP request is consumed.
+func ReadHTTPRequest(r *http.Request) (*HTTPRequest, error) {
+	var (
+		req = &HTTPRequest{}
+		err error
+	)
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get(header.ContentType))
+	switch {
+	case IsQueryParametersRequest(r):
+		if req.Body, err = requestFromQuery(r); err != nil {
+			return nil, err
+		}
+	case mediaType == MediaTypeMultipartFormData:
+		if req.Body, err = requestFromMultipart(r.Body, params["boundary"]); err != nil {
+			return nil, err
+		}
+		req.Upload = true
+	default:
+		if req.Body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	if !bytes.Contains(req.Body, []byte("@"+deferDirectiveName)) && !bytes.Contains(req.Body, []byte("@"+streamDirectiveName)) {
+		return req, nil
+	}
+
+	query, err := jsonparser.GetString(req.Body, "query")
+	if err != nil {
+		// malformed requests are reported by the engine
+		return req, nil
+	}
+
+	query, removed, err := removeIncrementalDelivery(query)
+	if err != nil || !removed {
+		return req, nil
+	}
+
+	rawQuery, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Body, err = jsonparser.Set(req.Body, rawQuery, "query"); err != nil {
+		return nil, err
+	}
+	req.Incremental = acceptsMediaType(r.Header.Get(header.Accept), MediaTypeMultipartMixed)
+
+	return req, nil
+}
+
+// IsQueryParametersRequest returns true for GET requests sending the GraphQL request in the query parameters, the
+// GET requests with a JSON body are read as before.
+func IsQueryParametersRequest(r *http.Request) bool {
+	return r.Method == http.MethodGet && r.URL.Query().Has("query")
+}
+
+// ResponseMediaType returns the media type of the GraphQL responses negotiated from the Accept header,
+// application/graphql-response+json when the client prefers it to application/json.
+func ResponseMediaType(accept string) string {
+	var graphQLResponseQuality, jsonQuality float64 = -1, -1
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case MediaTypeGraphQLResponse:
+			graphQLResponseQuality = quality
+		case header.ApplicationJSON:
+			jsonQuality = quality
+		}
+	}
+
+	if graphQLResponseQuality > 0 && graphQLResponseQuality >= jsonQuality {
+		return MediaTypeGraphQLResponse
+	}
+
+	return header.ApplicationJSON
+}
+
+func acceptsMediaType(accept, mediaType string) bool {
+	for _, accepted := range strings.Split(accept, ",") {
+		if acceptedType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted)); err == nil && acceptedType == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// requestFromQuery reads the request of the query, operationName, variables and extensions query parameters.
+// Only queries can be sent with GET.
+func requestFromQuery(r *http.Request) ([]byte, error) {
+	values := r.URL.Query()
+
+	req := map[string]interface{}{"query": values.Get("query")}
+	if operationName := values.Get("operationName"); operationName != "" {
+		req["operationName"] = operationName
+	}
+
+	for _, name := range []string{"variables", "extensions"} {
+		value := values.Get(name)
+		if value == "" {
+			continue
+		}
+
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("%w: %s is not valid JSON", ErrInvalidHTTPRequest, name)
+		}
+		req[name] = json.RawMessage(value)
+	}
+
+	if query := values.Get("query"); query != "" {
+		operation, report := astparser.ParseGraphqlDocumentString(query)
+		if report.HasErrors() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHTTPRequest, report.Error())
+		}
+
+		ref, err := selectOperation(&operation, values.Get("operationName"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHTTPRequest, err.Error())
+		}
+
+		if operation.OperationDefinitions[ref].OperationType != ast.OperationTypeQuery {
+			return nil, ErrOperationNotAllowedOverGET
+		}
+	}
+
+	return json.Marshal(req)
+}
+
+// requestFromMultipart reads the operations of a multipart request, setting a placeholder in the variables
+// mapped to the files, so the request can be validated. The operations and map fields precede the files, the
+// reading stops after them and the files are left in the body.
+func requestFromMultipart(body io.Reader, boundary string) ([]byte, error) {
+	if boundary == "" {
+		return nil, fmt.Errorf("%w: missing multipart boundary", ErrInvalidHTTPRequest)
+	}
+
+	var operations, fileMap []byte
+	reader := multipart.NewReader(body, boundary)
+	for operations == nil || fileMap == nil {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, fmt.Errorf("%w: the operations and map fields are required", ErrInvalidHTTPRequest)
+		}
+
+		switch part.FormName() {
+		case "operations":
+			operations, err = io.ReadAll(part)
+		case "map":
+			fileMap, err = io.ReadAll(part)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(operations), []byte("[")) {
+		return nil, fmt.Errorf("%w: batched operations are not supported", ErrInvalidHTTPRequest)
+	}
+
+	var paths map[string][]string
+	if err := json.Unmarshal(fileMap, &paths); err != nil {
+		return nil, fmt.Errorf("%w: invalid map field", ErrInvalidHTTPRequest)
+	}
+
+	for file, filePaths := range paths {
+		placeholder, err := json.Marshal(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range filePaths {
+			keys := strings.Split(path, ".")
+			if len(keys) < 2 || keys[0] != "variables" {
+				return nil, fmt.Errorf("%w: invalid file path %s", ErrInvalidHTTPRequest, path)
+			}
+
+			for i, key := range keys {
+				if _, err := strconv.Atoi(key); err == nil {
+					keys[i] = "[" + key + "]"
+				}
+			}
+
+			if operations, err = jsonparser.Set(operations, placeholder, keys...); err != nil {
+				return nil, fmt.Errorf("%w: invalid file path %s", ErrInvalidHTTPRequest, path)
+			}
+		}
+	}
+
+	return operations, nil
+}
+
+// removeIncrementalDelivery removes the @defer and @stream directives of the query, the fields are then
+// delivered in the initial response.
+func removeIncrementalDelivery(query string) (string, bool, error) {
+	operation, report := astparser.ParseGraphqlDocumentString(query)
+	if report.HasErrors() {
+		return "", false, report
+	}
+
+	removed := false
+	remove := func(directives *ast.DirectiveList) {
+		refs := directives.Refs[:0]
+		for _, ref := range directives.Refs {
+			switch operation.DirectiveNameString(ref) {
+			case deferDirectiveName, streamDirectiveName:
+				removed = true
+			default:
+				refs = append(refs, ref)
+			}
+		}
+		directives.Refs = refs
+	}
+
+	for i := range operation.Fields {
+		remove(&operation.Fields[i].Directives)
+		operation.Fields[i].HasDirectives = len(operation.Fields[i].Directives.Refs) > 0
+	}
+	for i := range operation.InlineFragments {
+		remove(&operation.InlineFragments[i].Directives)
+		operation.InlineFragments[i].HasDirectives = len(operation.InlineFragments[i].Directives.Refs) > 0
+	}
+	for i := range operation.FragmentSpreads {
+		remove(&operation.FragmentSpreads[i].Directives)
+		operation.FragmentSpreads[i].HasDirectives = len(operation.FragmentSpreads[i].Directives.Refs) > 0
+	}
+
+	if !removed {
+		return query, false, nil
+	}
+
+	printed, err := astprinter.PrintString(&operation, nil)
+	if err != nil {
+		return "", false, err
+	}
+
+	return printed, true, nil
+}
@@ -0,0 +1,111 @@
+package graphengine
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/header"
+)
+
+func TestReadHTTPRequest(t *testing.T) {
+	t.Run("JSON body", func(t *testing.T) {
+		body := `{"query":"{ hello }"}`
+		req, err := ReadHTTPRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		require.NoError(t, err)
+		assert.JSONEq(t, body, string(req.Body))
+		assert.False(t, req.Upload)
+		assert.False(t, req.Incremental)
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		get := func(values url.Values) (*HTTPRequest, error) {
+			return ReadHTTPRequest(httptest.NewRequest(http.MethodGet, "/?"+values.Encode(), nil))
+		}
+
+		req, err := get(url.Values{
+			"query":         {`query Hello($name: String!) { hello(name: $name) }`},
+			"operationName": {"Hello"},
+			"variables":     {`{"name":"World"}`},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"query":"query Hello($name: String!) { hello(name: $name) }","operationName":"Hello","variables":{"name":"World"}}`, string(req.Body))
+
+		_, err = get(url.Values{"query": {`mutation { hello }`}})
+		assert.ErrorIs(t, err, ErrOperationNotAllowedOverGET)
+
+		_, err = get(url.Values{"query": {`{ hello }`}, "variables": {`{`}})
+		assert.ErrorIs(t, err, ErrInvalidHTTPRequest)
+
+		_, err = get(url.Values{"query": {`query A { hello } query B { hello }`}})
+		assert.ErrorIs(t, err, ErrInvalidHTTPRequest)
+	})
+
+	t.Run("multipart upload", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		require.NoError(t, writer.WriteField("operations", `{"query":"mutation ($file: Upload!, $files: [Upload!]!) { upload(file: $file) uploadMany(files: $files) }","variables":{"file":null,"files":[null,null]}}`))
+		require.NoError(t, writer.WriteField("map", `{"0":["variables.file"],"1":["variables.files.0"],"2":["variables.files.1"]}`))
+		part, err := writer.CreateFormFile("0", "a.txt")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte("content"))
+		require.NoError(t, writer.Close())
+
+		r := httptest.NewRequest(http.MethodPost, "/", &body)
+		r.Header.Set(header.ContentType, writer.FormDataContentType())
+
+		req, err := ReadHTTPRequest(r)
+		require.NoError(t, err)
+		assert.True(t, req.Upload)
+		assert.Contains(t, string(req.Body), `"variables":{"file":"0","files":["1","2"]}`)
+
+		r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("--x--"))
+		r.Header.Set(header.ContentType, "multipart/form-data; boundary=x")
+		_, err = ReadHTTPRequest(r)
+		assert.ErrorIs(t, err, ErrInvalidHTTPRequest)
+	})
+
+	t.Run("incremental delivery", func(t *testing.T) {
+		body := `{"query":"{ me { name ... @defer(label: \"friends\") { friends @stream(initialCount: 1) } } }"}`
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set(header.Accept, "multipart/mixed;deferSpec=20220824, application/json")
+		req, err := ReadHTTPRequest(r)
+		require.NoError(t, err)
+		assert.True(t, req.Incremental)
+		assert.NotContains(t, string(req.Body), "@defer")
+		assert.NotContains(t, string(req.Body), "@stream")
+
+		req, err = ReadHTTPRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		require.NoError(t, err)
+		assert.False(t, req.Incremental)
+		assert.NotContains(t, string(req.Body), "@defer")
+	})
+}
+
+func TestResponseMediaType(t *testing.T) {
+	testCases := []struct {
+		accept    string
+		mediaType string
+	}{
+		{accept: "", mediaType: header.ApplicationJSON},
+		{accept: "*/*", mediaType: header.ApplicationJSON},
+		{accept: "application/json", mediaType: header.ApplicationJSON},
+		{accept: "application/graphql-response+json", mediaType: MediaTypeGraphQLResponse},
+		{accept: "application/graphql-response+json, application/json;q=0.9", mediaType: MediaTypeGraphQLResponse},
+		{accept: "application/graphql-response+json;q=0.5, application/json", mediaType: header.ApplicationJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.accept, func(t *testing.T) {
+			assert.Equal(t, tc.mediaType, ResponseMediaType(tc.accept))
+		})
+	}
+}
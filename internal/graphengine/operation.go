@@ -0,0 +1,74 @@
+package graphengine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/ast"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astnormalization"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/asttransform"
+)
+
+var errOperationNotFound = errors.New("operation not found")
+
+// parseDefinition parses and normalizes the schema, for the static analysis of the operations.
+func parseDefinition(schema string) (*ast.Document, error) {
+	definition, report := astparser.ParseGraphqlDocumentString(schema)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	if err := asttransform.MergeDefinitionWithBaseSchema(&definition); err != nil {
+		return nil, err
+	}
+
+	astnormalization.NormalizeDefinition(&definition, &report)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	return &definition, nil
+}
+
+// selectOperation returns the operation definition with the name, the name can be omitted when the document holds
+// a single operation.
+func selectOperation(operation *ast.Document, operationName string) (int, error) {
+	ref := -1
+	for i := range operation.RootNodes {
+		if operation.RootNodes[i].Kind != ast.NodeKindOperationDefinition {
+			continue
+		}
+
+		nodeRef := operation.RootNodes[i].Ref
+		if operationName == "" {
+			if ref != -1 {
+				return 0, fmt.Errorf("%w: operation name is required", errOperationNotFound)
+			}
+			ref = nodeRef
+			continue
+		}
+
+		if operation.OperationDefinitionNameString(nodeRef) == operationName {
+			return nodeRef, nil
+		}
+	}
+
+	if ref == -1 {
+		return 0, errOperationNotFound
+	}
+
+	return ref, nil
+}
+
+// rootTypeName returns the name of the root type of the operation definition.
+func rootTypeName(definition, operation *ast.Document, operationRef int) string {
+	switch operation.OperationDefinitions[operationRef].OperationType {
+	case ast.OperationTypeMutation:
+		return string(definition.Index.MutationTypeName)
+	case ast.OperationTypeSubscription:
+		return string(definition.Index.SubscriptionTypeName)
+	default:
+		return string(definition.Index.QueryTypeName)
+	}
+}
@@ -44,7 +44,10 @@ func (g *GraphQLEngineTransport) RoundTrip(request *http.Request) (res *http.Res
 }
 
 func (g *GraphQLEngineTransport) handleProxyOnly(proxyOnlyValues *GraphQLProxyOnlyContextValues, request *http.Request) (*http.Response, error) {
-	request.Method = proxyOnlyValues.forwardedRequest.Method
+	// the engine sends the request in the body, the requests read from the query parameters are sent as POST
+	if !IsQueryParametersRequest(proxyOnlyValues.forwardedRequest) {
+		request.Method = proxyOnlyValues.forwardedRequest.Method
+	}
 	g.setProxyOnlyHeaders(proxyOnlyValues, request)
 	g.applyRequestHeadersRewriteRules(request)
 
@@ -52,8 +52,8 @@ func absLoggerLevel(level logrus.Level) abstractlogger.Level {
 	return abstractlogger.InfoLevel
 }
 
-func writeGraphQLError(logger abstractlogger.Logger, w http.ResponseWriter, errors graphql.Errors) (error, int) {
-	w.Header().Set(header.ContentType, header.ApplicationJSON)
+func writeGraphQLError(logger abstractlogger.Logger, w http.ResponseWriter, r *http.Request, errors graphql.Errors) (error, int) {
+	w.Header().Set(header.ContentType, ResponseMediaType(r.Header.Get(header.Accept)))
 	w.WriteHeader(http.StatusBadRequest)
 	_, _ = errors.WriteResponse(w)
 	logger.Error("error while validating GraphQL request", abstractlogger.Error(errors))
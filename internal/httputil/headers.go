@@ -3,6 +3,7 @@ package httputil
 import (
 	"encoding/base64"
 	"fmt"
+	"mime"
 	"strings"
 )
 
@@ -24,3 +25,24 @@ func AuthHeader(username, password string) string {
 	encodedPass := base64.StdEncoding.EncodeToString([]byte(toEncode))
 	return fmt.Sprintf("Basic %s", encodedPass)
 }
+
+// MatchMediaType returns true if the media type of the content type matches one of the patterns. A pattern is a
+// media type, and may end with a `/*` wildcard.
+func MatchMediaType(contentType string, patterns []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType || pattern == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
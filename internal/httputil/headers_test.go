@@ -0,0 +1,17 @@
+package httputil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchMediaType(t *testing.T) {
+	patterns := []string{"application/json", "text/*"}
+
+	assert.True(t, MatchMediaType("application/json; charset=utf-8", patterns))
+	assert.True(t, MatchMediaType("text/html", patterns))
+	assert.False(t, MatchMediaType("application/xml", patterns))
+	assert.False(t, MatchMediaType("", patterns))
+	assert.True(t, MatchMediaType("image/png", []string{"*/*"}))
+}
@@ -2,6 +2,8 @@ package httputil
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // EntityTooLarge responds with HTTP 413 Request Entity Too Large.
@@ -32,3 +34,33 @@ func RemoveResponseTransferEncoding(response *http.Response, victim string) {
 		}
 	}
 }
+
+// MatchStatusCode returns true if the status code matches one of the patterns: a code such as `404`, a class such
+// as `5xx`, or an inclusive range such as `500-504`. Invalid patterns don't match.
+func MatchStatusCode(code int, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+		if len(pattern) == 3 && strings.HasSuffix(pattern, "xx") {
+			if class, err := strconv.Atoi(pattern[:1]); err == nil && code/100 == class {
+				return true
+			}
+			continue
+		}
+
+		if from, to, ok := strings.Cut(pattern, "-"); ok {
+			low, errLow := strconv.Atoi(strings.TrimSpace(from))
+			high, errHigh := strconv.Atoi(strings.TrimSpace(to))
+			if errLow == nil && errHigh == nil && code >= low && code <= high {
+				return true
+			}
+			continue
+		}
+
+		if value, err := strconv.Atoi(pattern); err == nil && code == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -70,3 +70,14 @@ func TestRemoveResponseTransferEncoding(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchStatusCode(t *testing.T) {
+	patterns := []string{"404", "5xx", "300-302"}
+
+	assert.True(t, MatchStatusCode(http.StatusNotFound, patterns))
+	assert.True(t, MatchStatusCode(http.StatusBadGateway, patterns))
+	assert.True(t, MatchStatusCode(http.StatusFound, patterns))
+	assert.False(t, MatchStatusCode(http.StatusOK, patterns))
+	assert.False(t, MatchStatusCode(http.StatusNotModified, patterns))
+	assert.False(t, MatchStatusCode(http.StatusOK, []string{"invalid", "xxx", "2-"}))
+}
@@ -0,0 +1,215 @@
+// Package persistedquery implements automatic persisted queries and the operation safelist of GraphQL APIs.
+// Operations are identified by the hex encoded SHA-256 hash of their query, as in the persistedQuery extension
+// of Apollo clients.
+package persistedquery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+// KeyPrefix is the storage key prefix of the persisted queries.
+const KeyPrefix = "graphql-persisted-query."
+
+var (
+	// ErrNotFound is returned when the hash of a request is not persisted, the client is expected to retry
+	// with the full query.
+	ErrNotFound = errors.New("PersistedQueryNotFound")
+	// ErrHashMismatch is returned when the hash of a request doesn't match its query.
+	ErrHashMismatch = errors.New("provided sha does not match query")
+	// ErrNotSafelisted is returned when the operation of a request is not in the safelist.
+	ErrNotSafelisted = errors.New("operation is not safelisted")
+	// ErrInvalidOperation is returned when an operation registered in the safelist is not a valid GraphQL document.
+	ErrInvalidOperation = errors.New("invalid GraphQL operation")
+)
+
+// Extension is the persistedQuery extension of a GraphQL request.
+type Extension struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// Request holds the fields of a GraphQL request used by persisted queries.
+type Request struct {
+	Query      string `json:"query"`
+	Extensions struct {
+		PersistedQuery *Extension `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// Hash returns the hash identifying the query.
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Operation is an operation of the safelist.
+type Operation struct {
+	Hash  string `json:"hash"`
+	Name  string `json:"name,omitempty"`
+	Query string `json:"query"`
+}
+
+// Config configures how the queries of the requests are resolved.
+type Config struct {
+	// Automatic persists the queries sent along with their hash.
+	Automatic bool
+	// TTL is the lifetime in seconds of the automatically persisted queries.
+	TTL int64
+	// Safelist only allows the operations of the safelist.
+	Safelist bool
+}
+
+// Store keeps the automatically persisted queries and the safelist of an API.
+type Store struct {
+	handler storage.Handler
+	apiID   string
+}
+
+// NewStore returns the store of the API.
+func NewStore(handler storage.Handler, apiID string) *Store {
+	return &Store{handler: handler, apiID: apiID}
+}
+
+func (s *Store) automaticKey(hash string) string {
+	return s.apiID + ".apq." + hash
+}
+
+func (s *Store) safelistKey(hash string) string {
+	return s.apiID + ".safelist." + hash
+}
+
+// Resolve returns the query of the request. A request with a hash and no query is resolved from the safelist,
+// then from the automatically persisted queries. A request with both is persisted when automatic persisted
+// queries are enabled. With the safelist enabled, only the safelisted operations are resolved.
+func (s *Store) Resolve(req Request, conf Config) (string, error) {
+	ext := req.Extensions.PersistedQuery
+	if ext == nil || ext.Sha256Hash == "" {
+		if conf.Safelist && !s.safelisted(Hash(req.Query)) {
+			return "", ErrNotSafelisted
+		}
+		return req.Query, nil
+	}
+
+	hash := strings.ToLower(ext.Sha256Hash)
+	if req.Query != "" {
+		if Hash(req.Query) != hash {
+			return "", ErrHashMismatch
+		}
+
+		if conf.Safelist {
+			if !s.safelisted(hash) {
+				return "", ErrNotSafelisted
+			}
+			return req.Query, nil
+		}
+
+		if conf.Automatic {
+			if err := s.handler.SetKey(s.automaticKey(hash), req.Query, conf.TTL); err != nil {
+				return "", err
+			}
+		}
+		return req.Query, nil
+	}
+
+	if op, err := s.Operation(hash); err == nil {
+		return op.Query, nil
+	}
+
+	if conf.Safelist {
+		return "", ErrNotSafelisted
+	}
+
+	if conf.Automatic {
+		if query, err := s.handler.GetKey(s.automaticKey(hash)); err == nil {
+			return query, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+func (s *Store) safelisted(hash string) bool {
+	exists, err := s.handler.Exists(s.safelistKey(hash))
+	return err == nil && exists
+}
+
+// Operation returns the operation of the safelist with the hash.
+func (s *Store) Operation(hash string) (*Operation, error) {
+	value, err := s.handler.GetKey(s.safelistKey(strings.ToLower(hash)))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var op Operation
+	if err := json.Unmarshal([]byte(value), &op); err != nil {
+		return nil, err
+	}
+
+	return &op, nil
+}
+
+// Operations returns the operations of the safelist, sorted by hash.
+func (s *Store) Operations() ([]Operation, error) {
+	prefix := s.safelistKey("")
+
+	keys := s.handler.GetKeys(prefix)
+	ops := make([]Operation, 0, len(keys))
+	for _, key := range keys {
+		op, err := s.Operation(strings.TrimPrefix(key, prefix))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, *op)
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Hash < ops[j].Hash
+	})
+
+	return ops, nil
+}
+
+// Register adds the operation to the safelist. The hash is computed from the query, a given hash must match it.
+func (s *Store) Register(op Operation) (*Operation, error) {
+	if strings.TrimSpace(op.Query) == "" {
+		return nil, ErrInvalidOperation
+	}
+
+	if _, report := astparser.ParseGraphqlDocumentString(op.Query); report.HasErrors() {
+		return nil, ErrInvalidOperation
+	}
+
+	hash := Hash(op.Query)
+	if op.Hash != "" && strings.ToLower(op.Hash) != hash {
+		return nil, ErrHashMismatch
+	}
+	op.Hash = hash
+
+	value, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.handler.SetKey(s.safelistKey(hash), string(value), 0); err != nil {
+		return nil, err
+	}
+
+	return &op, nil
+}
+
+// Unregister removes the operation with the hash from the safelist.
+func (s *Store) Unregister(hash string) bool {
+	return s.handler.DeleteKey(s.safelistKey(strings.ToLower(hash)))
+}
@@ -0,0 +1,98 @@
+package persistedquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/internal/persistedquery"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+const query = `{ hello }`
+
+func newRequest(query, hash string) persistedquery.Request {
+	var req persistedquery.Request
+	req.Query = query
+	if hash != "" {
+		req.Extensions.PersistedQuery = &persistedquery.Extension{Version: 1, Sha256Hash: hash}
+	}
+	return req
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "ecf4edb46db40b5132295c0291d62fb65d6759a9eedfa4d5d612dd5ec54a6b38", persistedquery.Hash("{__typename}"))
+}
+
+func TestStore_ResolveAutomatic(t *testing.T) {
+	store := persistedquery.NewStore(storage.NewDummyStorage(), "api")
+	conf := persistedquery.Config{Automatic: true}
+	hash := persistedquery.Hash(query)
+
+	_, err := store.Resolve(newRequest("", hash), conf)
+	assert.ErrorIs(t, err, persistedquery.ErrNotFound)
+
+	_, err = store.Resolve(newRequest(query, persistedquery.Hash("other")), conf)
+	assert.ErrorIs(t, err, persistedquery.ErrHashMismatch)
+
+	resolved, err := store.Resolve(newRequest(query, hash), conf)
+	require.NoError(t, err)
+	assert.Equal(t, query, resolved)
+
+	resolved, err = store.Resolve(newRequest("", hash), conf)
+	require.NoError(t, err)
+	assert.Equal(t, query, resolved)
+
+	_, err = store.Resolve(newRequest("", hash), persistedquery.Config{})
+	assert.ErrorIs(t, err, persistedquery.ErrNotFound, "automatically persisted queries are only resolved when enabled")
+
+	resolved, err = store.Resolve(newRequest(`{ other }`, ""), conf)
+	require.NoError(t, err)
+	assert.Equal(t, `{ other }`, resolved, "queries without hash are left as is")
+}
+
+func TestStore_ResolveSafelist(t *testing.T) {
+	store := persistedquery.NewStore(storage.NewDummyStorage(), "api")
+	conf := persistedquery.Config{Automatic: true, Safelist: true}
+
+	op, err := store.Register(persistedquery.Operation{Name: "Hello", Query: query})
+	require.NoError(t, err)
+	assert.Equal(t, persistedquery.Hash(query), op.Hash)
+
+	for _, req := range []persistedquery.Request{newRequest(query, ""), newRequest(query, op.Hash), newRequest("", op.Hash)} {
+		resolved, err := store.Resolve(req, conf)
+		require.NoError(t, err)
+		assert.Equal(t, query, resolved)
+	}
+
+	_, err = store.Resolve(newRequest(`{ other }`, ""), conf)
+	assert.ErrorIs(t, err, persistedquery.ErrNotSafelisted)
+
+	_, err = store.Resolve(newRequest(`{ other }`, persistedquery.Hash(`{ other }`)), conf)
+	assert.ErrorIs(t, err, persistedquery.ErrNotSafelisted)
+
+	_, err = store.Resolve(newRequest("", persistedquery.Hash(`{ other }`)), conf)
+	assert.ErrorIs(t, err, persistedquery.ErrNotSafelisted, "queries can't be persisted automatically with the safelist")
+
+	assert.True(t, store.Unregister(op.Hash))
+	_, err = store.Resolve(newRequest(query, ""), conf)
+	assert.ErrorIs(t, err, persistedquery.ErrNotSafelisted)
+}
+
+func TestStore_Register(t *testing.T) {
+	store := persistedquery.NewStore(storage.NewDummyStorage(), "api")
+
+	_, err := store.Register(persistedquery.Operation{Query: " "})
+	assert.ErrorIs(t, err, persistedquery.ErrInvalidOperation)
+
+	_, err = store.Register(persistedquery.Operation{Query: `{ hello `})
+	assert.ErrorIs(t, err, persistedquery.ErrInvalidOperation)
+
+	_, err = store.Register(persistedquery.Operation{Query: query, Hash: persistedquery.Hash("other")})
+	assert.ErrorIs(t, err, persistedquery.ErrHashMismatch)
+
+	op, err := store.Operation(persistedquery.Hash(query))
+	assert.ErrorIs(t, err, persistedquery.ErrNotFound)
+	assert.Nil(t, op)
+}
@@ -60,6 +60,7 @@ func (t *Service) ClearSession(session *user.SessionState) error {
 
 		if policy.Partitions.Complexity || all {
 			session.MaxQueryDepth = 0
+			session.MaxQueryCost = 0
 		}
 	}
 
@@ -206,6 +207,7 @@ func (t *Service) Apply(session *user.SessionState) error {
 
 		if !applyState.didComplexity[k] {
 			v.Limit.MaxQueryDepth = session.MaxQueryDepth
+			v.Limit.MaxQueryCost = session.MaxQueryCost
 		}
 
 		if !applyState.didQuota[k] {
@@ -312,6 +314,10 @@ func (t *Service) applyPerAPI(policy user.Policy, session *user.SessionState, ri
 			if r.DisableIntrospection {
 				accessRights.DisableIntrospection = r.DisableIntrospection
 			}
+			// If the GQL operation safelist is enforced, keep that configuration.
+			if r.EnforceOperationSafelist {
+				accessRights.EnforceOperationSafelist = r.EnforceOperationSafelist
+			}
 		}
 
 		if currAD, ok := rights[apiID]; ok {
@@ -367,6 +373,10 @@ func (t *Service) applyPartitions(policy user.Policy, session *user.SessionState
 				if v.DisableIntrospection {
 					r.DisableIntrospection = v.DisableIntrospection
 				}
+				// If the GQL operation safelist is enforced, keep that configuration.
+				if v.EnforceOperationSafelist {
+					r.EnforceOperationSafelist = v.EnforceOperationSafelist
+				}
 				r.Versions = appendIfMissing(rights[k].Versions, v.Versions...)
 
 				r.AllowedURLs = MergeAllowedURLs(r.AllowedURLs, v.AllowedURLs)
@@ -476,6 +486,13 @@ func (t *Service) applyPartitions(policy user.Policy, session *user.SessionState
 					session.MaxQueryDepth = policy.MaxQueryDepth
 				}
 			}
+
+			if greaterThanInt(policy.MaxQueryCost, ar.Limit.MaxQueryCost) {
+				ar.Limit.MaxQueryCost = policy.MaxQueryCost
+				if greaterThanInt(policy.MaxQueryCost, session.MaxQueryCost) {
+					session.MaxQueryCost = policy.MaxQueryCost
+				}
+			}
 		}
 
 		// Respect existing QuotaRenews
@@ -498,6 +515,7 @@ func (t *Service) applyPartitions(policy user.Policy, session *user.SessionState
 
 		if !usePartitions || policy.Partitions.Complexity {
 			session.MaxQueryDepth = policy.MaxQueryDepth
+			session.MaxQueryCost = policy.MaxQueryCost
 		}
 
 		if !usePartitions || policy.Partitions.Quota {
@@ -536,6 +554,7 @@ func (t *Service) updateSessionRootVars(session *user.SessionState, rights map[s
 
 			if len(applyState.didComplexity) == 1 {
 				session.MaxQueryDepth = v.Limit.MaxQueryDepth
+				session.MaxQueryCost = v.Limit.MaxQueryCost
 			}
 		}
 	}
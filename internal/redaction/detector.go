@@ -0,0 +1,70 @@
+package redaction
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Built-in detectors of sensitive values.
+const (
+	DetectorCardNumber = "card_number"
+	DetectorEmail      = "email"
+	DetectorSSN        = "ssn"
+	DetectorIBAN       = "iban"
+)
+
+// matcher matches sensitive values in text, the matches are checked by valid if it's set.
+type matcher struct {
+	re    *regexp.Regexp
+	valid func(string) bool
+}
+
+var detectors = map[string]matcher{
+	DetectorCardNumber: {re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: validLuhn},
+	DetectorEmail:      {re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+	DetectorSSN:        {re: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	DetectorIBAN:       {re: regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`), valid: validIBAN},
+}
+
+// validLuhn checks the Luhn checksum of a card number, separators are ignored.
+func validLuhn(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// validIBAN checks the ISO 7064 mod 97-10 checksum of an IBAN, spaces are ignored.
+func validIBAN(iban string) bool {
+	iban = strings.ReplaceAll(iban, " ", "")
+	rearranged := iban[4:] + iban[:4]
+
+	var digits strings.Builder
+	for _, c := range rearranged {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(c - 'A' + 10)))
+			continue
+		}
+		digits.WriteRune(c)
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
@@ -0,0 +1,278 @@
+// Package redaction masks, hashes or removes sensitive data from request and response bodies.
+package redaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+const defaultMaskCharacter = "*"
+
+// Rule is a compiled redaction rule.
+type Rule struct {
+	conf      apidef.RedactionRule
+	selectors []selector
+	matchers  []matcher
+	action    apidef.RedactionAction
+	mask      string
+}
+
+// Compile compiles the redaction rule, it fails if a selector, detector or pattern is invalid.
+func Compile(conf apidef.RedactionRule) (*Rule, error) {
+	r := &Rule{
+		conf:   conf,
+		action: conf.Action,
+		mask:   conf.MaskCharacter,
+	}
+
+	switch r.action {
+	case "":
+		r.action = apidef.RedactionMask
+	case apidef.RedactionMask, apidef.RedactionHash, apidef.RedactionRemove:
+	default:
+		return nil, fmt.Errorf("unsupported redaction action %q", r.action)
+	}
+
+	if r.mask == "" {
+		r.mask = defaultMaskCharacter
+	}
+
+	for _, expr := range conf.JSONPath {
+		sel, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		r.selectors = append(r.selectors, sel)
+	}
+
+	for _, expr := range conf.JMESPath {
+		sel, err := parseJMESPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		r.selectors = append(r.selectors, sel)
+	}
+
+	for _, name := range conf.Detectors {
+		m, ok := detectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction detector %q", name)
+		}
+		r.matchers = append(r.matchers, m)
+	}
+
+	for _, pattern := range conf.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.matchers = append(r.matchers, matcher{re: re})
+	}
+
+	if len(r.selectors) == 0 && len(r.matchers) == 0 {
+		return nil, fmt.Errorf("redaction rule %q has no selector, detector or pattern", conf.Name)
+	}
+
+	return r, nil
+}
+
+// Name returns the name of the rule.
+func (r *Rule) Name() string {
+	return r.conf.Name
+}
+
+// AppliesTo returns true if the rule applies to the target.
+func (r *Rule) AppliesTo(target apidef.RedactionTarget) bool {
+	if len(r.conf.Targets) == 0 {
+		return true
+	}
+
+	for _, t := range r.conf.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesToSession returns true if the rule applies to a session having the policies and tags.
+// Rules without policies and tags apply to all the requests, even without session.
+func (r *Rule) AppliesToSession(policies, tags []string) bool {
+	if len(r.conf.Policies) == 0 && len(r.conf.Tags) == 0 {
+		return true
+	}
+
+	return intersects(r.conf.Policies, policies) || intersects(r.conf.Tags, tags)
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ForTarget returns the rules applying to the target.
+func ForTarget(rules []*Rule, target apidef.RedactionTarget) []*Rule {
+	var filtered []*Rule
+	for _, r := range rules {
+		if r.AppliesTo(target) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// Redact redacts the body with the rules, it returns the redacted body and true if it was changed.
+//
+// JSON bodies are redacted value by value and re-encoded, other bodies are redacted as text by the detectors
+// and patterns of the rules.
+func Redact(body []byte, rules []*Rule) ([]byte, bool) {
+	if len(rules) == 0 || len(bytes.TrimSpace(body)) == 0 {
+		return body, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return redactText(body, rules)
+	}
+
+	changed := false
+	for _, r := range rules {
+		var ok bool
+		doc, ok = r.redactDocument(doc)
+		changed = changed || ok
+	}
+
+	if !changed {
+		return body, false
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return body, false
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), true
+}
+
+func redactText(body []byte, rules []*Rule) ([]byte, bool) {
+	text := string(body)
+	changed := false
+	for _, r := range rules {
+		var ok bool
+		text, ok = r.redactString(text)
+		changed = changed || ok
+	}
+
+	if !changed {
+		return body, false
+	}
+	return []byte(text), true
+}
+
+// redactDocument redacts a decoded JSON document, it returns the document and true if it was changed.
+func (r *Rule) redactDocument(doc interface{}) (interface{}, bool) {
+	changed := false
+
+	if len(r.selectors) == 0 {
+		return r.redactStrings(doc, &changed), changed
+	}
+
+	for _, sel := range r.selectors {
+		doc = sel.apply(doc, func(value interface{}) (interface{}, bool) {
+			if len(r.matchers) > 0 {
+				return r.redactStrings(value, &changed), false
+			}
+
+			changed = true
+			if r.action == apidef.RedactionRemove {
+				return nil, true
+			}
+			return r.replace(text(value)), false
+		})
+	}
+
+	return doc, changed
+}
+
+// redactStrings redacts the string values within value with the detectors and patterns.
+func (r *Rule) redactStrings(value interface{}, changed *bool) interface{} {
+	switch value := value.(type) {
+	case string:
+		redacted, ok := r.redactString(value)
+		*changed = *changed || ok
+		return redacted
+	case map[string]interface{}, []interface{}:
+		return eachChild(value, func(child interface{}) (interface{}, bool) {
+			return r.redactStrings(child, changed), false
+		})
+	default:
+		return value
+	}
+}
+
+// redactString redacts the matches of the detectors and patterns in s.
+func (r *Rule) redactString(s string) (string, bool) {
+	changed := false
+	for _, m := range r.matchers {
+		s = m.re.ReplaceAllStringFunc(s, func(match string) string {
+			if m.valid != nil && !m.valid(match) {
+				return match
+			}
+
+			changed = true
+			if r.action == apidef.RedactionRemove {
+				return ""
+			}
+			return r.replace(match)
+		})
+	}
+
+	return s, changed
+}
+
+// replace returns the masked or hashed value.
+func (r *Rule) replace(value string) string {
+	if r.action == apidef.RedactionHash {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+
+	runes := []rune(value)
+	masked := len(runes) - r.conf.KeepLast
+	if masked < 0 {
+		masked = 0
+	}
+
+	return strings.Repeat(r.mask, masked) + string(runes[masked:])
+}
+
+// text returns the text of a JSON value, strings are returned as they are and other values are JSON encoded.
+func text(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	default:
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+}
@@ -0,0 +1,137 @@
+package redaction_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/redaction"
+)
+
+const testBody = `{"user":{"email":"jane@example.com","ssn":"123-45-6789","cards":[{"number":"4111 1111 1111 1111"},{"number":"5500005555555559"}]},"note":"contact jane@example.com","id":7}`
+
+func redact(t *testing.T, body string, rules ...apidef.RedactionRule) string {
+	t.Helper()
+
+	compiled := make([]*redaction.Rule, 0, len(rules))
+	for _, rule := range rules {
+		r, err := redaction.Compile(rule)
+		require.NoError(t, err)
+		compiled = append(compiled, r)
+	}
+
+	redacted, _ := redaction.Redact([]byte(body), compiled)
+	return string(redacted)
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	t.Run("JSONPath selectors", func(t *testing.T) {
+		t.Parallel()
+
+		assert.JSONEq(t,
+			`{"user":{"email":"****************","ssn":"123-45-6789","cards":[{"number":"***************1111"},{"number":"************5559"}]},"note":"contact jane@example.com","id":7}`,
+			redact(t, testBody, apidef.RedactionRule{JSONPath: []string{"$.user.email"}},
+				apidef.RedactionRule{JSONPath: []string{"$.user.cards[*].number"}, KeepLast: 4}))
+
+		assert.JSONEq(t,
+			`{"user":{"ssn":"123-45-6789","cards":[{"number":"4111 1111 1111 1111"},{"number":"5500005555555559"}]},"note":"contact jane@example.com"}`,
+			redact(t, testBody, apidef.RedactionRule{JSONPath: []string{"$..email", "$['id']"}, Action: apidef.RedactionRemove}))
+
+		assert.JSONEq(t,
+			`{"user":{"email":"jane@example.com","ssn":"123-45-6789","cards":[{"number":"4111 1111 1111 1111"}]},"note":"contact jane@example.com","id":7}`,
+			redact(t, testBody, apidef.RedactionRule{JSONPath: []string{"$.user.cards[-1]"}, Action: apidef.RedactionRemove}))
+	})
+
+	t.Run("JMESPath selectors", func(t *testing.T) {
+		t.Parallel()
+
+		assert.JSONEq(t,
+			`{"user":{"email":"jane@example.com","ssn":"01a54629efb952287e554eb23ef69c52097a75aecc0e3a93ca0855ab6d7a31a0","cards":[{"number":"4111 1111 1111 1111"},{"number":"5500005555555559"}]},"note":"contact jane@example.com","id":"#"}`,
+			redact(t, testBody, apidef.RedactionRule{JMESPath: []string{"user.ssn"}, Action: apidef.RedactionHash},
+				apidef.RedactionRule{JMESPath: []string{`"id"`}, MaskCharacter: "#"},
+				apidef.RedactionRule{JMESPath: []string{"missing.field", "user.cards[5]"}}))
+
+		assert.JSONEq(t,
+			`{"user":{"email":"jane@example.com","ssn":"123-45-6789","cards":[{},{}]},"note":"contact jane@example.com","id":7}`,
+			redact(t, testBody, apidef.RedactionRule{JMESPath: []string{"user.cards[].number"}, Action: apidef.RedactionRemove}))
+	})
+
+	t.Run("detectors and patterns", func(t *testing.T) {
+		t.Parallel()
+
+		assert.JSONEq(t,
+			`{"user":{"email":"****************","ssn":"***********","cards":[{"number":"*******************"},{"number":"****************"}]},"note":"contact ****************","id":7}`,
+			redact(t, testBody, apidef.RedactionRule{Detectors: []string{"email", "ssn", "card_number"}}))
+
+		// detectors apply within the selected values only
+		assert.JSONEq(t,
+			`{"user":{"email":"jane@example.com","ssn":"123-45-6789","cards":[{"number":"4111 1111 1111 1111"},{"number":"5500005555555559"}]},"note":"contact ","id":7}`,
+			redact(t, testBody, apidef.RedactionRule{JSONPath: []string{"$.note"}, Patterns: []string{`\S+@\S+`}, Action: apidef.RedactionRemove}))
+
+		// invalid checksums aren't redacted
+		assert.Equal(t, `{"card":"4111 1111 1111 1112","iban":"***********************4 32"}`,
+			redact(t, `{"card":"4111 1111 1111 1112","iban":"GB82 WEST 1234 5698 7654 32"}`,
+				apidef.RedactionRule{Detectors: []string{"card_number", "iban"}, KeepLast: 4}))
+
+		assert.Equal(t, "name=jane&email=****************",
+			redact(t, "name=jane&email=jane@example.com", apidef.RedactionRule{Detectors: []string{"email"}}))
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		body := `{ "a": "b" }`
+		assert.Equal(t, body, redact(t, body, apidef.RedactionRule{Detectors: []string{"email"}}))
+		assert.Equal(t, "", redact(t, "", apidef.RedactionRule{Detectors: []string{"email"}}))
+	})
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	invalid := []apidef.RedactionRule{
+		{},
+		{JSONPath: []string{"user.email"}},
+		{JSONPath: []string{"$.user..", "$.a"}},
+		{JSONPath: []string{"$.cards[?(@.number)]"}},
+		{JMESPath: []string{"user."}},
+		{JMESPath: []string{"users[?active]"}},
+		{Detectors: []string{"phone"}},
+		{Patterns: []string{"("}},
+		{Detectors: []string{"email"}, Action: "encrypt"},
+	}
+
+	for _, rule := range invalid {
+		_, err := redaction.Compile(rule)
+		assert.Error(t, err, "%+v", rule)
+	}
+}
+
+func TestRule_Applies(t *testing.T) {
+	t.Parallel()
+
+	rule, err := redaction.Compile(apidef.RedactionRule{
+		Detectors: []string{"email"},
+		Targets:   []apidef.RedactionTarget{apidef.RedactionTargetResponse},
+		Policies:  []string{"partners"},
+		Tags:      []string{"external"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, rule.AppliesTo(apidef.RedactionTargetResponse))
+	assert.False(t, rule.AppliesTo(apidef.RedactionTargetAnalytics))
+	assert.Len(t, redaction.ForTarget([]*redaction.Rule{rule}, apidef.RedactionTargetRequest), 0)
+
+	assert.True(t, rule.AppliesToSession([]string{"partners"}, nil))
+	assert.True(t, rule.AppliesToSession(nil, []string{"external"}))
+	assert.False(t, rule.AppliesToSession([]string{"internal"}, []string{"staff"}))
+
+	unconditional, err := redaction.Compile(apidef.RedactionRule{Detectors: []string{"email"}})
+	require.NoError(t, err)
+	assert.True(t, unconditional.AppliesToSession(nil, nil))
+	assert.True(t, unconditional.AppliesTo(apidef.RedactionTargetAnalytics))
+}
@@ -0,0 +1,304 @@
+package redaction
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	// stepField selects a field of an object.
+	stepField stepKind = iota
+	// stepIndex selects an item of an array, negative indexes count from the end.
+	stepIndex
+	// stepWildcard selects all the fields of an object or items of an array.
+	stepWildcard
+	// stepDescendant applies the next steps to the value and all its descendants.
+	stepDescendant
+)
+
+type step struct {
+	kind  stepKind
+	name  string
+	index int
+}
+
+// selector is a compiled JSONPath or JMESPath selector.
+type selector []step
+
+// visitFunc is called with the selected values, it returns the value replacing the selected one,
+// or true to remove it.
+type visitFunc func(value interface{}) (interface{}, bool)
+
+var errEmptyName = errors.New("empty field name")
+
+// parseJSONPath parses a JSONPath selector, supporting the `.name`, `['name']`, `[index]`, `*`, `[*]` and `..`
+// (recursive descent) segments.
+func parseJSONPath(expr string) (selector, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", expr)
+	}
+
+	var sel selector
+	for i := 1; i < len(expr); {
+		switch {
+		case strings.HasPrefix(expr[i:], ".."):
+			sel = append(sel, step{kind: stepDescendant})
+			i += 2
+			if i < len(expr) && expr[i] == '[' {
+				continue
+			}
+
+			name, n := readJSONPathName(expr[i:])
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q: %w", expr, errEmptyName)
+			}
+			sel = append(sel, nameStep(name))
+			i += n
+		case expr[i] == '.':
+			i++
+			name, n := readJSONPathName(expr[i:])
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q: %w", expr, errEmptyName)
+			}
+			sel = append(sel, nameStep(name))
+			i += n
+		case expr[i] == '[':
+			st, n, err := readBracket(expr[i:], '\'', '"')
+			if err != nil {
+				return nil, fmt.Errorf("JSONPath %q: %w", expr, err)
+			}
+			sel = append(sel, st)
+			i += n
+		default:
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q at %d", expr, expr[i], i)
+		}
+	}
+
+	return sel, nil
+}
+
+func readJSONPathName(expr string) (string, int) {
+	n := strings.IndexAny(expr, ".[")
+	if n < 0 {
+		n = len(expr)
+	}
+	return expr[:n], n
+}
+
+// parseJMESPath parses a JMESPath selector, supporting the identifier, quoted identifier, `*`, `[index]`, `[*]` and
+// `[]` expressions.
+func parseJMESPath(expr string) (selector, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("empty JMESPath")
+	}
+
+	var sel selector
+	expectName := true
+	for i := 0; i < len(expr); {
+		switch {
+		case expr[i] == '[':
+			if strings.HasPrefix(expr[i:], "[]") {
+				sel = append(sel, step{kind: stepWildcard})
+				i += 2
+			} else {
+				st, n, err := readBracket(expr[i:])
+				if err != nil {
+					return nil, fmt.Errorf("JMESPath %q: %w", expr, err)
+				}
+				sel = append(sel, st)
+				i += n
+			}
+			expectName = false
+		case expr[i] == '.' && !expectName:
+			i++
+			expectName = true
+		case expectName:
+			name, n, err := readJMESPathName(expr[i:])
+			if err != nil {
+				return nil, fmt.Errorf("JMESPath %q: %w", expr, err)
+			}
+			sel = append(sel, name)
+			i += n
+			expectName = false
+		default:
+			return nil, fmt.Errorf("JMESPath %q: unexpected %q at %d", expr, expr[i], i)
+		}
+	}
+
+	if expectName {
+		return nil, fmt.Errorf("JMESPath %q: %w", expr, errEmptyName)
+	}
+
+	return sel, nil
+}
+
+func readJMESPathName(expr string) (step, int, error) {
+	if expr[0] == '"' {
+		end := 1
+		for end < len(expr) && (expr[end] != '"' || expr[end-1] == '\\') {
+			end++
+		}
+		if end == len(expr) {
+			return step{}, 0, errors.New("unterminated quoted identifier")
+		}
+
+		name, err := strconv.Unquote(expr[:end+1])
+		if err != nil {
+			return step{}, 0, err
+		}
+		return step{kind: stepField, name: name}, end + 1, nil
+	}
+
+	n := 0
+	for n < len(expr) && (expr[n] == '_' || expr[n] == '*' || isAlphanumeric(expr[n])) {
+		n++
+	}
+	if n == 0 {
+		return step{}, 0, fmt.Errorf("unexpected %q", expr[0])
+	}
+
+	return nameStep(expr[:n]), n, nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// readBracket reads a bracket segment: a wildcard, an index, or a field name quoted with one of the quotes.
+func readBracket(expr string, quotes ...byte) (step, int, error) {
+	for _, quote := range quotes {
+		if len(expr) < 2 || expr[1] != quote {
+			continue
+		}
+
+		end := strings.IndexByte(expr[2:], quote)
+		if end < 0 || len(expr) < end+4 || expr[end+3] != ']' {
+			return step{}, 0, errors.New("unterminated quoted field name")
+		}
+		return step{kind: stepField, name: expr[2 : end+2]}, end + 4, nil
+	}
+
+	end := strings.IndexByte(expr, ']')
+	if end < 0 {
+		return step{}, 0, errors.New("unterminated bracket")
+	}
+
+	content := strings.TrimSpace(expr[1:end])
+	if content == "*" {
+		return step{kind: stepWildcard}, end + 1, nil
+	}
+
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return step{}, 0, fmt.Errorf("unsupported bracket expression %q", content)
+	}
+	return step{kind: stepIndex, index: index}, end + 1, nil
+}
+
+func nameStep(name string) step {
+	if name == "*" {
+		return step{kind: stepWildcard}
+	}
+	return step{kind: stepField, name: name}
+}
+
+// apply calls visit with the values selected in the document, it returns the updated document.
+func (s selector) apply(doc interface{}, visit visitFunc) interface{} {
+	doc, remove := walk(doc, s, visit)
+	if remove {
+		return nil
+	}
+	return doc
+}
+
+func walk(node interface{}, steps []step, visit visitFunc) (interface{}, bool) {
+	if len(steps) == 0 {
+		return visit(node)
+	}
+
+	st, rest := steps[0], steps[1:]
+	switch st.kind {
+	case stepDescendant:
+		node, remove := walk(node, rest, visit)
+		if remove {
+			return nil, true
+		}
+		return eachChild(node, func(child interface{}) (interface{}, bool) {
+			return walk(child, steps, visit)
+		}), false
+	case stepWildcard:
+		return eachChild(node, func(child interface{}) (interface{}, bool) {
+			return walk(child, rest, visit)
+		}), false
+	case stepField:
+		fields, ok := node.(map[string]interface{})
+		if !ok {
+			return node, false
+		}
+
+		child, ok := fields[st.name]
+		if !ok {
+			return node, false
+		}
+
+		if value, remove := walk(child, rest, visit); remove {
+			delete(fields, st.name)
+		} else {
+			fields[st.name] = value
+		}
+		return fields, false
+	case stepIndex:
+		items, ok := node.([]interface{})
+		if !ok {
+			return node, false
+		}
+
+		i := st.index
+		if i < 0 {
+			i += len(items)
+		}
+		if i < 0 || i >= len(items) {
+			return node, false
+		}
+
+		value, remove := walk(items[i], rest, visit)
+		if remove {
+			return append(items[:i:i], items[i+1:]...), false
+		}
+		items[i] = value
+		return items, false
+	default:
+		return node, false
+	}
+}
+
+// eachChild calls visit with the fields of an object or the items of an array, it returns the updated value.
+func eachChild(node interface{}, visit visitFunc) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for name, child := range node {
+			if value, remove := visit(child); remove {
+				delete(node, name)
+			} else {
+				node[name] = value
+			}
+		}
+		return node
+	case []interface{}:
+		kept := node[:0]
+		for _, child := range node {
+			if value, remove := visit(child); !remove {
+				kept = append(kept, value)
+			}
+		}
+		return kept
+	default:
+		return node
+	}
+}
@@ -36,6 +36,7 @@ type (
 	Subscription = redis.Subscription
 
 	IntCmd         = redis.IntCmd
+	DurationCmd    = redis.DurationCmd
 	StringCmd      = redis.StringCmd
 	StringSliceCmd = redis.StringSliceCmd
 )
@@ -0,0 +1,287 @@
+// Package routing evaluates the conditional routing rules of APIs against requests.
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/TykTechnologies/tyk/apidef"
+)
+
+// Request provides the values of a request read by the conditions.
+type Request interface {
+	// Value returns the value of the source named by the key, and false if the request doesn't have it.
+	Value(source apidef.RoutingSource, key string) (interface{}, bool)
+}
+
+// Rule is a compiled routing rule.
+type Rule struct {
+	conf       apidef.RoutingRule
+	any        bool
+	conditions []condition
+}
+
+type condition struct {
+	conf apidef.RoutingCondition
+	re   *regexp.Regexp
+	num  float64
+}
+
+// Result is the evaluation result of a rule.
+type Result struct {
+	Name       string            `json:"name"`
+	Matched    bool              `json:"matched"`
+	Conditions []ConditionResult `json:"conditions"`
+}
+
+// ConditionResult is the evaluation result of a condition, with the value read from the request.
+type ConditionResult struct {
+	apidef.RoutingCondition
+	Actual  interface{} `json:"actual"`
+	Found   bool        `json:"found"`
+	Matched bool        `json:"matched"`
+}
+
+// Compile compiles the routing rule, it fails if a source, operator or operand is invalid.
+func Compile(conf apidef.RoutingRule) (*Rule, error) {
+	r := &Rule{conf: conf}
+
+	switch conf.Match {
+	case "", apidef.All:
+	case apidef.Any:
+		r.any = true
+	default:
+		return nil, fmt.Errorf("routing rule %q: unsupported match %q", conf.Name, conf.Match)
+	}
+
+	for _, c := range conf.Conditions {
+		compiled, err := compileCondition(c)
+		if err != nil {
+			return nil, fmt.Errorf("routing rule %q: %w", conf.Name, err)
+		}
+		r.conditions = append(r.conditions, compiled)
+	}
+
+	return r, nil
+}
+
+func compileCondition(conf apidef.RoutingCondition) (condition, error) {
+	c := condition{conf: conf}
+
+	switch conf.Source {
+	case apidef.RoutingSourcePath, apidef.RoutingSourceMethod, apidef.RoutingSourceHost:
+	case apidef.RoutingSourceHeader, apidef.RoutingSourceQuery, apidef.RoutingSourceSessionMeta,
+		apidef.RoutingSourceContext, apidef.RoutingSourceBody:
+		if conf.Key == "" {
+			return c, fmt.Errorf("%s condition requires a key", conf.Source)
+		}
+	default:
+		return c, fmt.Errorf("unsupported condition source %q", conf.Source)
+	}
+
+	switch conf.Operator {
+	case apidef.RoutingEquals, apidef.RoutingNotEquals, apidef.RoutingContains, apidef.RoutingPrefix,
+		apidef.RoutingSuffix, apidef.RoutingIn, apidef.RoutingExists:
+	case apidef.RoutingRegex:
+		re, err := regexp.Compile(conf.Value)
+		if err != nil {
+			return c, fmt.Errorf("invalid condition pattern %q: %w", conf.Value, err)
+		}
+		c.re = re
+	case apidef.RoutingGreater, apidef.RoutingGreaterOrEqual, apidef.RoutingLess, apidef.RoutingLessOrEqual:
+		num, err := strconv.ParseFloat(conf.Value, 64)
+		if err != nil {
+			return c, fmt.Errorf("%s condition requires a number, got %q", conf.Operator, conf.Value)
+		}
+		c.num = num
+	default:
+		return c, fmt.Errorf("unsupported condition operator %q", conf.Operator)
+	}
+
+	return c, nil
+}
+
+// Name returns the name of the rule.
+func (r *Rule) Name() string {
+	return r.conf.Name
+}
+
+// Action returns the action of the rule.
+func (r *Rule) Action() apidef.RoutingAction {
+	return r.conf.Action
+}
+
+// Match returns true if the request matches the conditions of the rule.
+func (r *Rule) Match(req Request) bool {
+	if len(r.conditions) == 0 {
+		return true
+	}
+
+	for _, c := range r.conditions {
+		value, found := req.Value(c.conf.Source, c.conf.Key)
+		if c.match(value, found) == r.any {
+			return r.any
+		}
+	}
+
+	return !r.any
+}
+
+// Evaluate evaluates all the conditions of the rule, reporting the values read from the request.
+func (r *Rule) Evaluate(req Request) Result {
+	res := Result{Name: r.conf.Name, Matched: r.Match(req)}
+	for _, c := range r.conditions {
+		value, found := req.Value(c.conf.Source, c.conf.Key)
+		res.Conditions = append(res.Conditions, ConditionResult{
+			RoutingCondition: c.conf,
+			Actual:           value,
+			Found:            found,
+			Matched:          c.match(value, found),
+		})
+	}
+
+	return res
+}
+
+// First returns the first rule matching the request, or nil.
+func First(rules []*Rule, req Request) *Rule {
+	for _, r := range rules {
+		if r.Match(req) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (c condition) match(value interface{}, found bool) bool {
+	return c.compare(value, found) != c.conf.Negate
+}
+
+func (c condition) compare(value interface{}, found bool) bool {
+	if c.conf.Operator == apidef.RoutingExists {
+		return found
+	}
+	if !found {
+		return c.conf.Operator == apidef.RoutingNotEquals
+	}
+
+	switch c.conf.Operator {
+	case apidef.RoutingEquals:
+		return equal(value, c.conf.Value)
+	case apidef.RoutingNotEquals:
+		return !equal(value, c.conf.Value)
+	case apidef.RoutingIn:
+		for _, v := range c.conf.Values {
+			if equal(value, v) {
+				return true
+			}
+		}
+		return false
+	case apidef.RoutingContains:
+		return strings.Contains(text(value), c.conf.Value)
+	case apidef.RoutingPrefix:
+		return strings.HasPrefix(text(value), c.conf.Value)
+	case apidef.RoutingSuffix:
+		return strings.HasSuffix(text(value), c.conf.Value)
+	case apidef.RoutingRegex:
+		return c.re.MatchString(text(value))
+	}
+
+	num, ok := number(value)
+	if !ok {
+		return false
+	}
+
+	switch c.conf.Operator {
+	case apidef.RoutingGreater:
+		return num > c.num
+	case apidef.RoutingGreaterOrEqual:
+		return num >= c.num
+	case apidef.RoutingLess:
+		return num < c.num
+	case apidef.RoutingLessOrEqual:
+		return num <= c.num
+	default:
+		return false
+	}
+}
+
+// equal compares a request value with an operand, numbers and booleans are compared by value.
+func equal(value interface{}, operand string) bool {
+	switch v := value.(type) {
+	case bool:
+		b, err := strconv.ParseBool(operand)
+		return err == nil && v == b
+	case float64, json.Number, int, int64:
+		num, _ := number(v)
+		f, err := strconv.ParseFloat(operand, 64)
+		return err == nil && num == f
+	default:
+		return text(value) == operand
+	}
+}
+
+// number returns the numeric value of a request value, strings are parsed.
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// text returns the text of a request value, values other than strings and numbers are JSON encoded.
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		if s, ok := v.(fmt.Stringer); ok {
+			return s.String()
+		}
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+// Lookup returns the value of a decoded JSON document at the dot separated path, array items are selected by index.
+func Lookup(doc interface{}, path string) (interface{}, bool) {
+	for _, part := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			doc = value
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return doc, true
+}
@@ -0,0 +1,136 @@
+package routing_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/apidef"
+	"github.com/TykTechnologies/tyk/internal/routing"
+)
+
+type request map[apidef.RoutingSource]map[string]interface{}
+
+func (r request) Value(source apidef.RoutingSource, key string) (interface{}, bool) {
+	value, ok := r[source][key]
+	return value, ok
+}
+
+func testRequest(t *testing.T) request {
+	t.Helper()
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"order":{"total":120.5,"express":true,"items":[{"sku":"A-1"}]}}`), &body))
+
+	return request{
+		apidef.RoutingSourceHeader: {"X-Tier": "gold"},
+		apidef.RoutingSourceMethod: {"": "POST"},
+		apidef.RoutingSourceBody:   {"order": body["order"]},
+	}
+}
+
+func compile(t *testing.T, match apidef.RoutingTriggerOnType, conditions ...apidef.RoutingCondition) *routing.Rule {
+	t.Helper()
+
+	rule, err := routing.Compile(apidef.RoutingRule{Name: "test", Match: match, Conditions: conditions})
+	require.NoError(t, err)
+	return rule
+}
+
+func TestRule_Match(t *testing.T) {
+	t.Parallel()
+
+	req := bodyRequest{testRequest(t)}
+
+	tcs := []struct {
+		name      string
+		condition apidef.RoutingCondition
+		matched   bool
+	}{
+		{"equals", apidef.RoutingCondition{Source: apidef.RoutingSourceHeader, Key: "X-Tier", Operator: apidef.RoutingEquals, Value: "gold"}, true},
+		{"not equals missing", apidef.RoutingCondition{Source: apidef.RoutingSourceHeader, Key: "X-Other", Operator: apidef.RoutingNotEquals, Value: "gold"}, true},
+		{"in", apidef.RoutingCondition{Source: apidef.RoutingSourceHeader, Key: "X-Tier", Operator: apidef.RoutingIn, Values: []string{"silver", "gold"}}, true},
+		{"prefix", apidef.RoutingCondition{Source: apidef.RoutingSourceMethod, Operator: apidef.RoutingPrefix, Value: "PO"}, true},
+		{"regex", apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.items.0.sku", Operator: apidef.RoutingRegex, Value: `^A-\d$`}, true},
+		{"number equals", apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.total", Operator: apidef.RoutingEquals, Value: "120.50"}, true},
+		{"greater", apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.total", Operator: apidef.RoutingGreater, Value: "100"}, true},
+		{"less or equal", apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.total", Operator: apidef.RoutingLessOrEqual, Value: "100"}, false},
+		{"boolean", apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.express", Operator: apidef.RoutingEquals, Value: "true"}, true},
+		{"number of string", apidef.RoutingCondition{Source: apidef.RoutingSourceHeader, Key: "X-Tier", Operator: apidef.RoutingGreater, Value: "1"}, false},
+		{"exists", apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.items.1", Operator: apidef.RoutingExists}, false},
+		{"negated exists", apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.items.1", Operator: apidef.RoutingExists, Negate: true}, true},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.matched, compile(t, apidef.All, tc.condition).Match(req))
+		})
+	}
+
+	t.Run("boolean logic", func(t *testing.T) {
+		t.Parallel()
+
+		gold := apidef.RoutingCondition{Source: apidef.RoutingSourceHeader, Key: "X-Tier", Operator: apidef.RoutingEquals, Value: "gold"}
+		get := apidef.RoutingCondition{Source: apidef.RoutingSourceMethod, Operator: apidef.RoutingEquals, Value: "GET"}
+
+		assert.False(t, compile(t, "", gold, get).Match(req))
+		assert.True(t, compile(t, apidef.Any, gold, get).Match(req))
+		assert.True(t, compile(t, apidef.Any).Match(req), "rules without conditions match all the requests")
+
+		first := routing.First([]*routing.Rule{compile(t, apidef.All, gold, get), compile(t, apidef.Any, get)}, req)
+		assert.Nil(t, first)
+	})
+}
+
+// bodyRequest resolves body field paths in the decoded body of the request.
+type bodyRequest struct {
+	request
+}
+
+func (r bodyRequest) Value(source apidef.RoutingSource, key string) (interface{}, bool) {
+	if source == apidef.RoutingSourceBody {
+		return routing.Lookup(map[string]interface{}(r.request[source]), key)
+	}
+	if source == apidef.RoutingSourceMethod {
+		key = ""
+	}
+	return r.request.Value(source, key)
+}
+
+func TestRule_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	rule := compile(t, apidef.All,
+		apidef.RoutingCondition{Source: apidef.RoutingSourceHeader, Key: "X-Tier", Operator: apidef.RoutingEquals, Value: "gold"},
+		apidef.RoutingCondition{Source: apidef.RoutingSourceBody, Key: "order.total", Operator: apidef.RoutingLess, Value: "100"})
+
+	res := rule.Evaluate(bodyRequest{testRequest(t)})
+	assert.False(t, res.Matched)
+	require.Len(t, res.Conditions, 2)
+	assert.True(t, res.Conditions[0].Matched)
+	assert.Equal(t, "gold", res.Conditions[0].Actual)
+	assert.False(t, res.Conditions[1].Matched)
+	assert.Equal(t, 120.5, res.Conditions[1].Actual)
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	invalid := []apidef.RoutingRule{
+		{Match: "some"},
+		{Conditions: []apidef.RoutingCondition{{Source: "cookie", Key: "a", Operator: apidef.RoutingEquals}}},
+		{Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourceHeader, Operator: apidef.RoutingEquals}}},
+		{Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourcePath, Operator: "like"}}},
+		{Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourcePath, Operator: apidef.RoutingRegex, Value: "("}}},
+		{Conditions: []apidef.RoutingCondition{{Source: apidef.RoutingSourcePath, Operator: apidef.RoutingGreater, Value: "ten"}}},
+	}
+
+	for _, rule := range invalid {
+		_, err := routing.Compile(rule)
+		assert.Error(t, err, "%+v", rule)
+	}
+}
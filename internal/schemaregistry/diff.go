@@ -0,0 +1,326 @@
+package schemaregistry
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/ast"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astnormalization"
+	"github.com/TykTechnologies/graphql-go-tools/pkg/astparser"
+)
+
+// ChangeType identifies the kind of a schema change.
+type ChangeType string
+
+const (
+	TypeAdded             ChangeType = "TYPE_ADDED"
+	TypeRemoved           ChangeType = "TYPE_REMOVED"
+	TypeKindChanged       ChangeType = "TYPE_KIND_CHANGED"
+	FieldAdded            ChangeType = "FIELD_ADDED"
+	FieldRemoved          ChangeType = "FIELD_REMOVED"
+	FieldTypeChanged      ChangeType = "FIELD_TYPE_CHANGED"
+	ArgumentAdded         ChangeType = "ARGUMENT_ADDED"
+	ArgumentRemoved       ChangeType = "ARGUMENT_REMOVED"
+	ArgumentTypeChanged   ChangeType = "ARGUMENT_TYPE_CHANGED"
+	EnumValueAdded        ChangeType = "ENUM_VALUE_ADDED"
+	EnumValueRemoved      ChangeType = "ENUM_VALUE_REMOVED"
+	UnionMemberAdded      ChangeType = "UNION_MEMBER_ADDED"
+	UnionMemberRemoved    ChangeType = "UNION_MEMBER_REMOVED"
+	InterfaceAdded        ChangeType = "INTERFACE_ADDED"
+	InterfaceRemoved      ChangeType = "INTERFACE_REMOVED"
+	InputFieldAdded       ChangeType = "INPUT_FIELD_ADDED"
+	InputFieldRemoved     ChangeType = "INPUT_FIELD_REMOVED"
+	InputFieldTypeChanged ChangeType = "INPUT_FIELD_TYPE_CHANGED"
+)
+
+// Change is a difference between two versions of a schema.
+type Change struct {
+	Type ChangeType `json:"type"`
+	// Path is the coordinate of the changed element, e.g. Query.country(code).
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Breaking is true when the change can break the operations of existing clients.
+	Breaking bool `json:"breaking"`
+}
+
+// HasBreakingChanges checks whether one of the changes is breaking.
+func HasBreakingChanges(changes []Change) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the changes from the old schema to the new one, sorted by path. Removed types, fields, arguments,
+// enum values and union members are breaking, as well as output types made nullable and input types made
+// non-nullable.
+func Diff(oldSchema, newSchema string) ([]Change, error) {
+	oldDoc, err := parseSchema(oldSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	newDoc, err := parseSchema(newSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &differ{old: oldDoc, new: newDoc}
+	d.diff()
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return d.changes[i].Path < d.changes[j].Path
+	})
+
+	return d.changes, nil
+}
+
+// Validate checks that the schema can be parsed.
+func Validate(schema string) error {
+	_, err := parseSchema(schema)
+	return err
+}
+
+func parseSchema(schema string) (*ast.Document, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(schema)
+	if report.HasErrors() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchema, report.Error())
+	}
+
+	astnormalization.NormalizeDefinition(&doc, &report)
+	if report.HasErrors() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchema, report.Error())
+	}
+
+	return &doc, nil
+}
+
+type differ struct {
+	old, new *ast.Document
+	changes  []Change
+}
+
+func (d *differ) add(changeType ChangeType, path string, breaking bool, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Type:     changeType,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+		Breaking: breaking,
+	})
+}
+
+func (d *differ) diff() {
+	oldTypes, newTypes := typeNodes(d.old), typeNodes(d.new)
+
+	for name, oldNode := range oldTypes {
+		newNode, ok := newTypes[name]
+		switch {
+		case !ok:
+			d.add(TypeRemoved, name, true, "type '%s' was removed", name)
+		case oldNode.Kind != newNode.Kind:
+			d.add(TypeKindChanged, name, true, "type '%s' changed from %s to %s", name, oldNode.Kind, newNode.Kind)
+		default:
+			d.diffType(name, oldNode, newNode)
+		}
+	}
+
+	for name := range newTypes {
+		if _, ok := oldTypes[name]; !ok {
+			d.add(TypeAdded, name, false, "type '%s' was added", name)
+		}
+	}
+}
+
+func (d *differ) diffType(name string, oldNode, newNode ast.Node) {
+	switch oldNode.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition:
+		d.diffFields(name, oldNode, newNode)
+		d.diffNames(name, InterfaceAdded, InterfaceRemoved, "interface",
+			typeRefNames(d.old, d.old.NodeInterfaceRefs(oldNode)), typeRefNames(d.new, d.new.NodeInterfaceRefs(newNode)))
+	case ast.NodeKindInputObjectTypeDefinition:
+		d.diffInputValues(name, InputFieldAdded, InputFieldRemoved, InputFieldTypeChanged, "input field",
+			d.old.NodeInputFieldDefinitions(oldNode), d.new.NodeInputFieldDefinitions(newNode))
+	case ast.NodeKindEnumTypeDefinition:
+		d.diffNames(name, EnumValueAdded, EnumValueRemoved, "enum value",
+			enumValueNames(d.old, oldNode.Ref), enumValueNames(d.new, newNode.Ref))
+	case ast.NodeKindUnionTypeDefinition:
+		d.diffNames(name, UnionMemberAdded, UnionMemberRemoved, "union member",
+			typeRefNames(d.old, d.old.UnionTypeDefinitions[oldNode.Ref].UnionMemberTypes.Refs),
+			typeRefNames(d.new, d.new.UnionTypeDefinitions[newNode.Ref].UnionMemberTypes.Refs))
+	}
+}
+
+func (d *differ) diffFields(typeName string, oldNode, newNode ast.Node) {
+	newFields := make(map[string]int)
+	for _, ref := range d.new.NodeFieldDefinitions(newNode) {
+		newFields[d.new.FieldDefinitionNameString(ref)] = ref
+	}
+
+	oldFields := make(map[string]bool)
+	for _, oldRef := range d.old.NodeFieldDefinitions(oldNode) {
+		name := d.old.FieldDefinitionNameString(oldRef)
+		oldFields[name] = true
+		path := typeName + "." + name
+
+		newRef, ok := newFields[name]
+		if !ok {
+			d.add(FieldRemoved, path, true, "field '%s' was removed", path)
+			continue
+		}
+
+		oldType, newType := d.old.FieldDefinitionType(oldRef), d.new.FieldDefinitionType(newRef)
+		if !sameType(d.old, oldType, d.new, newType) {
+			d.add(FieldTypeChanged, path, !outputTypeCompatible(d.old, oldType, d.new, newType),
+				"field '%s' changed type from '%s' to '%s'", path, printType(d.old, oldType), printType(d.new, newType))
+		}
+
+		d.diffInputValues(path, ArgumentAdded, ArgumentRemoved, ArgumentTypeChanged, "argument",
+			d.old.FieldDefinitionArgumentsDefinitions(oldRef), d.new.FieldDefinitionArgumentsDefinitions(newRef))
+	}
+
+	for name := range newFields {
+		if !oldFields[name] {
+			path := typeName + "." + name
+			d.add(FieldAdded, path, false, "field '%s' was added", path)
+		}
+	}
+}
+
+// diffInputValues compares arguments or input fields, adding a required one is breaking.
+func (d *differ) diffInputValues(parent string, added, removed, typeChanged ChangeType, kind string, oldRefs, newRefs []int) {
+	path := func(name string) string {
+		if kind == "argument" {
+			return parent + "(" + name + ")"
+		}
+		return parent + "." + name
+	}
+
+	newValues := make(map[string]int, len(newRefs))
+	for _, ref := range newRefs {
+		newValues[d.new.InputValueDefinitionNameString(ref)] = ref
+	}
+
+	oldValues := make(map[string]bool, len(oldRefs))
+	for _, oldRef := range oldRefs {
+		name := d.old.InputValueDefinitionNameString(oldRef)
+		oldValues[name] = true
+
+		newRef, ok := newValues[name]
+		if !ok {
+			d.add(removed, path(name), true, "%s '%s' was removed", kind, path(name))
+			continue
+		}
+
+		oldType, newType := d.old.InputValueDefinitionType(oldRef), d.new.InputValueDefinitionType(newRef)
+		if !sameType(d.old, oldType, d.new, newType) {
+			// input types are contravariant, a nullable input accepts the values of the non-nullable one
+			d.add(typeChanged, path(name), !outputTypeCompatible(d.new, newType, d.old, oldType),
+				"%s '%s' changed type from '%s' to '%s'", kind, path(name), printType(d.old, oldType), printType(d.new, newType))
+		}
+	}
+
+	for _, ref := range newRefs {
+		name := d.new.InputValueDefinitionNameString(ref)
+		if oldValues[name] {
+			continue
+		}
+
+		required := d.new.Types[d.new.InputValueDefinitionType(ref)].TypeKind == ast.TypeKindNonNull &&
+			!d.new.InputValueDefinitionHasDefaultValue(ref)
+		if required {
+			d.add(added, path(name), true, "required %s '%s' was added", kind, path(name))
+		} else {
+			d.add(added, path(name), false, "%s '%s' was added", kind, path(name))
+		}
+	}
+}
+
+// diffNames compares the members of a type, removing one is breaking.
+func (d *differ) diffNames(typeName string, added, removed ChangeType, kind string, oldNames, newNames []string) {
+	newSet := make(map[string]bool, len(newNames))
+	for _, name := range newNames {
+		newSet[name] = true
+	}
+
+	oldSet := make(map[string]bool, len(oldNames))
+	for _, name := range oldNames {
+		oldSet[name] = true
+		if !newSet[name] {
+			d.add(removed, typeName+"."+name, true, "%s '%s' was removed from '%s'", kind, name, typeName)
+		}
+	}
+
+	for _, name := range newNames {
+		if !oldSet[name] {
+			d.add(added, typeName+"."+name, false, "%s '%s' was added to '%s'", kind, name, typeName)
+		}
+	}
+}
+
+// typeNodes returns the type definitions of the document by name, the directives and the schema definition
+// are not compared.
+func typeNodes(doc *ast.Document) map[string]ast.Node {
+	nodes := make(map[string]ast.Node)
+	for _, node := range doc.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindInputObjectTypeDefinition,
+			ast.NodeKindEnumTypeDefinition, ast.NodeKindUnionTypeDefinition, ast.NodeKindScalarTypeDefinition:
+			nodes[doc.NodeNameString(node)] = node
+		}
+	}
+	return nodes
+}
+
+func typeRefNames(doc *ast.Document, refs []int) []string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, doc.TypeNameString(ref))
+	}
+	return names
+}
+
+func enumValueNames(doc *ast.Document, ref int) []string {
+	refs := doc.EnumTypeDefinitions[ref].EnumValuesDefinition.Refs
+	names := make([]string, 0, len(refs))
+	for _, valueRef := range refs {
+		names = append(names, doc.EnumValueDefinitionNameString(valueRef))
+	}
+	return names
+}
+
+func printType(doc *ast.Document, ref int) string {
+	out, err := doc.PrintTypeBytes(ref, nil)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+func sameType(oldDoc *ast.Document, oldRef int, newDoc *ast.Document, newRef int) bool {
+	return printType(oldDoc, oldRef) == printType(newDoc, newRef)
+}
+
+// outputTypeCompatible checks that the values of the new type are values of the old type, the new type can only
+// make the old one non-nullable.
+func outputTypeCompatible(oldDoc *ast.Document, oldRef int, newDoc *ast.Document, newRef int) bool {
+	oldType, newType := oldDoc.Types[oldRef], newDoc.Types[newRef]
+
+	if newType.TypeKind == ast.TypeKindNonNull {
+		if oldType.TypeKind == ast.TypeKindNonNull {
+			return outputTypeCompatible(oldDoc, oldType.OfType, newDoc, newType.OfType)
+		}
+		return outputTypeCompatible(oldDoc, oldRef, newDoc, newType.OfType)
+	}
+
+	if oldType.TypeKind != newType.TypeKind {
+		return false
+	}
+
+	if newType.TypeKind == ast.TypeKindList {
+		return outputTypeCompatible(oldDoc, oldType.OfType, newDoc, newType.OfType)
+	}
+
+	return oldDoc.TypeNameString(oldRef) == newDoc.TypeNameString(newRef)
+}
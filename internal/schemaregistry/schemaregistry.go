@@ -0,0 +1,229 @@
+// Package schemaregistry keeps the history of the schemas of GraphQL APIs. Each published schema is stored as a
+// version along with its changes from the previous version, so breaking changes can be reviewed before they are
+// accepted and earlier versions can be restored.
+package schemaregistry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/TykTechnologies/graphql-go-tools/pkg/federation/sdlmerge"
+
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+// KeyPrefix is the storage key prefix of the schema versions.
+const KeyPrefix = "graphql-schema-registry."
+
+var (
+	// ErrNotFound is returned when a schema version doesn't exist.
+	ErrNotFound = errors.New("schema version not found")
+	// ErrInvalidSchema is returned when a schema is not a valid GraphQL document.
+	ErrInvalidSchema = errors.New("invalid GraphQL schema")
+	// ErrComposition is returned when the subgraphs of a supergraph can't be composed.
+	ErrComposition = errors.New("supergraph composition failed")
+	// ErrVersionConflict is returned when the version was saved by a concurrent publish.
+	ErrVersionConflict = errors.New("schema version was published concurrently")
+)
+
+// Version is a version of the schema of an API.
+type Version struct {
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	Schema    string    `json:"schema"`
+	CreatedAt time.Time `json:"created_at"`
+	// Changes are the changes from the previous version.
+	Changes []Change `json:"changes"`
+	// RolledBackFrom is the version restored by this version.
+	RolledBackFrom int `json:"rolled_back_from,omitempty"`
+}
+
+// Hash returns the hash identifying the schema.
+func Hash(schema string) string {
+	sum := sha256.Sum256([]byte(schema))
+	return hex.EncodeToString(sum[:])
+}
+
+// Compose merges the schemas of the subgraphs into the schema of the supergraph.
+func Compose(subgraphSchemas ...string) (string, error) {
+	merged, err := sdlmerge.MergeSDLs(subgraphSchemas...)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrComposition, err.Error())
+	}
+
+	return merged, nil
+}
+
+// Store keeps the schema versions of an API.
+type Store struct {
+	handler storage.Handler
+	apiID   string
+}
+
+// NewStore returns the store of the API.
+func NewStore(handler storage.Handler, apiID string) *Store {
+	return &Store{handler: handler, apiID: apiID}
+}
+
+func (s *Store) versionKey(version int) string {
+	return s.apiID + ".version." + strconv.Itoa(version)
+}
+
+func (s *Store) latestKey() string {
+	return s.apiID + ".latest"
+}
+
+// Versions returns the versions of the schema, the oldest first.
+func (s *Store) Versions() ([]Version, error) {
+	latest, err := s.latestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]Version, 0, latest)
+	for number := 1; number <= latest; number++ {
+		version, err := s.Version(number)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+
+	return versions, nil
+}
+
+// latestVersion returns the number of the latest version, 0 when no schema was published.
+func (s *Store) latestVersion() (int, error) {
+	value, err := s.handler.GetKey(s.latestKey())
+	if err != nil {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+// Version returns the version with the number.
+func (s *Store) Version(number int) (*Version, error) {
+	value, err := s.handler.GetKey(s.versionKey(number))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var version Version
+	if err := json.Unmarshal([]byte(value), &version); err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// Latest returns the latest version, or ErrNotFound when no schema was published.
+func (s *Store) Latest() (*Version, error) {
+	latest, err := s.latestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Version(latest)
+}
+
+// Next returns the version following the latest one for the schema, with its changes from the latest version, or
+// from the base schema when no schema was published. The version is not stored.
+func (s *Store) Next(schema, base string) (*Version, error) {
+	if err := Validate(schema); err != nil {
+		return nil, err
+	}
+
+	next := &Version{Version: 1, Hash: Hash(schema), Schema: schema, CreatedAt: time.Now().UTC()}
+
+	latest, err := s.Latest()
+	switch {
+	case err == nil:
+		next.Version = latest.Version + 1
+		base = latest.Schema
+	case !errors.Is(err, ErrNotFound):
+		return nil, err
+	}
+
+	if base == "" {
+		return next, nil
+	}
+
+	if next.Changes, err = Diff(base, schema); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// locker sets keys that don't exist yet, as storage.RedisCluster does.
+type locker interface {
+	Lock(key string, timeout time.Duration) (bool, error)
+}
+
+// Save stores the version. It returns ErrVersionConflict when a version with the same number was saved, the
+// version should then be computed again from the new latest version.
+func (s *Store) Save(version *Version) error {
+	value, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+
+	claimed, err := s.claim(version.Version)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrVersionConflict
+	}
+
+	if err := s.handler.SetKey(s.versionKey(version.Version), string(value), 0); err != nil {
+		s.handler.DeleteKey(s.versionKey(version.Version))
+		return err
+	}
+
+	return s.handler.SetKey(s.latestKey(), strconv.Itoa(version.Version), 0)
+}
+
+// Discard removes a saved version which couldn't be applied. The latest version before it becomes the latest one
+// again, unless a later version was saved since.
+func (s *Store) Discard(version *Version) error {
+	latest, err := s.latestVersion()
+	if err != nil {
+		return err
+	}
+
+	s.handler.DeleteKey(s.versionKey(version.Version))
+	if latest != version.Version {
+		return nil
+	}
+
+	for previous := version.Version - 1; previous > 0; previous-- {
+		if _, err := s.Version(previous); err == nil {
+			return s.handler.SetKey(s.latestKey(), strconv.Itoa(previous), 0)
+		}
+	}
+
+	s.handler.DeleteKey(s.latestKey())
+	return nil
+}
+
+// claim reserves the number of a version by setting its key when it doesn't exist, only one of the concurrent
+// publishes of a version claims it.
+func (s *Store) claim(number int) (bool, error) {
+	key := s.versionKey(number)
+	if l, ok := s.handler.(locker); ok {
+		return l.Lock(s.handler.GetKeyPrefix()+key, 0)
+	}
+
+	exists, err := s.handler.Exists(key)
+	return !exists, err
+}
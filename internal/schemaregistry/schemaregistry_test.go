@@ -0,0 +1,227 @@
+package schemaregistry_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/internal/schemaregistry"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+const schemaV1 = `
+type Query {
+	country(code: String!): Country
+	countries(filter: CountryFilter): [Country!]!
+}
+
+type Country {
+	code: String!
+	name: String
+	continent: Continent!
+}
+
+input CountryFilter {
+	code: String
+}
+
+enum Continent { EUROPE ASIA }
+
+union SearchResult = Country
+`
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   string
+		change   schemaregistry.Change
+		breaking bool
+	}{
+		{
+			name:   "field removed",
+			schema: replace(schemaV1, "\tname: String\n", ""),
+			change: schemaregistry.Change{Type: schemaregistry.FieldRemoved, Path: "Country.name", Breaking: true},
+		},
+		{
+			name:   "field added",
+			schema: replace(schemaV1, "\tname: String\n", "\tname: String\n\tcapital: String\n"),
+			change: schemaregistry.Change{Type: schemaregistry.FieldAdded, Path: "Country.capital"},
+		},
+		{
+			name:   "output made nullable",
+			schema: replace(schemaV1, "code: String!\n", "code: String\n"),
+			change: schemaregistry.Change{Type: schemaregistry.FieldTypeChanged, Path: "Country.code", Breaking: true},
+		},
+		{
+			name:   "output made non-nullable",
+			schema: replace(schemaV1, "name: String\n", "name: String!\n"),
+			change: schemaregistry.Change{Type: schemaregistry.FieldTypeChanged, Path: "Country.name"},
+		},
+		{
+			name:   "list items made nullable",
+			schema: replace(schemaV1, "[Country!]!", "[Country]!"),
+			change: schemaregistry.Change{Type: schemaregistry.FieldTypeChanged, Path: "Query.countries", Breaking: true},
+		},
+		{
+			name:   "argument made nullable",
+			schema: replace(schemaV1, "code: String!)", "code: String)"),
+			change: schemaregistry.Change{Type: schemaregistry.ArgumentTypeChanged, Path: "Query.country(code)"},
+		},
+		{
+			name:   "required argument added",
+			schema: replace(schemaV1, "(filter: CountryFilter)", "(filter: CountryFilter, first: Int!)"),
+			change: schemaregistry.Change{Type: schemaregistry.ArgumentAdded, Path: "Query.countries(first)", Breaking: true},
+		},
+		{
+			name:   "optional argument added",
+			schema: replace(schemaV1, "(filter: CountryFilter)", "(filter: CountryFilter, first: Int = 10)"),
+			change: schemaregistry.Change{Type: schemaregistry.ArgumentAdded, Path: "Query.countries(first)"},
+		},
+		{
+			name:   "input field made non-nullable",
+			schema: replace(schemaV1, "\tcode: String\n}", "\tcode: String!\n}"),
+			change: schemaregistry.Change{Type: schemaregistry.InputFieldTypeChanged, Path: "CountryFilter.code", Breaking: true},
+		},
+		{
+			name:   "enum value removed",
+			schema: replace(schemaV1, "EUROPE ASIA", "EUROPE"),
+			change: schemaregistry.Change{Type: schemaregistry.EnumValueRemoved, Path: "Continent.ASIA", Breaking: true},
+		},
+		{
+			name:   "type removed",
+			schema: replace(schemaV1, "union SearchResult = Country\n", ""),
+			change: schemaregistry.Change{Type: schemaregistry.TypeRemoved, Path: "SearchResult", Breaking: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes, err := schemaregistry.Diff(schemaV1, tc.schema)
+			require.NoError(t, err)
+			require.Len(t, changes, 1)
+
+			change := changes[0]
+			assert.NotEmpty(t, change.Message)
+			change.Message = ""
+			assert.Equal(t, tc.change, change)
+			assert.Equal(t, tc.change.Breaking, schemaregistry.HasBreakingChanges(changes))
+		})
+	}
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := schemaregistry.Diff(schemaV1, "type Query {")
+		assert.ErrorIs(t, err, schemaregistry.ErrInvalidSchema)
+	})
+}
+
+func TestCompose(t *testing.T) {
+	users := `
+		extend type Query { me: User }
+		type User @key(fields: "id") { id: ID! username: String! }
+	`
+	reviews := `
+		type Review { body: String! author: User! }
+		extend type User @key(fields: "id") { id: ID! @external reviews: [Review] }
+	`
+
+	merged, err := schemaregistry.Compose(users, reviews)
+	require.NoError(t, err)
+	assert.Contains(t, merged, "reviews: [Review]")
+
+	_, err = schemaregistry.Compose(users, replace(reviews, "type Review", "type User"))
+	assert.ErrorIs(t, err, schemaregistry.ErrComposition)
+}
+
+func TestStore(t *testing.T) {
+	store := schemaregistry.NewStore(storage.NewDummyStorage(), "api")
+
+	_, err := store.Latest()
+	assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+
+	first, err := store.Next(schemaV1, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Version)
+	assert.Empty(t, first.Changes)
+	require.NoError(t, store.Save(first))
+
+	second, err := store.Next(replace(schemaV1, "\tname: String\n", ""), "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, second.Version)
+	assert.True(t, schemaregistry.HasBreakingChanges(second.Changes))
+	require.NoError(t, store.Save(second))
+
+	versions, err := store.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, schemaregistry.Hash(schemaV1), versions[0].Hash)
+
+	latest, err := store.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, 2, latest.Version)
+
+	_, err = store.Version(3)
+	assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+
+	_, err = store.Next("type Query {", "")
+	assert.ErrorIs(t, err, schemaregistry.ErrInvalidSchema)
+
+	t.Run("concurrent publish", func(t *testing.T) {
+		third, err := store.Next(schemaV1, "")
+		require.NoError(t, err)
+		concurrent, err := store.Next(schemaV1, "")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Save(third))
+		assert.ErrorIs(t, store.Save(concurrent), schemaregistry.ErrVersionConflict)
+
+		latest, err := store.Latest()
+		require.NoError(t, err)
+		assert.Equal(t, 3, latest.Version)
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		fourth, err := store.Next(schemaV1, "")
+		require.NoError(t, err)
+		require.NoError(t, store.Save(fourth))
+		require.NoError(t, store.Discard(fourth))
+
+		latest, err := store.Latest()
+		require.NoError(t, err)
+		assert.Equal(t, 3, latest.Version)
+		_, err = store.Version(4)
+		assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+
+		// the number of a discarded version is reused
+		next, err := store.Next(schemaV1, "")
+		require.NoError(t, err)
+		assert.Equal(t, 4, next.Version)
+		require.NoError(t, store.Save(next))
+
+		// a version discarded after a later one was saved leaves the later one as the latest
+		third, err := store.Version(3)
+		require.NoError(t, err)
+		require.NoError(t, store.Discard(third))
+		latest, err = store.Latest()
+		require.NoError(t, err)
+		assert.Equal(t, 4, latest.Version)
+
+		versions, err := store.Versions()
+		require.NoError(t, err)
+		assert.Len(t, versions, 3)
+
+		// discarding the only version leaves no schema published
+		only := schemaregistry.NewStore(storage.NewDummyStorage(), "only")
+		first, err := only.Next(schemaV1, "")
+		require.NoError(t, err)
+		require.NoError(t, only.Save(first))
+		require.NoError(t, only.Discard(first))
+		_, err = only.Latest()
+		assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+	})
+}
+
+func replace(schema, old, new string) string {
+	return strings.Replace(schema, old, new, 1)
+}
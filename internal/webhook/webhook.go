@@ -0,0 +1,356 @@
+// Package webhook keeps the deliveries of the webhook events until they succeed. Each delivery is stored in a
+// persistent outbox, failed deliveries are retried with an exponential backoff and are moved to a dead-letter queue
+// once their retries are exhausted, from which they can be replayed.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/uuid"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+// KeyPrefix is the storage key prefix of the deliveries.
+const KeyPrefix = "webhook.delivery."
+
+const (
+	outboxKey     = "outbox"
+	deadLetterKey = "dead-letter"
+	lockKey       = "lock."
+
+	// maxResponseSize is the size of the response read before the connection is closed.
+	maxResponseSize = 64 << 10
+)
+
+var (
+	// ErrNotFound is returned when a delivery doesn't exist.
+	ErrNotFound = errors.New("delivery not found")
+	// ErrUnexpectedStatus is returned when the receiver responds with a status code other than 2xx.
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+	// ErrInvalidSignature is returned when the signature of a payload can't be verified.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Delivery is a webhook request to deliver.
+type Delivery struct {
+	ID      string      `json:"id"`
+	APIID   string      `json:"api_id,omitempty"`
+	Webhook string      `json:"webhook,omitempty"`
+	Event   string      `json:"event"`
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body"`
+	// Signed is set when the body is signed, the secret isn't stored and is looked up when the delivery is attempted.
+	Signed bool `json:"signed,omitempty"`
+
+	Attempts      int       `json:"attempts"`
+	CreatedAt     time.Time `json:"created_at"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// LastError and LastStatusCode describe the last failed attempt.
+	LastError      string `json:"last_error,omitempty"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	// DeadLetteredAt is set once the delivery is moved to the dead-letter queue.
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
+}
+
+// NewDelivery returns a delivery of the request body, to attempt immediately.
+func NewDelivery(req *http.Request, body string, now time.Time) *Delivery {
+	return &Delivery{
+		ID:            uuid.NewHex(),
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		Headers:       req.Header.Clone(),
+		Body:          body,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
+
+// Backoff returns the delay before the retry following the attempt, the base delay doubled for each previous
+// attempt up to the maximum delay.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// Sign returns the signature of the body sent in the X-Tyk-Signature header, the timestamp and the HMAC-SHA256 of
+// the timestamp and the body, formatted as "t=<unix timestamp>,v1=<hex signature>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + signature(secret, unix, body)
+}
+
+// Verify checks the signature of the body, and that it was signed within the tolerance.
+func Verify(secret, signed string, body []byte, now time.Time, tolerance time.Duration) error {
+	var unix, sig string
+	for _, part := range strings.Split(signed, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			sig = value
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrInvalidSignature, age)
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, unix, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func signature(secret, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send attempts the delivery, signing the body when the secret isn't empty. It returns the status code of the
+// response, and an error when the request fails or when the status code isn't 2xx.
+func Send(ctx context.Context, client *http.Client, d *Delivery, secret string, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, d.Method, d.URL, strings.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header = d.Headers.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(header.XTykDeliveryID, d.ID)
+	if secret != "" {
+		req.Header.Set(header.XTykSignature, Sign(secret, now, []byte(d.Body)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Store keeps the deliveries in the outbox and in the dead-letter queue.
+type Store struct {
+	handler storage.Handler
+}
+
+// NewStore returns the store of the deliveries.
+func NewStore(handler storage.Handler) *Store {
+	return &Store{handler: handler}
+}
+
+// Enqueue adds the delivery to the outbox.
+func (s *Store) Enqueue(d *Delivery) error {
+	if err := s.save(d); err != nil {
+		return err
+	}
+
+	s.handler.AppendToSet(outboxKey, d.ID)
+	return nil
+}
+
+// Pending returns the deliveries of the outbox, in the order they were enqueued.
+func (s *Store) Pending() ([]*Delivery, error) {
+	return s.list(outboxKey)
+}
+
+// DeadLetters returns the deliveries of the dead-letter queue, in the order they were moved to it. The deliveries
+// past their retention are removed from the queue.
+func (s *Store) DeadLetters() ([]*Delivery, error) {
+	return s.list(deadLetterKey)
+}
+
+func (s *Store) list(key string) ([]*Delivery, error) {
+	ids, err := s.handler.GetListRange(key, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*Delivery, 0, len(ids))
+	for _, id := range ids {
+		d, err := s.Get(id)
+		if errors.Is(err, ErrNotFound) {
+			_ = s.handler.RemoveFromList(key, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// Get returns the delivery with the ID.
+func (s *Store) Get(id string) (*Delivery, error) {
+	value, err := s.handler.GetKey(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var d Delivery
+	if err := json.Unmarshal([]byte(value), &d); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// Claim locks the delivery for the duration of an attempt, so it's only attempted by one gateway at a time. It
+// returns false when the delivery is already locked.
+func (s *Store) Claim(id string, ttl time.Duration) bool {
+	seconds := int64(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return s.handler.IncrememntWithExpire(s.lockKey(id), seconds) == 1
+}
+
+// Release unlocks the delivery.
+func (s *Store) Release(id string) {
+	s.handler.DeleteRawKey(s.lockKey(id))
+}
+
+func (s *Store) lockKey(id string) string {
+	return s.handler.GetKeyPrefix() + lockKey + id
+}
+
+// Complete removes the delivered delivery from the outbox.
+func (s *Store) Complete(id string) error {
+	if err := s.handler.RemoveFromList(outboxKey, id); err != nil {
+		return err
+	}
+
+	s.handler.DeleteKey(id)
+	return nil
+}
+
+// Reschedule saves the delivery with its next attempt.
+func (s *Store) Reschedule(d *Delivery) error {
+	return s.save(d)
+}
+
+// DeadLetter moves the delivery from the outbox to the dead-letter queue, it's deleted once the retention has passed.
+func (s *Store) DeadLetter(d *Delivery, now time.Time, retention time.Duration) error {
+	seconds := int64(retention / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	d.DeadLetteredAt = &now
+	if err := s.saveWithExpiry(d, seconds); err != nil {
+		return err
+	}
+
+	if err := s.handler.RemoveFromList(outboxKey, d.ID); err != nil {
+		return err
+	}
+
+	s.handler.AppendToSet(deadLetterKey, d.ID)
+	return nil
+}
+
+// Replay moves the delivery from the dead-letter queue back to the outbox, to be attempted immediately with its
+// retries reset.
+func (s *Store) Replay(id string, now time.Time) (*Delivery, error) {
+	d, err := s.deadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.handler.RemoveFromList(deadLetterKey, id); err != nil {
+		return nil, err
+	}
+
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.DeadLetteredAt = nil
+	if err := s.Enqueue(d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Discard deletes the delivery from the dead-letter queue.
+func (s *Store) Discard(id string) error {
+	if _, err := s.deadLetter(id); err != nil {
+		return err
+	}
+
+	if err := s.handler.RemoveFromList(deadLetterKey, id); err != nil {
+		return err
+	}
+
+	s.handler.DeleteKey(id)
+	return nil
+}
+
+func (s *Store) deadLetter(id string) (*Delivery, error) {
+	d, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.DeadLetteredAt == nil {
+		return nil, ErrNotFound
+	}
+
+	return d, nil
+}
+
+func (s *Store) save(d *Delivery) error {
+	return s.saveWithExpiry(d, 0)
+}
+
+func (s *Store) saveWithExpiry(d *Delivery, seconds int64) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return s.handler.SetKey(d.ID, string(value), seconds)
+}
@@ -0,0 +1,162 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/header"
+	"github.com/TykTechnologies/tyk/internal/webhook"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: time.Second},
+		{attempt: 2, expected: 2 * time.Second},
+		{attempt: 3, expected: 4 * time.Second},
+		{attempt: 5, expected: 10 * time.Second},
+		{attempt: 100, expected: 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, webhook.Backoff(tc.attempt, time.Second, 10*time.Second))
+	}
+}
+
+func TestSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"QuotaExceeded"}`)
+
+	signed := webhook.Sign("secret", now, body)
+	assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, signed)
+
+	assert.NoError(t, webhook.Verify("secret", signed, body, now.Add(time.Minute), 5*time.Minute))
+	assert.ErrorIs(t, webhook.Verify("other", signed, body, now, 5*time.Minute), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", signed, []byte(`{}`), now, 5*time.Minute), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", signed, body, now.Add(time.Hour), 5*time.Minute), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", "v1=abc", body, now, 5*time.Minute), webhook.ErrInvalidSignature)
+}
+
+func TestSend(t *testing.T) {
+	status := http.StatusOK
+	var received *http.Request
+	var receivedBody []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Custom", "value")
+
+	now := time.Now()
+	d := webhook.NewDelivery(req, `{"event":"BreakerTripped"}`, now)
+
+	code, err := webhook.Send(context.Background(), server.Client(), d, "secret", now)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "value", received.Header.Get("X-Custom"))
+	assert.Equal(t, d.ID, received.Header.Get(header.XTykDeliveryID))
+	assert.NoError(t, webhook.Verify("secret", received.Header.Get(header.XTykSignature), receivedBody, now, time.Minute))
+
+	status = http.StatusServiceUnavailable
+	code, err = webhook.Send(context.Background(), server.Client(), d, "secret", now)
+	assert.ErrorIs(t, err, webhook.ErrUnexpectedStatus)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	status = http.StatusOK
+	_, err = webhook.Send(context.Background(), server.Client(), d, "", now)
+	require.NoError(t, err)
+	assert.Empty(t, received.Header.Get(header.XTykSignature))
+}
+
+func TestStore(t *testing.T) {
+	handler := storage.NewDummyStorage()
+	store := webhook.NewStore(handler)
+	now := time.Now()
+
+	newDelivery := func() *webhook.Delivery {
+		req, err := http.NewRequest(http.MethodPost, "http://receiver.example", strings.NewReader(""))
+		require.NoError(t, err)
+		return webhook.NewDelivery(req, "{}", now)
+	}
+
+	first, second := newDelivery(), newDelivery()
+	require.NoError(t, store.Enqueue(first))
+	require.NoError(t, store.Enqueue(second))
+
+	pending, err := store.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, first.ID, pending[0].ID)
+
+	require.NoError(t, store.Complete(first.ID))
+	_, err = store.Get(first.ID)
+	assert.ErrorIs(t, err, webhook.ErrNotFound)
+
+	second.Attempts = 3
+	second.LastError = "unexpected status code: 503"
+	require.NoError(t, store.DeadLetter(second, now, time.Hour))
+
+	pending, err = store.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	deadLetters, err := store.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, "unexpected status code: 503", deadLetters[0].LastError)
+	assert.NotNil(t, deadLetters[0].DeadLetteredAt)
+
+	_, err = store.Replay(first.ID, now)
+	assert.ErrorIs(t, err, webhook.ErrNotFound)
+
+	replayed, err := store.Replay(second.ID, now)
+	require.NoError(t, err)
+	assert.Zero(t, replayed.Attempts)
+	assert.Nil(t, replayed.DeadLetteredAt)
+
+	pending, err = store.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.ErrorIs(t, store.Discard(second.ID), webhook.ErrNotFound, "only dead letters can be discarded")
+
+	require.NoError(t, store.DeadLetter(pending[0], now, time.Hour))
+	require.NoError(t, store.Discard(second.ID))
+
+	deadLetters, err = store.DeadLetters()
+	require.NoError(t, err)
+	assert.Empty(t, deadLetters)
+
+	t.Run("retention", func(t *testing.T) {
+		expired := newDelivery()
+		require.NoError(t, store.Enqueue(expired))
+		require.NoError(t, store.DeadLetter(expired, now, time.Hour))
+
+		// the delivery is deleted by the storage once the retention has passed
+		handler.DeleteKey(expired.ID)
+
+		deadLetters, err := store.DeadLetters()
+		require.NoError(t, err)
+		assert.Empty(t, deadLetters)
+
+		ids, err := handler.GetListRange("dead-letter", 0, -1)
+		require.NoError(t, err)
+		assert.Empty(t, ids, "the expired delivery should be removed from the queue")
+	})
+}
@@ -0,0 +1,161 @@
+package cachestore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/internal/cache"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+const (
+	defaultDiskMaxSize = 1 << 30
+	defaultDiskDir     = "tyk-response-cache"
+
+	diskFileExt  = ".cache"
+	diskLockFile = ".lock"
+	// maxDiskDirs bounds the number of subdirectories used by the gateways of a host.
+	maxDiskDirs = 64
+)
+
+// Disk is a store writing the values to files in a directory, bounded by their total size.
+// The files are written to a subdirectory named after the host, which the store locks so gateways
+// sharing the directory don't remove each other's files. When it's locked by another gateway of
+// the host, the next free one of `<host>-1`, `<host>-2`, ... is used. The index of the files is
+// held in memory, files left in the subdirectory by a previous run are removed when the store is created.
+type Disk struct {
+	dir     string
+	maxSize int64
+	lru     *cache.LRU
+	seq     uint64
+
+	// lock holds the lock on the subdirectory, released when the process exits.
+	lock *os.File
+}
+
+// NewDisk returns an on-disk store.
+func NewDisk(conf config.DiskCacheStorageConf) (*Disk, error) {
+	dir := conf.Path
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), defaultDiskDir)
+	}
+
+	maxSize := conf.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultDiskMaxSize
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, lock, err := lockDiskDir(dir, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+diskFileExt))
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			lock.Close()
+			return nil, err
+		}
+	}
+
+	d := &Disk{dir: dir, maxSize: maxSize, lru: cache.NewLRU(maxSize), lock: lock}
+	d.lru.OnEvict = func(_ string, path interface{}) {
+		_ = os.Remove(path.(string))
+	}
+
+	return d, nil
+}
+
+// lockDiskDir creates and locks the first subdirectory of base for the host not locked by another store.
+func lockDiskDir(base, hostname string) (string, *os.File, error) {
+	for i := 0; i < maxDiskDirs; i++ {
+		dir := filepath.Join(base, hostname)
+		if i > 0 {
+			dir = fmt.Sprintf("%s-%d", dir, i)
+		}
+
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", nil, err
+		}
+
+		lock, err := os.OpenFile(filepath.Join(dir, diskLockFile), os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return "", nil, err
+		}
+
+		err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return dir, lock, nil
+		}
+
+		lock.Close()
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return "", nil, err
+		}
+	}
+
+	return "", nil, fmt.Errorf("all %d cache directories of host %s are locked", maxDiskDirs, hostname)
+}
+
+func (d *Disk) Get(key string) (string, error) {
+	path, ok := d.lru.Get(key)
+	if !ok {
+		return "", storage.ErrKeyNotFound
+	}
+
+	// the file is gone if the value was replaced or evicted since
+	value, err := os.ReadFile(path.(string))
+	if err != nil {
+		return "", storage.ErrKeyNotFound
+	}
+
+	return string(value), nil
+}
+
+// Set writes the value to a new file, so readers of the previous value are not affected.
+func (d *Disk) Set(key, value string, ttl int64) error {
+	size := int64(len(value))
+	if size > d.maxSize {
+		return errValueTooLarge
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	name := fmt.Sprintf("%s-%d%s", hex.EncodeToString(hash[:16]), atomic.AddUint64(&d.seq, 1), diskFileExt)
+	path := filepath.Join(d.dir, name)
+
+	if err := os.WriteFile(path, []byte(value), 0600); err != nil {
+		return err
+	}
+
+	d.lru.Set(key, path, size, ttlDuration(ttl))
+	return nil
+}
+
+func (d *Disk) Delete(keys ...string) error {
+	for _, key := range keys {
+		d.lru.Delete(key)
+	}
+	return nil
+}
+
+func (d *Disk) DeletePrefix(prefix string) error {
+	d.lru.DeletePrefix(prefix)
+	return nil
+}
@@ -0,0 +1,55 @@
+package cachestore
+
+import (
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/internal/cache"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+const defaultMemoryMaxSize = 256 << 20
+
+// Memory is a store holding the values in memory on the node, bounded by their total size.
+type Memory struct {
+	maxSize int64
+	lru     *cache.LRU
+}
+
+// NewMemory returns an in-memory store.
+func NewMemory(conf config.MemoryCacheStorageConf) *Memory {
+	maxSize := conf.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMemoryMaxSize
+	}
+
+	return &Memory{maxSize: maxSize, lru: cache.NewLRU(maxSize)}
+}
+
+func (m *Memory) Get(key string) (string, error) {
+	value, ok := m.lru.Get(key)
+	if !ok {
+		return "", storage.ErrKeyNotFound
+	}
+	return value.(string), nil
+}
+
+func (m *Memory) Set(key, value string, ttl int64) error {
+	size := int64(len(value))
+	if size > m.maxSize {
+		return errValueTooLarge
+	}
+
+	m.lru.Set(key, value, size, ttlDuration(ttl))
+	return nil
+}
+
+func (m *Memory) Delete(keys ...string) error {
+	for _, key := range keys {
+		m.lru.Delete(key)
+	}
+	return nil
+}
+
+func (m *Memory) DeletePrefix(prefix string) error {
+	m.lru.DeletePrefix(prefix)
+	return nil
+}
@@ -0,0 +1,46 @@
+package cachestore
+
+import (
+	"errors"
+
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+var errRedisDelete = errors.New("could not delete keys from redis")
+
+// Redis is a store backed by a storage handler, the default storage of the response cache.
+type Redis struct {
+	handler storage.Handler
+}
+
+// NewRedis returns a store using the storage handler.
+func NewRedis(handler storage.Handler) *Redis {
+	return &Redis{handler: handler}
+}
+
+func (r *Redis) Get(key string) (string, error) {
+	return r.handler.GetKey(key)
+}
+
+func (r *Redis) Set(key, value string, ttl int64) error {
+	return r.handler.SetKey(key, value, ttl)
+}
+
+func (r *Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// DeleteKeys prefixes the keys in place
+	if !r.handler.DeleteKeys(append([]string(nil), keys...)) {
+		return errRedisDelete
+	}
+	return nil
+}
+
+func (r *Redis) DeletePrefix(prefix string) error {
+	if !r.handler.DeleteScanMatch(r.handler.GetKeyPrefix() + prefix + "*") {
+		return errRedisDelete
+	}
+	return nil
+}
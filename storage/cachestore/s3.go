@@ -0,0 +1,231 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/storage"
+)
+
+const (
+	defaultS3Timeout = 5 * time.Second
+
+	// s3DeleteBatchSize is the maximum number of objects removed by a DeleteObjects request.
+	s3DeleteBatchSize = 1000
+
+	// s3ExpiresMeta is the object metadata holding the unix time the value expires at.
+	s3ExpiresMeta = "tyk-expires"
+)
+
+var errS3Bucket = errors.New("s3 bucket is not configured")
+
+// S3 is a store writing the values to objects in an S3 compatible object store.
+// Objects don't expire in S3, expired objects are removed when read.
+type S3 struct {
+	client  *s3.Client
+	bucket  string
+	prefix  string
+	timeout time.Duration
+}
+
+// NewS3 returns an S3 store.
+func NewS3(conf config.S3CacheStorageConf) (*S3, error) {
+	if conf.Bucket == "" {
+		return nil, errS3Bucket
+	}
+
+	timeout := defaultS3Timeout
+	if conf.Timeout > 0 {
+		timeout = time.Duration(conf.Timeout) * time.Second
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if conf.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(conf.Region))
+	}
+
+	if conf.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(conf.AccessKeyID, conf.SecretAccessKey, ""),
+		))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	awsConf, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(awsConf, func(o *s3.Options) {
+		if conf.Endpoint != "" {
+			o.BaseEndpoint = aws.String(conf.Endpoint)
+		}
+		o.UsePathStyle = conf.UsePathStyle
+	})
+
+	return &S3{client: client, bucket: conf.Bucket, prefix: conf.Prefix, timeout: timeout}, nil
+}
+
+func (s *S3) Get(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return "", storage.ErrKeyNotFound
+		}
+		return "", err
+	}
+	defer out.Body.Close()
+
+	if expires, err := strconv.ParseInt(out.Metadata[s3ExpiresMeta], 10, 64); err == nil && expires <= time.Now().Unix() {
+		return "", s.deleteExpired(ctx, key)
+	}
+
+	value, err := io.ReadAll(out.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+func (s *S3) deleteExpired(ctx context.Context, key string) error {
+	if err := s.delete(ctx, s.prefix+key); err != nil {
+		return err
+	}
+	return storage.ErrKeyNotFound
+}
+
+func (s *S3) Set(key, value string, ttl int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+		Body:   strings.NewReader(value),
+	}
+
+	if ttl > 0 {
+		input.Metadata = map[string]string{
+			s3ExpiresMeta: strconv.FormatInt(time.Now().Unix()+ttl, 10),
+		}
+	}
+
+	_, err := s.client.PutObject(ctx, input)
+	return err
+}
+
+func (s *S3) Delete(keys ...string) error {
+	objectKeys := make([]string, len(keys))
+	for i, key := range keys {
+		objectKeys[i] = s.prefix + key
+	}
+
+	for len(objectKeys) > 0 {
+		n := len(objectKeys)
+		if n > s3DeleteBatchSize {
+			n = s3DeleteBatchSize
+		}
+
+		if err := s.deleteObjects(objectKeys[:n]); err != nil {
+			return err
+		}
+		objectKeys = objectKeys[n:]
+	}
+
+	return nil
+}
+
+// DeletePrefix removes the objects page by page, each page of up to 1000 objects is listed and
+// removed with its own timeout.
+func (s *S3) DeletePrefix(prefix string) error {
+	pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(s.prefix + prefix),
+		MaxKeys: aws.Int32(s3DeleteBatchSize),
+	})
+
+	for pages.HasMorePages() {
+		page, err := s.nextPage(pages)
+		if err != nil {
+			return err
+		}
+
+		objectKeys := make([]string, len(page.Contents))
+		for i, object := range page.Contents {
+			objectKeys[i] = aws.ToString(object.Key)
+		}
+
+		if err := s.deleteObjects(objectKeys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *S3) nextPage(pages *s3.ListObjectsV2Paginator) (*s3.ListObjectsV2Output, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pages.NextPage(ctx)
+}
+
+// deleteObjects removes a batch of up to 1000 objects with a single request.
+func (s *S3) deleteObjects(objectKeys []string) error {
+	if len(objectKeys) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	objects := make([]types.ObjectIdentifier, len(objectKeys))
+	for i, key := range objectKeys {
+		objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+	}
+
+	out, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(s.bucket),
+		Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(out.Errors) > 0 {
+		failed := out.Errors[0]
+		return fmt.Errorf("could not delete %d objects, %s: %s", len(out.Errors), aws.ToString(failed.Key), aws.ToString(failed.Message))
+	}
+
+	return nil
+}
+
+func (s *S3) delete(ctx context.Context, objectKey string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey),
+	})
+	return err
+}
@@ -0,0 +1,70 @@
+// Package cachestore provides the storage backends of the response cache.
+package cachestore
+
+import (
+	"errors"
+	"time"
+)
+
+// Backends an API can select for its response cache.
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendDisk   = "disk"
+	BackendS3     = "s3"
+)
+
+var errValueTooLarge = errors.New("value exceeds the maximum size of the store")
+
+// noExpiry is the ttl of values set without expiry in the stores which require one.
+const noExpiry = 100 * 365 * 24 * time.Hour
+
+// Store is a storage backend of the response cache.
+type Store interface {
+	// Get returns the value of the key, or storage.ErrKeyNotFound if it doesn't exist or has expired.
+	Get(key string) (string, error)
+	// Set writes the value of the key, expiring after ttl seconds. Values with a ttl of 0 don't expire.
+	// Stores bounded by size return an error for values larger than the store.
+	Set(key, value string, ttl int64) error
+	// Delete removes the keys.
+	Delete(keys ...string) error
+	// DeletePrefix removes all the keys starting with the prefix.
+	DeletePrefix(prefix string) error
+}
+
+// WithPrefix returns a view of the store prefixing all keys, so several APIs can share a store.
+func WithPrefix(store Store, prefix string) Store {
+	return &prefixed{store: store, prefix: prefix}
+}
+
+type prefixed struct {
+	store  Store
+	prefix string
+}
+
+func (p *prefixed) Get(key string) (string, error) {
+	return p.store.Get(p.prefix + key)
+}
+
+func (p *prefixed) Set(key, value string, ttl int64) error {
+	return p.store.Set(p.prefix+key, value, ttl)
+}
+
+func (p *prefixed) Delete(keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = p.prefix + key
+	}
+	return p.store.Delete(prefixed...)
+}
+
+func (p *prefixed) DeletePrefix(prefix string) error {
+	return p.store.DeletePrefix(p.prefix + prefix)
+}
+
+func ttlDuration(ttl int64) time.Duration {
+	if ttl <= 0 {
+		return noExpiry
+	}
+	return time.Duration(ttl) * time.Second
+}
@@ -0,0 +1,297 @@
+package cachestore_test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TykTechnologies/tyk/config"
+	"github.com/TykTechnologies/tyk/storage"
+	"github.com/TykTechnologies/tyk/storage/cachestore"
+)
+
+func testStore(t *testing.T, store cachestore.Store) {
+	t.Helper()
+
+	_, err := store.Get("missing")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	require.NoError(t, store.Set("api1/a", "a", 60))
+	require.NoError(t, store.Set("api1/b", "b", 0))
+	require.NoError(t, store.Set("api2/a", "c", 60))
+
+	value, err := store.Get("api1/a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", value)
+
+	value, err = store.Get("api1/b")
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+
+	require.NoError(t, store.Set("api1/a", "aa", 60))
+	value, err = store.Get("api1/a")
+	require.NoError(t, err)
+	assert.Equal(t, "aa", value)
+
+	require.NoError(t, store.Delete("api1/a"))
+	_, err = store.Get("api1/a")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	// a view of the store only sees its own keys
+	api2 := cachestore.WithPrefix(store, "api2/")
+	value, err = api2.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, "c", value)
+
+	require.NoError(t, store.DeletePrefix("api1/"))
+	_, err = store.Get("api1/b")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	require.NoError(t, api2.DeletePrefix(""))
+	_, err = store.Get("api2/a")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	require.NoError(t, store.Set("expiring", "value", 1))
+	time.Sleep(2 * time.Second)
+	_, err = store.Get("expiring")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+}
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	testStore(t, cachestore.NewMemory(config.MemoryCacheStorageConf{}))
+
+	store := cachestore.NewMemory(config.MemoryCacheStorageConf{MaxSize: 10})
+	assert.Error(t, store.Set("large", "aaaaaaaaaaa", 60))
+	_, err := store.Get("large")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+}
+
+func TestDisk(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	dir := filepath.Join(base, hostname)
+	require.NoError(t, os.MkdirAll(dir, 0700))
+
+	// files of a previous run of the gateway are removed
+	stale := filepath.Join(dir, "stale.cache")
+	require.NoError(t, os.WriteFile(stale, []byte("stale"), 0600))
+	other := filepath.Join(dir, "other.txt")
+	require.NoError(t, os.WriteFile(other, []byte("other"), 0600))
+
+	// files of other gateways sharing the directory are kept
+	otherGateway := filepath.Join(base, "other-host-1", "entry.cache")
+	require.NoError(t, os.MkdirAll(filepath.Dir(otherGateway), 0700))
+	require.NoError(t, os.WriteFile(otherGateway, []byte("other"), 0600))
+
+	store, err := cachestore.NewDisk(config.DiskCacheStorageConf{Path: base, MaxSize: 10})
+	require.NoError(t, err)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, other)
+	assert.FileExists(t, otherGateway)
+
+	testStore(t, store)
+
+	t.Run("size bound", func(t *testing.T) {
+		require.NoError(t, store.Set("a", "aaaaa", 60))
+		require.NoError(t, store.Set("b", "bbbbb", 60))
+		require.NoError(t, store.Set("c", "ccccc", 60))
+
+		_, err := store.Get("a")
+		assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+		assert.Error(t, store.Set("d", "ddddddddddd", 60))
+
+		// evicted and replaced values are removed from disk
+		files, err := filepath.Glob(filepath.Join(dir, "*.cache"))
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+	})
+
+	t.Run("directory locked by another gateway of the host", func(t *testing.T) {
+		other, err := cachestore.NewDisk(config.DiskCacheStorageConf{Path: base, MaxSize: 10})
+		require.NoError(t, err)
+		require.NoError(t, other.Set("a", "aaaaa", 60))
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.cache"))
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		files, err = filepath.Glob(filepath.Join(base, hostname+"-1", "*.cache"))
+		require.NoError(t, err)
+		assert.Len(t, files, 1)
+	})
+}
+
+func TestS3(t *testing.T) {
+	t.Parallel()
+
+	server := newS3Server()
+	defer server.Close()
+
+	store, err := cachestore.NewS3(config.S3CacheStorageConf{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "cache",
+		Prefix:          "tyk/",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		UsePathStyle:    true,
+	})
+	require.NoError(t, err)
+
+	testStore(t, store)
+
+	// expired objects are removed when read
+	assert.Empty(t, server.keys())
+
+	t.Run("batched deletes", func(t *testing.T) {
+		keys := make([]string, 1500)
+		for i := range keys {
+			keys[i] = strconv.Itoa(i)
+			require.NoError(t, store.Set(keys[i], "value", 60))
+		}
+
+		server.mu.Lock()
+		server.batches = nil
+		server.mu.Unlock()
+
+		require.NoError(t, store.Delete(keys...))
+		assert.Empty(t, server.keys())
+
+		server.mu.Lock()
+		assert.Equal(t, []int{1000, 500}, server.batches)
+		server.mu.Unlock()
+	})
+
+	_, err = cachestore.NewS3(config.S3CacheStorageConf{})
+	assert.Error(t, err)
+}
+
+// s3Server is a minimal S3 compatible object store, serving path style requests for objects in any bucket.
+type s3Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]s3Object
+	// batches are the number of objects of the DeleteObjects requests.
+	batches []int
+}
+
+type s3Object struct {
+	body []byte
+	meta http.Header
+}
+
+func newS3Server() *s3Server {
+	s := &s3Server{objects: map[string]s3Object{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *s3Server) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *s3Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Query().Has("delete"):
+		s.deleteObjects(w, r)
+	case r.Method == http.MethodGet && key == "":
+		s.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodGet:
+		object, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+
+		for name, values := range object.meta {
+			w.Header()[name] = values
+		}
+		_, _ = w.Write(object.body)
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		meta := http.Header{}
+		for name, values := range r.Header {
+			if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+				meta[name] = values
+			}
+		}
+		s.objects[key] = s3Object{body: body, meta: meta}
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *s3Server) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key string `xml:"Key"`
+	}
+
+	result := struct {
+		XMLName     xml.Name  `xml:"ListBucketResult"`
+		IsTruncated bool      `xml:"IsTruncated"`
+		Contents    []content `xml:"Contents"`
+	}{}
+
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, content{Key: key})
+		}
+	}
+
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func (s *s3Server) deleteObjects(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, object := range req.Objects {
+		delete(s.objects, object.Key)
+	}
+	s.batches = append(s.batches, len(req.Objects))
+
+	_, _ = io.WriteString(w, `<DeleteResult></DeleteResult>`)
+}
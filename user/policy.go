@@ -22,6 +22,7 @@ type Policy struct {
 	ThrottleInterval              float64                          `bson:"throttle_interval" json:"throttle_interval"`
 	ThrottleRetryLimit            int                              `bson:"throttle_retry_limit" json:"throttle_retry_limit"`
 	MaxQueryDepth                 int                              `bson:"max_query_depth" json:"max_query_depth"`
+	MaxQueryCost                  int                              `bson:"max_query_cost" json:"max_query_cost"`
 	AccessRights                  map[string]AccessDefinition      `bson:"access_rights" json:"access_rights"`
 	HMACEnabled                   bool                             `bson:"hmac_enabled" json:"hmac_enabled"`
 	EnableHTTPSignatureValidation bool                             `json:"enable_http_signature_validation" msg:"enable_http_signature_validation"`
@@ -45,6 +46,7 @@ func (p *Policy) APILimit() APILimit {
 		ThrottleInterval:   p.ThrottleInterval,
 		ThrottleRetryLimit: p.ThrottleRetryLimit,
 		MaxQueryDepth:      p.MaxQueryDepth,
+		MaxQueryCost:       p.MaxQueryCost,
 		RateLimit: RateLimit{
 			Rate:      p.Rate,
 			Per:       p.Per,
@@ -21,11 +21,13 @@ const (
 	HashMurmur32           = "murmur32"
 	HashMurmur64           = "murmur64"
 	HashMurmur128          = "murmur128"
+	HashArgon2id           = "argon2id"
+	HashScrypt             = "scrypt"
 )
 
 func IsHashType(t string) bool {
 	switch HashType(t) {
-	case HashBCrypt, HashSha256, HashMurmur32, HashMurmur64, HashMurmur128:
+	case HashBCrypt, HashSha256, HashMurmur32, HashMurmur64, HashMurmur128, HashArgon2id, HashScrypt:
 		return true
 	}
 	return false
@@ -54,6 +56,7 @@ type APILimit struct {
 	ThrottleInterval   float64 `json:"throttle_interval" msg:"throttle_interval"`
 	ThrottleRetryLimit int     `json:"throttle_retry_limit" msg:"throttle_retry_limit"`
 	MaxQueryDepth      int     `json:"max_query_depth" msg:"max_query_depth"`
+	MaxQueryCost       int     `json:"max_query_cost" msg:"max_query_cost"`
 	QuotaMax           int64   `json:"quota_max" msg:"quota_max"`
 	QuotaRenews        int64   `json:"quota_renews" msg:"quota_renews"`
 	QuotaRemaining     int64   `json:"quota_remaining" msg:"quota_remaining"`
@@ -78,6 +81,7 @@ func (a APILimit) Clone() *APILimit {
 		ThrottleInterval:   a.ThrottleInterval,
 		ThrottleRetryLimit: a.ThrottleRetryLimit,
 		MaxQueryDepth:      a.MaxQueryDepth,
+		MaxQueryCost:       a.MaxQueryCost,
 		QuotaMax:           a.QuotaMax,
 		QuotaRenews:        a.QuotaRenews,
 		QuotaRemaining:     a.QuotaRemaining,
@@ -100,15 +104,16 @@ func (r RateLimit) Duration() time.Duration {
 // in the gateway/policy.go:19
 // TODO: is it possible to share fields?
 type AccessDefinition struct {
-	APIName              string                  `json:"api_name" msg:"api_name"`
-	APIID                string                  `json:"api_id" msg:"api_id"`
-	Versions             []string                `json:"versions" msg:"versions"`
-	AllowedURLs          []AccessSpec            `bson:"allowed_urls" json:"allowed_urls" msg:"allowed_urls"` // mapped string MUST be a valid regex
-	RestrictedTypes      []graphql.Type          `json:"restricted_types" msg:"restricted_types"`
-	AllowedTypes         []graphql.Type          `json:"allowed_types" msg:"allowed_types"`
-	Limit                APILimit                `json:"limit" msg:"limit"`
-	FieldAccessRights    []FieldAccessDefinition `json:"field_access_rights" msg:"field_access_rights"`
-	DisableIntrospection bool                    `json:"disable_introspection" msg:"disable_introspection"`
+	APIName                  string                  `json:"api_name" msg:"api_name"`
+	APIID                    string                  `json:"api_id" msg:"api_id"`
+	Versions                 []string                `json:"versions" msg:"versions"`
+	AllowedURLs              []AccessSpec            `bson:"allowed_urls" json:"allowed_urls" msg:"allowed_urls"` // mapped string MUST be a valid regex
+	RestrictedTypes          []graphql.Type          `json:"restricted_types" msg:"restricted_types"`
+	AllowedTypes             []graphql.Type          `json:"allowed_types" msg:"allowed_types"`
+	Limit                    APILimit                `json:"limit" msg:"limit"`
+	FieldAccessRights        []FieldAccessDefinition `json:"field_access_rights" msg:"field_access_rights"`
+	DisableIntrospection     bool                    `json:"disable_introspection" msg:"disable_introspection"`
+	EnforceOperationSafelist bool                    `json:"enforce_operation_safelist" msg:"enforce_operation_safelist"`
 
 	AllowanceScope string `json:"allowance_scope" msg:"allowance_scope"`
 
@@ -137,6 +142,10 @@ func (a APILimit) IsEmpty() bool {
 		return false
 	}
 
+	if a.MaxQueryCost != 0 {
+		return false
+	}
+
 	if a.QuotaMax != 0 {
 		return false
 	}
@@ -253,6 +262,7 @@ type SessionState struct {
 	ThrottleInterval              float64                     `json:"throttle_interval" msg:"throttle_interval"`
 	ThrottleRetryLimit            int                         `json:"throttle_retry_limit" msg:"throttle_retry_limit"`
 	MaxQueryDepth                 int                         `json:"max_query_depth" msg:"max_query_depth"`
+	MaxQueryCost                  int                         `json:"max_query_cost" msg:"max_query_cost"`
 	DateCreated                   time.Time                   `json:"date_created" msg:"date_created"`
 	Expires                       int64                       `json:"expires" msg:"expires"`
 	QuotaMax                      int64                       `json:"quota_max" msg:"quota_max"`
@@ -316,6 +326,7 @@ func (s *SessionState) APILimit() APILimit {
 		ThrottleInterval:   s.ThrottleInterval,
 		ThrottleRetryLimit: s.ThrottleRetryLimit,
 		MaxQueryDepth:      s.MaxQueryDepth,
+		MaxQueryCost:       s.MaxQueryCost,
 	}
 }
 
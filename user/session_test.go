@@ -40,7 +40,7 @@ func TestSessionState_Touch_and_IsModified(t *testing.T) {
 func TestIsHashType(t *testing.T) {
 	assert.False(t, IsHashType(""))
 	assert.False(t, IsHashType("invalid"))
-	valids := []string{"sha256", "bcrypt", "murmur32", "murmur64", "murmur128"}
+	valids := []string{"sha256", "bcrypt", "murmur32", "murmur64", "murmur128", "argon2id", "scrypt"}
 	for _, ok := range valids {
 		assert.True(t, IsHashType(ok))
 	}